@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/internal/analytics"
+)
+
+// exitHotspotsOverThreshold is the exit code for a --fail-on breach. It is
+// distinct from 1, which reports bad arguments, so CI can tell a risky
+// change from a misconfigured job.
+const exitHotspotsOverThreshold = 2
+
+type analyticsOptions struct {
+	period  string
+	format  string
+	section string
+	failOn  int
+}
+
+func runAnalytics(repoCtx *repositoryContext, args []string) int {
+	opts, exitCode, err := parseAnalyticsArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	q, err := analytics.ParseQuery(analytics.QueryParams{Period: opts.period})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gitvista-cli analytics: %v\n", err)
+		return 1
+	}
+	resp, err := analytics.Build(repoCtx.repo, q)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+
+	switch opts.format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(resp)
+	case "csv":
+		err = writeAnalyticsCSV(resp, opts.section)
+	case "html":
+		err = resp.WriteHTML(os.Stdout, repoCtx.repo.Name()+" analytics")
+	default:
+		fmt.Print(resp.Markdown())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+
+	if opts.failOn >= 0 {
+		var over []string
+		for _, h := range resp.Hotspots {
+			if h.RiskScore >= opts.failOn {
+				over = append(over, fmt.Sprintf("%s (%d)", h.Path, h.RiskScore))
+			}
+		}
+		if len(over) > 0 {
+			fmt.Fprintf(os.Stderr, "gitvista-cli analytics: %d hotspots at or above risk %d: %s\n",
+				len(over), opts.failOn, strings.Join(over, ", "))
+			return exitHotspotsOverThreshold
+		}
+	}
+	return 0
+}
+
+// writeAnalyticsCSV writes one section, or every section under a "# name"
+// line when section is empty.
+func writeAnalyticsCSV(resp *analytics.Response, section string) error {
+	if section != "" {
+		return resp.WriteCSV(os.Stdout, section)
+	}
+	for i, name := range analytics.CSVSections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("# %s\n", name)
+		if err := resp.WriteCSV(os.Stdout, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseAnalyticsArgs(args []string) (analyticsOptions, int, error) {
+	opts := analyticsOptions{format: "markdown", failOn: -1}
+	value := func(i *int, flag, want string) (string, error) {
+		if *i+1 >= len(args) {
+			return "", fmt.Errorf("gitvista-cli analytics: %s requires %s", flag, want)
+		}
+		*i++
+		return args[*i], nil
+	}
+	failOn := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var err error
+		switch {
+		case arg == "--period":
+			opts.period, err = value(&i, arg, "3m, 6m, 1y, or all")
+		case strings.HasPrefix(arg, "--period="):
+			opts.period = strings.TrimPrefix(arg, "--period=")
+		case arg == "--format":
+			opts.format, err = value(&i, arg, "markdown, json, csv, or html")
+		case strings.HasPrefix(arg, "--format="):
+			opts.format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--json":
+			opts.format = "json"
+		case arg == "--section":
+			opts.section, err = value(&i, arg, "a section name")
+		case strings.HasPrefix(arg, "--section="):
+			opts.section = strings.TrimPrefix(arg, "--section=")
+		case arg == "--fail-on":
+			failOn, err = value(&i, arg, "a risk score")
+		case strings.HasPrefix(arg, "--fail-on="):
+			failOn = strings.TrimPrefix(arg, "--fail-on=")
+		default:
+			return analyticsOptions{}, 1, fmt.Errorf("gitvista-cli analytics: unsupported argument %q", arg)
+		}
+		if err != nil {
+			return analyticsOptions{}, 1, err
+		}
+	}
+
+	switch opts.format {
+	case "markdown", "json", "csv", "html":
+	default:
+		return analyticsOptions{}, 1, fmt.Errorf("gitvista-cli analytics: unsupported format %q (want markdown, json, csv, or html)", opts.format)
+	}
+	if opts.section != "" {
+		if opts.format != "csv" {
+			return analyticsOptions{}, 1, fmt.Errorf("gitvista-cli analytics: --section requires --format csv")
+		}
+		if !slices.Contains(analytics.CSVSections, opts.section) {
+			return analyticsOptions{}, 1, fmt.Errorf("gitvista-cli analytics: unknown section %q (want %s)", opts.section, strings.Join(analytics.CSVSections, ", "))
+		}
+	}
+	if failOn != "" {
+		score, err := strconv.Atoi(failOn)
+		if err != nil || score < 0 || score > 100 {
+			return analyticsOptions{}, 1, fmt.Errorf("gitvista-cli analytics: --fail-on requires a risk score from 0 to 100, got %q", failOn)
+		}
+		opts.failOn = score
+	}
+	return opts, 0, nil
+}
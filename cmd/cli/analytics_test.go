@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func TestParseAnalyticsArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    analyticsOptions
+		wantErr string
+	}{
+		{name: "defaults", args: nil, want: analyticsOptions{format: "markdown", failOn: -1}},
+		{name: "period", args: []string{"--period", "6m"}, want: analyticsOptions{period: "6m", format: "markdown", failOn: -1}},
+		{name: "equals forms", args: []string{"--period=1y", "--format=csv", "--section=authors", "--fail-on=70"}, want: analyticsOptions{period: "1y", format: "csv", section: "authors", failOn: 70}},
+		{name: "json shorthand", args: []string{"--json"}, want: analyticsOptions{format: "json", failOn: -1}},
+		{name: "html", args: []string{"--format", "html", "--fail-on", "0"}, want: analyticsOptions{format: "html", failOn: 0}},
+		{name: "missing period", args: []string{"--period"}, wantErr: "--period requires"},
+		{name: "bad format", args: []string{"--format=pdf"}, wantErr: "unsupported format"},
+		{name: "section without csv", args: []string{"--section", "authors"}, wantErr: "--section requires --format csv"},
+		{name: "unknown section", args: []string{"--format=csv", "--section=heatmap"}, wantErr: "unknown section"},
+		{name: "bad fail-on", args: []string{"--fail-on", "high"}, wantErr: "risk score from 0 to 100"},
+		{name: "fail-on out of range", args: []string{"--fail-on=101"}, wantErr: "risk score from 0 to 100"},
+		{name: "unsupported", args: []string{"HEAD"}, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parseAnalyticsArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != 1 {
+					t.Fatalf("parseAnalyticsArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 || opts != tt.want {
+				t.Fatalf("parseAnalyticsArgs() = (%+v, %d, %v), want %+v", opts, code, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunAnalytics(t *testing.T) {
+	repoDir, gitDir := newStatusCLIRepoDir(t)
+	rootID := writeStatusCommit(t, gitDir, writeStatusTree(t, gitDir))
+	blobID := writeStatusObject(t, gitDir, "blob", []byte("package main\n"))
+	rawBlobID, err := hex.DecodeString(string(blobID))
+	if err != nil {
+		t.Fatalf("decode blob id: %v", err)
+	}
+	treeID := writeStatusObject(t, gitDir, "tree", append([]byte("100644 main.go\x00"), rawBlobID...))
+	commitID := writeStatusObject(t, gitDir, "commit", []byte("tree "+string(treeID)+"\nparent "+string(rootID)+
+		"\nauthor Jane Doe <jane@example.com> 1700003600 +0000\ncommitter Jane Doe <jane@example.com> 1700003600 +0000\n\nadd main\n"))
+	writeCLITextFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/main\n")
+	writeCLITextFile(t, filepath.Join(gitDir, "refs", "heads", "main"), string(commitID)+"\n")
+	repo, err := gitcore.NewRepository(repoDir)
+	if err != nil {
+		t.Fatalf("NewRepository() error: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	repoCtx := &repositoryContext{repo: repo}
+
+	stdout, stderr, code := captureCLIOutput(t, func() int { return runAnalytics(repoCtx, nil) })
+	if code != 0 || stderr != "" {
+		t.Fatalf("runAnalytics() = code %d stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "## Analytics for all history") || !strings.Contains(stdout, "| Jane Doe | 2 |") {
+		t.Fatalf("markdown = %q", stdout)
+	}
+
+	stdout, _, code = captureCLIOutput(t, func() int { return runAnalytics(repoCtx, []string{"--json"}) })
+	var resp struct {
+		Velocity struct {
+			TotalCommits int `json:"totalCommits"`
+		} `json:"velocity"`
+		Hotspots []struct {
+			Path string `json:"path"`
+		} `json:"hotspots"`
+	}
+	if code != 0 {
+		t.Fatalf("runAnalytics(--json) code = %d", code)
+	}
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout, err)
+	}
+	if resp.Velocity.TotalCommits != 2 || len(resp.Hotspots) == 0 {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	stdout, _, code = captureCLIOutput(t, func() int { return runAnalytics(repoCtx, []string{"--format", "csv"}) })
+	if code != 0 || !strings.HasPrefix(stdout, "# velocity\nweek,commits,rolling_avg\n") ||
+		!strings.Contains(stdout, "\n\n# authors\nname,email,commits\nJane Doe,") || !strings.Contains(stdout, "\n\n# hotspots\npath,") {
+		t.Fatalf("csv = %q (code %d)", stdout, code)
+	}
+
+	stdout, _, code = captureCLIOutput(t, func() int {
+		return runAnalytics(repoCtx, []string{"--format=csv", "--section=authors"})
+	})
+	if code != 0 || strings.Contains(stdout, "#") || !strings.HasPrefix(stdout, "name,email,commits\n") {
+		t.Fatalf("authors csv = %q (code %d)", stdout, code)
+	}
+
+	stdout, _, code = captureCLIOutput(t, func() int { return runAnalytics(repoCtx, []string{"--format", "html"}) })
+	if code != 0 || !strings.HasPrefix(stdout, "<!DOCTYPE html>") || !strings.Contains(stdout, "<svg") {
+		t.Fatalf("html = %q (code %d)", stdout, code)
+	}
+
+	// The report is still written when the threshold trips.
+	stdout, stderr, code = captureCLIOutput(t, func() int { return runAnalytics(repoCtx, []string{"--fail-on", "0"}) })
+	if code != exitHotspotsOverThreshold || stdout == "" || !strings.Contains(stderr, "at or above risk 0: "+resp.Hotspots[0].Path) {
+		t.Fatalf("runAnalytics(--fail-on 0) = code %d stderr %q", code, stderr)
+	}
+	if _, _, code = captureCLIOutput(t, func() int { return runAnalytics(repoCtx, []string{"--fail-on=100"}) }); code != 0 {
+		t.Fatalf("runAnalytics(--fail-on=100) code = %d", code)
+	}
+
+	_, stderr, code = captureCLIOutput(t, func() int { return runAnalytics(repoCtx, []string{"--period", "2w"}) })
+	if code != 1 || !strings.Contains(stderr, "gitvista-cli analytics: invalid period") {
+		t.Fatalf("runAnalytics(--period 2w) = code %d stderr %q", code, stderr)
+	}
+}
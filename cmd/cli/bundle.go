@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const bundleUsage = "usage: gitvista-cli bundle create [--version=<2|3>] <file> (--all | <rev-range>...)"
+
+type bundleCreateOptions struct {
+	file    string
+	version int
+	all     bool
+	// positive names refs whose history is bundled; negative names commits
+	// the receiver already has.
+	positive []string
+	negative []string
+}
+
+func runBundle(repoCtx *repositoryContext, args []string) int {
+	opts, exitCode, err := parseBundleArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	bundleOpts, err := resolveBundleRevisions(repoCtx.repo, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+	if opts.file == "-" {
+		out := bufio.NewWriter(os.Stdout)
+		if _, err := repoCtx.repo.WriteBundle(out, bundleOpts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		if err := out.Flush(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		return 0
+	}
+	if err := writeBundleFile(repoCtx.repo, opts.file, bundleOpts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+	return 0
+}
+
+func parseBundleArgs(args []string) (bundleCreateOptions, int, error) {
+	if len(args) == 0 || args[0] != "create" {
+		return bundleCreateOptions{}, 1, fmt.Errorf("%s", bundleUsage)
+	}
+
+	opts := bundleCreateOptions{version: 2}
+	for _, arg := range args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--version="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--version="))
+			if err != nil || (n != 2 && n != 3) {
+				return bundleCreateOptions{}, 1, fmt.Errorf("gitvista-cli bundle: invalid version %q", arg)
+			}
+			opts.version = n
+		case arg == "--all":
+			opts.all = true
+		case opts.file == "":
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return bundleCreateOptions{}, 1, fmt.Errorf("gitvista-cli bundle: unsupported argument %q", arg)
+			}
+			opts.file = arg
+		case strings.Contains(arg, ".."):
+			rng, err := gitcore.ParseRevRange(arg)
+			if err != nil {
+				return bundleCreateOptions{}, 1, fmt.Errorf("gitvista-cli bundle: %q: %w", arg, err)
+			}
+			opts.negative = append(opts.negative, rng.From)
+			opts.positive = append(opts.positive, rng.To)
+		case strings.HasPrefix(arg, "^") && len(arg) > 1:
+			opts.negative = append(opts.negative, arg[1:])
+		case strings.HasPrefix(arg, "-"):
+			return bundleCreateOptions{}, 1, fmt.Errorf("gitvista-cli bundle: unsupported argument %q", arg)
+		default:
+			opts.positive = append(opts.positive, arg)
+		}
+	}
+	if opts.file == "" || (!opts.all && len(opts.positive) == 0) {
+		return bundleCreateOptions{}, 1, fmt.Errorf("%s", bundleUsage)
+	}
+	return opts, 0, nil
+}
+
+// resolveBundleRevisions maps positive revisions to the refs they name, the
+// way git bundle records them, and negative revisions to commits.
+func resolveBundleRevisions(repo *gitcore.Repository, opts bundleCreateOptions) (gitcore.BundleOptions, error) {
+	bundleOpts := gitcore.BundleOptions{Version: opts.version}
+	refs := repo.Refs()
+	seen := make(map[string]struct{})
+	addRef := func(name string, id gitcore.Hash) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		bundleOpts.Refs = append(bundleOpts.Refs, gitcore.BundleRef{Name: name, ID: id})
+	}
+
+	if opts.all {
+		if head := repo.Head(); head != "" {
+			addRef("HEAD", head)
+		}
+		for _, name := range slices.Sorted(maps.Keys(refs)) {
+			addRef(name, refs[name])
+		}
+	}
+	for _, rev := range opts.positive {
+		name, ok := dwimRef(repo, refs, rev)
+		if !ok {
+			return gitcore.BundleOptions{}, fmt.Errorf("gitvista-cli bundle: %q does not name a ref", rev)
+		}
+		if name == "HEAD" {
+			addRef(name, repo.Head())
+		} else {
+			addRef(name, refs[name])
+		}
+	}
+	for _, rev := range opts.negative {
+		id, err := repo.ResolveRevision(rev)
+		if err != nil {
+			return gitcore.BundleOptions{}, err
+		}
+		bundleOpts.Haves = append(bundleOpts.Haves, id)
+	}
+	return bundleOpts, nil
+}
+
+// dwimRef expands a short ref name in the order gitrevisions(7) documents.
+func dwimRef(repo *gitcore.Repository, refs map[string]gitcore.Hash, name string) (string, bool) {
+	if name == "HEAD" {
+		return name, repo.Head() != ""
+	}
+	for _, candidate := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name} {
+		if _, ok := refs[candidate]; ok {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// writeBundleFile writes the bundle under a temporary name and renames it into
+// place, so a failed write leaves no partial bundle behind.
+func writeBundleFile(repo *gitcore.Repository, path string, opts gitcore.BundleOptions) error {
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-bundle-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	out := bufio.NewWriter(file)
+	_, err = repo.WriteBundle(out, opts)
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// Bundles are made to be handed to others, unlike the private temp file.
+	if err := os.Chmod(file.Name(), 0o644); err != nil { // #nosec G302 -- bundles are meant to be shared
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func TestParseBundleArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantFile     string
+		wantVersion  int
+		wantAll      bool
+		wantPositive []string
+		wantNegative []string
+		wantErr      string
+	}{
+		{name: "ref", args: []string{"create", "out.bundle", "main"}, wantFile: "out.bundle", wantVersion: 2, wantPositive: []string{"main"}},
+		{name: "range", args: []string{"create", "out.bundle", "v1.0..main"}, wantFile: "out.bundle", wantVersion: 2, wantPositive: []string{"main"}, wantNegative: []string{"v1.0"}},
+		{name: "open range", args: []string{"create", "out.bundle", "v1.0.."}, wantFile: "out.bundle", wantVersion: 2, wantPositive: []string{"HEAD"}, wantNegative: []string{"v1.0"}},
+		{name: "exclusion", args: []string{"create", "-", "main", "^a1b2c3d"}, wantFile: "-", wantVersion: 2, wantPositive: []string{"main"}, wantNegative: []string{"a1b2c3d"}},
+		{name: "all v3", args: []string{"create", "--version=3", "all.bundle", "--all"}, wantFile: "all.bundle", wantVersion: 3, wantAll: true},
+		{name: "missing create", args: []string{"verify", "out.bundle"}, wantErr: "usage: gitvista-cli bundle"},
+		{name: "missing revision", args: []string{"create", "out.bundle"}, wantErr: "usage: gitvista-cli bundle"},
+		{name: "bad version", args: []string{"create", "--version=1", "out.bundle", "main"}, wantErr: "invalid version"},
+		{name: "symmetric difference", args: []string{"create", "out.bundle", "a...b"}, wantErr: "symmetric difference"},
+		{name: "unsupported flag", args: []string{"create", "out.bundle", "--branches"}, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parseBundleArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != 1 {
+					t.Fatalf("parseBundleArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 || opts.file != tt.wantFile || opts.version != tt.wantVersion || opts.all != tt.wantAll ||
+				!slices.Equal(opts.positive, tt.wantPositive) || !slices.Equal(opts.negative, tt.wantNegative) {
+				t.Fatalf("parseBundleArgs() = (%+v, %d, %v)", opts, code, err)
+			}
+		})
+	}
+}
+
+func TestRunBundleCreatesOpenableBundle(t *testing.T) {
+	repo := newStatusCLIRepo(t)
+	path := filepath.Join(t.TempDir(), "repo.bundle")
+	repoCtx := &repositoryContext{repo: repo}
+	stdout, stderr, code := captureCLIOutput(t, func() int {
+		return runBundle(repoCtx, []string{"create", path, "main"})
+	})
+	if code != 0 || stdout != "" || stderr != "" {
+		t.Fatalf("runBundle() = code %d stdout %q stderr %q", code, stdout, stderr)
+	}
+
+	bundle, err := gitcore.NewRepository(path)
+	if err != nil {
+		t.Fatalf("NewRepository(bundle) error = %v", err)
+	}
+	t.Cleanup(func() { _ = bundle.Close() })
+	if bundle.Head() != repo.Head() || bundle.HeadRef() != "refs/heads/main" {
+		t.Fatalf("bundle HEAD = %s (%s), want %s", bundle.Head(), bundle.HeadRef(), repo.Head())
+	}
+
+	_, stderr, code = captureCLIOutput(t, func() int {
+		return runBundle(repoCtx, []string{"create", path, string(repo.Head())})
+	})
+	if code != 128 || !strings.Contains(stderr, "does not name a ref") {
+		t.Fatalf("runBundle(commit) = code %d stderr %q", code, stderr)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rybkr/gitvista/internal/changelog"
+)
+
+type changelogOptions struct {
+	revRange string
+	format   string
+}
+
+func runChangelog(repoCtx *repositoryContext, args []string) int {
+	opts, exitCode, err := parseChangelogArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	log, err := changelog.Build(repoCtx.repo, opts.revRange)
+	if errors.Is(err, changelog.ErrInvalidRange) {
+		fmt.Fprintf(os.Stderr, "gitvista-cli changelog: %v\n", err)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(log); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		return 0
+	}
+	fmt.Print(log.Markdown())
+	return 0
+}
+
+func parseChangelogArgs(args []string) (changelogOptions, int, error) {
+	opts := changelogOptions{format: "markdown"}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return changelogOptions{}, 1, fmt.Errorf("gitvista-cli changelog: --format requires markdown or json")
+			}
+			i++
+			opts.format = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			opts.format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--json":
+			opts.format = "json"
+		case strings.HasPrefix(arg, "-"):
+			return changelogOptions{}, 1, fmt.Errorf("gitvista-cli changelog: unsupported argument %q", arg)
+		case opts.revRange != "":
+			return changelogOptions{}, 1, fmt.Errorf("gitvista-cli changelog: expected a single revision range, got %q and %q", opts.revRange, arg)
+		default:
+			opts.revRange = arg
+		}
+	}
+	if opts.format != "markdown" && opts.format != "json" {
+		return changelogOptions{}, 1, fmt.Errorf("gitvista-cli changelog: unsupported format %q (want markdown or json)", opts.format)
+	}
+	if opts.revRange == "" {
+		return changelogOptions{}, 1, fmt.Errorf("gitvista-cli changelog: requires a revision range such as v1.0..HEAD")
+	}
+	return opts, 0, nil
+}
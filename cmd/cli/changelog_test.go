@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func TestParseChangelogArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantRange  string
+		wantFormat string
+		wantErr    string
+	}{
+		{name: "range", args: []string{"v1.0..HEAD"}, wantRange: "v1.0..HEAD", wantFormat: "markdown"},
+		{name: "format json", args: []string{"--format", "json", "v1.0.."}, wantRange: "v1.0..", wantFormat: "json"},
+		{name: "format equals", args: []string{"v1.0..v2.0", "--format=markdown"}, wantRange: "v1.0..v2.0", wantFormat: "markdown"},
+		{name: "json shorthand", args: []string{"--json", "HEAD"}, wantRange: "HEAD", wantFormat: "json"},
+		{name: "missing range", args: nil, wantErr: "requires a revision range"},
+		{name: "missing format", args: []string{"--format"}, wantErr: "--format requires"},
+		{name: "bad format", args: []string{"--format=html", "HEAD"}, wantErr: "unsupported format"},
+		{name: "two ranges", args: []string{"a..b", "c..d"}, wantErr: "single revision range"},
+		{name: "unsupported", args: []string{"--all"}, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parseChangelogArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != 1 {
+					t.Fatalf("parseChangelogArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 || opts.revRange != tt.wantRange || opts.format != tt.wantFormat {
+				t.Fatalf("parseChangelogArgs() = (%+v, %d, %v)", opts, code, err)
+			}
+		})
+	}
+}
+
+func TestRunChangelog(t *testing.T) {
+	repoDir, gitDir := newStatusCLIRepoDir(t)
+	commitID := writeStatusCommit(t, gitDir, writeStatusTree(t, gitDir))
+	writeCLITextFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/main\n")
+	writeCLITextFile(t, filepath.Join(gitDir, "refs", "heads", "main"), string(commitID)+"\n")
+	repo, err := gitcore.NewRepository(repoDir)
+	if err != nil {
+		t.Fatalf("NewRepository() error: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	repoCtx := &repositoryContext{repo: repo}
+
+	stdout, stderr, code := captureCLIOutput(t, func() int { return runChangelog(repoCtx, []string{"HEAD"}) })
+	if code != 0 || stderr != "" {
+		t.Fatalf("runChangelog() = code %d stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "### Other Changes\n\n- initial commit (") || !strings.Contains(stdout, "- Jane Doe (1)") {
+		t.Fatalf("markdown = %q", stdout)
+	}
+
+	stdout, _, code = captureCLIOutput(t, func() int { return runChangelog(repoCtx, []string{"--json", "HEAD"}) })
+	var log struct {
+		Commits int `json:"commits"`
+		Groups  []struct {
+			Type string `json:"type"`
+		} `json:"groups"`
+	}
+	if code != 0 {
+		t.Fatalf("runChangelog(--json) code = %d", code)
+	}
+	if err := json.Unmarshal([]byte(stdout), &log); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout, err)
+	}
+	if log.Commits != 1 || len(log.Groups) != 1 || log.Groups[0].Type != "other" {
+		t.Fatalf("changelog = %+v", log)
+	}
+
+	_, stderr, code = captureCLIOutput(t, func() int { return runChangelog(repoCtx, []string{"HEAD..missing"}) })
+	if code != 1 || !strings.Contains(stderr, "gitvista-cli changelog: invalid revision range") {
+		t.Fatalf("invalid range = code %d stderr %q", code, stderr)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"time"
 
 	"github.com/rybkr/gitvista/gitcore"
@@ -44,7 +45,7 @@ func registerCommands(app *cli.App, repoCtx *repositoryContext, cw *cli.Writer)
 	app.Register(&cli.Command{
 		Name:      "rev-list",
 		Summary:   "List commits like git rev-list",
-		Usage:     "gitvista-cli rev-list [--all | <commit>] [--count] [--no-merges] [--topo-order] [--date-order]",
+		Usage:     "gitvista-cli rev-list [--all | <commit>] [--count] [--no-merges] [--topo-order] [--date-order] [-S<string> | -G<regex>] [--pickaxe-regex] [-i] [-- <pathspec>...]",
 		NeedsRepo: true,
 		Flags: []string{
 			"--all         Walk from all branch and tag refs",
@@ -53,12 +54,20 @@ func registerCommands(app *cli.App, repoCtx *repositoryContext, cw *cli.Writer)
 			"--no-merges   Exclude merge commits from the output",
 			"--topo-order  Keep parents after children in topological order",
 			"--date-order  Keep topological constraints while preferring newer commits first",
+			"-S<string>    Keep commits that change the number of occurrences of <string>",
+			"-G<regex>     Keep commits whose added or removed lines match <regex>",
+			"--pickaxe-regex",
+			"              Treat the -S argument as a regular expression",
+			"-i            Match -S and -G patterns without regard to letter case",
+			"<pathspec>    Limit -S and -G to matching paths or globs",
 		},
 		Examples: []string{
 			"List commits reachable from HEAD\ngitvista-cli rev-list HEAD",
 			"Print how many commits are reachable from branch main\ngitvista-cli rev-list --count main",
 			"List commits reachable from a specific commit\ngitvista-cli rev-list a1b2c3d",
 			"List all refs in topological order\ngitvista-cli rev-list --all --topo-order",
+			"Find commits that added or removed a function call\ngitvista-cli rev-list -SloadConfig HEAD",
+			"Find commits touching TODO lines in Go files\ngitvista-cli rev-list --all -G 'TODO' -- '*.go'",
 		},
 		Run: func(args []string) int { return runRevList(repoCtx, args, cw) },
 	})
@@ -78,6 +87,29 @@ func registerCommands(app *cli.App, repoCtx *repositoryContext, cw *cli.Writer)
 		Run: func(args []string) int { return runLsTree(repoCtx, args) },
 	})
 
+	app.Register(&cli.Command{
+		Name:      "grep",
+		Summary:   "Search file contents like git grep",
+		Usage:     "gitvista-cli grep [-i] [-F] [-n] [-l | -c] [-C <n>] <pattern> [<tree-ish>...] [-- <pathspec>...]",
+		NeedsRepo: true,
+		Flags: []string{
+			"-i            Match without regard to letter case",
+			"-F            Treat the pattern as a fixed string, not a regular expression",
+			"-n            Prefix each matching line with its line number",
+			"-l            Print only the names of files that contain matches",
+			"-c            Print the number of matching lines per file",
+			"-C <n>        Show <n> lines of context around each match",
+			"<tree-ish>    Search a revision, <rev>:<dir>, or tree (defaults to the working tree)",
+			"<pathspec>    Limit the search to matching paths or globs",
+		},
+		Examples: []string{
+			"Search tracked files in the working tree\ngitvista-cli grep -n TODO",
+			"Search a release tag for a config key\ngitvista-cli grep -F max_connections v1.4.0",
+			"Search Go files under internal/ on main\ngitvista-cli grep -i 'func new' main -- 'internal/*.go'",
+		},
+		Run: func(args []string) int { return runGrep(repoCtx, args, cw) },
+	})
+
 	app.Register(&cli.Command{
 		Name:      "merge-base",
 		Summary:   "Find the best common ancestor of two commits",
@@ -93,6 +125,137 @@ func registerCommands(app *cli.App, repoCtx *repositoryContext, cw *cli.Writer)
 		Run: func(args []string) int { return runMergeBase(repoCtx, args) },
 	})
 
+	app.Register(&cli.Command{
+		Name:      "describe",
+		Summary:   "Name a commit after the nearest tag like git describe",
+		Usage:     "gitvista-cli describe [--tags] [--abbrev=<n>] [--match <pattern>] [--long] [--always] [--dirty[=<mark>]] [--contains] [<commit>...]",
+		NeedsRepo: true,
+		Flags: []string{
+			"--tags        Use lightweight tags as well as annotated tags",
+			"--abbrev=<n>  Use <n> hex digits for the object name (0 prints only the tag)",
+			"--match <p>   Only consider tags matching the glob pattern <p>",
+			"--long        Always print the long format, even on an exact match",
+			"--always      Fall back to the abbreviated object name",
+			"--dirty[=<m>] Append <m> (default -dirty) when the working tree has changes",
+			"--contains    Name the commit after a tag that contains it",
+			"<commit>      Commits to describe (defaults to HEAD)",
+		},
+		Examples: []string{
+			"Describe HEAD\ngitvista-cli describe",
+			"Describe a build including local modifications\ngitvista-cli describe --tags --dirty",
+			"Find the first release containing a commit\ngitvista-cli describe --contains a1b2c3d",
+		},
+		Run: func(args []string) int { return runDescribe(repoCtx, args) },
+	})
+
+	app.Register(&cli.Command{
+		Name:      "name-rev",
+		Summary:   "Find symbolic names for commits like git name-rev",
+		Usage:     "gitvista-cli name-rev [--tags] [--name-only] [--refs=<pattern>] <commit>...",
+		NeedsRepo: true,
+		Flags: []string{
+			"--tags        Only use tags to name commits",
+			"--name-only   Print only the name, not the revision",
+			"--refs=<p>    Only use refs whose short name matches the glob pattern <p>",
+			"<commit>      A commit hash, short hash, branch, tag, remote ref, or HEAD",
+		},
+		Examples: []string{
+			"Name a commit relative to the nearest ref\ngitvista-cli name-rev a1b2c3d",
+			"Name HEAD relative to tags only\ngitvista-cli name-rev --tags --name-only HEAD",
+		},
+		Run: func(args []string) int { return runNameRev(repoCtx, args) },
+	})
+
+	app.Register(&cli.Command{
+		Name:      "bundle",
+		Summary:   "Write a git bundle like git bundle create",
+		Usage:     "gitvista-cli bundle create [--version=<2|3>] <file> (--all | <rev-range>...)",
+		NeedsRepo: true,
+		Flags: []string{
+			"--version=<n> Write a v2 (default) or v3 bundle",
+			"<file>        The bundle to write, or - for standard output",
+			"--all         Bundle HEAD and every ref",
+			"<rev-range>   A ref to bundle, a range such as v1.0..main, or ^<commit> to leave out",
+		},
+		Examples: []string{
+			"Bundle the whole history of main\ngitvista-cli bundle create repo.bundle main",
+			"Bundle what changed since a release\ngitvista-cli bundle create update.bundle v1.0..main",
+			"Bundle every ref\ngitvista-cli bundle create --version=3 all.bundle --all",
+		},
+		Run: func(args []string) int { return runBundle(repoCtx, args) },
+	})
+
+	app.Register(&cli.Command{
+		Name:      "pack-objects",
+		Summary:   "Write a pack of objects read from stdin like git pack-objects",
+		Usage:     "gitvista-cli pack-objects [--window=<n>] [--depth=<n>] (--stdout | <base-name>)",
+		NeedsRepo: true,
+		Flags: []string{
+			"--window=<n>  Try <n> similar objects as delta bases (default 10, 0 disables deltas)",
+			"--depth=<n>   Limit delta chains to <n> objects (default 50)",
+			"--stdout      Write the pack to standard output instead of files",
+			"<base-name>   Write <base-name>-<checksum>.pack and .idx and print the checksum",
+		},
+		Examples: []string{
+			"Pack everything reachable from HEAD\ngit rev-list --objects HEAD | gitvista-cli pack-objects out/pack",
+			"Stream a pack without deltas\ngit rev-list --objects main | gitvista-cli pack-objects --window=0 --stdout > main.pack",
+		},
+		Run: func(args []string) int { return runPackObjects(repoCtx, args, os.Stdin) },
+	})
+
+	app.Register(&cli.Command{
+		Name:      "analytics",
+		Summary:   "Report commit velocity, contributors, and risk hotspots",
+		Usage:     "gitvista-cli analytics [--period 3m|6m|1y|all] [--format markdown|json|csv|html] [--fail-on <score>]",
+		NeedsRepo: true,
+		Flags: []string{
+			"--period <p>     Window to analyze: 3m, 6m, 1y, or all (default)",
+			"--format <f>     Output format: markdown (default), json, csv, or html",
+			"--json           Shorthand for --format json",
+			"--section <s>    With csv, print only velocity, authors, or hotspots",
+			"--fail-on <n>    Exit 2 when any hotspot's risk score is at least <n> (bad arguments exit 1)",
+		},
+		Examples: []string{
+			"Summarize the last six months\ngitvista-cli analytics --period 6m",
+			"Export hotspots for a spreadsheet\ngitvista-cli analytics --format csv --section hotspots > hotspots.csv",
+			"Share a single-file report\ngitvista-cli analytics --format html > analytics.html",
+			"Fail CI on risky hotspots\ngitvista-cli analytics --period 3m --fail-on 70",
+		},
+		Run: func(args []string) int { return runAnalytics(repoCtx, args) },
+	})
+
+	app.Register(&cli.Command{
+		Name:      "releases",
+		Summary:   "Show the release timeline with lead time metrics",
+		Usage:     "gitvista-cli releases [--pattern <glob>] [--json]",
+		NeedsRepo: true,
+		Flags: []string{
+			"--pattern <p> Only treat tags matching the glob pattern <p> as releases",
+			"--json        Print the full timeline as JSON",
+		},
+		Examples: []string{
+			"List releases tagged v*\ngitvista-cli releases --pattern 'v*'",
+			"Export the release timeline\ngitvista-cli releases --json > releases.json",
+		},
+		Run: func(args []string) int { return runReleases(repoCtx, args, cw) },
+	})
+
+	app.Register(&cli.Command{
+		Name:      "changelog",
+		Summary:   "Generate a changelog from conventional commit subjects",
+		Usage:     "gitvista-cli changelog [--format markdown|json] <from>..<to>",
+		NeedsRepo: true,
+		Flags: []string{
+			"--format <f>  Output format: markdown (default) or json",
+			"--json        Shorthand for --format json",
+		},
+		Examples: []string{
+			"Changes since the last release\ngitvista-cli changelog v1.2.0..HEAD",
+			"Export a release's changes as JSON\ngitvista-cli changelog --format json v1.1.0..v1.2.0",
+		},
+		Run: func(args []string) int { return runChangelog(repoCtx, args) },
+	})
+
 	app.Register(&cli.Command{
 		Name:      "status",
 		Summary:   "Show working tree status",
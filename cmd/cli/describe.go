@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+type describeOptions struct {
+	revisions []string
+	core      gitcore.DescribeOptions
+}
+
+func runDescribe(repoCtx *repositoryContext, args []string) int {
+	opts, exitCode, err := parseDescribeArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	if opts.core.Dirty != "" && repoCtx.repo.IsBare() {
+		fmt.Fprintln(os.Stderr, "fatal: option '--dirty' requires a working tree")
+		return 128
+	}
+
+	revisions := opts.revisions
+	if len(revisions) == 0 {
+		revisions = []string{"HEAD"}
+	}
+
+	for _, revision := range revisions {
+		hash, err := repoCtx.repo.ResolveRevision(revision)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+
+		name, err := gitcore.Describe(repoCtx.repo, hash, opts.core)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		fmt.Fprintln(os.Stdout, name)
+	}
+	return 0
+}
+
+func parseDescribeArgs(args []string) (describeOptions, int, error) {
+	opts := describeOptions{core: gitcore.DescribeOptions{Abbrev: -1}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--tags":
+			opts.core.Tags = true
+		case arg == "--long":
+			opts.core.Long = true
+		case arg == "--always":
+			opts.core.Always = true
+		case arg == "--contains":
+			opts.core.Contains = true
+		case arg == "--dirty":
+			opts.core.Dirty = "-dirty"
+		case strings.HasPrefix(arg, "--dirty="):
+			opts.core.Dirty = strings.TrimPrefix(arg, "--dirty=")
+			if opts.core.Dirty == "" {
+				return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: --dirty requires a non-empty mark")
+			}
+		case strings.HasPrefix(arg, "--abbrev="):
+			abbrev, err := strconv.Atoi(strings.TrimPrefix(arg, "--abbrev="))
+			if err != nil || abbrev < 0 || abbrev > 40 {
+				return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: invalid --abbrev value %q", strings.TrimPrefix(arg, "--abbrev="))
+			}
+			if abbrev > 0 && abbrev < 4 {
+				abbrev = 4
+			}
+			opts.core.Abbrev = abbrev
+		case arg == "--match":
+			if i+1 >= len(args) {
+				return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: --match requires a pattern")
+			}
+			i++
+			opts.core.Match = append(opts.core.Match, args[i])
+		case strings.HasPrefix(arg, "--match="):
+			opts.core.Match = append(opts.core.Match, strings.TrimPrefix(arg, "--match="))
+		case strings.HasPrefix(arg, "-"):
+			return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: unsupported argument %q", arg)
+		default:
+			opts.revisions = append(opts.revisions, arg)
+		}
+	}
+
+	if opts.core.Dirty != "" && len(opts.revisions) > 0 {
+		return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: --dirty cannot be used with commit-ishes")
+	}
+	if opts.core.Dirty != "" && opts.core.Contains {
+		return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: --dirty cannot be used with --contains")
+	}
+	if opts.core.Long && opts.core.Abbrev == 0 {
+		return describeOptions{}, 1, fmt.Errorf("gitvista-cli describe: --long is incompatible with --abbrev=0")
+	}
+
+	return opts, 0, nil
+}
+
+type nameRevOptions struct {
+	revisions []string
+	nameOnly  bool
+	core      gitcore.NameRevOptions
+}
+
+func runNameRev(repoCtx *repositoryContext, args []string) int {
+	opts, exitCode, err := parseNameRevArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	for _, revision := range opts.revisions {
+		hash, err := repoCtx.repo.ResolveRevision(revision)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+
+		name, err := gitcore.NameRev(repoCtx.repo, hash, opts.core)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		if name == "" {
+			name = "undefined"
+		}
+
+		if opts.nameOnly {
+			fmt.Fprintln(os.Stdout, name)
+		} else {
+			fmt.Fprintf(os.Stdout, "%s %s\n", revision, name)
+		}
+	}
+	return 0
+}
+
+func parseNameRevArgs(args []string) (nameRevOptions, int, error) {
+	var opts nameRevOptions
+	for _, arg := range args {
+		switch {
+		case arg == "--tags":
+			opts.core.TagsOnly = true
+		case arg == "--name-only":
+			opts.nameOnly = true
+		case strings.HasPrefix(arg, "--refs="):
+			opts.core.Match = append(opts.core.Match, strings.TrimPrefix(arg, "--refs="))
+		case strings.HasPrefix(arg, "-"):
+			return nameRevOptions{}, 1, fmt.Errorf("gitvista-cli name-rev: unsupported argument %q", arg)
+		default:
+			opts.revisions = append(opts.revisions, arg)
+		}
+	}
+
+	if len(opts.revisions) == 0 {
+		return nameRevOptions{}, 1, fmt.Errorf("usage: gitvista-cli name-rev [--tags] [--name-only] [--refs=<pattern>] <commit>...")
+	}
+	return opts, 0, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDescribeArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantRevisions []string
+		wantAbbrev    int
+		wantMatch     []string
+		wantDirty     string
+		wantCode      int
+		wantErr       string
+	}{
+		{name: "defaults", args: nil, wantAbbrev: -1},
+		{name: "revision", args: []string{"main"}, wantRevisions: []string{"main"}, wantAbbrev: -1},
+		{name: "abbrev", args: []string{"--abbrev=10", "HEAD"}, wantRevisions: []string{"HEAD"}, wantAbbrev: 10},
+		{name: "abbrev floor", args: []string{"--abbrev=2"}, wantAbbrev: 4},
+		{name: "match forms", args: []string{"--match", "v*", "--match=rc*"}, wantAbbrev: -1, wantMatch: []string{"v*", "rc*"}},
+		{name: "dirty default", args: []string{"--dirty"}, wantAbbrev: -1, wantDirty: "-dirty"},
+		{name: "dirty mark", args: []string{"--dirty=+"}, wantAbbrev: -1, wantDirty: "+"},
+		{name: "invalid abbrev", args: []string{"--abbrev=x"}, wantCode: 1, wantErr: "invalid --abbrev"},
+		{name: "missing match", args: []string{"--match"}, wantCode: 1, wantErr: "--match requires a pattern"},
+		{name: "dirty with revision", args: []string{"--dirty", "HEAD"}, wantCode: 1, wantErr: "cannot be used with commit-ishes"},
+		{name: "long with zero abbrev", args: []string{"--long", "--abbrev=0"}, wantCode: 1, wantErr: "incompatible"},
+		{name: "unsupported", args: []string{"--first-parent"}, wantCode: 1, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parseDescribeArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != tt.wantCode {
+					t.Fatalf("parseDescribeArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 {
+				t.Fatalf("parseDescribeArgs() = (%+v, %d, %v)", opts, code, err)
+			}
+			if !slices.Equal(opts.revisions, tt.wantRevisions) || opts.core.Abbrev != tt.wantAbbrev ||
+				!slices.Equal(opts.core.Match, tt.wantMatch) || opts.core.Dirty != tt.wantDirty {
+				t.Fatalf("parseDescribeArgs() = %+v", opts)
+			}
+		})
+	}
+}
+
+func TestParseNameRevArgs(t *testing.T) {
+	opts, code, err := parseNameRevArgs([]string{"--tags", "--name-only", "--refs=v*", "HEAD", "main"})
+	if err != nil || code != 0 {
+		t.Fatalf("parseNameRevArgs() = (%+v, %d, %v)", opts, code, err)
+	}
+	if !opts.core.TagsOnly || !opts.nameOnly || !slices.Equal(opts.core.Match, []string{"v*"}) || !slices.Equal(opts.revisions, []string{"HEAD", "main"}) {
+		t.Fatalf("parseNameRevArgs() = %+v", opts)
+	}
+
+	if _, code, err := parseNameRevArgs(nil); err == nil || code != 1 || !strings.Contains(err.Error(), "usage: gitvista-cli name-rev") {
+		t.Fatalf("parseNameRevArgs(nil) = (%d, %v)", code, err)
+	}
+	if _, code, err := parseNameRevArgs([]string{"--all"}); err == nil || code != 1 {
+		t.Fatalf("parseNameRevArgs(--all) = (%d, %v)", code, err)
+	}
+}
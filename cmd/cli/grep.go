@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/cli"
+)
+
+const grepUsage = "usage: gitvista-cli grep [-i] [-F] [-n] [-l | -c] [-C <n>] <pattern> [<tree-ish>...] [-- <pathspec>...]"
+
+type grepOptions struct {
+	pattern    string
+	revisions  []string
+	lineNumber bool
+	filesOnly  bool
+	count      bool
+	core       gitcore.GrepOptions
+}
+
+func runGrep(repoCtx *repositoryContext, args []string, cw *cli.Writer) int {
+	opts, exitCode, err := parseGrepArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	revisions := opts.revisions
+	if len(revisions) == 0 {
+		revisions = []string{""}
+	}
+
+	found := false
+	for _, revision := range revisions {
+		prefix := ""
+		if revision != "" {
+			prefix = revision
+			if !strings.Contains(revision, ":") {
+				prefix += ":"
+			} else if !strings.HasSuffix(revision, ":") && !strings.HasSuffix(revision, "/") {
+				prefix += "/"
+			}
+		}
+
+		err := gitcore.GrepFunc(repoCtx.repo, revision, opts.pattern, opts.core, func(result gitcore.GrepFileResult) error {
+			found = true
+			printGrepResult(result, prefix, opts, cw)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+	}
+
+	if !found {
+		return 1
+	}
+	return 0
+}
+
+func printGrepResult(result gitcore.GrepFileResult, prefix string, opts grepOptions, cw *cli.Writer) {
+	name := prefix + relativeGrepPath(result.Path, prefix)
+	switch {
+	case opts.filesOnly:
+		fmt.Fprintln(os.Stdout, cw.Cyan(name))
+		return
+	case opts.count:
+		fmt.Fprintf(os.Stdout, "%s%s%d\n", cw.Cyan(name), cw.Muted(":"), result.Matches)
+		return
+	}
+
+	for i, line := range result.Lines {
+		if i > 0 && opts.core.ContextLines > 0 && line.Number != result.Lines[i-1].Number+1 {
+			fmt.Fprintln(os.Stdout, cw.Muted("--"))
+		}
+
+		sep := "-"
+		text := line.Text
+		if line.Match {
+			sep = ":"
+			text = highlightGrepRanges(line, cw)
+		}
+
+		var b strings.Builder
+		b.WriteString(cw.Cyan(name))
+		b.WriteString(cw.Muted(sep))
+		if opts.lineNumber {
+			b.WriteString(cw.Green(strconv.Itoa(line.Number)))
+			b.WriteString(cw.Muted(sep))
+		}
+		b.WriteString(text)
+		fmt.Fprintln(os.Stdout, b.String())
+	}
+}
+
+// relativeGrepPath trims the "<rev>:<dir>/" portion already carried by prefix
+// so paths under a subdirectory tree-ish are not printed twice.
+func relativeGrepPath(filePath, prefix string) string {
+	_, dir, ok := strings.Cut(prefix, ":")
+	if !ok || dir == "" {
+		return filePath
+	}
+	return strings.TrimPrefix(filePath, strings.TrimSuffix(dir, "/")+"/")
+}
+
+func highlightGrepRanges(line gitcore.GrepLine, cw *cli.Writer) string {
+	if !cw.Enabled() || len(line.Ranges) == 0 {
+		return line.Text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, r := range line.Ranges {
+		if r[0] < last || r[1] > len(line.Text) {
+			continue
+		}
+		b.WriteString(line.Text[last:r[0]])
+		b.WriteString(cw.Red(line.Text[r[0]:r[1]]))
+		last = r[1]
+	}
+	b.WriteString(line.Text[last:])
+	return b.String()
+}
+
+func parseGrepArgs(args []string) (grepOptions, int, error) {
+	var opts grepOptions
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			opts.core.Pathspecs = append(opts.core.Pathspecs, args[i+1:]...)
+			i = len(args)
+		case arg == "-i" || arg == "--ignore-case":
+			opts.core.IgnoreCase = true
+		case arg == "-F" || arg == "--fixed-strings":
+			opts.core.FixedStrings = true
+		case arg == "-E" || arg == "--extended-regexp":
+			opts.core.FixedStrings = false
+		case arg == "-n" || arg == "--line-number":
+			opts.lineNumber = true
+		case arg == "-l" || arg == "--files-with-matches" || arg == "--name-only":
+			opts.filesOnly = true
+		case arg == "-c" || arg == "--count":
+			opts.count = true
+		case arg == "-C" || arg == "--context":
+			if i+1 >= len(args) {
+				return grepOptions{}, 1, fmt.Errorf("gitvista-cli grep: %s requires a value", arg)
+			}
+			i++
+			n, err := parseGrepContext(args[i])
+			if err != nil {
+				return grepOptions{}, 1, err
+			}
+			opts.core.ContextLines = n
+		case strings.HasPrefix(arg, "--context=") || (strings.HasPrefix(arg, "-C") && len(arg) > 2):
+			value := strings.TrimPrefix(strings.TrimPrefix(arg, "--context="), "-C")
+			n, err := parseGrepContext(value)
+			if err != nil {
+				return grepOptions{}, 1, err
+			}
+			opts.core.ContextLines = n
+		case arg == "-e":
+			if i+1 >= len(args) {
+				return grepOptions{}, 1, fmt.Errorf("gitvista-cli grep: -e requires a pattern")
+			}
+			i++
+			if opts.pattern != "" {
+				return grepOptions{}, 1, fmt.Errorf("gitvista-cli grep: accepts at most one pattern")
+			}
+			opts.pattern = args[i]
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return grepOptions{}, 1, fmt.Errorf("gitvista-cli grep: unsupported argument %q", arg)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if opts.pattern == "" {
+		if len(positional) == 0 {
+			return grepOptions{}, 1, fmt.Errorf("%s", grepUsage)
+		}
+		opts.pattern = positional[0]
+		positional = positional[1:]
+	}
+	opts.revisions = positional
+
+	if opts.filesOnly && opts.count {
+		return grepOptions{}, 1, fmt.Errorf("gitvista-cli grep: -l and -c cannot be used together")
+	}
+	return opts, 0, nil
+}
+
+func parseGrepContext(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("gitvista-cli grep: invalid context length %q", value)
+	}
+	return n, nil
+}
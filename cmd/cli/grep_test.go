@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/cli"
+)
+
+func TestParseGrepArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantPattern   string
+		wantRevisions []string
+		wantPaths     []string
+		wantContext   int
+		wantCode      int
+		wantErr       string
+	}{
+		{name: "pattern only", args: []string{"TODO"}, wantPattern: "TODO"},
+		{name: "revisions and pathspecs", args: []string{"-n", "TODO", "main", "v1.0", "--", "cmd", "*.go"}, wantPattern: "TODO", wantRevisions: []string{"main", "v1.0"}, wantPaths: []string{"cmd", "*.go"}},
+		{name: "explicit pattern", args: []string{"-e", "-x", "HEAD"}, wantPattern: "-x", wantRevisions: []string{"HEAD"}},
+		{name: "context forms", args: []string{"-C", "2", "x"}, wantPattern: "x", wantContext: 2},
+		{name: "attached context", args: []string{"-C3", "x"}, wantPattern: "x", wantContext: 3},
+		{name: "long context", args: []string{"--context=1", "x"}, wantPattern: "x", wantContext: 1},
+		{name: "missing pattern", args: nil, wantCode: 1, wantErr: "usage: gitvista-cli grep"},
+		{name: "bad context", args: []string{"-C", "x", "y"}, wantCode: 1, wantErr: "invalid context length"},
+		{name: "files and count", args: []string{"-l", "-c", "x"}, wantCode: 1, wantErr: "cannot be used together"},
+		{name: "unsupported", args: []string{"--perl-regexp", "x"}, wantCode: 1, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parseGrepArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != tt.wantCode {
+					t.Fatalf("parseGrepArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 {
+				t.Fatalf("parseGrepArgs() = (%+v, %d, %v)", opts, code, err)
+			}
+			if opts.pattern != tt.wantPattern || !slices.Equal(opts.revisions, tt.wantRevisions) ||
+				!slices.Equal(opts.core.Pathspecs, tt.wantPaths) || opts.core.ContextLines != tt.wantContext {
+				t.Fatalf("parseGrepArgs() = %+v", opts)
+			}
+		})
+	}
+}
+
+func TestRelativeGrepPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   string
+	}{
+		{path: "pkg/util.go", prefix: "", want: "pkg/util.go"},
+		{path: "pkg/util.go", prefix: "HEAD:", want: "pkg/util.go"},
+		{path: "pkg/util.go", prefix: "HEAD:pkg/", want: "util.go"},
+	}
+	for _, tt := range tests {
+		if got := relativeGrepPath(tt.path, tt.prefix); got != tt.want {
+			t.Fatalf("relativeGrepPath(%q, %q) = %q, want %q", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestPrintGrepResult(t *testing.T) {
+	result := gitcore.GrepFileResult{
+		Path:    "pkg/util.go",
+		Matches: 2,
+		Lines: []gitcore.GrepLine{
+			{Number: 1, Text: "package util", Match: true},
+			{Number: 2, Text: ""},
+			{Number: 9, Text: "var x = util.New()", Match: true},
+		},
+	}
+	cw := cli.NewWriter(os.Stdout, cli.ColorNever)
+
+	stdout, _, _ := captureCLIOutput(t, func() int {
+		printGrepResult(result, "HEAD:", grepOptions{lineNumber: true, core: gitcore.GrepOptions{ContextLines: 1}}, cw)
+		return 0
+	})
+	want := strings.Join([]string{
+		"HEAD:pkg/util.go:1:package util",
+		"HEAD:pkg/util.go-2-",
+		"--",
+		"HEAD:pkg/util.go:9:var x = util.New()",
+		"",
+	}, "\n")
+	if stdout != want {
+		t.Fatalf("printGrepResult() stdout = %q, want %q", stdout, want)
+	}
+
+	stdout, _, _ = captureCLIOutput(t, func() int {
+		printGrepResult(result, "", grepOptions{count: true}, cw)
+		return 0
+	})
+	if stdout != "pkg/util.go:2\n" {
+		t.Fatalf("printGrepResult(count) stdout = %q", stdout)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const packObjectsUsage = "usage: gitvista-cli pack-objects [--window=<n>] [--depth=<n>] (--stdout | <base-name>)"
+
+type packObjectsOptions struct {
+	baseName string
+	stdout   bool
+	window   int
+	depth    int
+}
+
+func runPackObjects(repoCtx *repositoryContext, args []string, stdin io.Reader) int {
+	opts, exitCode, err := parsePackObjectsArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	objects, err := readPackObjectIDs(stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+	packOpts := gitcore.PackOptions{Window: opts.window, MaxDepth: opts.depth}
+
+	if opts.stdout {
+		out := bufio.NewWriter(os.Stdout)
+		if _, err := repoCtx.repo.WritePack(out, objects, packOpts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		if err := out.Flush(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		return 0
+	}
+
+	checksum, err := writePackFiles(repoCtx.repo, opts.baseName, objects, packOpts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+	fmt.Fprintln(os.Stdout, checksum)
+	return 0
+}
+
+func parsePackObjectsArgs(args []string) (packObjectsOptions, int, error) {
+	opts := packObjectsOptions{}
+	for _, arg := range args {
+		switch {
+		case arg == "--stdout":
+			opts.stdout = true
+		case strings.HasPrefix(arg, "--window="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--window="))
+			if err != nil || n < 0 {
+				return packObjectsOptions{}, 1, fmt.Errorf("gitvista-cli pack-objects: invalid window %q", arg)
+			}
+			// git treats --window=0 as no delta search.
+			opts.window = n
+			if n == 0 {
+				opts.window = -1
+			}
+		case strings.HasPrefix(arg, "--depth="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--depth="))
+			if err != nil || n <= 0 {
+				return packObjectsOptions{}, 1, fmt.Errorf("gitvista-cli pack-objects: invalid depth %q", arg)
+			}
+			opts.depth = n
+		case strings.HasPrefix(arg, "-"):
+			return packObjectsOptions{}, 1, fmt.Errorf("gitvista-cli pack-objects: unsupported argument %q", arg)
+		case opts.baseName == "":
+			opts.baseName = arg
+		default:
+			return packObjectsOptions{}, 1, fmt.Errorf("%s", packObjectsUsage)
+		}
+	}
+	if opts.stdout == (opts.baseName != "") {
+		return packObjectsOptions{}, 1, fmt.Errorf("%s", packObjectsUsage)
+	}
+	return opts, 0, nil
+}
+
+// readPackObjectIDs reads object IDs one per line, as `git rev-list --objects`
+// prints them; anything after the ID, such as a path, is ignored.
+func readPackObjectIDs(r io.Reader) ([]gitcore.Hash, error) {
+	var objects []gitcore.Hash
+	seen := make(map[gitcore.Hash]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		field, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if field == "" {
+			continue
+		}
+		id, err := gitcore.NewHash(field)
+		if err != nil {
+			return nil, fmt.Errorf("gitvista-cli pack-objects: expected object ID, got %q", scanner.Text())
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		objects = append(objects, id)
+	}
+	return objects, scanner.Err()
+}
+
+// writePackFiles writes <baseName>-<checksum>.pack and its .idx, each under a
+// temporary name first, and returns the checksum.
+func writePackFiles(repo *gitcore.Repository, baseName string, objects []gitcore.Hash, opts gitcore.PackOptions) (gitcore.Hash, error) {
+	dir := filepath.Dir(baseName)
+	packFile, err := os.CreateTemp(dir, ".tmp-pack-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(packFile.Name()) }()
+	out := bufio.NewWriter(packFile)
+	written, err := repo.WritePack(out, objects, opts)
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := packFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	idxFile, err := os.CreateTemp(dir, ".tmp-idx-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(idxFile.Name()) }()
+	out = bufio.NewWriter(idxFile)
+	err = gitcore.WritePackIndex(out, written.Checksum, written.Objects)
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := idxFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	name := baseName + "-" + string(written.Checksum)
+	if err := os.Rename(packFile.Name(), name+".pack"); err != nil {
+		return "", err
+	}
+	if err := os.Rename(idxFile.Name(), name+".idx"); err != nil {
+		return "", err
+	}
+	return written.Checksum, nil
+}
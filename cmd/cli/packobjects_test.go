@@ -0,0 +1,89 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func TestParsePackObjectsArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantBase   string
+		wantStdout bool
+		wantWindow int
+		wantDepth  int
+		wantErr    string
+	}{
+		{name: "base name", args: []string{"out/pack"}, wantBase: "out/pack"},
+		{name: "stdout", args: []string{"--stdout"}, wantStdout: true},
+		{name: "window and depth", args: []string{"--window=4", "--depth=2", "pack"}, wantBase: "pack", wantWindow: 4, wantDepth: 2},
+		{name: "window zero disables deltas", args: []string{"--window=0", "--stdout"}, wantStdout: true, wantWindow: -1},
+		{name: "missing output", args: nil, wantErr: "usage: gitvista-cli pack-objects"},
+		{name: "both outputs", args: []string{"--stdout", "pack"}, wantErr: "usage: gitvista-cli pack-objects"},
+		{name: "bad window", args: []string{"--window=x", "pack"}, wantErr: "invalid window"},
+		{name: "bad depth", args: []string{"--depth=0", "pack"}, wantErr: "invalid depth"},
+		{name: "unsupported flag", args: []string{"--thin", "pack"}, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parsePackObjectsArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != 1 {
+					t.Fatalf("parsePackObjectsArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 || opts.baseName != tt.wantBase || opts.stdout != tt.wantStdout ||
+				opts.window != tt.wantWindow || opts.depth != tt.wantDepth {
+				t.Fatalf("parsePackObjectsArgs() = (%+v, %d, %v)", opts, code, err)
+			}
+		})
+	}
+}
+
+func TestRunPackObjectsWritesPackAndIndex(t *testing.T) {
+	repo := newStatusCLIRepo(t)
+	commit := repo.Head()
+	commitObj, err := repo.CatFile(gitcore.CatFileOptions{Revision: string(commit)})
+	if err != nil {
+		t.Fatalf("CatFile() error = %v", err)
+	}
+	tree := strings.Fields(string(commitObj.Data))[1]
+
+	// rev-list --objects output, with a path and a duplicate.
+	input := string(commit) + "\n" + tree + " \n\n" + string(commit) + "\n"
+	base := filepath.Join(t.TempDir(), "pack")
+	repoCtx := &repositoryContext{repo: repo}
+	stdout, stderr, code := captureCLIOutput(t, func() int {
+		return runPackObjects(repoCtx, []string{base}, strings.NewReader(input))
+	})
+	if code != 0 || stderr != "" {
+		t.Fatalf("runPackObjects() = code %d stderr %q", code, stderr)
+	}
+
+	checksum := strings.TrimSpace(stdout)
+	idx, err := gitcore.NewPackIndex(base + "-" + checksum + ".idx")
+	if err != nil {
+		t.Fatalf("NewPackIndex() error = %v", err)
+	}
+	if idx.NumObjects() != 2 {
+		t.Fatalf("pack holds %d objects, want 2", idx.NumObjects())
+	}
+	for _, id := range []gitcore.Hash{commit, gitcore.Hash(tree)} {
+		if _, ok := idx.FindObject(id); !ok {
+			t.Errorf("pack index is missing %s", id)
+		}
+	}
+
+	_, stderr, code = captureCLIOutput(t, func() int {
+		return runPackObjects(repoCtx, []string{base}, strings.NewReader("not-a-hash\n"))
+	})
+	if code != 128 || !strings.Contains(stderr, "expected object ID") {
+		t.Fatalf("runPackObjects(garbage) = code %d stderr %q", code, stderr)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rybkr/gitvista/internal/analytics"
+	"github.com/rybkr/gitvista/internal/cli"
+)
+
+type releasesOptions struct {
+	pattern string
+	json    bool
+}
+
+func runReleases(repoCtx *repositoryContext, args []string, cw *cli.Writer) int {
+	opts, exitCode, err := parseReleasesArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode
+	}
+
+	releases, err := analytics.BuildReleases(repoCtx.repo, analytics.ReleaseOptions{Pattern: opts.pattern})
+	if errors.Is(err, analytics.ErrInvalidTagPattern) {
+		fmt.Fprintf(os.Stderr, "gitvista-cli releases: %v\n", err)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 128
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(releases); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 128
+		}
+		return 0
+	}
+
+	fmt.Printf("%s %d releases, %.1f per month, %d unreleased commits\n",
+		cw.Command("Releases"), len(releases.Releases), releases.PerMonth, releases.Unreleased)
+	fmt.Printf("  %s median %s, p90 %s\n", cw.Cyan("lead time"),
+		formatLeadHours(releases.LeadTime.MedianHours), formatLeadHours(releases.LeadTime.P90Hours))
+	for _, r := range releases.Releases {
+		fmt.Printf("%s %s  %d commits  %d contributors  %d files +%d -%d  lead %s / %s\n",
+			cw.Yellow(r.Tag), strings.SplitN(r.Date, "T", 2)[0], r.Commits, r.Contributors,
+			r.Diffstat.FilesChanged, r.Diffstat.Additions, r.Diffstat.Deletions,
+			formatLeadHours(r.LeadTime.MedianHours), formatLeadHours(r.LeadTime.P90Hours))
+	}
+	return 0
+}
+
+// formatLeadHours prints a duration in hours, or days once it reaches two.
+func formatLeadHours(hours float64) string {
+	if hours < 48 {
+		return fmt.Sprintf("%.1fh", hours)
+	}
+	return fmt.Sprintf("%.1fd", hours/24)
+}
+
+func parseReleasesArgs(args []string) (releasesOptions, int, error) {
+	var opts releasesOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--json":
+			opts.json = true
+		case arg == "--pattern":
+			if i+1 >= len(args) {
+				return releasesOptions{}, 1, fmt.Errorf("gitvista-cli releases: --pattern requires a glob")
+			}
+			i++
+			opts.pattern = args[i]
+		case strings.HasPrefix(arg, "--pattern="):
+			opts.pattern = strings.TrimPrefix(arg, "--pattern=")
+		default:
+			return releasesOptions{}, 1, fmt.Errorf("gitvista-cli releases: unsupported argument %q", arg)
+		}
+	}
+	return opts, 0, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/cli"
+)
+
+func TestParseReleasesArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantPattern string
+		wantJSON    bool
+		wantErr     string
+	}{
+		{name: "defaults", args: nil},
+		{name: "pattern and json", args: []string{"--pattern", "v*", "--json"}, wantPattern: "v*", wantJSON: true},
+		{name: "pattern equals", args: []string{"--pattern=release-*"}, wantPattern: "release-*"},
+		{name: "missing pattern", args: []string{"--pattern"}, wantErr: "requires a glob"},
+		{name: "unsupported", args: []string{"v1.0"}, wantErr: "unsupported argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, code, err := parseReleasesArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || code != 1 {
+					t.Fatalf("parseReleasesArgs() = (%+v, %d, %v)", opts, code, err)
+				}
+				return
+			}
+			if err != nil || code != 0 || opts.pattern != tt.wantPattern || opts.json != tt.wantJSON {
+				t.Fatalf("parseReleasesArgs() = (%+v, %d, %v)", opts, code, err)
+			}
+		})
+	}
+}
+
+func TestRunReleases(t *testing.T) {
+	repoDir, gitDir := newStatusCLIRepoDir(t)
+	commitID := writeStatusCommit(t, gitDir, writeStatusTree(t, gitDir))
+	writeCLITextFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/main\n")
+	writeCLITextFile(t, filepath.Join(gitDir, "refs", "heads", "main"), string(commitID)+"\n")
+	writeCLITextFile(t, filepath.Join(gitDir, "refs", "tags", "v1.0"), string(commitID)+"\n")
+	repo, err := gitcore.NewRepository(repoDir)
+	if err != nil {
+		t.Fatalf("NewRepository() error: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	repoCtx := &repositoryContext{repo: repo}
+	cw := cli.NewWriter(os.Stdout, cli.ColorNever)
+
+	stdout, stderr, code := captureCLIOutput(t, func() int {
+		return runReleases(repoCtx, []string{"--pattern", "v*", "--json"}, cw)
+	})
+	if code != 0 || stderr != "" {
+		t.Fatalf("runReleases(--json) = code %d stderr %q", code, stderr)
+	}
+	var releases struct {
+		Pattern  string `json:"pattern"`
+		Releases []struct {
+			Tag     string `json:"tag"`
+			Commits int    `json:"commits"`
+		} `json:"releases"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &releases); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout, err)
+	}
+	if releases.Pattern != "v*" || len(releases.Releases) != 1 || releases.Releases[0].Tag != "v1.0" || releases.Releases[0].Commits != 1 {
+		t.Fatalf("releases = %+v", releases)
+	}
+
+	stdout, _, code = captureCLIOutput(t, func() int { return runReleases(repoCtx, nil, cw) })
+	if code != 0 || !strings.Contains(stdout, "1 releases") || !strings.Contains(stdout, "v1.0 2023-11-14  1 commits") {
+		t.Fatalf("runReleases() = code %d stdout %q", code, stdout)
+	}
+
+	_, stderr, code = captureCLIOutput(t, func() int { return runReleases(repoCtx, []string{"--pattern", "v["}, cw) })
+	if code != 1 || !strings.Contains(stderr, "invalid tag pattern") {
+		t.Fatalf("runReleases(bad pattern) = code %d stderr %q", code, stderr)
+	}
+}
@@ -22,7 +22,10 @@ func runRepo(repoCtx *repositoryContext, args []string, cw *cli.Writer) int {
 	}
 
 	repoType := "worktree"
-	if repo.IsBare() {
+	switch {
+	case repo.IsBundle():
+		repoType = "bundle"
+	case repo.IsBare():
 		repoType = "bare"
 	}
 
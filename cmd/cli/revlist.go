@@ -48,16 +48,21 @@ type revListOptions struct {
 	count     bool
 	noMerges  bool
 	orderMode revListOrder
+	pickaxe   gitcore.PickaxeOptions
 }
 
 func parseRevListArgs(args []string) (revListOptions, int, error) {
 	if len(args) == 0 {
-		return revListOptions{}, 1, fmt.Errorf("usage: gitvista-cli rev-list [--all | <commit>] [--count] [--no-merges] [--topo-order] [--date-order]")
+		return revListOptions{}, 1, fmt.Errorf("usage: gitvista-cli rev-list [--all | <commit>] [--count] [--no-merges] [--topo-order] [--date-order] [-S<string> | -G<regex>] [--pickaxe-regex] [-i] [-- <pathspec>...]")
 	}
 
 	opts := revListOptions{orderMode: revListOrderChronological}
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch arg {
+		case "--":
+			opts.pickaxe.Pathspecs = append(opts.pickaxe.Pathspecs, args[i+1:]...)
+			i = len(args)
 		case "--all":
 			opts.all = true
 		case "--count":
@@ -68,8 +73,22 @@ func parseRevListArgs(args []string) (revListOptions, int, error) {
 			opts.orderMode = revListOrderTopo
 		case "--date-order":
 			opts.orderMode = revListOrderDate
+		case "--pickaxe-regex":
+			opts.pickaxe.PickaxeRegex = true
+		case "-i", "--regexp-ignore-case":
+			opts.pickaxe.IgnoreCase = true
+		case "-S", "-G":
+			if i+1 >= len(args) {
+				return revListOptions{}, 1, fmt.Errorf("gitvista-cli rev-list: %s requires a value", arg)
+			}
+			i++
+			setRevListPickaxe(&opts.pickaxe, arg, args[i])
 		default:
-			if strings.HasPrefix(arg, "--") {
+			if strings.HasPrefix(arg, "-S") || strings.HasPrefix(arg, "-G") {
+				setRevListPickaxe(&opts.pickaxe, arg[:2], arg[2:])
+				continue
+			}
+			if strings.HasPrefix(arg, "-") {
 				return revListOptions{}, 1, fmt.Errorf("gitvista-cli rev-list: unsupported argument %q", arg)
 			}
 			if opts.revision != "" {
@@ -82,6 +101,12 @@ func parseRevListArgs(args []string) (revListOptions, int, error) {
 	if !opts.all && opts.revision == "" {
 		return revListOptions{}, 1, fmt.Errorf("gitvista-cli rev-list: missing revision (expected <commit> or --all)")
 	}
+	if opts.pickaxe.String != "" && opts.pickaxe.Regex != "" {
+		return revListOptions{}, 1, fmt.Errorf("gitvista-cli rev-list: -S and -G cannot be used together")
+	}
+	if !opts.pickaxe.Enabled() && (opts.pickaxe.PickaxeRegex || opts.pickaxe.IgnoreCase || len(opts.pickaxe.Pathspecs) > 0) {
+		return revListOptions{}, 1, fmt.Errorf("gitvista-cli rev-list: --pickaxe-regex, -i, and pathspecs require -S or -G")
+	}
 
 	return opts, 0, nil
 }
@@ -92,6 +117,7 @@ func revList(repo *gitcore.Repository, opts revListOptions) ([]*gitcore.Commit,
 		Revision: opts.revision,
 		NoMerges: opts.noMerges,
 		Order:    mapRevListOrder(opts.orderMode),
+		Pickaxe:  opts.pickaxe,
 	})
 	if err != nil {
 		return nil, 128, err
@@ -99,6 +125,14 @@ func revList(repo *gitcore.Repository, opts revListOptions) ([]*gitcore.Commit,
 	return commits, 0, nil
 }
 
+func setRevListPickaxe(pickaxe *gitcore.PickaxeOptions, flag, value string) {
+	if flag == "-S" {
+		pickaxe.String = value
+		return
+	}
+	pickaxe.Regex = value
+}
+
 func mapRevListOrder(order revListOrder) gitcore.RevListOrder {
 	switch order {
 	case revListOrderTopo:
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/rybkr/gitvista/gitcore"
@@ -32,6 +33,53 @@ func TestParseRevListArgs(t *testing.T) {
 				orderMode: revListOrderDate,
 			},
 		},
+		{
+			name: "pickaxe string with pathspecs",
+			args: []string{"-Sneedle", "-i", "main", "--", "docs", "*.go"},
+			want: revListOptions{
+				revision: "main",
+				pickaxe: gitcore.PickaxeOptions{
+					String:     "needle",
+					IgnoreCase: true,
+					Pathspecs:  []string{"docs", "*.go"},
+				},
+			},
+		},
+		{
+			name: "pickaxe regex as separate value",
+			args: []string{"--all", "-G", "func \\w+", "--count"},
+			want: revListOptions{
+				all:     true,
+				count:   true,
+				pickaxe: gitcore.PickaxeOptions{Regex: "func \\w+"},
+			},
+		},
+		{
+			name: "pickaxe regex flag",
+			args: []string{"HEAD", "-S", "v[0-9]+", "--pickaxe-regex"},
+			want: revListOptions{
+				revision: "HEAD",
+				pickaxe:  gitcore.PickaxeOptions{String: "v[0-9]+", PickaxeRegex: true},
+			},
+		},
+		{
+			name:     "pickaxe modes conflict",
+			args:     []string{"HEAD", "-Sa", "-Gb"},
+			wantCode: 1,
+			wantErr:  "cannot be used together",
+		},
+		{
+			name:     "pathspec without pickaxe",
+			args:     []string{"HEAD", "--", "docs"},
+			wantCode: 1,
+			wantErr:  "require -S or -G",
+		},
+		{
+			name:     "pickaxe missing value",
+			args:     []string{"HEAD", "-G"},
+			wantCode: 1,
+			wantErr:  "-G requires a value",
+		},
 		{
 			name:     "missing selector",
 			args:     []string{"--count"},
@@ -64,7 +112,7 @@ func TestParseRevListArgs(t *testing.T) {
 			if err != nil || code != 0 {
 				t.Fatalf("parseRevListArgs() = (%+v, %d, %v)", got, code, err)
 			}
-			if got != tt.want {
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Fatalf("parseRevListArgs() = %+v, want %+v", got, tt.want)
 			}
 		})
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/internal/auth"
+)
+
+const (
+	authModeNone  = "none"
+	authModeToken = "token"
+	authModeBasic = "basic"
+	authModeOIDC  = "oidc"
+)
+
+func registerAuthFlags(fs *flag.FlagSet, flags *appFlags, getenv func(string, string) string) {
+	fs.StringVar(&flags.authMode, "auth", getenv("GITVISTA_AUTH", ""), "Authentication: none, token, basic, oidc (default: token off loopback)")
+	fs.StringVar(&flags.authToken, "auth-token", getenv("GITVISTA_AUTH_TOKEN", ""), "Static bearer token accepted from API clients")
+	fs.StringVar(&flags.htpasswd, "htpasswd", getenv("GITVISTA_HTPASSWD", ""), "htpasswd file for HTTP basic auth")
+	fs.StringVar(&flags.oidcIssuer, "oidc-issuer", getenv("GITVISTA_OIDC_ISSUER", ""), "OpenID Connect issuer URL")
+	fs.StringVar(&flags.oidcClientID, "oidc-client-id", getenv("GITVISTA_OIDC_CLIENT_ID", ""), "OpenID Connect client ID")
+	fs.StringVar(&flags.oidcRedirectURL, "oidc-redirect-url", getenv("GITVISTA_OIDC_REDIRECT_URL", ""), "OpenID Connect callback URL (default: derived from the request)")
+	fs.StringVar(&flags.oidcAllowedEmails, "oidc-allowed-emails", getenv("GITVISTA_OIDC_ALLOWED_EMAILS", ""), "Comma-separated emails or @domains allowed to sign in")
+	// The client secret is read from the environment only so it never
+	// appears in process listings.
+	flags.oidcClientSecret = getenv("GITVISTA_OIDC_CLIENT_SECRET", "")
+}
+
+// resolveAuthMode picks the authentication mode. An explicit -auth wins;
+// otherwise the mode follows from the credentials configured, and a server
+// bound beyond loopback defaults to token auth so it is never left open.
+func resolveAuthMode(parsed appFlags) (string, error) {
+	switch parsed.authMode {
+	case authModeNone, authModeToken, authModeBasic, authModeOIDC:
+		return parsed.authMode, nil
+	case "":
+	default:
+		return "", fmt.Errorf("-auth %q is not valid; use none, token, basic, or oidc", parsed.authMode)
+	}
+
+	switch {
+	case parsed.oidcIssuer != "":
+		return authModeOIDC, nil
+	case parsed.htpasswd != "":
+		return authModeBasic, nil
+	case parsed.authToken != "":
+		return authModeToken, nil
+	case !isLoopbackHost(resolveBindHost(parsed.host)):
+		return authModeToken, nil
+	}
+	return authModeNone, nil
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// buildAuthenticator returns nil when authentication is disabled. The token
+// provider, when present, is returned so the caller can issue login links.
+func buildAuthenticator(ctx context.Context, parsed appFlags) (*auth.Authenticator, *auth.TokenProvider, error) {
+	mode, err := resolveAuthMode(parsed)
+	if err != nil || mode == authModeNone {
+		return nil, nil, err
+	}
+
+	var providers []auth.Provider
+	switch mode {
+	case authModeBasic:
+		if parsed.htpasswd == "" {
+			return nil, nil, fmt.Errorf("-auth basic requires -htpasswd")
+		}
+		basic, err := auth.LoadHtpasswd(parsed.htpasswd)
+		if err != nil {
+			return nil, nil, err
+		}
+		providers = append(providers, basic)
+	case authModeOIDC:
+		if parsed.oidcIssuer == "" || parsed.oidcClientID == "" {
+			return nil, nil, fmt.Errorf("-auth oidc requires -oidc-issuer and -oidc-client-id")
+		}
+		var allowed []string
+		for _, entry := range strings.Split(parsed.oidcAllowedEmails, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				allowed = append(allowed, entry)
+			}
+		}
+		discoverCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		oidc, err := auth.NewOIDCProvider(discoverCtx, auth.OIDCConfig{
+			Issuer:        parsed.oidcIssuer,
+			ClientID:      parsed.oidcClientID,
+			ClientSecret:  parsed.oidcClientSecret,
+			RedirectURL:   parsed.oidcRedirectURL,
+			AllowedEmails: allowed,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		providers = append(providers, oidc)
+	}
+
+	// Token mode always has a token provider for login links; other modes
+	// add one only when a static token is configured for scripts.
+	var tokens *auth.TokenProvider
+	if mode == authModeToken || parsed.authToken != "" {
+		tokens = auth.NewTokenProvider(parsed.authToken)
+		providers = append(providers, tokens)
+	}
+
+	authenticator, err := auth.New(auth.Options{Providers: providers})
+	if err != nil {
+		return nil, nil, err
+	}
+	return authenticator, tokens, nil
+}
+
+// withLoginLink rewrites openURL into a one-time login link that lands on the
+// same page, keeping its fragment.
+func withLoginLink(baseURL, openURL, code string) string {
+	u, err := neturl.Parse(openURL)
+	if err != nil {
+		return openURL
+	}
+	link := baseURL + auth.LoginLink(code, u.RequestURI())
+	if u.Fragment != "" {
+		link += "#" + u.Fragment
+	}
+	return link
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveAuthMode(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags appFlags
+		want  string
+	}{
+		{name: "loopback default", flags: appFlags{}, want: authModeNone},
+		{name: "explicit loopback", flags: appFlags{host: "::1"}, want: authModeNone},
+		{name: "all interfaces default", flags: appFlags{host: "0.0.0.0"}, want: authModeToken},
+		{name: "explicit none wins", flags: appFlags{host: "0.0.0.0", authMode: "none"}, want: authModeNone},
+		{name: "htpasswd implies basic", flags: appFlags{htpasswd: "users"}, want: authModeBasic},
+		{name: "issuer implies oidc", flags: appFlags{oidcIssuer: "https://id.example", htpasswd: "users"}, want: authModeOIDC},
+		{name: "token implies token", flags: appFlags{authToken: "abc"}, want: authModeToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAuthMode(tt.flags)
+			if err != nil {
+				t.Fatalf("resolveAuthMode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("resolveAuthMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := resolveAuthMode(appFlags{authMode: "ldap"}); err == nil {
+		t.Fatal("resolveAuthMode(ldap) error = nil, want error")
+	}
+}
+
+func TestBuildAuthenticator(t *testing.T) {
+	a, tokens, err := buildAuthenticator(context.Background(), appFlags{})
+	if err != nil || a != nil || tokens != nil {
+		t.Fatalf("loopback: authenticator = %v, tokens = %v, err = %v; want all nil", a, tokens, err)
+	}
+
+	a, tokens, err = buildAuthenticator(context.Background(), appFlags{host: "0.0.0.0"})
+	if err != nil || a == nil || tokens == nil {
+		t.Fatalf("token mode: authenticator = %v, tokens = %v, err = %v", a, tokens, err)
+	}
+
+	if _, _, err := buildAuthenticator(context.Background(), appFlags{authMode: authModeBasic}); err == nil || !strings.Contains(err.Error(), "-htpasswd") {
+		t.Fatalf("basic without htpasswd: err = %v", err)
+	}
+	if _, _, err := buildAuthenticator(context.Background(), appFlags{authMode: authModeOIDC}); err == nil || !strings.Contains(err.Error(), "-oidc-issuer") {
+		t.Fatalf("oidc without issuer: err = %v", err)
+	}
+
+	htpasswd := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(htpasswd, []byte("bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"), 0o600); err != nil {
+		t.Fatalf("write htpasswd: %v", err)
+	}
+	a, tokens, err = buildAuthenticator(context.Background(), appFlags{htpasswd: htpasswd})
+	if err != nil || a == nil || tokens != nil {
+		t.Fatalf("basic: authenticator = %v, tokens = %v, err = %v", a, tokens, err)
+	}
+}
+
+func TestWithLoginLink(t *testing.T) {
+	tests := []struct {
+		open string
+		want string
+	}{
+		{open: "http://0.0.0.0:8080", want: "http://0.0.0.0:8080/auth/login?code=c0de"},
+		{
+			open: "http://0.0.0.0:8080?path=internal%2Fserver#abcdef",
+			want: "http://0.0.0.0:8080/auth/login?code=c0de&next=%2F%3Fpath%3Dinternal%252Fserver#abcdef",
+		},
+	}
+	for _, tt := range tests {
+		if got := withLoginLink("http://0.0.0.0:8080", tt.open, "c0de"); got != tt.want {
+			t.Errorf("withLoginLink(%q) = %q, want %q", tt.open, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/rybkr/gitvista/internal/diskcache"
+)
+
+// cacheDirOff disables the persistent cache when passed to -cache-dir.
+const cacheDirOff = "off"
+
+func registerCacheFlags(fs *flag.FlagSet, flags *appFlags, getenv func(string, string) string) {
+	fs.StringVar(&flags.cacheDir, "cache-dir", getenv("GITVISTA_CACHE_DIR", ""), "Persistent cache directory, or off (default: .git/gitvista/cache)")
+}
+
+// openDiskCache opens the persistent cache for the repository at gitDir. It
+// returns nil when the cache is turned off.
+func openDiskCache(parsed appFlags, gitDir string) (*diskcache.Cache, error) {
+	dir := parsed.cacheDir
+	switch dir {
+	case cacheDirOff:
+		return nil, nil
+	case "":
+		var err error
+		if dir, err = diskcache.Dir(gitDir); err != nil {
+			return nil, err
+		}
+	}
+	return diskcache.Open(dir, diskcache.Options{})
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rybkr/gitvista/internal/cli"
+	"github.com/rybkr/gitvista/internal/config"
+	"github.com/rybkr/gitvista/internal/server"
+)
+
+const configActionPrint = "print"
+
+// Sources of a setting's effective value, from lowest to highest precedence:
+// the built-in default, the user's config file, the repository's
+// .gitvista.toml, an environment variable, and a command-line flag.
+const (
+	sourceDefault = "default"
+	sourceEnv     = "env "
+	sourceFlag    = "flag -"
+)
+
+// configSetting is a setting that may be read from a configuration file.
+type configSetting struct {
+	key  string
+	env  string
+	flag string
+	def  string
+	// repo allows the setting in a per-repository file. Settings that decide
+	// where GitVista listens, what it exposes, who may sign in, or where it
+	// writes files are left out, so a cloned repository cannot change them.
+	repo bool
+	// check validates a restart-only setting.
+	check func(string) error
+	// tune stores the value in the server tuning. These settings are applied
+	// again when the configuration is reloaded.
+	tune func(*server.Tuning, string) error
+}
+
+var configSettings = []configSetting{
+	{key: "server.host", env: "GITVISTA_HOST", flag: "host"},
+	{key: "server.port", env: "GITVISTA_PORT", flag: "port", def: "8080", repo: true, check: checkPort},
+	{key: "server.git_http", env: "GITVISTA_GIT_HTTP", flag: "git-http", def: "false", check: checkBool},
+	{key: "auth.mode", env: "GITVISTA_AUTH", flag: "auth"},
+	{key: "auth.htpasswd", env: "GITVISTA_HTPASSWD", flag: "htpasswd"},
+	{key: "auth.oidc_issuer", env: "GITVISTA_OIDC_ISSUER", flag: "oidc-issuer"},
+	{key: "auth.oidc_client_id", env: "GITVISTA_OIDC_CLIENT_ID", flag: "oidc-client-id"},
+	{key: "auth.oidc_redirect_url", env: "GITVISTA_OIDC_REDIRECT_URL", flag: "oidc-redirect-url"},
+	{key: "auth.oidc_allowed_emails", env: "GITVISTA_OIDC_ALLOWED_EMAILS", flag: "oidc-allowed-emails"},
+	{key: "tls.cert", env: "GITVISTA_TLS_CERT", flag: "tls-cert"},
+	{key: "tls.key", env: "GITVISTA_TLS_KEY", flag: "tls-key"},
+	{key: "tls.self_signed", env: "GITVISTA_TLS_SELF_SIGNED", flag: "tls-self-signed", def: "false", check: checkBool},
+	{key: "cache.dir", env: "GITVISTA_CACHE_DIR", flag: "cache-dir"},
+	{key: "cache.entries", env: "GITVISTA_CACHE_SIZE", def: "500", repo: true,
+		tune: intSetting(func(t *server.Tuning, n int) { t.CacheEntries = n })},
+	{key: "cache.bytes", env: "GITVISTA_CACHE_BYTES", def: "256MiB", repo: true,
+		tune: bytesSetting(func(t *server.Tuning, n int64) { t.CacheBytes = n })},
+	{key: "cache.ttl", env: "GITVISTA_CACHE_TTL", def: "0s", repo: true,
+		tune: durationSetting(true, func(t *server.Tuning, d time.Duration) { t.CacheTTL = d })},
+	{key: "watcher.debounce", def: "100ms", repo: true,
+		tune: durationSetting(false, func(t *server.Tuning, d time.Duration) { t.WatchDebounce = d })},
+	{key: "watcher.status_poll_interval", def: "2s", repo: true,
+		tune: durationSetting(false, func(t *server.Tuning, d time.Duration) { t.StatusPollInterval = d })},
+	{key: "bootstrap.window_days", def: "30", repo: true,
+		tune: intSetting(func(t *server.Tuning, n int) { t.BootstrapWindowDays = n })},
+	{key: "bootstrap.first_batch_bytes", def: "450KiB", repo: true,
+		tune: bytesSetting(func(t *server.Tuning, n int64) { t.BootstrapFirstBatchBytes = int(n) })},
+	{key: "bootstrap.batch_bytes", def: "300KiB", repo: true,
+		tune: bytesSetting(func(t *server.Tuning, n int64) { t.BootstrapBatchBytes = int(n) })},
+	{key: "bootstrap.max_commits_per_batch", def: "300", repo: true,
+		tune: intSetting(func(t *server.Tuning, n int) { t.BootstrapMaxCommitsPerBatch = n })},
+	{key: "bootstrap.batch_pause", def: "8ms", repo: true,
+		tune: durationSetting(true, func(t *server.Tuning, d time.Duration) { t.BootstrapBatchPause = d })},
+	{key: "bootstrap.force_mode_max_commits", def: "10000", repo: true,
+		tune: intSetting(func(t *server.Tuning, n int) { t.ForceModeMaxCommits = n })},
+}
+
+func settingByKey(key string) (configSetting, bool) {
+	i := slices.IndexFunc(configSettings, func(s configSetting) bool { return s.key == key })
+	if i < 0 {
+		return configSetting{}, false
+	}
+	return configSettings[i], true
+}
+
+func settingByEnv(env string) (configSetting, bool) {
+	i := slices.IndexFunc(configSettings, func(s configSetting) bool { return s.env != "" && s.env == env })
+	if i < 0 {
+		return configSetting{}, false
+	}
+	return configSettings[i], true
+}
+
+// loadedConfig is the user and repository configuration files, layered under
+// the environment.
+type loadedConfig struct {
+	userPath string
+	repoPath string
+	user     *config.File
+	repo     *config.File
+	// errs holds files that could not be read or parsed; they are reported
+	// by validate so that help and version still work with a broken file.
+	errs []error
+	env  func(string, string) string
+}
+
+// loadConfig reads the user's config file and, when repoPath is set, the
+// .gitvista.toml of the repository containing it.
+func loadConfig(repoPath string, getenv func(string, string) string) *loadedConfig {
+	c := &loadedConfig{env: getenv}
+	if path, err := config.UserFile(); err == nil {
+		c.userPath = path
+		c.user, err = config.Load(path)
+		if err != nil {
+			c.errs = append(c.errs, err)
+		}
+	}
+	if repoPath != "" {
+		if path := config.RepoFile(repoPath); path != "" {
+			c.repoPath = path
+			var err error
+			c.repo, err = config.Load(path)
+			if err != nil {
+				c.errs = append(c.errs, err)
+			}
+		}
+	}
+	return c
+}
+
+// parseFlagsWithConfig parses args with configuration file values standing in
+// for unset environment variables. Which repository file applies depends on
+// -repo, so the arguments are parsed once to find the repository and again
+// with its file loaded.
+func parseFlagsWithConfig(args []string, getenv func(string, string) string) (appFlags, *loadedConfig, error) {
+	cfg := loadConfig("", getenv)
+	parsed, err := parseFlags(args, cfg.getenv)
+	if err != nil || parsed.showHelp {
+		return parsed, cfg, err
+	}
+	repoPath := parsed.repoPath
+	if repoPath == "" {
+		repoPath = "."
+	}
+	cfg = loadConfig(repoPath, getenv)
+	if cfg.repo == nil {
+		return parsed, cfg, nil
+	}
+	parsed, err = parseFlags(args, cfg.getenv)
+	return parsed, cfg, err
+}
+
+// getenv looks key up in the environment and then in the configuration files,
+// for use as parseFlags' getenv.
+func (c *loadedConfig) getenv(key, fallback string) string {
+	if v := c.env(key, ""); v != "" {
+		return v
+	}
+	if s, ok := settingByEnv(key); ok {
+		if v, _, ok := c.fileValue(s); ok {
+			return v
+		}
+	}
+	return fallback
+}
+
+// fileValue returns the value of s from the repository file, or else the user
+// file, with its location.
+func (c *loadedConfig) fileValue(s configSetting) (string, string, bool) {
+	if c.repo != nil && s.repo {
+		if v, ok := c.repo.Values[s.key]; ok {
+			return v.Text, c.repo.Location(s.key), true
+		}
+	}
+	if c.user != nil {
+		if v, ok := c.user.Values[s.key]; ok {
+			return v.Text, c.user.Location(s.key), true
+		}
+	}
+	return "", "", false
+}
+
+// resolve returns the effective value of s and where it came from. explicit
+// holds the flags given on the command line.
+func (c *loadedConfig) resolve(s configSetting, explicit map[string]string) (string, string) {
+	if s.flag != "" {
+		if v, ok := explicit[s.flag]; ok {
+			return v, sourceFlag + s.flag
+		}
+	}
+	if s.env != "" {
+		if v := c.env(s.env, ""); v != "" {
+			return v, sourceEnv + s.env
+		}
+	}
+	if v, loc, ok := c.fileValue(s); ok {
+		return v, loc
+	}
+	return s.def, sourceDefault
+}
+
+// validate reports unreadable files, unknown keys, settings a repository file
+// may not change, and invalid values.
+func (c *loadedConfig) validate() error {
+	return errors.Join(c.problems()...)
+}
+
+func (c *loadedConfig) problems() []error {
+	errs := slices.Clone(c.errs)
+	for _, f := range []*config.File{c.user, c.repo} {
+		if f == nil {
+			continue
+		}
+		keys := make([]string, 0, len(f.Values))
+		for key := range f.Values {
+			keys = append(keys, key)
+		}
+		slices.SortFunc(keys, func(a, b string) int { return f.Values[a].Line - f.Values[b].Line })
+		for _, key := range keys {
+			s, ok := settingByKey(key)
+			switch {
+			case !ok:
+				errs = append(errs, fmt.Errorf("%s: unknown setting %q", f.Location(key), key))
+			case f == c.repo && !s.repo:
+				errs = append(errs, fmt.Errorf("%s: %s cannot be set in %s; use the user config file, environment, or flags", f.Location(key), key, config.RepoFileName))
+			default:
+				if err := s.validate(f.Values[key].Text); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s: %w", f.Location(key), key, err))
+				}
+			}
+		}
+	}
+	for _, s := range configSettings {
+		if s.env == "" {
+			continue
+		}
+		if v := c.env(s.env, ""); v != "" {
+			if err := s.validate(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	return errs
+}
+
+func (s configSetting) validate(value string) error {
+	switch {
+	case s.tune != nil:
+		var t server.Tuning
+		return s.tune(&t, value)
+	case s.check != nil:
+		return s.check(value)
+	}
+	return nil
+}
+
+// tuning returns the server tuning the configuration describes.
+func (c *loadedConfig) tuning() (server.Tuning, error) {
+	t := server.DefaultTuning()
+	for _, s := range configSettings {
+		if s.tune == nil {
+			continue
+		}
+		value, source := c.resolve(s, nil)
+		if err := s.tune(&t, value); err != nil {
+			return t, fmt.Errorf("%s: %s: %w", source, s.key, err)
+		}
+	}
+	return t, nil
+}
+
+// reloadConfig rereads the configuration files and applies the tuning they
+// describe to serv. Invalid files leave the current settings in place, and
+// changed restart-only settings are reported rather than applied.
+func reloadConfig(serv *server.Server, parsed appFlags, current *loadedConfig) *loadedConfig {
+	next := loadConfig(parsed.repoPath, current.env)
+	if err := next.validate(); err != nil {
+		slog.Error("Configuration reload failed; keeping current settings", "err", err)
+		return current
+	}
+	tuning, err := next.tuning()
+	if err != nil {
+		slog.Error("Configuration reload failed; keeping current settings", "err", err)
+		return current
+	}
+	serv.SetTuning(tuning)
+	for _, s := range configSettings {
+		if s.tune != nil {
+			continue
+		}
+		before, _ := current.resolve(s, parsed.explicit)
+		if after, source := next.resolve(s, parsed.explicit); after != before {
+			slog.Warn("Setting changed; restart GitVista to apply it", "key", s.key, "source", source)
+		}
+	}
+	slog.Info("Configuration reloaded", "user", next.userPath, "repo", next.repoPath)
+	return next
+}
+
+func checkPort(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %q", value)
+	}
+	return nil
+}
+
+func checkBool(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("expected true or false, got %q", value)
+	}
+	return nil
+}
+
+func intSetting(set func(*server.Tuning, int)) func(*server.Tuning, string) error {
+	return func(t *server.Tuning, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("expected a positive integer, got %q", value)
+		}
+		set(t, n)
+		return nil
+	}
+}
+
+func bytesSetting(set func(*server.Tuning, int64)) func(*server.Tuning, string) error {
+	return func(t *server.Tuning, value string) error {
+		n, err := server.ParseByteSize(value)
+		if err != nil {
+			return err
+		}
+		if n <= 0 || n > 1<<40 {
+			return fmt.Errorf("byte size %q is out of range", value)
+		}
+		set(t, n)
+		return nil
+	}
+}
+
+func durationSetting(allowZero bool, set func(*server.Tuning, time.Duration)) func(*server.Tuning, string) error {
+	return func(t *server.Tuning, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 250ms or 2s, got %q", value)
+		}
+		if d < 0 || (d == 0 && !allowZero) {
+			return fmt.Errorf("duration %q must be positive", value)
+		}
+		set(t, d)
+		return nil
+	}
+}
+
+type configFileReport struct {
+	Scope string `json:"scope"`
+	Path  string `json:"path"`
+	Found bool   `json:"found"`
+}
+
+type configSettingReport struct {
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Source     string `json:"source"`
+	Reloadable bool   `json:"reloadable"`
+}
+
+type configReport struct {
+	Files    []configFileReport    `json:"files"`
+	Settings []configSettingReport `json:"settings"`
+	Errors   []string              `json:"errors,omitempty"`
+}
+
+func buildConfigReport(parsed appFlags, cfg *loadedConfig) configReport {
+	report := configReport{
+		Files: []configFileReport{
+			{Scope: "user", Path: cfg.userPath, Found: cfg.user != nil},
+			{Scope: "repo", Path: cfg.repoPath, Found: cfg.repo != nil},
+		},
+		Settings: make([]configSettingReport, 0, len(configSettings)),
+	}
+	for _, s := range configSettings {
+		value, source := cfg.resolve(s, parsed.explicit)
+		report.Settings = append(report.Settings, configSettingReport{
+			Key:        s.key,
+			Value:      value,
+			Source:     source,
+			Reloadable: s.tune != nil,
+		})
+	}
+	for _, err := range cfg.problems() {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	return report
+}
+
+// runConfigPrint prints every setting's effective value and where it came
+// from. It fails when the configuration is invalid.
+func runConfigPrint(parsed appFlags, cfg *loadedConfig, cw *cli.Writer) int {
+	report := buildConfigReport(parsed, cfg)
+	code := 0
+	if len(report.Errors) > 0 {
+		code = 1
+	}
+
+	if parsed.jsonOutput {
+		data, _ := json.Marshal(report)
+		fmt.Println(string(data))
+		return code
+	}
+
+	fmt.Printf("%s %s\n", cw.Command("GitVista config"), cw.Muted(version))
+	for _, f := range report.Files {
+		path, state := f.Path, ""
+		switch {
+		case path == "":
+			path, state = "none", cw.Muted("(not in a repository)")
+		case !f.Found:
+			state = cw.Muted("(not found)")
+		}
+		fmt.Printf("  %-5s %s %s\n", f.Scope+":", path, state)
+	}
+	fmt.Println()
+	for _, s := range report.Settings {
+		value := s.Value
+		if value == "" {
+			value = "-"
+		}
+		source := cw.Muted(s.Source)
+		if s.Source != sourceDefault {
+			source = cw.Cyan(s.Source)
+		}
+		fmt.Printf("  %-34s %-18s %s\n", s.Key, value, source)
+	}
+	for _, msg := range report.Errors {
+		fmt.Fprintf(os.Stderr, "%s %s\n", cw.Red("error:"), msg) // #nosec G705
+	}
+	return code
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/server"
+)
+
+// writeConfigFiles points the user config directory at a temp dir and creates
+// a repository directory, writing the given user and repository files.
+func writeConfigFiles(t *testing.T, user, repo string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	if user != "" {
+		if err := os.MkdirAll(filepath.Join(home, "gitvista"), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(home, "gitvista", "config.toml"), []byte(user), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repoDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repoDir, ".git"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if repo != "" {
+		if err := os.WriteFile(filepath.Join(repoDir, ".gitvista.toml"), []byte(repo), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repoDir
+}
+
+func envFrom(vars map[string]string) func(string, string) string {
+	return func(key, fallback string) string {
+		if v, ok := vars[key]; ok {
+			return v
+		}
+		return fallback
+	}
+}
+
+func TestParseFlagsWithConfigPrecedence(t *testing.T) {
+	repoDir := writeConfigFiles(t,
+		"[server]\nport = 7000\nhost = \"0.0.0.0\"\n[cache]\nbytes = \"64MiB\"\nttl = \"1m\"\n",
+		"[server]\nport = 7001\n[cache]\nbytes = \"128MiB\"\n")
+
+	parsed, cfg, err := parseFlagsWithConfig([]string{"serve", "-repo", repoDir}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("parseFlagsWithConfig() error = %v", err)
+	}
+	if parsed.port != "7001" || parsed.host != "0.0.0.0" {
+		t.Fatalf("port, host = %q, %q; want repo port and user host", parsed.port, parsed.host)
+	}
+	tuning, err := cfg.tuning()
+	if err != nil {
+		t.Fatalf("tuning() error = %v", err)
+	}
+	if tuning.CacheBytes != 128<<20 || tuning.CacheTTL != time.Minute {
+		t.Fatalf("cache bytes, ttl = %d, %v", tuning.CacheBytes, tuning.CacheTTL)
+	}
+
+	env := envFrom(map[string]string{"GITVISTA_PORT": "7002", "GITVISTA_CACHE_BYTES": "32MiB"})
+	parsed, cfg, err = parseFlagsWithConfig([]string{"serve", "-repo", repoDir}, env)
+	if err != nil {
+		t.Fatalf("parseFlagsWithConfig() error = %v", err)
+	}
+	if parsed.port != "7002" {
+		t.Fatalf("port = %q, want environment to override files", parsed.port)
+	}
+	if tuning, _ := cfg.tuning(); tuning.CacheBytes != 32<<20 {
+		t.Fatalf("cache bytes = %d, want environment to override files", tuning.CacheBytes)
+	}
+
+	parsed, cfg, err = parseFlagsWithConfig([]string{"serve", "-repo", repoDir, "-port", "7003"}, env)
+	if err != nil {
+		t.Fatalf("parseFlagsWithConfig() error = %v", err)
+	}
+	if parsed.port != "7003" {
+		t.Fatalf("port = %q, want flag to override everything", parsed.port)
+	}
+
+	sources := make(map[string]string)
+	for _, s := range buildConfigReport(parsed, cfg).Settings {
+		sources[s.Key] = s.Value + " " + s.Source
+	}
+	want := map[string]string{
+		"server.port":      "7003 flag -port",
+		"server.host":      "0.0.0.0 " + cfg.user.Location("server.host"),
+		"cache.bytes":      "32MiB env GITVISTA_CACHE_BYTES",
+		"cache.ttl":        "1m " + cfg.user.Location("cache.ttl"),
+		"watcher.debounce": "100ms default",
+	}
+	for key, w := range want {
+		if sources[key] != w {
+			t.Errorf("%s = %q, want %q", key, sources[key], w)
+		}
+	}
+}
+
+func TestValidateConfigReportsFileErrors(t *testing.T) {
+	repoDir := writeConfigFiles(t,
+		"[watcher]\ndebounce = \"soon\"\n[colour]\nmode = 1\n",
+		"[server]\nhost = \"0.0.0.0\"\n[bootstrap]\nwindow_days = 0\n")
+
+	parsed, cfg, err := parseFlagsWithConfig([]string{"serve", "-repo", repoDir}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("parseFlagsWithConfig() error = %v", err)
+	}
+	if parsed.host != "" {
+		t.Fatalf("host = %q; a repository file must not change it", parsed.host)
+	}
+	err = validateConfig(repoDir, "", 8080, cfg)
+	if err == nil {
+		t.Fatal("validateConfig() succeeded, want errors")
+	}
+	for _, want := range []string{
+		"config.toml:2: watcher.debounce: expected a duration",
+		"config.toml:4: unknown setting \"colour.mode\"",
+		".gitvista.toml:2: server.host cannot be set in .gitvista.toml",
+		".gitvista.toml:4: bootstrap.window_days: expected a positive integer",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateConfig() error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateConfigReportsSyntaxErrors(t *testing.T) {
+	repoDir := writeConfigFiles(t, "", "[cache\n")
+	_, cfg, err := parseFlagsWithConfig([]string{"serve", "-repo", repoDir}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("parseFlagsWithConfig() error = %v", err)
+	}
+	if err := validateConfig(repoDir, "", 8080, cfg); err == nil || !strings.Contains(err.Error(), ".gitvista.toml:1:") {
+		t.Fatalf("validateConfig() error = %v, want syntax error with location", err)
+	}
+}
+
+func TestConfigDefaultsMatchServerDefaults(t *testing.T) {
+	writeConfigFiles(t, "", "")
+	tuning, err := loadConfig("", envFrom(nil)).tuning()
+	if err != nil {
+		t.Fatalf("tuning() error = %v", err)
+	}
+	if tuning != server.DefaultTuning() {
+		t.Fatalf("default config tuning = %+v, want %+v", tuning, server.DefaultTuning())
+	}
+}
+
+func TestParseFlagsConfigPrint(t *testing.T) {
+	flags, err := parseFlags([]string{"config", "print", "--json"}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if flags.command != commandConfig || !flags.jsonOutput {
+		t.Fatalf("parseFlags = %+v", flags)
+	}
+	if _, err := parseFlags([]string{"config"}, envFrom(nil)); err == nil {
+		t.Fatal("parseFlags accepted config without an action")
+	}
+}
+
+func TestReloadConfigAppliesTuning(t *testing.T) {
+	repoDir := writeConfigFiles(t, "", "[cache]\nentries = 50\n")
+	parsed, cfg, err := parseFlagsWithConfig([]string{"serve", "-repo", repoDir}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("parseFlagsWithConfig() error = %v", err)
+	}
+	serv := server.NewServer(gitcore.NewEmptyRepository(), "127.0.0.1:0", fstest.MapFS{})
+	tuning, _ := cfg.tuning()
+	serv.SetTuning(tuning)
+	if got := serv.CacheSize(); got != 50 {
+		t.Fatalf("CacheSize() = %d, want 50", got)
+	}
+
+	configPath := filepath.Join(repoDir, ".gitvista.toml")
+	if err := os.WriteFile(configPath, []byte("[cache]\nentries = 75\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg = reloadConfig(serv, parsed, cfg)
+	if got := serv.CacheSize(); got != 75 {
+		t.Fatalf("CacheSize() after reload = %d, want 75", got)
+	}
+
+	// An invalid file keeps the settings already in effect.
+	if err := os.WriteFile(configPath, []byte("[cache]\nentries = -1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if next := reloadConfig(serv, parsed, cfg); next != cfg {
+		t.Fatal("reloadConfig replaced the config with an invalid one")
+	}
+	if got := serv.CacheSize(); got != 75 {
+		t.Fatalf("CacheSize() after failed reload = %d, want 75", got)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/rybkr/gitvista/internal/githttp"
+	"github.com/rybkr/gitvista/internal/server"
+)
+
+func registerGitHTTPFlags(fs *flag.FlagSet, flags *appFlags, getenv func(string, string) string) {
+	fs.BoolVar(&flags.gitHTTP, "git-http", getenv("GITVISTA_GIT_HTTP", "") == "true", "Serve read-only git clones at /git/")
+}
+
+// mountGitHTTP lets git clone and fetch the served repository. The routes sit
+// behind the same authentication as the rest of the server.
+func mountGitHTTP(serv *server.Server) {
+	handler := githttp.NewHandler(serv.Repo, version, serv.Logger())
+	serv.AddRoutes(func(mux *http.ServeMux) {
+		mux.Handle(githttp.RoutePrefix, handler)
+	})
+}
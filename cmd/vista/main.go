@@ -22,6 +22,7 @@ import (
 
 	"github.com/rybkr/gitvista"
 	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/auth"
 	"github.com/rybkr/gitvista/internal/cli"
 	"github.com/rybkr/gitvista/internal/selfupdate"
 	"github.com/rybkr/gitvista/internal/server"
@@ -38,6 +39,7 @@ const (
 	commandURL    = "url"
 	commandDoctor = "doctor"
 	commandUpdate = "update"
+	commandConfig = "config"
 )
 
 // Build-time variables set via -ldflags.
@@ -66,12 +68,34 @@ type appFlags struct {
 	branch       string
 	targetRev    string
 	targetPath   string
+	compare      string
 	jsonOutput   bool
+
+	authMode          string
+	authToken         string
+	htpasswd          string
+	oidcIssuer        string
+	oidcClientID      string
+	oidcClientSecret  string
+	oidcRedirectURL   string
+	oidcAllowedEmails string
+
+	tlsCert       string
+	tlsKey        string
+	tlsSelfSigned bool
+
+	cacheDir string
+	gitHTTP  bool
+
+	// explicit maps the flags given on the command line to their values.
+	explicit map[string]string
 }
 
 type launchTarget struct {
 	CommitHash gitcore.Hash
 	Path       string
+	// Compare is a "<base>...<head>" range shown in the branch comparison view.
+	Compare string
 }
 
 type startupInfo struct {
@@ -100,7 +124,7 @@ type doctorReport struct {
 func main() {
 	initLogger()
 
-	parsed, err := parseFlags(os.Args[1:], getEnv)
+	parsed, cfg, err := parseFlagsWithConfig(os.Args[1:], getEnv)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -137,7 +161,7 @@ func main() {
 
 	portNum, _ := strconv.Atoi(parsed.port)
 	if parsed.command == commandOpen || parsed.command == commandServe || parsed.command == commandDoctor || parsed.command == commandURL {
-		if err := validateConfig(parsed.repoPath, parsed.outputFormat, portNum); err != nil {
+		if err := validateConfig(parsed.repoPath, parsed.outputFormat, portNum, cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "%s %v\n", cw.Red("error:"), err) // #nosec G705
 			os.Exit(1)
 		}
@@ -145,15 +169,17 @@ func main() {
 
 	switch parsed.command {
 	case commandServe:
-		os.Exit(runServe(parsed, cw, false))
+		os.Exit(runServe(parsed, cfg, cw, false))
 	case commandOpen:
-		os.Exit(runServe(parsed, cw, true))
+		os.Exit(runServe(parsed, cfg, cw, true))
 	case commandURL:
 		os.Exit(runURL(parsed))
 	case commandDoctor:
 		os.Exit(runDoctor(parsed, cw))
 	case commandUpdate:
 		os.Exit(runUpdate(cw))
+	case commandConfig:
+		os.Exit(runConfigPrint(parsed, cfg, cw))
 	default:
 		fmt.Fprintf(os.Stderr, "%s unknown command %q\n", cw.Red("error:"), parsed.command)
 		os.Exit(1)
@@ -170,12 +196,18 @@ func parseFlags(args []string, getenv func(string, string) string) (appFlags, er
 		case commandOpen, commandServe, commandURL, commandDoctor, commandUpdate:
 			flags.command = args[0]
 			args = args[1:]
+		case commandConfig:
+			if len(args) < 2 || args[1] != configActionPrint {
+				return flags, fmt.Errorf("usage: gitvista config print [flags]")
+			}
+			flags.command = commandConfig
+			args = args[2:]
 		case commandHelp:
 			flags.command = commandHelp
 			flags.showHelp = true
 			if len(args) > 1 {
 				switch args[1] {
-				case commandHelp, commandOpen, commandServe, commandURL, commandDoctor, commandUpdate:
+				case commandHelp, commandOpen, commandServe, commandURL, commandDoctor, commandUpdate, commandConfig:
 					flags.command = args[1]
 				}
 			}
@@ -204,15 +236,29 @@ func parseFlags(args []string, getenv func(string, string) string) (appFlags, er
 		fs.StringVar(&flags.branch, "branch", "", "Open the graph focused on a branch tip")
 		fs.StringVar(&flags.targetRev, "commit", "", "Open the graph focused on a commit or revision")
 		fs.StringVar(&flags.targetPath, "path", "", "Open the file explorer focused on a path")
+		fs.StringVar(&flags.compare, "compare", "", "Open the comparison of two revisions, as <base>...<head>")
+		registerAuthFlags(fs, &flags, getenv)
+		registerTLSFlags(fs, &flags, getenv)
+		registerCacheFlags(fs, &flags, getenv)
+		registerGitHTTPFlags(fs, &flags, getenv)
 	case commandServe:
 		fs.StringVar(&flags.outputFormat, "output", "", "Startup output format: json")
+		registerAuthFlags(fs, &flags, getenv)
+		registerTLSFlags(fs, &flags, getenv)
+		registerCacheFlags(fs, &flags, getenv)
+		registerGitHTTPFlags(fs, &flags, getenv)
 	case commandURL:
 		fs.StringVar(&flags.branch, "branch", "", "Build a URL focused on a branch tip")
 		fs.StringVar(&flags.targetRev, "commit", "", "Build a URL focused on a commit or revision")
 		fs.StringVar(&flags.targetPath, "path", "", "Build a URL focused on a path")
+		fs.StringVar(&flags.compare, "compare", "", "Build a URL comparing two revisions, as <base>...<head>")
 		fs.BoolVar(&flags.jsonOutput, "json", false, "Print structured JSON output")
+		registerTLSFlags(fs, &flags, getenv)
 	case commandDoctor:
 		fs.BoolVar(&flags.jsonOutput, "json", false, "Print structured JSON output")
+		registerTLSFlags(fs, &flags, getenv)
+	case commandConfig:
+		fs.BoolVar(&flags.jsonOutput, "json", false, "Print structured JSON output")
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -222,6 +268,10 @@ func parseFlags(args []string, getenv func(string, string) string) (appFlags, er
 		}
 		return flags, fmt.Errorf("%s", msg)
 	}
+	flags.explicit = make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		flags.explicit[f.Name] = f.Value.String()
+	})
 
 	rest := fs.Args()
 	if len(rest) == 0 {
@@ -386,7 +436,12 @@ func updateInstructionForPath(execPath string) string {
 	return ""
 }
 
-func validateConfig(repoPath, outputFormat string, portNum int) error {
+func validateConfig(repoPath, outputFormat string, portNum int, cfg *loadedConfig) error {
+	if cfg != nil {
+		if err := cfg.validate(); err != nil {
+			return fmt.Errorf("invalid configuration:\n%w", err)
+		}
+	}
 	if repoPath == "" {
 		return fmt.Errorf("repository path is required")
 	}
@@ -399,7 +454,7 @@ func validateConfig(repoPath, outputFormat string, portNum int) error {
 	return nil
 }
 
-func runServe(parsed appFlags, cw *cli.Writer, launchBrowser bool) int {
+func runServe(parsed appFlags, cfg *loadedConfig, cw *cli.Writer, launchBrowser bool) int {
 	spin := cli.NewSpinner("Loading repository...")
 	spin.Start()
 	repoLoadStart := time.Now()
@@ -427,7 +482,13 @@ func runServe(parsed appFlags, cw *cli.Writer, launchBrowser bool) int {
 	}
 
 	addr := fmt.Sprintf("%s:%s", resolveBindHost(parsed.host), parsed.port)
-	baseURL, openURL := buildURLs(addr, target)
+	baseURL, openURL := buildURLs(urlScheme(parsed), addr, target)
+
+	tlsConfig, caPath, err := buildTLSConfig(parsed, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", cw.Red("error:"), err)
+		return 1
+	}
 
 	webFS, err := gitvista.GetWebFS()
 	if err != nil {
@@ -435,7 +496,38 @@ func runServe(parsed appFlags, cw *cli.Writer, launchBrowser bool) int {
 		return 1
 	}
 
+	authenticator, tokens, err := buildAuthenticator(context.Background(), parsed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", cw.Red("error:"), err)
+		return 1
+	}
+	if tokens != nil {
+		code, err := tokens.IssueLoginCode(auth.DefaultLoginCodeTTL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", cw.Red("error:"), err)
+			return 1
+		}
+		openURL = withLoginLink(baseURL, openURL, code)
+	}
+
 	serv := server.NewServer(repo, addr, webFS)
+	if authenticator != nil {
+		serv.SetAuthenticator(authenticator)
+	}
+	if tlsConfig != nil {
+		serv.SetTLSConfig(tlsConfig)
+	}
+	if tuning, err := cfg.tuning(); err == nil {
+		serv.SetTuning(tuning)
+	}
+	if cache, err := openDiskCache(parsed, repo.GitDir()); err != nil {
+		slog.Warn("Persistent cache disabled", "err", err)
+	} else if cache != nil {
+		serv.SetDiskCache(cache)
+	}
+	if parsed.gitHTTP {
+		mountGitHTTP(serv)
+	}
 	repoOwned = false
 
 	slog.Info("Starting GitVista", "version", version, "command", parsed.command)
@@ -445,12 +537,16 @@ func runServe(parsed appFlags, cw *cli.Writer, launchBrowser bool) int {
 	if parsed.outputFormat == outputFormatJS {
 		printStartupJSON(parsed.command, baseURL, openURL, parsed.repoPath, repoLoadDur)
 	} else {
-		printStartupBanner(cw, parsed.command, baseURL, openURL, parsed.repoPath, repoLoadDur, launchBrowser)
+		printStartupBanner(cw, parsed.command, baseURL, openURL, caPath, parsed.repoPath, repoLoadDur, launchBrowser)
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+	defer signal.Stop(reloadCh)
+
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- serv.Start()
@@ -470,19 +566,23 @@ func runServe(parsed appFlags, cw *cli.Writer, launchBrowser bool) int {
 		fmt.Println(openURL)
 	}
 
-	select {
-	case err := <-errCh:
-		if err != nil {
-			slog.Error("Server error", "err", err)
-			return 1
+	for {
+		select {
+		case err := <-errCh:
+			if err != nil {
+				slog.Error("Server error", "err", err)
+				return 1
+			}
+			return 0
+		case <-reloadCh:
+			cfg = reloadConfig(serv, parsed, cfg)
+		case <-ctx.Done():
+			slog.Info("Shutdown initiated, press Ctrl+C again to force exit")
+			stop()
+			serv.Shutdown()
+			return 0
 		}
-	case <-ctx.Done():
-		slog.Info("Shutdown initiated, press Ctrl+C again to force exit")
-		stop()
-		serv.Shutdown()
 	}
-
-	return 0
 }
 
 func runURL(parsed appFlags) int {
@@ -503,8 +603,13 @@ func runURL(parsed appFlags) int {
 		return 1
 	}
 
+	if err := validateTLSFlags(parsed); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
 	addr := fmt.Sprintf("%s:%s", resolveBindHost(parsed.host), parsed.port)
-	baseURL, openURL := buildURLs(addr, target)
+	baseURL, openURL := buildURLs(urlScheme(parsed), addr, target)
 
 	if parsed.jsonOutput {
 		data, _ := json.Marshal(struct {
@@ -555,12 +660,25 @@ func runDoctor(parsed appFlags, cw *cli.Writer) int {
 
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
-		report.OK = false
-		report.Checks = append(report.Checks, doctorCheck{
-			Name:    "listener",
-			Status:  "fail",
-			Message: err.Error(),
-		})
+		// A busy port is expected when GitVista itself is already running
+		// there; in that case report on the running instance instead.
+		if instance, ok := probeInstance(newProbeClient(), probeBaseURL(parsed)); ok {
+			report.Checks = append(report.Checks, doctorCheck{
+				Name:    "listener",
+				Status:  "ok",
+				Message: fmt.Sprintf("%s is served by a running GitVista", addr),
+			}, instance)
+			if instance.Status == "fail" {
+				report.OK = false
+			}
+		} else {
+			report.OK = false
+			report.Checks = append(report.Checks, doctorCheck{
+				Name:    "listener",
+				Status:  "fail",
+				Message: err.Error(),
+			})
+		}
 	} else {
 		_ = ln.Close()
 		report.Checks = append(report.Checks, doctorCheck{
@@ -570,6 +688,13 @@ func runDoctor(parsed appFlags, cw *cli.Writer) int {
 		})
 	}
 
+	if check, ok := tlsDoctorCheck(parsed, time.Now()); ok {
+		if check.Status == "fail" {
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
 	if launcher, err := browserLauncher(); err != nil {
 		report.Checks = append(report.Checks, doctorCheck{
 			Name:    "browser",
@@ -620,6 +745,24 @@ func resolveLaunchTarget(repo *gitcore.Repository, parsed appFlags) (launchTarge
 	if parsed.branch != "" && parsed.targetRev != "" {
 		return target, fmt.Errorf("use either --branch or --commit, not both")
 	}
+	if parsed.compare != "" {
+		if parsed.branch != "" || parsed.targetRev != "" {
+			return target, fmt.Errorf("--compare cannot be combined with --branch or --commit")
+		}
+		base, head, ok := strings.Cut(parsed.compare, "...")
+		if !ok || base == "" || head == "" {
+			return target, fmt.Errorf("--compare expects <base>...<head>, got %q", parsed.compare)
+		}
+		if _, err := resolveHash(repo, base); err != nil {
+			return target, err
+		}
+		headHash, err := resolveHash(repo, head)
+		if err != nil {
+			return target, err
+		}
+		target.Compare = parsed.compare
+		target.CommitHash = headHash
+	}
 	if parsed.branch != "" {
 		hash, ok := repo.Branches()[parsed.branch]
 		if !ok {
@@ -644,27 +787,30 @@ func resolveLaunchTarget(repo *gitcore.Repository, parsed appFlags) (launchTarge
 	return target, nil
 }
 
-func buildURLs(addr string, target launchTarget) (string, string) {
+func buildURLs(scheme, addr string, target launchTarget) (string, string) {
 	base := (&neturl.URL{
-		Scheme: "http",
+		Scheme: scheme,
 		Host:   addr,
 	}).String()
 	launch := &neturl.URL{
-		Scheme: "http",
+		Scheme: scheme,
 		Host:   addr,
 	}
+	q := launch.Query()
 	if target.Path != "" {
-		q := launch.Query()
 		q.Set("path", target.Path)
-		launch.RawQuery = q.Encode()
 	}
+	if target.Compare != "" {
+		q.Set("compare", target.Compare)
+	}
+	launch.RawQuery = q.Encode()
 	if target.CommitHash != "" {
 		launch.Fragment = string(target.CommitHash)
 	}
 	return base, launch.String()
 }
 
-func printStartupBanner(cw *cli.Writer, command, baseURL, openURL, repoPath string, repoLoadDur time.Duration, launchBrowser bool) {
+func printStartupBanner(cw *cli.Writer, command, baseURL, openURL, caPath, repoPath string, repoLoadDur time.Duration, launchBrowser bool) {
 	fmt.Printf("%s %s\n", cw.Command("GitVista"), cw.Muted(version))
 	fmt.Printf("  %s    %s\n", cw.Cyan("cmd:"), command)
 	timing := fmt.Sprintf("loaded in %s", cw.Yellow(repoLoadDur.String()))
@@ -673,6 +819,9 @@ func printStartupBanner(cw *cli.Writer, command, baseURL, openURL, repoPath stri
 	if openURL != baseURL {
 		fmt.Printf("  %s   %s\n", cw.Cyan("open:"), openURL)
 	}
+	if caPath != "" {
+		fmt.Printf("  %s     %s %s\n", cw.Cyan("ca:"), caPath, cw.Muted("(trust this to avoid browser warnings)"))
+	}
 	if launchBrowser {
 		fmt.Printf("  %s %s\n", cw.Cyan("browser:"), "launching")
 	}
@@ -717,6 +866,7 @@ func printHelp(cw *cli.Writer, command string) {
 	fmt.Println("  gitvista url [flags]")
 	fmt.Println("  gitvista doctor [flags]")
 	fmt.Println("  gitvista update")
+	fmt.Println("  gitvista config print [flags]")
 	fmt.Println("  gitvista help [command]")
 	fmt.Println()
 	fmt.Println(cw.Bold("Commands:"))
@@ -724,11 +874,12 @@ func printHelp(cw *cli.Writer, command string) {
 	fmt.Println("  open     Start GitVista and launch the browser")
 	fmt.Println("  serve    Start GitVista without launching the browser")
 	fmt.Println("  url      Print the resolved launch URL")
-	fmt.Println("  doctor   Validate repo, listener, and browser readiness")
+	fmt.Println("  doctor   Validate repo, listener, and browser readiness, or probe a running instance")
 	fmt.Println("  update   Download and install the latest release")
+	fmt.Println("  config   Print effective settings and where each one came from")
 	fmt.Println()
 	fmt.Println(cw.Bold("Global flags:"))
-	printFlag("-repo <path>", "Path to git repository (default: current directory)")
+	printFlag("-repo <path>", "Path to git repository or bundle (default: current directory)")
 	printFlag("-port, --port <port>", "Port to listen on (default: 8080)")
 	printFlag("-host <host>", "Host to bind to (default: 127.0.0.1)")
 	printFlag("-color <mode>", "Color output: auto, always, never")
@@ -744,24 +895,60 @@ func printHelp(cw *cli.Writer, command string) {
 		printFlag("-commit <rev>", "Open focused on a commit or revision")
 		printFlag("-branch <name>", "Open focused on a branch tip")
 		printFlag("-path <path>", "Open the file explorer focused on a path")
+		printFlag("-compare <base...head>", "Open the comparison of two revisions")
 		printFlag("-no-browser", "Start the server without opening a browser")
 		printFlag("-print-url", "Print the resolved launch URL")
 		printFlag("-output <format>", "Startup output format: json")
+		printFlag("-auth <mode>", "Authentication: none, token, basic, oidc (default: token off loopback)")
+		printFlag("-auth-token <token>", "Static bearer token accepted from API clients")
+		printFlag("-htpasswd <file>", "htpasswd file for HTTP basic auth (bcrypt entries)")
+		printFlag("-oidc-issuer <url>", "OpenID Connect issuer URL")
+		printFlag("-oidc-client-id <id>", "OpenID Connect client ID")
+		printFlag("-oidc-redirect-url <url>", "OpenID Connect callback URL (default: derived from the request)")
+		printFlag("-oidc-allowed-emails", "Comma-separated emails or @domains allowed to sign in")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
+		printFlag("-cache-dir <dir>", "Persistent cache directory, or off (default: .git/gitvista/cache)")
+		printFlag("-git-http", "Serve read-only git clones at /git/")
 		fmt.Println()
 	case commandServe:
 		fmt.Println(cw.Bold("Serve flags:"))
 		printFlag("-output <format>", "Startup output format: json")
+		printFlag("-auth <mode>", "Authentication: none, token, basic, oidc (default: token off loopback)")
+		printFlag("-auth-token <token>", "Static bearer token accepted from API clients")
+		printFlag("-htpasswd <file>", "htpasswd file for HTTP basic auth (bcrypt entries)")
+		printFlag("-oidc-issuer <url>", "OpenID Connect issuer URL")
+		printFlag("-oidc-client-id <id>", "OpenID Connect client ID")
+		printFlag("-oidc-redirect-url <url>", "OpenID Connect callback URL (default: derived from the request)")
+		printFlag("-oidc-allowed-emails", "Comma-separated emails or @domains allowed to sign in")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
+		printFlag("-cache-dir <dir>", "Persistent cache directory, or off (default: .git/gitvista/cache)")
+		printFlag("-git-http", "Serve read-only git clones at /git/")
 		fmt.Println()
 	case commandURL:
 		fmt.Println(cw.Bold("URL flags:"))
 		printFlag("-commit <rev>", "Build a URL focused on a commit or revision")
 		printFlag("-branch <name>", "Build a URL focused on a branch tip")
 		printFlag("-path <path>", "Build a URL focused on a path")
+		printFlag("-compare <base...head>", "Build a URL comparing two revisions")
 		printFlag("-json", "Print structured JSON output")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
 		fmt.Println()
 	case commandDoctor:
 		fmt.Println(cw.Bold("Doctor flags:"))
 		printFlag("-json", "Print structured JSON output")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
+		fmt.Println()
+	case commandConfig:
+		fmt.Println(cw.Bold("Config flags:"))
+		printFlag("-json", "Print structured JSON output")
 		fmt.Println()
 	}
 
@@ -769,10 +956,19 @@ func printHelp(cw *cli.Writer, command string) {
 	fmt.Println("  gitvista")
 	fmt.Println("  gitvista open --branch main")
 	fmt.Println("  gitvista open --path internal/server")
+	fmt.Println("  gitvista open --compare main...feature")
 	fmt.Println("  gitvista serve --port 3000")
+	fmt.Println("  gitvista serve --host 0.0.0.0 --tls-self-signed")
 	fmt.Println("  gitvista url --commit HEAD~1")
 	fmt.Println("  gitvista doctor")
 	fmt.Println("  gitvista update")
+	fmt.Println("  gitvista config print")
+	fmt.Println()
+	fmt.Println(cw.Bold("Configuration files:"))
+	fmt.Println("  $XDG_CONFIG_HOME/gitvista/config.toml   User settings")
+	fmt.Println("  <repo>/.gitvista.toml                   Repository settings (tuning, cache, port)")
+	fmt.Println("  Flags override environment variables, which override the repository")
+	fmt.Println("  file, which overrides the user file. Send SIGHUP to reload the files.")
 	fmt.Println()
 	fmt.Println(cw.Bold("Environment Variables:"))
 	fmt.Println("  GITVISTA_REPO         Repository path (default: current directory)")
@@ -780,6 +976,11 @@ func printHelp(cw *cli.Writer, command string) {
 	fmt.Println("  GITVISTA_HOST         Default host")
 	fmt.Println("  GITVISTA_LOG_LEVEL    Log level: debug, info, warn, error (default: info)")
 	fmt.Println("  GITVISTA_LOG_FORMAT   Log format: text, json (default: text)")
+	fmt.Println("  GITVISTA_AUTH_TOKEN   Static bearer token (same as -auth-token)")
+	fmt.Println("  GITVISTA_TLS_CERT     TLS certificate file (same as -tls-cert)")
+	fmt.Println("  GITVISTA_TLS_KEY      TLS private key file (same as -tls-key)")
+	fmt.Println("  GITVISTA_OIDC_CLIENT_SECRET")
+	fmt.Println("                        OpenID Connect client secret (environment only)")
 }
 
 func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, string)) {
@@ -794,7 +995,7 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		fmt.Println("Start GitVista and launch the browser.")
 		fmt.Println()
 		fmt.Println(cw.Bold("Flags:"))
-		printFlag("-repo <path>", "Path to git repository (default: current directory)")
+		printFlag("-repo <path>", "Path to git repository or bundle (default: current directory)")
 		printFlag("-port, --port <port>", "Port to listen on (default: 8080)")
 		printFlag("-host <host>", "Host to bind to (default: 127.0.0.1)")
 		printFlag("-color <mode>", "Color output: auto, always, never")
@@ -805,15 +1006,29 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		printFlag("-commit <rev>", "Open focused on a commit or revision")
 		printFlag("-branch <name>", "Open focused on a branch tip")
 		printFlag("-path <path>", "Open the file explorer focused on a path")
+		printFlag("-compare <base...head>", "Open the comparison of two revisions")
 		printFlag("-no-browser", "Start the server without opening a browser")
 		printFlag("-print-url", "Print the resolved launch URL")
 		printFlag("-output <format>", "Startup output format: json")
+		printFlag("-auth <mode>", "Authentication: none, token, basic, oidc (default: token off loopback)")
+		printFlag("-auth-token <token>", "Static bearer token accepted from API clients")
+		printFlag("-htpasswd <file>", "htpasswd file for HTTP basic auth (bcrypt entries)")
+		printFlag("-oidc-issuer <url>", "OpenID Connect issuer URL")
+		printFlag("-oidc-client-id <id>", "OpenID Connect client ID")
+		printFlag("-oidc-redirect-url <url>", "OpenID Connect callback URL (default: derived from the request)")
+		printFlag("-oidc-allowed-emails", "Comma-separated emails or @domains allowed to sign in")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
+		printFlag("-cache-dir <dir>", "Persistent cache directory, or off (default: .git/gitvista/cache)")
+		printFlag("-git-http", "Serve read-only git clones at /git/")
 		fmt.Println()
 		fmt.Println(cw.Bold("Examples:"))
 		fmt.Println("  gitvista open")
 		fmt.Println("  gitvista open HEAD~2")
 		fmt.Println("  gitvista open --branch main")
 		fmt.Println("  gitvista open --path internal/server")
+		fmt.Println("  gitvista open --compare main...feature")
 	case commandServe:
 		fmt.Println(cw.Bold("Usage:"))
 		fmt.Println("  gitvista serve [flags]")
@@ -821,7 +1036,7 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		fmt.Println("Start GitVista without launching the browser.")
 		fmt.Println()
 		fmt.Println(cw.Bold("Flags:"))
-		printFlag("-repo <path>", "Path to git repository (default: current directory)")
+		printFlag("-repo <path>", "Path to git repository or bundle (default: current directory)")
 		printFlag("-port, --port <port>", "Port to listen on (default: 8080)")
 		printFlag("-host <host>", "Host to bind to (default: 127.0.0.1)")
 		printFlag("-color <mode>", "Color output: auto, always, never")
@@ -830,10 +1045,24 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		printFlag("-check-update", "Check for a newer release and exit")
 		printFlag("-help, -h", "Show help and exit")
 		printFlag("-output <format>", "Startup output format: json")
+		printFlag("-auth <mode>", "Authentication: none, token, basic, oidc (default: token off loopback)")
+		printFlag("-auth-token <token>", "Static bearer token accepted from API clients")
+		printFlag("-htpasswd <file>", "htpasswd file for HTTP basic auth (bcrypt entries)")
+		printFlag("-oidc-issuer <url>", "OpenID Connect issuer URL")
+		printFlag("-oidc-client-id <id>", "OpenID Connect client ID")
+		printFlag("-oidc-redirect-url <url>", "OpenID Connect callback URL (default: derived from the request)")
+		printFlag("-oidc-allowed-emails", "Comma-separated emails or @domains allowed to sign in")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
+		printFlag("-cache-dir <dir>", "Persistent cache directory, or off (default: .git/gitvista/cache)")
+		printFlag("-git-http", "Serve read-only git clones at /git/")
 		fmt.Println()
 		fmt.Println(cw.Bold("Examples:"))
 		fmt.Println("  gitvista serve")
 		fmt.Println("  gitvista serve --port 3000")
+		fmt.Println("  gitvista serve --host 0.0.0.0 --htpasswd ./team.htpasswd")
+		fmt.Println("  gitvista serve --host 0.0.0.0 --tls-cert cert.pem --tls-key key.pem")
 	case commandURL:
 		fmt.Println(cw.Bold("Usage:"))
 		fmt.Println("  gitvista url [flags]")
@@ -841,7 +1070,7 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		fmt.Println("Print the resolved launch URL.")
 		fmt.Println()
 		fmt.Println(cw.Bold("Flags:"))
-		printFlag("-repo <path>", "Path to git repository (default: current directory)")
+		printFlag("-repo <path>", "Path to git repository or bundle (default: current directory)")
 		printFlag("-port, --port <port>", "Port to listen on (default: 8080)")
 		printFlag("-host <host>", "Host to bind to (default: 127.0.0.1)")
 		printFlag("-color <mode>", "Color output: auto, always, never")
@@ -852,7 +1081,11 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		printFlag("-commit <rev>", "Build a URL focused on a commit or revision")
 		printFlag("-branch <name>", "Build a URL focused on a branch tip")
 		printFlag("-path <path>", "Build a URL focused on a path")
+		printFlag("-compare <base...head>", "Build a URL comparing two revisions")
 		printFlag("-json", "Print structured JSON output")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
 		fmt.Println()
 		fmt.Println(cw.Bold("Examples:"))
 		fmt.Println("  gitvista url")
@@ -862,10 +1095,11 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		fmt.Println(cw.Bold("Usage:"))
 		fmt.Println("  gitvista doctor [flags]")
 		fmt.Println()
-		fmt.Println("Validate repo, listener, and browser readiness.")
+		fmt.Println("Validate repo, listener, and browser readiness. If GitVista is already")
+		fmt.Println("running on the port, report its /livez and /readyz status instead.")
 		fmt.Println()
 		fmt.Println(cw.Bold("Flags:"))
-		printFlag("-repo <path>", "Path to git repository (default: current directory)")
+		printFlag("-repo <path>", "Path to git repository or bundle (default: current directory)")
 		printFlag("-port, --port <port>", "Port to listen on (default: 8080)")
 		printFlag("-host <host>", "Host to bind to (default: 127.0.0.1)")
 		printFlag("-color <mode>", "Color output: auto, always, never")
@@ -874,10 +1108,36 @@ func printCommandHelp(cw *cli.Writer, command string, printFlag func(string, str
 		printFlag("-check-update", "Check for a newer release and exit")
 		printFlag("-help, -h", "Show help and exit")
 		printFlag("-json", "Print structured JSON output")
+		printFlag("-tls-cert <file>", "PEM certificate file to serve HTTPS with")
+		printFlag("-tls-key <file>", "PEM private key file for -tls-cert")
+		printFlag("-tls-self-signed", "Serve HTTPS with a certificate from a cached local CA")
 		fmt.Println()
 		fmt.Println(cw.Bold("Examples:"))
 		fmt.Println("  gitvista doctor")
 		fmt.Println("  gitvista doctor --json")
+		fmt.Println("  gitvista doctor --tls-cert cert.pem --tls-key key.pem")
+	case commandConfig:
+		fmt.Println(cw.Bold("Usage:"))
+		fmt.Println("  gitvista config print [flags]")
+		fmt.Println()
+		fmt.Println("Print every setting's effective value and where it came from: a flag, an")
+		fmt.Println("environment variable, a config file line, or the built-in default. Exits")
+		fmt.Println("non-zero if a config file is invalid.")
+		fmt.Println()
+		fmt.Println(cw.Bold("Files:"))
+		fmt.Println("  $XDG_CONFIG_HOME/gitvista/config.toml   User settings")
+		fmt.Println("  <repo>/.gitvista.toml                   Repository settings (tuning, cache, port)")
+		fmt.Println()
+		fmt.Println(cw.Bold("Flags:"))
+		printFlag("-repo <path>", "Path to git repository or bundle (default: current directory)")
+		printFlag("-color <mode>", "Color output: auto, always, never")
+		printFlag("-no-color", "Disable color output")
+		printFlag("-help, -h", "Show help and exit")
+		printFlag("-json", "Print structured JSON output")
+		fmt.Println()
+		fmt.Println(cw.Bold("Examples:"))
+		fmt.Println("  gitvista config print")
+		fmt.Println("  gitvista config print --repo ../other --json")
 	case commandUpdate:
 		fmt.Println(cw.Bold("Usage:"))
 		fmt.Println("  gitvista update")
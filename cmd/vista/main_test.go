@@ -116,7 +116,7 @@ func TestApplyInvocationDefaults(t *testing.T) {
 }
 
 func TestBuildURLs(t *testing.T) {
-	base, open := buildURLs("127.0.0.1:8080", launchTarget{
+	base, open := buildURLs("http", "127.0.0.1:8080", launchTarget{
 		CommitHash: "abcdef1234567890abcdef1234567890abcdef12",
 		Path:       "internal/server",
 	})
@@ -129,6 +129,49 @@ func TestBuildURLs(t *testing.T) {
 	}
 }
 
+func TestBuildURLsCompare(t *testing.T) {
+	_, open := buildURLs("http", "127.0.0.1:8080", launchTarget{
+		CommitHash: "abcdef1234567890abcdef1234567890abcdef12",
+		Compare:    "main...feature",
+	})
+	wantOpen := "http://127.0.0.1:8080?compare=main...feature#abcdef1234567890abcdef1234567890abcdef12"
+	if open != wantOpen {
+		t.Fatalf("open = %q, want %q", open, wantOpen)
+	}
+}
+
+func TestParseFlagsOpenCompare(t *testing.T) {
+	flags, err := parseFlags([]string{"open", "--compare", "main...feature"}, func(key, fallback string) string {
+		return fallback
+	})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if flags.compare != "main...feature" {
+		t.Fatalf("compare = %q, want %q", flags.compare, "main...feature")
+	}
+}
+
+func TestResolveLaunchTargetRejectsInvalidCompare(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags appFlags
+		want  string
+	}{
+		{name: "two dots", flags: appFlags{compare: "main..feature"}, want: "expects <base>...<head>"},
+		{name: "missing head", flags: appFlags{compare: "main..."}, want: "expects <base>...<head>"},
+		{name: "with branch", flags: appFlags{compare: "main...feature", branch: "main"}, want: "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolveLaunchTarget(nil, tt.flags)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("resolveLaunchTarget() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseFlagsHelpCommand(t *testing.T) {
 	flags, err := parseFlags([]string{"help", "url"}, func(key, fallback string) string {
 		return fallback
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/internal/server"
+)
+
+const probeTimeout = 3 * time.Second
+
+// probeBaseURL is where doctor looks for a running instance. A wildcard bind
+// is reached over loopback.
+func probeBaseURL(parsed appFlags) string {
+	host := resolveBindHost(parsed.host)
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("%s://%s", urlScheme(parsed), net.JoinHostPort(strings.Trim(host, "[]"), parsed.port))
+}
+
+func newProbeClient() *http.Client {
+	return &http.Client{
+		Timeout: probeTimeout,
+		Transport: &http.Transport{
+			// Doctor checks the certificate separately; the probe only asks
+			// the instance how it is doing, so a self-signed cert is fine.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402 -- loopback readiness probe
+		},
+	}
+}
+
+// probeInstance asks a GitVista instance at baseURL for its liveness and
+// readiness. It reports false when nothing answers /livez like GitVista.
+func probeInstance(client *http.Client, baseURL string) (doctorCheck, bool) {
+	live, err := client.Get(baseURL + "/livez")
+	if err != nil {
+		return doctorCheck{}, false
+	}
+	var liveness server.LivenessStatus
+	err = json.NewDecoder(live.Body).Decode(&liveness)
+	_ = live.Body.Close()
+	if err != nil || live.StatusCode != http.StatusOK || liveness.Status != "ok" {
+		return doctorCheck{}, false
+	}
+
+	check := doctorCheck{Name: "instance"}
+	resp, err := client.Get(baseURL + "/readyz")
+	if err != nil {
+		check.Status, check.Message = "fail", fmt.Sprintf("live but /readyz failed: %v", err)
+		return check, true
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var ready server.ReadinessStatus
+	if err := json.NewDecoder(resp.Body).Decode(&ready); err != nil {
+		check.Status, check.Message = "fail", fmt.Sprintf("live but /readyz returned %s", resp.Status)
+		return check, true
+	}
+
+	if ready.Status != "ready" {
+		check.Status = "fail"
+		check.Message = "not ready: " + strings.Join(ready.Reasons, "; ")
+		if ready.Reload != nil && ready.Reload.LastError != "" {
+			check.Message += " (" + ready.Reload.LastError + ")"
+		}
+		return check, true
+	}
+
+	parts := []string{fmt.Sprintf("ready at %s, up %s", baseURL, (time.Duration(ready.UptimeSeconds) * time.Second).String())}
+	if ready.Reload != nil && ready.Reload.LastSuccess != nil {
+		parts = append(parts, fmt.Sprintf("reloaded %s ago", time.Since(*ready.Reload.LastSuccess).Round(time.Second)))
+	}
+	if ready.Objects != nil {
+		parts = append(parts, fmt.Sprintf("%d packs, %d loose objects", ready.Objects.Packs, ready.Objects.LooseObjects))
+	}
+	check.Status, check.Message = "ok", strings.Join(parts, ", ")
+	return check, true
+}
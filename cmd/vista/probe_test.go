@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/server"
+)
+
+func newProbeTarget(t *testing.T, ready server.ReadinessStatus) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(server.LivenessStatus{Status: "ok", UptimeSeconds: 90})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if ready.Status != "ready" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(ready)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestProbeInstance(t *testing.T) {
+	lastSuccess := time.Now().Add(-time.Minute)
+	ready := newProbeTarget(t, server.ReadinessStatus{
+		Status:        "ready",
+		UptimeSeconds: 90,
+		Reload:        &server.ReloadStatus{LastSuccess: &lastSuccess},
+		Objects:       &gitcore.ObjectStats{Packs: 2, LooseObjects: 7},
+	})
+	check, ok := probeInstance(ready.Client(), ready.URL)
+	if !ok || check.Status != "ok" || !strings.Contains(check.Message, "2 packs, 7 loose objects") {
+		t.Fatalf("ready: ok = %v, check = %+v", ok, check)
+	}
+
+	unready := newProbeTarget(t, server.ReadinessStatus{
+		Status:  "unready",
+		Reasons: []string{"last repository reload failed"},
+		Reload:  &server.ReloadStatus{LastError: "bad packfile"},
+	})
+	check, ok = probeInstance(unready.Client(), unready.URL)
+	if !ok || check.Status != "fail" || !strings.Contains(check.Message, "reload failed") || !strings.Contains(check.Message, "bad packfile") {
+		t.Fatalf("unready: ok = %v, check = %+v", ok, check)
+	}
+
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+	if _, ok := probeInstance(other.Client(), other.URL); ok {
+		t.Fatal("probeInstance() recognised a server that is not GitVista")
+	}
+}
+
+func TestProbeBaseURL(t *testing.T) {
+	tests := []struct {
+		flags appFlags
+		want  string
+	}{
+		{flags: appFlags{port: "8080"}, want: "http://127.0.0.1:8080"},
+		{flags: appFlags{host: "0.0.0.0", port: "8443", tlsSelfSigned: true}, want: "https://127.0.0.1:8443"},
+		{flags: appFlags{host: "::1", port: "8080"}, want: "http://[::1]:8080"},
+	}
+	for _, tt := range tests {
+		if got := probeBaseURL(tt.flags); got != tt.want {
+			t.Errorf("probeBaseURL(%+v) = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/internal/tlscert"
+)
+
+var tlsCacheDirFunc = tlscert.DefaultCacheDir
+
+func registerTLSFlags(fs *flag.FlagSet, flags *appFlags, getenv func(string, string) string) {
+	fs.StringVar(&flags.tlsCert, "tls-cert", getenv("GITVISTA_TLS_CERT", ""), "PEM certificate file to serve HTTPS with")
+	fs.StringVar(&flags.tlsKey, "tls-key", getenv("GITVISTA_TLS_KEY", ""), "PEM private key file for -tls-cert")
+	fs.BoolVar(&flags.tlsSelfSigned, "tls-self-signed", getenv("GITVISTA_TLS_SELF_SIGNED", "") == "true", "Serve HTTPS with a certificate from a cached local CA")
+}
+
+func tlsEnabled(parsed appFlags) bool {
+	return parsed.tlsSelfSigned || parsed.tlsCert != "" || parsed.tlsKey != ""
+}
+
+func urlScheme(parsed appFlags) string {
+	if tlsEnabled(parsed) {
+		return "https"
+	}
+	return "http"
+}
+
+func validateTLSFlags(parsed appFlags) error {
+	if (parsed.tlsCert == "") != (parsed.tlsKey == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be used together")
+	}
+	if parsed.tlsSelfSigned && parsed.tlsCert != "" {
+		return fmt.Errorf("use either -tls-self-signed or -tls-cert, not both")
+	}
+	return nil
+}
+
+// buildTLSConfig returns nil when TLS is off. For self-signed mode it also
+// returns the CA certificate path users import to trust the server.
+func buildTLSConfig(parsed appFlags, now time.Time) (*tls.Config, string, error) {
+	if err := validateTLSFlags(parsed); err != nil || !tlsEnabled(parsed) {
+		return nil, "", err
+	}
+
+	var (
+		cert   tls.Certificate
+		caPath string
+		err    error
+	)
+	if parsed.tlsSelfSigned {
+		dir, dirErr := tlsCacheDirFunc()
+		if dirErr != nil {
+			return nil, "", dirErr
+		}
+		cert, err = tlscert.SelfSigned(dir, selfSignedHosts(resolveBindHost(parsed.host)), now)
+		caPath = tlscert.CAPath(dir)
+	} else {
+		cert, err = tlscert.LoadKeyPair(parsed.tlsCert, parsed.tlsKey)
+		if err == nil {
+			err = tlscert.CheckValidity(cert.Leaf, now)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, caPath, nil
+}
+
+// selfSignedHosts lists the names a self-signed certificate must cover: the
+// loopback names, and for a wildcard bind the machine's hostname and
+// interface addresses so other devices on the network can connect.
+func selfSignedHosts(bindHost string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	add := func(host string) {
+		for _, existing := range hosts {
+			if strings.EqualFold(existing, host) {
+				return
+			}
+		}
+		hosts = append(hosts, host)
+	}
+
+	ip := net.ParseIP(strings.Trim(bindHost, "[]"))
+	if ip == nil || !ip.IsUnspecified() {
+		add(strings.Trim(bindHost, "[]"))
+		return hosts
+	}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		add(name)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+				add(ipNet.IP.String())
+			}
+		}
+	}
+	return hosts
+}
+
+// tlsDoctorCheck inspects the configured certificate without issuing one.
+// It reports false when TLS is off.
+func tlsDoctorCheck(parsed appFlags, now time.Time) (doctorCheck, bool) {
+	if !tlsEnabled(parsed) {
+		return doctorCheck{}, false
+	}
+	check := doctorCheck{Name: "tls"}
+	if err := validateTLSFlags(parsed); err != nil {
+		check.Status, check.Message = "fail", err.Error()
+		return check, true
+	}
+
+	certFile, keyFile := parsed.tlsCert, parsed.tlsKey
+	if parsed.tlsSelfSigned {
+		dir, err := tlsCacheDirFunc()
+		if err != nil {
+			check.Status, check.Message = "fail", err.Error()
+			return check, true
+		}
+		certFile, keyFile = tlscert.CertPaths(dir)
+		if _, err := os.Stat(certFile); os.IsNotExist(err) {
+			check.Status, check.Message = "ok", "self-signed certificate will be issued on first start"
+			return check, true
+		}
+	}
+
+	cert, err := tlscert.LoadKeyPair(certFile, keyFile)
+	if err != nil {
+		check.Status, check.Message = "fail", err.Error()
+		return check, true
+	}
+	if err := tlscert.CheckValidity(cert.Leaf, now); err != nil {
+		// An expired self-signed certificate is reissued at startup.
+		if parsed.tlsSelfSigned {
+			check.Status, check.Message = "warn", err.Error()+"; it will be reissued on start"
+		} else {
+			check.Status, check.Message = "fail", err.Error()
+		}
+		return check, true
+	}
+
+	remaining := cert.Leaf.NotAfter.Sub(now)
+	check.Status = "ok"
+	if remaining < tlscert.RenewBefore && !parsed.tlsSelfSigned {
+		check.Status = "warn"
+	}
+	names := append([]string{}, cert.Leaf.DNSNames...)
+	for _, ip := range cert.Leaf.IPAddresses {
+		names = append(names, ip.String())
+	}
+	check.Message = fmt.Sprintf("valid until %s (%d days), covers %s",
+		cert.Leaf.NotAfter.UTC().Format("2006-01-02"), int(remaining.Hours()/24), strings.Join(names, ", "))
+	return check, true
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/internal/tlscert"
+)
+
+func useTLSCacheDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := tlsCacheDirFunc
+	tlsCacheDirFunc = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { tlsCacheDirFunc = orig })
+	return dir
+}
+
+func TestBuildURLsHTTPS(t *testing.T) {
+	base, open := buildURLs(urlScheme(appFlags{tlsSelfSigned: true}), "0.0.0.0:8443", launchTarget{Path: "README.md"})
+	if base != "https://0.0.0.0:8443" || open != "https://0.0.0.0:8443?path=README.md" {
+		t.Fatalf("base = %q, open = %q", base, open)
+	}
+}
+
+func TestValidateTLSFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags appFlags
+		ok    bool
+	}{
+		{name: "off", flags: appFlags{}, ok: true},
+		{name: "pair", flags: appFlags{tlsCert: "c.pem", tlsKey: "k.pem"}, ok: true},
+		{name: "self-signed", flags: appFlags{tlsSelfSigned: true}, ok: true},
+		{name: "cert without key", flags: appFlags{tlsCert: "c.pem"}},
+		{name: "both modes", flags: appFlags{tlsCert: "c.pem", tlsKey: "k.pem", tlsSelfSigned: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTLSFlags(tt.flags); (err == nil) != tt.ok {
+				t.Fatalf("validateTLSFlags() error = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	cfg, caPath, err := buildTLSConfig(appFlags{}, time.Now())
+	if cfg != nil || caPath != "" || err != nil {
+		t.Fatalf("off: cfg = %v, caPath = %q, err = %v", cfg, caPath, err)
+	}
+
+	dir := useTLSCacheDir(t)
+	cfg, caPath, err = buildTLSConfig(appFlags{tlsSelfSigned: true, host: "192.0.2.10"}, time.Now())
+	if err != nil {
+		t.Fatalf("self-signed: err = %v", err)
+	}
+	if caPath != tlscert.CAPath(dir) {
+		t.Fatalf("caPath = %q, want %q", caPath, tlscert.CAPath(dir))
+	}
+	if err := cfg.Certificates[0].Leaf.VerifyHostname("192.0.2.10"); err != nil {
+		t.Fatalf("certificate does not cover bind host: %v", err)
+	}
+
+	certFile, keyFile := tlscert.CertPaths(dir)
+	cfg, caPath, err = buildTLSConfig(appFlags{tlsCert: certFile, tlsKey: keyFile}, time.Now())
+	if err != nil || cfg == nil || caPath != "" {
+		t.Fatalf("provided: cfg = %v, caPath = %q, err = %v", cfg, caPath, err)
+	}
+	if _, _, err := buildTLSConfig(appFlags{tlsCert: certFile, tlsKey: keyFile}, time.Now().AddDate(2, 0, 0)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expired: err = %v, want expired", err)
+	}
+}
+
+func TestSelfSignedHosts(t *testing.T) {
+	hosts := selfSignedHosts("127.0.0.1")
+	if !slices.Equal(hosts, []string{"localhost", "127.0.0.1", "::1"}) {
+		t.Fatalf("loopback hosts = %v", hosts)
+	}
+	if hosts := selfSignedHosts("devbox.lan"); !slices.Contains(hosts, "devbox.lan") {
+		t.Fatalf("named hosts = %v, want devbox.lan", hosts)
+	}
+}
+
+func TestTLSDoctorCheck(t *testing.T) {
+	if _, ok := tlsDoctorCheck(appFlags{}, time.Now()); ok {
+		t.Fatal("tlsDoctorCheck() reported a check with TLS off")
+	}
+
+	dir := useTLSCacheDir(t)
+	check, ok := tlsDoctorCheck(appFlags{tlsSelfSigned: true}, time.Now())
+	if !ok || check.Status != "ok" || !strings.Contains(check.Message, "first start") {
+		t.Fatalf("self-signed before issue: %+v", check)
+	}
+
+	cert, err := tlscert.SelfSigned(dir, []string{"localhost"}, time.Now())
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	certFile, keyFile := tlscert.CertPaths(dir)
+	provided := appFlags{tlsCert: certFile, tlsKey: keyFile}
+
+	tests := []struct {
+		name   string
+		flags  appFlags
+		now    time.Time
+		status string
+		text   string
+	}{
+		{name: "valid", flags: provided, now: time.Now(), status: "ok", text: "covers localhost"},
+		{name: "expiring", flags: provided, now: cert.Leaf.NotAfter.Add(-24 * time.Hour), status: "warn", text: "(1 days)"},
+		{name: "expired", flags: provided, now: cert.Leaf.NotAfter.Add(time.Hour), status: "fail", text: "expired"},
+		{name: "self-signed expired", flags: appFlags{tlsSelfSigned: true}, now: cert.Leaf.NotAfter.Add(time.Hour), status: "warn", text: "reissued"},
+		{name: "missing key", flags: appFlags{tlsCert: certFile}, now: time.Now(), status: "fail", text: "together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, ok := tlsDoctorCheck(tt.flags, tt.now)
+			if !ok || check.Status != tt.status || !strings.Contains(check.Message, tt.text) {
+				t.Fatalf("check = %+v, want status %q containing %q", check, tt.status, tt.text)
+			}
+		})
+	}
+}
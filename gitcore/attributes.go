@@ -0,0 +1,84 @@
+package gitcore
+
+import "strings"
+
+// Attribute states as git check-attr reports them. Any other state is the
+// value given with "attr=value".
+const (
+	AttributeSet         = "set"
+	AttributeUnset       = "unset"
+	AttributeUnspecified = "unspecified"
+)
+
+// Attributes is a parsed .gitattributes file. Patterns follow gitignore
+// syntax relative to the file's directory, except that negation is not
+// supported and a pattern never matches the paths inside a directory it
+// names. Macro definitions and quoted patterns are skipped.
+type Attributes struct {
+	// Dir is the directory holding the file, relative to the repository
+	// root, with a trailing slash; empty for the root.
+	Dir   string          `json:"dir"`
+	Rules []AttributeRule `json:"rules"`
+}
+
+// AttributeRule is one pattern line and the attribute states it assigns.
+type AttributeRule struct {
+	Pattern string            `json:"pattern"`
+	States  map[string]string `json:"states"`
+	pat     ignorePattern
+}
+
+// ParseAttributes parses the .gitattributes file found in dir.
+func ParseAttributes(dir string, data []byte) *Attributes {
+	dir = strings.Trim(dir, "/")
+	if dir != "" {
+		dir += "/"
+	}
+	a := &Attributes{Dir: dir, Rules: []AttributeRule{}}
+	for _, raw := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(raw)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") ||
+			strings.HasPrefix(fields[0], `"`) || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		pat, ok := parseIgnoreLine(fields[0])
+		if !ok || pat.dirOnly {
+			continue
+		}
+		rule := AttributeRule{Pattern: fields[0], States: make(map[string]string, len(fields)-1), pat: pat}
+		for _, attr := range fields[1:] {
+			switch {
+			case strings.HasPrefix(attr, "-"):
+				rule.States[attr[1:]] = AttributeUnset
+			case strings.HasPrefix(attr, "!"):
+				rule.States[attr[1:]] = AttributeUnspecified
+			default:
+				name, value, hasValue := strings.Cut(attr, "=")
+				if !hasValue {
+					value = AttributeSet
+				}
+				rule.States[name] = value
+			}
+		}
+		a.Rules = append(a.Rules, rule)
+	}
+	return a
+}
+
+// Apply records in states the state each rule matching filePath gives an
+// attribute, in file order so later rules win. Applying the files from the
+// root down gives deeper files precedence, as git does. filePath is relative
+// to the repository root.
+func (a *Attributes) Apply(filePath string, states map[string]string) {
+	if a == nil || !strings.HasPrefix(filePath, a.Dir) {
+		return
+	}
+	for _, rule := range a.Rules {
+		if !matchPattern(ignoreRule{baseDir: a.Dir, pat: rule.pat}, filePath, false) {
+			continue
+		}
+		for name, state := range rule.States {
+			states[name] = state
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package gitcore
+
+import (
+	"maps"
+	"testing"
+)
+
+const testAttributes = `# Linguist overrides
+*.pb.go           linguist-generated
+vendor/**         linguist-vendored
+/docs/**          linguist-documentation -diff
+third_party/      linguist-vendored
+*.inc             linguist-language=PHP
+vendor/ours/**    -linguist-vendored
+[attr]binary      -diff -merge -text
+"quoted name"     text
+legacy.js         !linguist-generated
+`
+
+func TestAttributesApply(t *testing.T) {
+	root := ParseAttributes("", []byte(testAttributes))
+	if len(root.Rules) != 6 {
+		t.Fatalf("rules = %+v", root.Rules)
+	}
+	nested := ParseAttributes("/web/", []byte("*.js linguist-generated\nlegacy.js -linguist-generated\n"))
+	if nested.Dir != "web/" {
+		t.Fatalf("Dir = %q", nested.Dir)
+	}
+
+	tests := []struct {
+		path string
+		want map[string]string
+	}{
+		{path: "api/types.pb.go", want: map[string]string{"linguist-generated": AttributeSet}},
+		{path: "vendor/lib/x.go", want: map[string]string{"linguist-vendored": AttributeSet}},
+		{path: "vendor/ours/x.go", want: map[string]string{"linguist-vendored": AttributeUnset}},
+		{path: "docs/guide.md", want: map[string]string{"linguist-documentation": AttributeSet, "diff": AttributeUnset}},
+		{path: "src/docs/guide.md", want: map[string]string{}},
+		{path: "third_party/lib.c", want: map[string]string{}},
+		{path: "lib/page.inc", want: map[string]string{"linguist-language": "PHP"}},
+		{path: "web/app.js", want: map[string]string{"linguist-generated": AttributeSet}},
+		{path: "web/legacy.js", want: map[string]string{"linguist-generated": AttributeUnset}},
+		{path: "legacy.js", want: map[string]string{"linguist-generated": AttributeUnspecified}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			states := map[string]string{}
+			root.Apply(tt.path, states)
+			nested.Apply(tt.path, states)
+			if !maps.Equal(states, tt.want) {
+				t.Fatalf("Apply(%q) = %v, want %v", tt.path, states, tt.want)
+			}
+		})
+	}
+}
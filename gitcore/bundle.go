@@ -0,0 +1,384 @@
+package gitcore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" // #nosec G505 -- Git pack checksums use SHA-1
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+const (
+	bundleV2Signature = "# v2 git bundle\n"
+	bundleV3Signature = "# v3 git bundle\n"
+)
+
+// BundleRef is a ref recorded in a bundle header.
+type BundleRef struct {
+	Name string
+	ID   Hash
+}
+
+// BundleOptions configures Repository.WriteBundle.
+type BundleOptions struct {
+	// Version is the bundle format, 2 or 3. Zero means 2.
+	Version int
+	// Refs are recorded in the bundle, and everything they reach is packed.
+	Refs []BundleRef
+	// Haves are commits the receiver already has. The commits they reach that
+	// the bundle's commits name as parents become its prerequisites.
+	Haves []Hash
+	Pack  PackOptions
+}
+
+// WrittenBundle describes a bundle produced by Repository.WriteBundle.
+type WrittenBundle struct {
+	Prerequisites []Hash
+	Pack          *WrittenPack
+}
+
+// WriteBundle writes a git bundle: a header naming its prerequisites and refs,
+// followed by a pack of every object the refs reach that a repository holding
+// the prerequisites lacks.
+// See: https://git-scm.com/docs/gitformat-bundle
+func (r *Repository) WriteBundle(w io.Writer, opts BundleOptions) (*WrittenBundle, error) {
+	version := opts.Version
+	if version == 0 {
+		version = 2
+	}
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported bundle version %d", version)
+	}
+	if len(opts.Refs) == 0 {
+		return nil, fmt.Errorf("refusing to create empty bundle")
+	}
+
+	wants := make([]Hash, 0, len(opts.Refs))
+	for _, ref := range opts.Refs {
+		if !strings.HasPrefix(ref.Name, "refs/") && ref.Name != "HEAD" {
+			return nil, fmt.Errorf("invalid bundle ref name %q", ref.Name)
+		}
+		wants = append(wants, ref.ID)
+	}
+	l, err := r.listObjects(ListObjectsOptions{Wants: wants, Haves: opts.Haves}, true)
+	if err != nil {
+		return nil, err
+	}
+	prerequisites := make([]Hash, 0, len(l.boundary))
+	for id := range l.boundary {
+		prerequisites = append(prerequisites, id)
+	}
+	slices.Sort(prerequisites)
+
+	bw := bufio.NewWriter(w)
+	if version == 3 {
+		_, _ = bw.WriteString(bundleV3Signature)
+		_, _ = bw.WriteString("@object-format=sha1\n")
+	} else {
+		_, _ = bw.WriteString(bundleV2Signature)
+	}
+	for _, id := range prerequisites {
+		commit, err := r.walkCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		_, _ = fmt.Fprintf(bw, "-%s %s\n", id, subject)
+	}
+	for _, ref := range opts.Refs {
+		_, _ = fmt.Fprintf(bw, "%s %s\n", ref.ID, ref.Name)
+	}
+	_ = bw.WriteByte('\n')
+
+	objects := make([]Hash, 0, len(l.commits)+len(l.tags)+len(l.trees)+len(l.blobs))
+	objects = append(objects, l.commits...)
+	objects = append(objects, l.tags...)
+	objects = append(objects, l.trees...)
+	objects = append(objects, l.blobs...)
+	written, err := r.WritePack(bw, objects, opts.Pack)
+	if err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return &WrittenBundle{Prerequisites: prerequisites, Pack: written}, nil
+}
+
+// bundleHeader is the parsed text header of a bundle file.
+type bundleHeader struct {
+	version       int
+	prerequisites []Hash
+	refs          []BundleRef
+	// packStart is the offset of the embedded pack.
+	packStart int
+}
+
+// isBundleFile reports whether path is a regular file that starts with a
+// bundle signature.
+func isBundleFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	//nolint:gosec // G304: The bundle path is chosen by the user opening it
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	signature := make([]byte, len(bundleV2Signature))
+	if _, err := io.ReadFull(file, signature); err != nil {
+		return false
+	}
+	return string(signature) == bundleV2Signature || string(signature) == bundleV3Signature
+}
+
+func parseBundleHeader(data []byte) (*bundleHeader, error) {
+	header := &bundleHeader{}
+	switch {
+	case bytes.HasPrefix(data, []byte(bundleV2Signature)):
+		header.version = 2
+	case bytes.HasPrefix(data, []byte(bundleV3Signature)):
+		header.version = 3
+	default:
+		return nil, fmt.Errorf("not a git bundle")
+	}
+
+	pos := len(bundleV2Signature)
+	for {
+		end := bytes.IndexByte(data[pos:], '\n')
+		if end < 0 {
+			return nil, fmt.Errorf("bundle header is not terminated")
+		}
+		line := string(data[pos : pos+end])
+		pos += end + 1
+		if line == "" {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "@"):
+			if header.version < 3 {
+				return nil, fmt.Errorf("bundle capability %q in a v2 bundle", line)
+			}
+			// Filtered bundles would be missing objects, so only the object
+			// format is understood.
+			if line != "@object-format=sha1" {
+				return nil, fmt.Errorf("unsupported bundle capability %q", line)
+			}
+		case strings.HasPrefix(line, "-"):
+			field, _, _ := strings.Cut(line[1:], " ")
+			id, err := NewHash(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bundle prerequisite %q: %w", line, err)
+			}
+			header.prerequisites = append(header.prerequisites, id)
+		default:
+			field, name, ok := strings.Cut(line, " ")
+			id, err := NewHash(field)
+			if !ok || err != nil || name == "" {
+				return nil, fmt.Errorf("invalid bundle ref line %q", line)
+			}
+			header.refs = append(header.refs, BundleRef{Name: name, ID: id})
+		}
+	}
+	header.packStart = pos
+	return header, nil
+}
+
+// openBundle loads a bundle file as a read-only repository. The embedded pack
+// is held and indexed in memory; objects that are deltas against the
+// bundle's prerequisites cannot be read.
+func openBundle(path string) (*Repository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	//nolint:gosec // G304: The bundle path is chosen by the user opening it
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	header, err := parseBundleHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{
+		gitDir:        absPath,
+		workDir:       absPath,
+		bundle:        true,
+		prerequisites: make(map[Hash]struct{}, len(header.prerequisites)),
+		refs:          make(map[string]Hash),
+		commits:       make([]*Commit, 0),
+		commitMap:     make(map[Hash]*Commit),
+		tags:          make([]*Tag, 0),
+		stashes:       make([]*StashEntry, 0),
+		packIndices:   make([]*PackIndex, 0),
+		packLocations: make(map[Hash]PackLocation),
+		packReaders:   make(map[string]*PackReader),
+	}
+	runtime.SetFinalizer(repo, func(r *Repository) {
+		_ = r.Close()
+	})
+	for _, id := range header.prerequisites {
+		repo.prerequisites[id] = struct{}{}
+	}
+
+	pack := data[header.packStart:]
+	repo.packReaders[absPath] = &PackReader{size: int64(len(pack)), data: pack}
+	if err := repo.indexBundlePack(absPath, pack); err != nil {
+		return nil, fmt.Errorf("failed to index bundle pack: %w", err)
+	}
+
+	headID := Hash("")
+	for _, ref := range header.refs {
+		if ref.Name == "HEAD" {
+			headID = ref.ID
+			continue
+		}
+		repo.refs[ref.Name] = ref.ID
+	}
+	repo.setBundleHead(headID)
+
+	if err := repo.loadObjects(); err != nil {
+		return nil, fmt.Errorf("failed to load objects: %w", err)
+	}
+	return repo, nil
+}
+
+// setBundleHead points HEAD at the branch the bundle's HEAD names, as git
+// clone guesses it, or at the first branch when the bundle records no HEAD.
+func (r *Repository) setBundleHead(headID Hash) {
+	branches := make([]string, 0, len(r.refs))
+	for name := range r.refs {
+		if strings.HasPrefix(name, "refs/heads/") {
+			branches = append(branches, name)
+		}
+	}
+	slices.Sort(branches)
+
+	if headID == "" {
+		if len(branches) > 0 {
+			r.headRef = branches[0]
+			r.head = r.refs[r.headRef]
+		}
+		return
+	}
+	for _, preferred := range []string{"refs/heads/main", "refs/heads/master"} {
+		if r.refs[preferred] == headID {
+			r.headRef, r.head = preferred, headID
+			return
+		}
+	}
+	for _, name := range branches {
+		if r.refs[name] == headID {
+			r.headRef, r.head = name, headID
+			return
+		}
+	}
+	r.head = headID
+	r.headDetached = true
+}
+
+// indexBundlePack records the location of every object in an in-memory pack.
+// Whole objects are hashed in one pass; deltas are resolved afterwards, a ref
+// delta possibly only once its base has been found.
+func (r *Repository) indexBundlePack(path string, pack []byte) error {
+	if len(pack) < 12+sha1.Size || string(pack[:4]) != "PACK" {
+		return fmt.Errorf("missing pack signature")
+	}
+	if version := binary.BigEndian.Uint32(pack[4:8]); version != 2 && version != 3 {
+		return fmt.Errorf("unsupported pack version %d", version)
+	}
+	body := pack[:len(pack)-sha1.Size]
+	sum := sha1.Sum(body) // #nosec G401 -- Git pack checksums use SHA-1
+	if !bytes.Equal(sum[:], pack[len(body):]) {
+		return fmt.Errorf("pack checksum mismatch")
+	}
+
+	count := binary.BigEndian.Uint32(pack[8:12])
+	rd := bytes.NewReader(body)
+	if _, err := rd.Seek(12, io.SeekStart); err != nil {
+		return err
+	}
+	var deltas []int64
+	for range count {
+		offset := int64(len(body) - rd.Len())
+		objectType, size, err := readPackObjectHeader(rd)
+		if err != nil {
+			return fmt.Errorf("object at %d: %w", offset, err)
+		}
+		switch objectType {
+		case ObjectTypeCommit, ObjectTypeTree, ObjectTypeBlob, ObjectTypeTag:
+			data, err := readCompressedObject(rd, size)
+			if err != nil {
+				return fmt.Errorf("object at %d: %w", offset, err)
+			}
+			r.addBundleObject(path, offset, objectType, data)
+			continue
+		case ObjectTypeOfsDelta:
+			for {
+				b, err := rd.ReadByte()
+				if err != nil {
+					return fmt.Errorf("object at %d: %w", offset, err)
+				}
+				if b&0x80 == 0 {
+					break
+				}
+			}
+		case ObjectTypeRefDelta:
+			if _, err := rd.Seek(20, io.SeekCurrent); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("object at %d: unsupported object type %d", offset, objectType)
+		}
+		if _, err := readCompressedObject(rd, size); err != nil {
+			return fmt.Errorf("object at %d: %w", offset, err)
+		}
+		deltas = append(deltas, offset)
+	}
+	if rd.Len() != 0 {
+		return fmt.Errorf("%d bytes of trailing data after %d objects", rd.Len(), count)
+	}
+
+	for len(deltas) > 0 {
+		var unresolved []int64
+		for _, offset := range deltas {
+			data, objectType, err := r.readPackedObjectData(path, offset, 0)
+			if err != nil {
+				unresolved = append(unresolved, offset)
+				continue
+			}
+			r.addBundleObject(path, offset, objectType, data)
+		}
+		if len(unresolved) == len(deltas) {
+			// The rest are thin deltas against prerequisite objects.
+			break
+		}
+		deltas = unresolved
+	}
+	return nil
+}
+
+func (r *Repository) addBundleObject(path string, offset int64, objectType ObjectType, data []byte) {
+	id := HashObject(objectType, data)
+	if _, exists := r.packLocations[id]; !exists {
+		r.packLocations[id] = PackLocation{packPath: path, offset: offset}
+	}
+}
+
+// IsBundle reports whether the repository was opened from a bundle file. Such
+// a repository is read-only and has no working tree.
+func (r *Repository) IsBundle() bool {
+	return r.bundle
+}
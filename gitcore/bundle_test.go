@@ -0,0 +1,177 @@
+package gitcore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeBundleFile(t *testing.T, repo *Repository, opts BundleOptions) (string, *WrittenBundle) {
+	t.Helper()
+	var buf bytes.Buffer
+	written, err := repo.WriteBundle(&buf, opts)
+	if err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "repo.bundle")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, written
+}
+
+func TestWriteBundleIsReadableByGit(t *testing.T) {
+	dir := newPackFixture(t)
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	refs := repo.Refs()
+	full, written := writeBundleFile(t, repo, BundleOptions{Refs: []BundleRef{
+		{Name: "HEAD", ID: repo.Head()},
+		{Name: "refs/heads/main", ID: refs["refs/heads/main"]},
+		{Name: "refs/tags/v1", ID: refs["refs/tags/v1"]},
+	}})
+	if len(written.Prerequisites) != 0 {
+		t.Fatalf("full bundle has prerequisites %v", written.Prerequisites)
+	}
+	mustRunGit(t, dir, "bundle", "verify", full)
+	clone := filepath.Join(t.TempDir(), "clone")
+	mustRunGit(t, dir, "clone", "-q", full, clone)
+	mustRunGit(t, clone, "fsck", "--strict", "--no-dangling")
+	if got := mustRunGit(t, clone, "rev-parse", "HEAD", "v1"); got != string(repo.Head())+"\n"+string(refs["refs/tags/v1"]) {
+		t.Fatalf("clone of bundle resolves HEAD and v1 to %s", got)
+	}
+
+	// An incremental v3 bundle names the first commit as its prerequisite and
+	// packs only what changed since.
+	first := refs["refs/heads/feature"]
+	incremental, written := writeBundleFile(t, repo, BundleOptions{
+		Version: 3,
+		Refs:    []BundleRef{{Name: "refs/heads/main", ID: repo.Head()}},
+		Haves:   []Hash{first},
+	})
+	if !slices.Equal(written.Prerequisites, []Hash{first}) {
+		t.Fatalf("incremental bundle prerequisites = %v, want %s", written.Prerequisites, first)
+	}
+	data, err := os.ReadFile(incremental)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHeader := "# v3 git bundle\n@object-format=sha1\n-" + string(first) + " first\n" + string(repo.Head()) + " refs/heads/main\n\n"
+	if !strings.HasPrefix(string(data), wantHeader) {
+		t.Fatalf("incremental bundle header = %q, want %q", data[:len(wantHeader)], wantHeader)
+	}
+	if got, want := len(written.Pack.Objects), len(gitObjectSet(t, dir, "feature..main")); got != want {
+		t.Fatalf("incremental bundle packs %d objects, want %d", got, want)
+	}
+	mustRunGit(t, clone, "bundle", "verify", incremental)
+	mustRunGit(t, clone, "fetch", "-q", incremental, "main:refs/heads/from-bundle")
+	mustRunGit(t, clone, "fsck", "--strict", "--no-dangling")
+
+	if _, err := repo.WriteBundle(&bytes.Buffer{}, BundleOptions{}); err == nil {
+		t.Error("WriteBundle() accepted a bundle without refs")
+	}
+	if _, err := repo.WriteBundle(&bytes.Buffer{}, BundleOptions{Version: 4, Refs: []BundleRef{{Name: "HEAD", ID: repo.Head()}}}); err == nil {
+		t.Error("WriteBundle() accepted version 4")
+	}
+}
+
+func TestOpenBundle(t *testing.T) {
+	dir := newDeltaFixture(t)
+	full := filepath.Join(t.TempDir(), "full.bundle")
+	mustRunGit(t, dir, "bundle", "create", "-q", full, "--all")
+	// git packs incremental bundles thin, so some objects are deltas against
+	// the prerequisite's blobs.
+	incremental := filepath.Join(t.TempDir(), "incremental.bundle")
+	mustRunGit(t, dir, "bundle", "create", "-q", incremental, "HEAD~2..main")
+
+	repo, err := NewRepository(full)
+	if err != nil {
+		t.Fatalf("NewRepository(bundle) error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	if !repo.IsBundle() || !repo.IsBare() {
+		t.Fatalf("bundle repository IsBundle() = %v, IsBare() = %v", repo.IsBundle(), repo.IsBare())
+	}
+	if got := repo.HeadRef(); got != "refs/heads/main" {
+		t.Fatalf("HeadRef() = %q, want refs/heads/main", got)
+	}
+	if got := string(repo.Head()); got != mustRunGit(t, dir, "rev-parse", "HEAD") {
+		t.Fatalf("Head() = %s", got)
+	}
+	wantCommits := len(strings.Fields(mustRunGit(t, dir, "rev-list", "--all")))
+	if repo.CommitCount() != wantCommits {
+		t.Fatalf("CommitCount() = %d, want %d", repo.CommitCount(), wantCommits)
+	}
+	// Every object in the bundle is indexed and reads back as git has it.
+	for _, id := range gitObjectSet(t, dir, "--all") {
+		data, objectType, err := repo.readObjectData(id, 0)
+		if err != nil {
+			t.Fatalf("readObjectData(%s) error = %v", id, err)
+		}
+		if got := HashObject(objectType, data); got != id {
+			t.Fatalf("object %s reads back as %s", id, got)
+		}
+	}
+	if _, err := repo.WriteLooseObject(ObjectTypeBlob, []byte("x")); err == nil {
+		t.Fatal("WriteLooseObject() wrote into a bundle")
+	}
+
+	incRepo, err := NewRepository(incremental)
+	if err != nil {
+		t.Fatalf("NewRepository(incremental bundle) error = %v", err)
+	}
+	t.Cleanup(func() { _ = incRepo.Close() })
+	if incRepo.CommitCount() != 2 {
+		t.Fatalf("incremental bundle CommitCount() = %d, want 2", incRepo.CommitCount())
+	}
+	commit, err := incRepo.GetCommit(incRepo.Head())
+	if err != nil {
+		t.Fatalf("GetCommit(HEAD) error = %v", err)
+	}
+	if _, err := incRepo.GetTree(commit.Tree); err != nil {
+		t.Fatalf("GetTree(HEAD^{tree}) error = %v", err)
+	}
+}
+
+func TestParseBundleHeader(t *testing.T) {
+	id := strings.Repeat("a", 40)
+	tests := []struct {
+		name    string
+		header  string
+		wantErr string
+	}{
+		{name: "v2", header: "# v2 git bundle\n-" + id + " subject\n" + id + " refs/heads/main\n\nPACK"},
+		{name: "v3", header: "# v3 git bundle\n@object-format=sha1\n" + id + " HEAD\n\nPACK"},
+		{name: "not a bundle", header: "PACK", wantErr: "not a git bundle"},
+		{name: "unterminated", header: "# v2 git bundle\n" + id + " refs/heads/main\n", wantErr: "not terminated"},
+		{name: "capability in v2", header: "# v2 git bundle\n@object-format=sha1\n\n", wantErr: "v2 bundle"},
+		{name: "filter", header: "# v3 git bundle\n@filter=blob:none\n\n", wantErr: "unsupported bundle capability"},
+		{name: "sha256", header: "# v3 git bundle\n@object-format=sha256\n\n", wantErr: "unsupported bundle capability"},
+		{name: "bad ref", header: "# v2 git bundle\n" + id + "\n\n", wantErr: "invalid bundle ref"},
+		{name: "bad prerequisite", header: "# v2 git bundle\n-xyz\n\n", wantErr: "invalid bundle prerequisite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := parseBundleHeader([]byte(tt.header))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseBundleHeader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBundleHeader() error = %v", err)
+			}
+			if got := tt.header[header.packStart:]; got != "PACK" {
+				t.Fatalf("packStart leaves %q", got)
+			}
+		})
+	}
+}
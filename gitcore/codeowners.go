@@ -0,0 +1,156 @@
+package gitcore
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// codeOwnersLocations are the paths searched for a CODEOWNERS file, in the
+// order GitHub searches them. The first one present is used.
+var codeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners is a parsed CODEOWNERS file. Patterns follow gitignore syntax,
+// except that negation is not supported and a pattern ending in "/*" matches
+// only the files directly inside its directory. The last matching rule wins.
+type CodeOwners struct {
+	// Path is where the file was found, relative to the repository root.
+	Path   string            `json:"path"`
+	Rules  []CodeOwnersRule  `json:"rules"`
+	Errors []CodeOwnersError `json:"errors"`
+}
+
+// CodeOwnersRule is one pattern line. A rule with no owners marks the paths
+// it matches as unowned.
+type CodeOwnersRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+	Line    int      `json:"line"`
+	pat     ignorePattern
+}
+
+// CodeOwnersError describes a line, or an owner on a line, that was skipped.
+type CodeOwnersError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ParseCodeOwners parses the CODEOWNERS file found at filePath.
+func ParseCodeOwners(filePath string, data []byte) *CodeOwners {
+	c := &CodeOwners{Path: filePath, Rules: []CodeOwnersRule{}, Errors: []CodeOwnersError{}}
+	for i, raw := range strings.Split(string(data), "\n") {
+		line := i + 1
+		fields := strings.Fields(raw)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "!") {
+			c.Errors = append(c.Errors, CodeOwnersError{Line: line, Message: "negated patterns are not supported"})
+			continue
+		}
+		pat, ok := parseIgnoreLine(fields[0])
+		if !ok {
+			c.Errors = append(c.Errors, CodeOwnersError{Line: line, Message: fmt.Sprintf("invalid pattern %q", fields[0])})
+			continue
+		}
+		rule := CodeOwnersRule{Pattern: fields[0], Owners: []string{}, Line: line, pat: pat}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			if !validCodeOwner(owner) {
+				c.Errors = append(c.Errors, CodeOwnersError{Line: line, Message: fmt.Sprintf("invalid owner %q", owner)})
+				continue
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+		c.Rules = append(c.Rules, rule)
+	}
+	return c
+}
+
+// validCodeOwner accepts @user, @org/team, and email owners.
+func validCodeOwner(owner string) bool {
+	if name, ok := strings.CutPrefix(owner, "@"); ok {
+		org, team, isTeam := strings.Cut(name, "/")
+		if isTeam {
+			return org != "" && team != "" && !strings.Contains(team, "/")
+		}
+		return name != ""
+	}
+	local, domain, ok := strings.Cut(owner, "@")
+	return ok && local != "" && domain != "" && !strings.Contains(domain, "@")
+}
+
+// Match returns the rule that decides who owns filePath: the last rule whose
+// pattern matches it or one of its parent directories. A path ending in "/"
+// names a directory.
+func (c *CodeOwners) Match(filePath string) (CodeOwnersRule, bool) {
+	isDir := strings.HasSuffix(filePath, "/")
+	filePath = strings.Trim(filePath, "/")
+	if c == nil || filePath == "" {
+		return CodeOwnersRule{}, false
+	}
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if codeOwnersPatternMatches(c.Rules[i].pat, filePath, isDir) {
+			return c.Rules[i], true
+		}
+	}
+	return CodeOwnersRule{}, false
+}
+
+// Owners returns the declared owners of filePath, or nil when it has none.
+func (c *CodeOwners) Owners(filePath string) []string {
+	rule, ok := c.Match(filePath)
+	if !ok || len(rule.Owners) == 0 {
+		return nil
+	}
+	return rule.Owners
+}
+
+func codeOwnersPatternMatches(pat ignorePattern, filePath string, isDir bool) bool {
+	rule := ignoreRule{pat: pat}
+	// A pattern matching a directory owns everything below it, except that
+	// "dir/*" stops at dir's direct files.
+	if strings.Contains(pat.pattern, "/") && path.Base(pat.pattern) == "*" {
+		return !isDir && matchPattern(rule, filePath, false)
+	}
+	// matchPattern ignores whether the path is a directory, so a
+	// directory-only pattern is tried against the parents alone.
+	if (!pat.dirOnly || isDir) && matchPattern(rule, filePath, isDir) {
+		return true
+	}
+	for dir := path.Dir(filePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if matchPattern(rule, dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// CodeOwners loads the CODEOWNERS file in commit's tree. It returns nil when
+// the tree has none.
+func (r *Repository) CodeOwners(commit Hash) (*CodeOwners, error) {
+	c, err := r.GetCommit(commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range codeOwnersLocations {
+		dir, name := path.Split(location)
+		tree, err := r.resolveTreeAtPath(c.Tree, dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range tree.Entries {
+			if entry.Name != name || isTreeEntry(entry) || isSubmodule(entry) {
+				continue
+			}
+			data, err := r.GetBlob(entry.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", location, err)
+			}
+			return ParseCodeOwners(location, data), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,134 @@
+package gitcore
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testCodeOwners = `# Default owners
+*                       @org/core
+
+*.js                    @web-team-lead  # trailing comment
+/docs/                  docs@example.com
+apps/                   @org/apps
+/build/logs/            @ops
+/scripts/*              @scripts
+**/generated            @bots
+/docs/internal/
+!/vendor                @nobody
+/api/                   @api not-an-owner
+`
+
+func TestCodeOwnersMatch(t *testing.T) {
+	c := ParseCodeOwners("CODEOWNERS", []byte(testCodeOwners))
+	if len(c.Rules) != 9 {
+		t.Fatalf("rules = %+v", c.Rules)
+	}
+	wantErrors := []CodeOwnersError{
+		{Line: 11, Message: "negated patterns are not supported"},
+		{Line: 12, Message: `invalid owner "not-an-owner"`},
+	}
+	if !slices.Equal(c.Errors, wantErrors) {
+		t.Fatalf("errors = %+v, want %+v", c.Errors, wantErrors)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "main.go", want: []string{"@org/core"}},
+		{path: "web/app.js", want: []string{"@web-team-lead"}},
+		{path: "docs/guide.md", want: []string{"docs@example.com"}},
+		{path: "docs/deep/nested/page.md", want: []string{"docs@example.com"}},
+		{path: "src/docs/readme.md", want: []string{"@org/core"}},
+		{path: "docs/internal/secret.md"},
+		{path: "apps/web/main.go", want: []string{"@org/apps"}},
+		{path: "services/apps/api/main.go", want: []string{"@org/apps"}},
+		{path: "build/logs/today.log", want: []string{"@ops"}},
+		{path: "src/build/logs/today.log", want: []string{"@org/core"}},
+		{path: "scripts/release.sh", want: []string{"@scripts"}},
+		{path: "scripts/ci/lint.sh", want: []string{"@org/core"}},
+		{path: "pkg/generated/types.go", want: []string{"@bots"}},
+		{path: "generated/deep/types.go", want: []string{"@bots"}},
+		{path: "api/openapi.yaml", want: []string{"@api"}},
+		{path: "docs/", want: []string{"docs@example.com"}},
+		{path: "build/logs/", want: []string{"@ops"}},
+		{path: "build/logs", want: []string{"@org/core"}},
+		{path: "scripts/ci/", want: []string{"@org/core"}},
+		{path: ""},
+	}
+	for _, tt := range tests {
+		if got := c.Owners(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	rule, ok := c.Match("docs/internal/secret.md")
+	if !ok || rule.Line != 10 || len(rule.Owners) != 0 {
+		t.Fatalf("Match(docs/internal/secret.md) = %+v, %v, want the unowning rule on line 10", rule, ok)
+	}
+	var none *CodeOwners
+	if _, ok := none.Match("main.go"); ok {
+		t.Fatal("nil CodeOwners matched a path")
+	}
+}
+
+func TestRepositoryCodeOwners(t *testing.T) {
+	dir := t.TempDir()
+	mustRunGit(t, dir, "init", "-q", "-b", "main")
+	write := func(name, content string) {
+		t.Helper()
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("README.md", "# repo\n")
+	mustRunGit(t, dir, "add", "-A")
+	mustRunGit(t, dir, "commit", "-q", "-m", "no owners")
+	bare := mustRunGit(t, dir, "rev-parse", "HEAD")
+
+	write("docs/CODEOWNERS", "* @docs\n")
+	write("CODEOWNERS", "* @root\n")
+	mustRunGit(t, dir, "add", "-A")
+	mustRunGit(t, dir, "commit", "-q", "-m", "root owners")
+	rooted := mustRunGit(t, dir, "rev-parse", "HEAD")
+
+	write(".github/CODEOWNERS", "* @github\n")
+	mustRunGit(t, dir, "add", "-A")
+	mustRunGit(t, dir, "commit", "-q", "-m", "github owners")
+
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	for _, tt := range []struct {
+		commit   string
+		wantPath string
+		want     string
+	}{
+		{commit: string(repo.Head()), wantPath: ".github/CODEOWNERS", want: "@github"},
+		{commit: rooted, wantPath: "CODEOWNERS", want: "@root"},
+	} {
+		c, err := repo.CodeOwners(Hash(strings.TrimSpace(tt.commit)))
+		if err != nil {
+			t.Fatalf("CodeOwners(%s) error = %v", tt.commit, err)
+		}
+		if c == nil || c.Path != tt.wantPath || !slices.Equal(c.Owners("README.md"), []string{tt.want}) {
+			t.Fatalf("CodeOwners(%s) = %+v, want %s from %s", tt.commit, c, tt.want, tt.wantPath)
+		}
+	}
+
+	c, err := repo.CodeOwners(Hash(strings.TrimSpace(bare)))
+	if err != nil || c != nil {
+		t.Fatalf("CodeOwners(no file) = %+v, %v, want nil", c, err)
+	}
+}
@@ -0,0 +1,175 @@
+package gitcore
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ComparisonFile is a single file changed between the merge base and the head
+// of a comparison, with its line counts. Binary files, submodules, and blobs
+// larger than the diff size limit report zero insertions and deletions.
+type ComparisonFile struct {
+	DiffEntry
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// CompareOptions selects which of the commits unique to each side a
+// Comparison lists. The Ahead and Behind counts always cover every commit.
+type CompareOptions struct {
+	// CommitOffset skips that many of the newest commits on each side.
+	CommitOffset int
+	// CommitLimit caps the commits listed per side; zero lists them all.
+	CommitLimit int
+}
+
+// Comparison describes how two commits relate, like git log base...head and
+// git diff base...head combined.
+type Comparison struct {
+	Base Hash `json:"base"`
+	Head Hash `json:"head"`
+	// MergeBase is the preferred best common ancestor, as git merge-base prints.
+	MergeBase Hash `json:"mergeBase"`
+	// MergeBases lists every best common ancestor; it has more than one entry
+	// only for criss-cross histories.
+	MergeBases []Hash `json:"mergeBases"`
+	// Ahead is the number of commits reachable from Head but not Base.
+	Ahead int `json:"ahead"`
+	// Behind is the number of commits reachable from Base but not Head.
+	Behind int `json:"behind"`
+	// AheadCommits and BehindCommits are the page of those commits selected
+	// by CompareOptions, newest first.
+	AheadCommits  []*Commit `json:"aheadCommits"`
+	BehindCommits []*Commit `json:"behindCommits"`
+	// Files is the three-dot diff: the merge base's tree against Head's tree,
+	// sorted by path. Past maxDiffEntries files the list is cut short and
+	// Truncated is set; Stats.FilesChanged still counts every file, while its
+	// line counts cover only the listed ones.
+	Files     []ComparisonFile `json:"files"`
+	Truncated bool             `json:"truncated"`
+	Stats     DiffStats        `json:"stats"`
+}
+
+// Compare relates base and head the way a pull request would: the commits
+// unique to each side, and the changes head introduces since it diverged from
+// base. Commits unique to either side are ordered newest first.
+func Compare(repo *Repository, base, head Hash, opts CompareOptions) (*Comparison, error) {
+	bases, err := MergeBases(repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	mergeBase := selectPreferredMergeBase(repo.commitMap, bases)
+	repo.mu.RUnlock()
+
+	cmp := &Comparison{
+		Base:       base,
+		Head:       head,
+		MergeBase:  mergeBase,
+		MergeBases: bases,
+	}
+	aheadHashes := exclusiveCommits(repo, head, base)
+	behindHashes := exclusiveCommits(repo, base, head)
+	cmp.Ahead = len(aheadHashes)
+	cmp.Behind = len(behindHashes)
+	if cmp.AheadCommits, err = comparisonCommits(repo, aheadHashes, opts); err != nil {
+		return nil, err
+	}
+	if cmp.BehindCommits, err = comparisonCommits(repo, behindHashes, opts); err != nil {
+		return nil, err
+	}
+
+	mergeBaseCommit, err := repo.GetCommit(mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := repo.GetCommit(head)
+	if err != nil {
+		return nil, err
+	}
+	// A long-lived branch can easily touch more files than one response
+	// should carry, so walk the whole diff for the count and list a prefix.
+	entries, err := treeDiffAll(repo, mergeBaseCommit.Tree, headCommit.Tree)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b DiffEntry) int { return strings.Compare(a.Path, b.Path) })
+	cmp.Stats.FilesChanged = len(entries)
+	if len(entries) > maxDiffEntries {
+		entries = entries[:maxDiffEntries]
+		cmp.Truncated = true
+	}
+
+	cmp.Files = make([]ComparisonFile, len(entries))
+	for i, entry := range entries {
+		file := ComparisonFile{DiffEntry: entry}
+		if !entry.IsBinary && entry.OldMode != "160000" && entry.NewMode != "160000" {
+			file.Insertions, file.Deletions, err = countLineChanges(repo, entry.OldHash, entry.NewHash)
+			if err != nil {
+				return nil, fmt.Errorf("comparing %s: %w", entry.Path, err)
+			}
+		}
+		cmp.Files[i] = file
+		cmp.Stats.Insertions += file.Insertions
+		cmp.Stats.Deletions += file.Deletions
+	}
+	return cmp, nil
+}
+
+// comparisonCommits loads hashes, orders them newest first, and returns the
+// page opts selects.
+func comparisonCommits(repo *Repository, hashes []Hash, opts CompareOptions) ([]*Commit, error) {
+	commits := make([]*Commit, 0, len(hashes))
+	for _, hash := range hashes {
+		commit, err := repo.GetCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	slices.SortFunc(commits, func(a, b *Commit) int {
+		if c := b.Committer.When.Compare(a.Committer.When); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.ID), string(b.ID))
+	})
+	commits = commits[min(max(opts.CommitOffset, 0), len(commits)):]
+	if opts.CommitLimit > 0 && len(commits) > opts.CommitLimit {
+		commits = commits[:opts.CommitLimit]
+	}
+	return commits, nil
+}
+
+// countLineChanges returns the number of inserted and deleted lines between
+// two blobs. Either hash may be empty for an added or deleted file.
+func countLineChanges(repo *Repository, oldHash, newHash Hash) (insertions, deletions int, err error) {
+	if oldHash == newHash {
+		return 0, 0, nil
+	}
+	var oldContent, newContent []byte
+	if oldHash != "" {
+		if oldContent, err = repo.GetBlob(oldHash); err != nil {
+			return 0, 0, err
+		}
+	}
+	if newHash != "" {
+		if newContent, err = repo.GetBlob(newHash); err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(oldContent) > maxBlobSize || len(newContent) > maxBlobSize ||
+		IsBinaryContent(oldContent) || IsBinaryContent(newContent) {
+		return 0, 0, nil
+	}
+
+	for _, e := range computeEdits(splitLines(oldContent), splitLines(newContent)) {
+		switch e.Type {
+		case editInsert:
+			insertions++
+		case editDelete:
+			deletions++
+		}
+	}
+	return insertions, deletions, nil
+}
@@ -0,0 +1,133 @@
+package gitcore
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tree := func(files map[string]string) Hash {
+		var entries []TreeEntry
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			if content, ok := files[name]; ok {
+				entries = append(entries, TreeEntry{ID: createBlob(t, repo, []byte(content)), Name: name, Mode: "100644", Type: ObjectTypeBlob})
+			}
+		}
+		return createTree(t, repo, entries)
+	}
+
+	hashes := map[string]Hash{
+		"root":  Hash(strings.Repeat("1", 40)),
+		"main":  Hash(strings.Repeat("2", 40)),
+		"feat1": Hash(strings.Repeat("3", 40)),
+		"feat2": Hash(strings.Repeat("4", 40)),
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(name string, offset int, treeHash Hash, parents ...string) {
+		c := &Commit{ID: hashes[name], Tree: treeHash, Committer: Signature{When: base.Add(time.Duration(offset) * time.Hour)}}
+		for _, parent := range parents {
+			c.Parents = append(c.Parents, hashes[parent])
+		}
+		repo.commits = append(repo.commits, c)
+		repo.commitMap[c.ID] = c
+	}
+
+	commit("root", 0, tree(map[string]string{"a.txt": "one\ntwo\n", "b.txt": "keep\n"}))
+	commit("main", 1, tree(map[string]string{"a.txt": "one\ntwo\n", "b.txt": "keep\nmain only\n"}), "root")
+	commit("feat1", 2, tree(map[string]string{"a.txt": "one\nTWO\nthree\n", "b.txt": "keep\n"}), "root")
+	commit("feat2", 3, tree(map[string]string{"a.txt": "one\nTWO\nthree\n", "b.txt": "keep\n", "c.txt": "new\n"}), "feat1")
+
+	cmp, err := Compare(repo, hashes["main"], hashes["feat2"], CompareOptions{})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if cmp.MergeBase != hashes["root"] {
+		t.Fatalf("MergeBase = %s, want %s", cmp.MergeBase, hashes["root"])
+	}
+	if cmp.Ahead != 2 || cmp.Behind != 1 {
+		t.Fatalf("ahead/behind = %d/%d, want 2/1", cmp.Ahead, cmp.Behind)
+	}
+	ids := func(commits []*Commit) []Hash {
+		out := make([]Hash, len(commits))
+		for i, c := range commits {
+			out[i] = c.ID
+		}
+		return out
+	}
+	if got, want := ids(cmp.AheadCommits), []Hash{hashes["feat2"], hashes["feat1"]}; !slices.Equal(got, want) {
+		t.Fatalf("AheadCommits = %v, want %v", got, want)
+	}
+	if got, want := ids(cmp.BehindCommits), []Hash{hashes["main"]}; !slices.Equal(got, want) {
+		t.Fatalf("BehindCommits = %v, want %v", got, want)
+	}
+
+	// The three-dot diff ignores b.txt, which only changed on the base side.
+	if len(cmp.Files) != 2 || cmp.Files[0].Path != "a.txt" || cmp.Files[1].Path != "c.txt" {
+		t.Fatalf("Files = %+v, want a.txt and c.txt", cmp.Files)
+	}
+	if cmp.Files[0].Insertions != 2 || cmp.Files[0].Deletions != 1 {
+		t.Fatalf("a.txt stats = +%d -%d, want +2 -1", cmp.Files[0].Insertions, cmp.Files[0].Deletions)
+	}
+	if want := (DiffStats{FilesChanged: 2, Insertions: 3, Deletions: 1}); cmp.Stats != want {
+		t.Fatalf("Stats = %+v, want %+v", cmp.Stats, want)
+	}
+
+	paged, err := Compare(repo, hashes["main"], hashes["feat2"], CompareOptions{CommitOffset: 1, CommitLimit: 1})
+	if err != nil {
+		t.Fatalf("Compare(paged) error = %v", err)
+	}
+	if paged.Ahead != 2 || paged.Behind != 1 {
+		t.Fatalf("paged ahead/behind = %d/%d, want the full 2/1", paged.Ahead, paged.Behind)
+	}
+	if got, want := ids(paged.AheadCommits), []Hash{hashes["feat1"]}; !slices.Equal(got, want) {
+		t.Fatalf("paged AheadCommits = %v, want %v", got, want)
+	}
+	if len(paged.BehindCommits) != 0 {
+		t.Fatalf("paged BehindCommits = %v, want none past the offset", ids(paged.BehindCommits))
+	}
+
+	same, err := Compare(repo, hashes["feat2"], hashes["feat2"], CompareOptions{})
+	if err != nil {
+		t.Fatalf("Compare(same) error = %v", err)
+	}
+	if same.Ahead != 0 || same.Behind != 0 || len(same.Files) != 0 {
+		t.Fatalf("Compare(same) = %+v, want no differences", same)
+	}
+
+	if _, err := Compare(repo, hashes["main"], Hash(strings.Repeat("9", 40)), CompareOptions{}); err == nil {
+		t.Fatal("Compare(unknown) error = nil, want error")
+	}
+}
+
+func TestCompareTruncatesLargeDiffs(t *testing.T) {
+	repo := setupTestRepo(t)
+	entries := make([]TreeEntry, maxDiffEntries+1)
+	for i := range entries {
+		entries[i] = TreeEntry{ID: createBlob(t, repo, fmt.Appendf(nil, "file %d\n", i)), Name: fmt.Sprintf("f%04d.txt", i), Mode: "100644", Type: ObjectTypeBlob}
+	}
+	root := &Commit{ID: Hash(strings.Repeat("1", 40)), Tree: createTree(t, repo, nil)}
+	head := &Commit{ID: Hash(strings.Repeat("2", 40)), Tree: createTree(t, repo, entries), Parents: []Hash{root.ID}}
+	for _, c := range []*Commit{root, head} {
+		repo.commits = append(repo.commits, c)
+		repo.commitMap[c.ID] = c
+	}
+
+	cmp, err := Compare(repo, root.ID, head.ID, CompareOptions{})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if !cmp.Truncated || len(cmp.Files) != maxDiffEntries {
+		t.Fatalf("Truncated = %v with %d files, want true with %d", cmp.Truncated, len(cmp.Files), maxDiffEntries)
+	}
+	if cmp.Stats.FilesChanged != maxDiffEntries+1 || cmp.Stats.Insertions != maxDiffEntries {
+		t.Fatalf("Stats = %+v, want %d files and %d listed insertions", cmp.Stats, maxDiffEntries+1, maxDiffEntries)
+	}
+	if cmp.Ahead != 1 || len(cmp.AheadCommits) != 1 {
+		t.Fatalf("ahead = %d with %d commits, want 1", cmp.Ahead, len(cmp.AheadCommits))
+	}
+}
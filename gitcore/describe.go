@@ -0,0 +1,404 @@
+package gitcore
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	// DefaultDescribeAbbrev is the abbreviated hash length used by git describe.
+	DefaultDescribeAbbrev = 7
+
+	describeMaxCandidates = 10
+	nameRevMergeWeight    = 65535
+)
+
+// DescribeOptions controls how Describe names a commit.
+// See: https://git-scm.com/docs/git-describe
+type DescribeOptions struct {
+	// Tags allows lightweight tags in addition to annotated tags.
+	Tags bool
+	// Abbrev is the abbreviated object name length. Zero suppresses the long
+	// format entirely and a negative value selects DefaultDescribeAbbrev.
+	Abbrev int
+	// Match restricts candidate tags to names matching any of the glob patterns.
+	Match []string
+	// Long always prints the "<tag>-<n>-g<hash>" format, even on an exact match.
+	Long bool
+	// Always falls back to the abbreviated object name when no tag applies.
+	Always bool
+	// Dirty, when non-empty, is appended if the working tree has tracked changes.
+	// The working tree only relates to HEAD, so Describe rejects Dirty for any
+	// other commit.
+	Dirty string
+	// Contains names the commit after a tag that contains it, like git name-rev.
+	Contains bool
+}
+
+// NameRevOptions controls how NameRev chooses a symbolic name.
+// See: https://git-scm.com/docs/git-name-rev
+type NameRevOptions struct {
+	// TagsOnly restricts naming to refs under refs/tags/.
+	TagsOnly bool
+	// Match restricts candidate refs to short names matching any of the glob patterns.
+	Match []string
+	// AnnotatedOnly ignores lightweight tags.
+	AnnotatedOnly bool
+}
+
+type describeTag struct {
+	name      string
+	commit    Hash
+	annotated bool
+	tagger    int64
+}
+
+// Describe returns a human-readable name for hash based on the nearest
+// reachable tag, such as "v1.4.2-17-gabc1234".
+func Describe(repo *Repository, hash Hash, opts DescribeOptions) (string, error) {
+	if opts.Dirty != "" && hash != repo.Head() {
+		return "", fmt.Errorf("describe: Dirty applies only to HEAD, not %s", hash.Short())
+	}
+	name, err := describeCommit(repo, hash, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Dirty != "" {
+		status, err := ComputeWorkingTreeStatus(repo)
+		if err != nil {
+			return "", fmt.Errorf("describe: computing working tree status: %w", err)
+		}
+		for _, file := range status.Files {
+			if !file.IsUntracked {
+				name += opts.Dirty
+				break
+			}
+		}
+	}
+	return name, nil
+}
+
+func describeCommit(repo *Repository, hash Hash, opts DescribeOptions) (string, error) {
+	abbrev := opts.Abbrev
+	if abbrev < 0 {
+		abbrev = DefaultDescribeAbbrev
+	}
+
+	if opts.Contains {
+		name, err := NameRev(repo, hash, NameRevOptions{
+			TagsOnly:      true,
+			Match:         opts.Match,
+			AnnotatedOnly: !opts.Tags,
+		})
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			if opts.Always {
+				return abbreviateHash(hash, abbrev), nil
+			}
+			return "", fmt.Errorf("fatal: cannot describe '%s'", hash)
+		}
+		return strings.TrimPrefix(name, "tags/"), nil
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if _, ok := repo.commitMap[hash]; !ok {
+		return "", fmt.Errorf("commit not found: %s", hash)
+	}
+
+	byCommit := make(map[Hash]describeTag)
+	for _, tag := range repo.describeTagsLocked(!opts.Tags, opts.Match) {
+		if current, ok := byCommit[tag.commit]; !ok || preferDescribeTag(tag, current) {
+			byCommit[tag.commit] = tag
+		}
+	}
+
+	if exact, ok := byCommit[hash]; ok && !opts.Long {
+		return exact.name, nil
+	}
+
+	candidates := describeCandidates(repo.commitMap, hash, byCommit)
+	if len(candidates) == 0 {
+		if opts.Always {
+			return abbreviateHash(hash, abbrev), nil
+		}
+		if len(byCommit) == 0 {
+			return "", errors.New("fatal: No names found, cannot describe anything.")
+		}
+		return "", fmt.Errorf("fatal: No tags can describe '%s'.\nTry --always, or create some tags.", hash)
+	}
+
+	// Ties go to the candidate found first, as in git describe.
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.depth < best.depth {
+			best = candidate
+		}
+	}
+
+	if abbrev == 0 {
+		return best.tag.name, nil
+	}
+	return fmt.Sprintf("%s-%d-g%s", best.tag.name, best.depth, abbreviateHash(hash, abbrev)), nil
+}
+
+// describeTagsLocked lists the tags eligible for describe, peeled to commits.
+// Callers must hold r.mu.RLock.
+func (r *Repository) describeTagsLocked(annotatedOnly bool, match []string) []describeTag {
+	tagObjects := make(map[Hash]*Tag, len(r.tags))
+	for _, tag := range r.tags {
+		tagObjects[tag.ID] = tag
+	}
+
+	var tags []describeTag
+	for ref, target := range r.refs {
+		name, ok := strings.CutPrefix(ref, "refs/tags/")
+		if !ok || !matchesAnyGlob(name, match) {
+			continue
+		}
+		tagObject, annotated := tagObjects[target]
+		if annotatedOnly && !annotated {
+			continue
+		}
+
+		candidate := describeTag{name: name, commit: target, annotated: annotated}
+		if annotated {
+			candidate.commit = r.peelTagTargetLocked(target)
+			candidate.tagger = tagObject.Tagger.When.Unix()
+		}
+		if _, ok := r.commitMap[candidate.commit]; !ok {
+			continue
+		}
+		tags = append(tags, candidate)
+	}
+
+	slices.SortFunc(tags, func(a, b describeTag) int { return strings.Compare(a.name, b.name) })
+	return tags
+}
+
+// preferDescribeTag reports whether a should name a commit instead of b when
+// both point at the same commit: annotated tags win, then the newest tag.
+func preferDescribeTag(a, b describeTag) bool {
+	if a.annotated != b.annotated {
+		return a.annotated
+	}
+	if a.tagger != b.tagger {
+		return a.tagger > b.tagger
+	}
+	return a.name < b.name
+}
+
+// describeCandidate is a tag found by describeCandidates and the number of
+// walked commits it cannot reach.
+type describeCandidate struct {
+	tag   describeTag
+	depth int
+}
+
+// describeCandidates walks the ancestors of start newest first, as git
+// describe does, collecting up to describeMaxCandidates tagged commits in
+// discovery order. Each candidate owns one bit of a per-commit mask that is
+// pushed down to parents, so a single walk counts the depth of every
+// candidate. The walk ends once every queued commit carries every candidate's
+// bit: from there no depth can grow, and any tag found later would be at
+// least as deep as the ones already found.
+func describeCandidates(commits map[Hash]*Commit, start Hash, byCommit map[Hash]describeTag) []describeCandidate {
+	var candidates []describeCandidate
+	var all uint16 // describeMaxCandidates bits
+
+	flags := make(map[Hash]uint16)
+	popped := make(map[Hash]bool)
+	queued := map[Hash]bool{start: true}
+	lacking := 0 // queued commits missing a bit in all
+	walked := 0
+	h := &commitLogHeap{commits[start]}
+	for h.Len() > 0 && (len(candidates) == 0 || lacking > 0) {
+		commit := heap.Pop(h).(*Commit) //nolint:errcheck
+		popped[commit.ID] = true
+		mask := flags[commit.ID]
+		if mask != all {
+			lacking--
+		}
+		for i := range candidates {
+			if mask&(1<<i) == 0 {
+				candidates[i].depth++
+			}
+		}
+		if tag, ok := byCommit[commit.ID]; ok && len(candidates) < describeMaxCandidates {
+			bit := uint16(1) << len(candidates)
+			candidates = append(candidates, describeCandidate{tag: tag, depth: walked})
+			mask |= bit
+			all |= bit
+			lacking = h.Len()
+		}
+		walked++
+
+		for _, parentHash := range commit.Parents {
+			if _, ok := commits[parentHash]; !ok {
+				continue
+			}
+			if !queued[parentHash] {
+				queued[parentHash] = true
+				flags[parentHash] = mask
+				if mask != all {
+					lacking++
+				}
+				heap.Push(h, commits[parentHash])
+				continue
+			}
+			old := flags[parentHash]
+			flags[parentHash] = old | mask
+			if !popped[parentHash] && old != all && old|mask == all {
+				lacking--
+			}
+		}
+	}
+	return candidates
+}
+
+type nameRevCandidate struct {
+	commit     Hash
+	base       string
+	generation int
+	distance   int
+}
+
+func (c nameRevCandidate) String() string {
+	if c.generation == 0 {
+		return c.base
+	}
+	return fmt.Sprintf("%s~%d", c.base, c.generation)
+}
+
+type nameRevHeap []nameRevCandidate
+
+func (h nameRevHeap) Len() int { return len(h) }
+
+func (h nameRevHeap) Less(i, j int) bool {
+	if h[i].distance != h[j].distance {
+		return h[i].distance < h[j].distance
+	}
+	return h[i].String() < h[j].String()
+}
+
+func (h nameRevHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *nameRevHeap) Push(x any) {
+	*h = append(*h, x.(nameRevCandidate)) //nolint:errcheck: nameRevHeap can only contain candidates.
+}
+
+func (h *nameRevHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// NameRev returns a symbolic name such as "tags/v1.0~2" or "main~3^2" for a
+// ref that contains hash. Tags are preferred over branches. An empty string
+// is returned when no eligible ref contains the commit.
+func NameRev(repo *Repository, hash Hash, opts NameRevOptions) (string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if _, ok := repo.commitMap[hash]; !ok {
+		return "", fmt.Errorf("commit not found: %s", hash)
+	}
+
+	var tagTips []nameRevCandidate
+	for _, tag := range repo.describeTagsLocked(opts.AnnotatedOnly, opts.Match) {
+		tagTips = append(tagTips, nameRevCandidate{commit: tag.commit, base: "tags/" + tag.name})
+	}
+	if name := nameRevFromTips(repo.commitMap, hash, tagTips); name != "" || opts.TagsOnly {
+		return name, nil
+	}
+
+	var refTips []nameRevCandidate
+	for ref, target := range repo.refs {
+		var short string
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			short = name
+		} else if name, ok := strings.CutPrefix(ref, "refs/remotes/"); ok {
+			short = "remotes/" + name
+		} else {
+			continue
+		}
+		if !matchesAnyGlob(short, opts.Match) {
+			continue
+		}
+		if _, ok := repo.commitMap[target]; ok {
+			refTips = append(refTips, nameRevCandidate{commit: target, base: short})
+		}
+	}
+	return nameRevFromTips(repo.commitMap, hash, refTips), nil
+}
+
+// nameRevFromTips runs a shortest-path walk from every tip toward its
+// ancestors, weighting merge-parent hops heavily so first-parent names win.
+func nameRevFromTips(commits map[Hash]*Commit, target Hash, tips []nameRevCandidate) string {
+	h := &nameRevHeap{}
+	for _, tip := range tips {
+		heap.Push(h, tip)
+	}
+
+	named := make(map[Hash]bool)
+	for h.Len() > 0 {
+		current := heap.Pop(h).(nameRevCandidate) //nolint:errcheck
+		if named[current.commit] {
+			continue
+		}
+		named[current.commit] = true
+		if current.commit == target {
+			return current.String()
+		}
+
+		commit, ok := commits[current.commit]
+		if !ok {
+			continue
+		}
+		for i, parent := range commit.Parents {
+			if named[parent] {
+				continue
+			}
+			next := nameRevCandidate{commit: parent}
+			if i == 0 {
+				next.base = current.base
+				next.generation = current.generation + 1
+				next.distance = current.distance + 1
+			} else {
+				next.base = fmt.Sprintf("%s^%d", current.String(), i+1)
+				next.distance = current.distance + nameRevMergeWeight
+			}
+			heap.Push(h, next)
+		}
+	}
+	return ""
+}
+
+func matchesAnyGlob(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func abbreviateHash(hash Hash, length int) string {
+	if length <= 0 || length >= len(hash) {
+		return string(hash)
+	}
+	return string(hash[:length])
+}
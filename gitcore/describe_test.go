@@ -0,0 +1,152 @@
+package gitcore
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDescribeTestRepo() (*Repository, map[string]Hash) {
+	hashes := map[string]Hash{
+		"root":    Hash("1111111111111111111111111111111111111111"),
+		"v1":      Hash("2222222222222222222222222222222222222222"),
+		"side":    Hash("3333333333333333333333333333333333333333"),
+		"main":    Hash("4444444444444444444444444444444444444444"),
+		"merge":   Hash("5555555555555555555555555555555555555555"),
+		"head":    Hash("6666666666666666666666666666666666666666"),
+		"tagV1":   Hash("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+		"tagV2":   Hash("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"),
+		"orphan":  Hash("7777777777777777777777777777777777777777"),
+		"tagSide": Hash("cccccccccccccccccccccccccccccccccccccccc"),
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(name string, offset int, parents ...string) *Commit {
+		c := &Commit{ID: hashes[name], Committer: Signature{When: base.Add(time.Duration(offset) * time.Hour)}}
+		for _, parent := range parents {
+			c.Parents = append(c.Parents, hashes[parent])
+		}
+		return c
+	}
+
+	// root <- v1 <- main <- merge <- head
+	//           \         /
+	//            side ----
+	commits := []*Commit{
+		commit("root", 0),
+		commit("v1", 1, "root"),
+		commit("side", 2, "v1"),
+		commit("main", 3, "v1"),
+		commit("merge", 4, "main", "side"),
+		commit("head", 5, "merge"),
+		commit("orphan", 6),
+	}
+
+	repo := &Repository{
+		head:      hashes["head"],
+		commitMap: make(map[Hash]*Commit),
+		refs: map[string]Hash{
+			"refs/heads/main":       hashes["head"],
+			"refs/heads/topic":      hashes["side"],
+			"refs/tags/v1.0.0":      hashes["tagV1"],
+			"refs/tags/v2.0.0":      hashes["tagV2"],
+			"refs/tags/light":       hashes["main"],
+			"refs/tags/side-marker": hashes["tagSide"],
+		},
+		tags: []*Tag{
+			{ID: hashes["tagV1"], Object: hashes["v1"], ObjType: ObjectTypeCommit, Name: "v1.0.0", Tagger: Signature{When: base}},
+			{ID: hashes["tagV2"], Object: hashes["head"], ObjType: ObjectTypeCommit, Name: "v2.0.0", Tagger: Signature{When: base}},
+			{ID: hashes["tagSide"], Object: hashes["side"], ObjType: ObjectTypeCommit, Name: "side-marker", Tagger: Signature{When: base}},
+		},
+	}
+	for _, c := range commits {
+		repo.commitMap[c.ID] = c
+		repo.commits = append(repo.commits, c)
+	}
+	return repo, hashes
+}
+
+func TestDescribe(t *testing.T) {
+	repo, hashes := newDescribeTestRepo()
+
+	tests := []struct {
+		name   string
+		commit string
+		opts   DescribeOptions
+		want   string
+	}{
+		{name: "exact annotated tag", commit: "head", opts: DescribeOptions{Abbrev: -1}, want: "v2.0.0"},
+		{name: "long exact", commit: "head", opts: DescribeOptions{Abbrev: -1, Long: true}, want: "v2.0.0-0-g6666666"},
+		{name: "annotated ancestor", commit: "main", opts: DescribeOptions{Abbrev: -1}, want: "v1.0.0-1-g4444444"},
+		{name: "lightweight with tags", commit: "main", opts: DescribeOptions{Abbrev: -1, Tags: true}, want: "light"},
+		{name: "merge picks nearest", commit: "merge", opts: DescribeOptions{Abbrev: -1}, want: "side-marker-2-g5555555"},
+		{name: "match filters candidates", commit: "merge", opts: DescribeOptions{Abbrev: -1, Match: []string{"v*"}}, want: "v1.0.0-3-g5555555"},
+		{name: "custom abbrev", commit: "main", opts: DescribeOptions{Abbrev: 10}, want: "v1.0.0-1-g4444444444"},
+		{name: "zero abbrev", commit: "main", opts: DescribeOptions{Abbrev: 0}, want: "v1.0.0"},
+		{name: "always without tags", commit: "orphan", opts: DescribeOptions{Abbrev: -1, Always: true}, want: "7777777"},
+		{name: "contains", commit: "main", opts: DescribeOptions{Abbrev: -1, Contains: true}, want: "v2.0.0~2"},
+		{name: "contains via merge parent", commit: "side", opts: DescribeOptions{Abbrev: -1, Contains: true, Match: []string{"v2*"}}, want: "v2.0.0~1^2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Describe(repo, hashes[tc.commit], tc.opts)
+			if err != nil {
+				t.Fatalf("Describe() error = %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("Describe() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	repo, hashes := newDescribeTestRepo()
+
+	if _, err := Describe(repo, hashes["orphan"], DescribeOptions{Abbrev: -1}); err == nil || !strings.Contains(err.Error(), "No tags can describe") {
+		t.Fatalf("Describe(orphan) error = %v, want no tags error", err)
+	}
+	if _, err := Describe(repo, Hash("8888888888888888888888888888888888888888"), DescribeOptions{}); err == nil {
+		t.Fatal("Describe(unknown) error = nil, want error")
+	}
+
+	if _, err := Describe(repo, hashes["main"], DescribeOptions{Abbrev: -1, Dirty: "-dirty"}); err == nil {
+		t.Fatal("Describe(main, Dirty) error = nil, want error for a commit other than HEAD")
+	}
+
+	empty := &Repository{commitMap: map[Hash]*Commit{hashes["root"]: {ID: hashes["root"]}}}
+	if _, err := Describe(empty, hashes["root"], DescribeOptions{Abbrev: -1}); err == nil || !strings.Contains(err.Error(), "No names found") {
+		t.Fatalf("Describe(empty) error = %v, want no names error", err)
+	}
+}
+
+func TestNameRev(t *testing.T) {
+	repo, hashes := newDescribeTestRepo()
+
+	tests := []struct {
+		name   string
+		commit string
+		opts   NameRevOptions
+		want   string
+	}{
+		{name: "prefers tags", commit: "root", want: "tags/v1.0.0~1"},
+		{name: "tag tip", commit: "head", want: "tags/v2.0.0"},
+		{name: "not contained by any ref", commit: "orphan", want: ""},
+		{name: "lightweight tag", commit: "main", want: "tags/light"},
+		{name: "annotated only", commit: "main", opts: NameRevOptions{AnnotatedOnly: true}, want: "tags/v2.0.0~2"},
+		{name: "branch when tags filtered", commit: "main", opts: NameRevOptions{Match: []string{"ma*"}}, want: "main~2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NameRev(repo, hashes[tc.commit], tc.opts)
+			if err != nil {
+				t.Fatalf("NameRev() error = %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("NameRev() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
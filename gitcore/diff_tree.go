@@ -5,15 +5,28 @@ import (
 )
 
 // TreeDiff recursively compares two trees and returns a flat list of changed files.
+// It fails with a "diff too large" error past maxDiffEntries.
 func TreeDiff(repo *Repository, oldTreeHash, newTreeHash Hash, prefix string) ([]DiffEntry, error) {
-	entries, err := treeDiffRecursive(repo, oldTreeHash, newTreeHash, prefix)
+	entries, err := treeDiffRecursive(repo, oldTreeHash, newTreeHash, prefix, maxDiffEntries)
 	if err != nil {
 		return nil, err
 	}
 	return detectRenames(entries), nil
 }
 
-func treeDiffRecursive(repo *Repository, oldTreeHash, newTreeHash Hash, prefix string) ([]DiffEntry, error) {
+// treeDiffAll is TreeDiff without the entry limit, for callers that examine
+// the changed files themselves rather than returning them to a client.
+func treeDiffAll(repo *Repository, oldTreeHash, newTreeHash Hash) ([]DiffEntry, error) {
+	entries, err := treeDiffRecursive(repo, oldTreeHash, newTreeHash, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	return detectRenames(entries), nil
+}
+
+// treeDiffRecursive diffs two trees. A positive limit caps the entries it
+// collects; zero means no limit.
+func treeDiffRecursive(repo *Repository, oldTreeHash, newTreeHash Hash, prefix string, limit int) ([]DiffEntry, error) {
 	entries := make([]DiffEntry, 0)
 
 	var oldTree *Tree
@@ -65,14 +78,14 @@ func treeDiffRecursive(repo *Repository, oldTreeHash, newTreeHash Hash, prefix s
 			path = prefix + "/" + name
 		}
 
-		if len(entries) >= maxDiffEntries {
-			return nil, fmt.Errorf("diff too large: exceeded maximum of %d entries", maxDiffEntries)
+		if limit > 0 && len(entries) >= limit {
+			return nil, fmt.Errorf("diff too large: exceeded maximum of %d entries", limit)
 		}
 
 		switch {
 		case !existsInOld && existsInNew:
 			if isTreeEntry(newEntry) {
-				subEntries, err := treeDiffRecursive(repo, "", newEntry.ID, path)
+				subEntries, err := treeDiffRecursive(repo, "", newEntry.ID, path, limit)
 				if err != nil {
 					return nil, err
 				}
@@ -88,7 +101,7 @@ func treeDiffRecursive(repo *Repository, oldTreeHash, newTreeHash Hash, prefix s
 			}
 		case existsInOld && !existsInNew:
 			if isTreeEntry(oldEntry) {
-				subEntries, err := treeDiffRecursive(repo, oldEntry.ID, "", path)
+				subEntries, err := treeDiffRecursive(repo, oldEntry.ID, "", path, limit)
 				if err != nil {
 					return nil, err
 				}
@@ -105,14 +118,14 @@ func treeDiffRecursive(repo *Repository, oldTreeHash, newTreeHash Hash, prefix s
 		case existsInOld && existsInNew:
 			if oldEntry.ID != newEntry.ID {
 				if isTreeEntry(oldEntry) && isTreeEntry(newEntry) {
-					subEntries, err := treeDiffRecursive(repo, oldEntry.ID, newEntry.ID, path)
+					subEntries, err := treeDiffRecursive(repo, oldEntry.ID, newEntry.ID, path, limit)
 					if err != nil {
 						return nil, err
 					}
 					entries = append(entries, subEntries...)
 				} else if isTreeEntry(oldEntry) || isTreeEntry(newEntry) {
 					if isTreeEntry(oldEntry) {
-						subEntries, err := treeDiffRecursive(repo, oldEntry.ID, "", path)
+						subEntries, err := treeDiffRecursive(repo, oldEntry.ID, "", path, limit)
 						if err != nil {
 							return nil, err
 						}
@@ -127,7 +140,7 @@ func treeDiffRecursive(repo *Repository, oldTreeHash, newTreeHash Hash, prefix s
 						})
 					}
 					if isTreeEntry(newEntry) {
-						subEntries, err := treeDiffRecursive(repo, "", newEntry.ID, path)
+						subEntries, err := treeDiffRecursive(repo, "", newEntry.ID, path, limit)
 						if err != nil {
 							return nil, err
 						}
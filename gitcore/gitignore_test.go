@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -479,13 +480,22 @@ func TestParseIgnoreLineAndMatchHelpers_EdgeCases(t *testing.T) {
 	})
 }
 
-func mustRunGit(t *testing.T, dir string, args ...string) {
+// mustRunGit runs git in dir with a fixed identity and no user or system
+// configuration, and returns its trimmed output.
+func mustRunGit(t *testing.T, dir string, args ...string) string {
 	t.Helper()
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
-	if output, err := cmd.CombinedOutput(); err != nil {
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
 		t.Fatalf("git %v failed: %v\n%s", args, err, output)
 	}
+	return strings.TrimSpace(string(output))
 }
 
 func gitCheckIgnored(t *testing.T, dir, path string) bool {
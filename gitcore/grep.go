@@ -0,0 +1,346 @@
+package gitcore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const defaultGrepWorkers = 8
+
+// GrepOptions controls how Grep matches file contents.
+// See: https://git-scm.com/docs/git-grep
+type GrepOptions struct {
+	// FixedStrings treats the pattern as a literal string instead of a regular expression.
+	FixedStrings bool
+	// IgnoreCase matches without regard to letter case.
+	IgnoreCase bool
+	// Pathspecs limits the search to paths equal to, beneath, or glob-matching any entry.
+	Pathspecs []string
+	// ContextLines includes this many lines of context around each match.
+	ContextLines int
+	// Workers bounds the number of blobs searched concurrently.
+	Workers int
+}
+
+// GrepLine is a single matching or context line within a file.
+type GrepLine struct {
+	Number int      `json:"number"`
+	Text   string   `json:"text"`
+	Match  bool     `json:"match"`
+	Ranges [][2]int `json:"ranges,omitempty"`
+}
+
+// GrepFileResult holds the matches found in one file. Lines are ordered by
+// line number; a gap between consecutive numbers separates context groups.
+type GrepFileResult struct {
+	Path    string     `json:"path"`
+	Hash    Hash       `json:"hash,omitempty"`
+	Matches int        `json:"matches"`
+	Lines   []GrepLine `json:"lines"`
+}
+
+type grepFile struct {
+	path string
+	hash Hash
+}
+
+// Grep searches the files of treeish for pattern and returns per-file results
+// in path order. Treeish accepts any revision understood by ResolveRevision,
+// optionally followed by ":<dir>" to search a subdirectory, or a tree hash.
+// An empty treeish searches tracked files in the working tree, as
+// `git grep` does when no revision is given.
+func Grep(repo *Repository, treeish, pattern string, opts GrepOptions) ([]GrepFileResult, error) {
+	var results []GrepFileResult
+	err := GrepFunc(repo, treeish, pattern, opts, func(result GrepFileResult) error {
+		results = append(results, result)
+		return nil
+	})
+	return results, err
+}
+
+// GrepFunc is like Grep but hands each file result to fn as soon as it and all
+// preceding paths have been searched. If fn returns an error the search stops
+// and that error is returned.
+func GrepFunc(repo *Repository, treeish, pattern string, opts GrepOptions, fn func(GrepFileResult) error) error {
+	matcher, err := compileGrepPattern(pattern, opts)
+	if err != nil {
+		return err
+	}
+
+	var files []grepFile
+	var read func(grepFile) ([]byte, error)
+	if treeish == "" {
+		files, err = listWorktreeGrepFiles(repo, opts.Pathspecs)
+		read = func(file grepFile) ([]byte, error) {
+			content, err := readWorktreeFile(repo.workDir, file.path)
+			if errors.Is(err, fs.ErrNotExist) {
+				// Tracked but deleted from disk; git grep skips these silently.
+				return nil, nil
+			}
+			return content, err
+		}
+	} else {
+		files, err = listTreeGrepFiles(repo, treeish, opts.Pathspecs)
+		read = func(file grepFile) ([]byte, error) { return repo.GetBlob(file.hash) }
+	}
+	if err != nil {
+		return err
+	}
+
+	return runGrepWorkers(files, read, matcher, opts, fn)
+}
+
+func compileGrepPattern(pattern string, opts GrepOptions) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("grep: empty pattern")
+	}
+	if opts.FixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("grep: invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+func listTreeGrepFiles(repo *Repository, treeish string, pathspecs []string) ([]grepFile, error) {
+	revision, dir, _ := strings.Cut(treeish, ":")
+	rootTree, err := repo.resolveGrepTree(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = strings.Trim(dir, "/")
+	tree, err := repo.resolveTreeAtPath(rootTree, dir)
+	if err != nil {
+		return nil, fmt.Errorf("grep: %s: %w", treeish, err)
+	}
+
+	var files []grepFile
+	var walk func(tree *Tree, prefix string) error
+	walk = func(tree *Tree, prefix string) error {
+		for _, entry := range tree.Entries {
+			entryPath := path.Join(prefix, entry.Name)
+			switch {
+			case isSubmodule(entry) || entry.Mode == "120000":
+				continue
+			case isTreeEntry(entry):
+				if !grepPathspecMayContain(pathspecs, entryPath) {
+					continue
+				}
+				subtree, err := repo.GetTree(entry.ID)
+				if err != nil {
+					return fmt.Errorf("grep: reading tree %s: %w", entryPath, err)
+				}
+				if err := walk(subtree, entryPath); err != nil {
+					return err
+				}
+			case matchesGrepPathspec(pathspecs, entryPath):
+				files = append(files, grepFile{path: entryPath, hash: entry.ID})
+			}
+		}
+		return nil
+	}
+	if err := walk(tree, dir); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// resolveGrepTree maps a revision or raw tree hash to a root tree hash.
+func (r *Repository) resolveGrepTree(revision string) (Hash, error) {
+	if commitHash, err := r.ResolveRevision(revision); err == nil {
+		commit, err := r.GetCommit(commitHash)
+		if err != nil {
+			return "", err
+		}
+		return commit.Tree, nil
+	}
+
+	hash, err := NewHash(revision)
+	if err == nil {
+		if _, treeErr := r.GetTree(hash); treeErr == nil {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("fatal: unable to resolve revision: %s", revision)
+}
+
+func listWorktreeGrepFiles(repo *Repository, pathspecs []string) ([]grepFile, error) {
+	if repo.IsBare() {
+		return nil, errors.New("grep: bare repositories do not have a working tree")
+	}
+
+	index, err := ReadIndex(repo.gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("grep: %w", err)
+	}
+
+	var files []grepFile
+	seen := make(map[string]struct{}, len(index.Entries))
+	for _, entry := range index.Entries {
+		mode := entry.Mode & 0o170000
+		if mode == 0o160000 || mode == 0o120000 {
+			continue
+		}
+		if _, ok := seen[entry.Path]; ok {
+			continue
+		}
+		seen[entry.Path] = struct{}{}
+		if matchesGrepPathspec(pathspecs, entry.Path) {
+			files = append(files, grepFile{path: entry.Path})
+		}
+	}
+	return files, nil
+}
+
+func matchesGrepPathspec(pathspecs []string, filePath string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+	for _, spec := range pathspecs {
+		spec = strings.Trim(spec, "/")
+		if spec == "" || spec == "." || filePath == spec || strings.HasPrefix(filePath, spec+"/") {
+			return true
+		}
+		if strings.ContainsAny(spec, "*?[") && (matchGlob(spec, filePath) || matchGlob(spec, path.Base(filePath))) {
+			return true
+		}
+	}
+	return false
+}
+
+// grepPathspecMayContain reports whether a directory could hold files matched
+// by pathspecs, so unrelated subtrees are never read.
+func grepPathspecMayContain(pathspecs []string, dir string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+	for _, spec := range pathspecs {
+		spec = strings.Trim(spec, "/")
+		if spec == "" || spec == "." || strings.ContainsAny(spec, "*?[") {
+			return true
+		}
+		if dir == spec || strings.HasPrefix(dir, spec+"/") || strings.HasPrefix(spec, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func runGrepWorkers(files []grepFile, read func(grepFile) ([]byte, error), matcher *regexp.Regexp, opts GrepOptions, fn func(GrepFileResult) error) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultGrepWorkers
+	}
+
+	type grepOutcome struct {
+		result *GrepFileResult
+		err    error
+	}
+
+	outcomes := make([]chan grepOutcome, len(files))
+	for i := range outcomes {
+		outcomes[i] = make(chan grepOutcome, 1)
+	}
+
+	stop := make(chan struct{})
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				content, err := read(files[i])
+				if err != nil {
+					outcomes[i] <- grepOutcome{err: fmt.Errorf("grep: reading %s: %w", files[i].path, err)}
+					continue
+				}
+				outcomes[i] <- grepOutcome{result: grepContent(files[i], content, matcher, opts.ContextLines)}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var err error
+	for i := range files {
+		outcome := <-outcomes[i]
+		if outcome.err == nil && outcome.result != nil {
+			outcome.err = fn(*outcome.result)
+		}
+		if outcome.err != nil {
+			err = outcome.err
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+	return err
+}
+
+// grepContent returns nil when content is binary or has no matches.
+func grepContent(file grepFile, content []byte, matcher *regexp.Regexp, contextLines int) *GrepFileResult {
+	if IsBinaryContent(content) {
+		return nil
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var matched []int
+	ranges := make(map[int][][2]int)
+	for i, line := range lines {
+		locs := matcher.FindAllIndex(line, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		matched = append(matched, i)
+		for _, loc := range locs {
+			ranges[i] = append(ranges[i], [2]int{loc[0], loc[1]})
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	result := &GrepFileResult{Path: file.path, Hash: file.hash, Matches: len(matched)}
+	next := 0
+	for _, lineIdx := range matched {
+		start := max(lineIdx-contextLines, next)
+		end := min(lineIdx+contextLines, len(lines)-1)
+		for i := start; i <= end; i++ {
+			_, isMatch := ranges[i]
+			result.Lines = append(result.Lines, GrepLine{
+				Number: i + 1,
+				Text:   strings.TrimSuffix(string(lines[i]), "\r"),
+				Match:  isMatch,
+				Ranges: ranges[i],
+			})
+			next = i + 1
+		}
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package gitcore
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func setupGrepTestRepo(t *testing.T) (*Repository, Hash) {
+	t.Helper()
+	repo := setupTestRepo(t)
+
+	mainGo := createBlob(t, repo, []byte("package main\n\nfunc main() {\n\tprintln(\"Hello\")\n}\n"))
+	readme := createBlob(t, repo, []byte("# hello\nsecond line\nthird line\nHELLO again\n"))
+	binary := createBlob(t, repo, []byte("hello\x00world"))
+	util := createBlob(t, repo, []byte("package util\n\nvar greeting = \"hello.world\"\n"))
+
+	pkg := createTree(t, repo, []TreeEntry{{ID: util, Name: "util.go", Mode: "100644", Type: ObjectTypeBlob}})
+	root := createTree(t, repo, []TreeEntry{
+		{ID: readme, Name: "README.md", Mode: "100644", Type: ObjectTypeBlob},
+		{ID: binary, Name: "data.bin", Mode: "100644", Type: ObjectTypeBlob},
+		{ID: mainGo, Name: "main.go", Mode: "100644", Type: ObjectTypeBlob},
+		{ID: pkg, Name: "pkg", Mode: "040000", Type: ObjectTypeTree},
+	})
+	wireHeadCommit(repo, root)
+	repo.refs["refs/heads/main"] = repo.head
+	return repo, root
+}
+
+func grepPaths(results []GrepFileResult) []string {
+	paths := make([]string, len(results))
+	for i, result := range results {
+		paths[i] = result.Path
+	}
+	return paths
+}
+
+func TestGrep(t *testing.T) {
+	repo, root := setupGrepTestRepo(t)
+
+	tests := []struct {
+		name      string
+		treeish   string
+		pattern   string
+		opts      GrepOptions
+		wantPaths []string
+	}{
+		{name: "regex", treeish: "HEAD", pattern: `hel+o`, wantPaths: []string{"README.md", "pkg/util.go"}},
+		{name: "ignore case", treeish: "main", pattern: "hello", opts: GrepOptions{IgnoreCase: true}, wantPaths: []string{"README.md", "main.go", "pkg/util.go"}},
+		{name: "fixed string", treeish: "HEAD", pattern: "hello.world", opts: GrepOptions{FixedStrings: true}, wantPaths: []string{"pkg/util.go"}},
+		{name: "pathspec directory", treeish: "HEAD", pattern: "hello", opts: GrepOptions{Pathspecs: []string{"pkg"}}, wantPaths: []string{"pkg/util.go"}},
+		{name: "pathspec glob", treeish: "HEAD", pattern: "package", opts: GrepOptions{Pathspecs: []string{"*.go"}}, wantPaths: []string{"main.go", "pkg/util.go"}},
+		{name: "subdirectory treeish", treeish: "HEAD:pkg", pattern: "package", wantPaths: []string{"pkg/util.go"}},
+		{name: "tree hash", treeish: string(root), pattern: "package main", wantPaths: []string{"main.go"}},
+		{name: "single worker", treeish: "HEAD", pattern: "line", opts: GrepOptions{Workers: 1}, wantPaths: []string{"README.md"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := Grep(repo, tc.treeish, tc.pattern, tc.opts)
+			if err != nil {
+				t.Fatalf("Grep() error = %v", err)
+			}
+			if got := grepPaths(results); !slices.Equal(got, tc.wantPaths) {
+				t.Fatalf("Grep() paths = %v, want %v", got, tc.wantPaths)
+			}
+		})
+	}
+}
+
+func TestGrepContextLines(t *testing.T) {
+	repo, _ := setupGrepTestRepo(t)
+
+	results, err := Grep(repo, "HEAD", "^second", GrepOptions{ContextLines: 1, Pathspecs: []string{"README.md"}})
+	if err != nil {
+		t.Fatalf("Grep() error = %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+
+	got := results[0]
+	if got.Matches != 1 || len(got.Lines) != 3 {
+		t.Fatalf("result = %+v, want 1 match with 3 lines", got)
+	}
+	wantNumbers := []int{1, 2, 3}
+	for i, line := range got.Lines {
+		if line.Number != wantNumbers[i] {
+			t.Fatalf("Lines[%d].Number = %d, want %d", i, line.Number, wantNumbers[i])
+		}
+		if line.Match != (line.Number == 2) {
+			t.Fatalf("Lines[%d].Match = %v", i, line.Match)
+		}
+	}
+	if !slices.Equal(got.Lines[1].Ranges, [][2]int{{0, 6}}) {
+		t.Fatalf("Lines[1].Ranges = %v, want [[0 6]]", got.Lines[1].Ranges)
+	}
+}
+
+func TestGrepWorkingTree(t *testing.T) {
+	repo, _ := setupGrepTestRepo(t)
+
+	writeDiskFile(t, repo, "tracked.txt", []byte("needle on disk\n"))
+	writeDiskFile(t, repo, "untracked.txt", []byte("needle untracked\n"))
+	writeIndexWithEntries(t, repo.gitDir, []indexEntrySpec{
+		{path: "gone.txt", blobHash: Hash("1111111111111111111111111111111111111111")},
+		{path: "tracked.txt", blobHash: Hash("2222222222222222222222222222222222222222")},
+	})
+
+	results, err := Grep(repo, "", "needle", GrepOptions{})
+	if err != nil {
+		t.Fatalf("Grep() error = %v", err)
+	}
+	if got := grepPaths(results); !slices.Equal(got, []string{"tracked.txt"}) {
+		t.Fatalf("Grep() paths = %v, want [tracked.txt]", got)
+	}
+}
+
+func TestGrepErrors(t *testing.T) {
+	repo, _ := setupGrepTestRepo(t)
+
+	if _, err := Grep(repo, "HEAD", "", GrepOptions{}); err == nil {
+		t.Fatal("Grep(empty pattern) error = nil, want error")
+	}
+	if _, err := Grep(repo, "HEAD", "(", GrepOptions{}); err == nil {
+		t.Fatal("Grep(invalid regex) error = nil, want error")
+	}
+	if _, err := Grep(repo, "missing", "x", GrepOptions{}); err == nil {
+		t.Fatal("Grep(unknown revision) error = nil, want error")
+	}
+
+	stopErr := errors.New("stop")
+	calls := 0
+	err := GrepFunc(repo, "HEAD", "package", GrepOptions{}, func(GrepFileResult) error {
+		calls++
+		return stopErr
+	})
+	if !errors.Is(err, stopErr) || calls != 1 {
+		t.Fatalf("GrepFunc() = %v after %d calls, want stop after 1", err, calls)
+	}
+}
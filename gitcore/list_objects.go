@@ -0,0 +1,341 @@
+package gitcore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitlinkMode is the tree entry mode of a submodule commit, which lives in
+// another repository and is never sent.
+const gitlinkMode = "160000"
+
+// ListObjectsOptions configures Repository.ListObjects.
+// See: https://git-scm.com/docs/git-rev-list#Documentation/git-rev-list.txt---objects
+type ListObjectsOptions struct {
+	// Wants are the objects to list along with everything they reach.
+	Wants []Hash
+	// Haves are objects the receiver already has. Commits reachable from them
+	// and the trees and blobs of the have commits themselves are left out.
+	// Haves missing from this repository are ignored.
+	Haves []Hash
+	// IncludeTags adds annotated tags that point at a listed object.
+	IncludeTags bool
+}
+
+// ListObjects returns the hashes of every object reachable from opts.Wants
+// and not from opts.Haves, grouped as commits, tags, trees, and blobs the way
+// git orders a pack.
+func (r *Repository) ListObjects(opts ListObjectsOptions) ([]Hash, error) {
+	l, err := r.listObjects(opts, false)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Hash, 0, len(l.commits)+len(l.tags)+len(l.trees)+len(l.blobs))
+	out = append(out, l.commits...)
+	out = append(out, l.tags...)
+	out = append(out, l.trees...)
+	out = append(out, l.blobs...)
+	return out, nil
+}
+
+// listObjects walks the objects for ListObjects. With excludeBoundary, the
+// trees and blobs of boundary commits are left out as well, the way git packs
+// a bundle whose receiver must already have those commits.
+func (r *Repository) listObjects(opts ListObjectsOptions, excludeBoundary bool) (*objectLister, error) {
+	l := &objectLister{
+		repo:     r,
+		seen:     make(map[Hash]struct{}),
+		basis:    make(map[Hash]struct{}),
+		boundary: make(map[Hash]struct{}),
+	}
+	if err := l.markHaves(opts.Haves); err != nil {
+		return nil, err
+	}
+	for _, want := range opts.Wants {
+		if err := l.addWant(want); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.walkCommits(); err != nil {
+		return nil, err
+	}
+	if excludeBoundary {
+		for id := range l.boundary {
+			commit, err := r.walkCommit(id)
+			if err != nil {
+				return nil, err
+			}
+			if err := l.markTreeBasis(commit.Tree); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, tree := range l.rootTrees {
+		if err := l.addTree(tree); err != nil {
+			return nil, err
+		}
+	}
+	if opts.IncludeTags {
+		l.includeTags()
+	}
+	return l, nil
+}
+
+// HasObject reports whether the object is stored in this repository.
+func (r *Repository) HasObject(id Hash) bool {
+	if _, ok := r.packLocations[id]; ok {
+		return true
+	}
+	if _, err := NewHash(string(id)); err != nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(r.gitDir, "objects", string(id)[:2], string(id)[2:]))
+	return err == nil
+}
+
+// Refs returns every ref, such as "refs/heads/main" or "refs/tags/v1.0", mapped
+// to the object it names. Annotated tags map to the tag object.
+func (r *Repository) Refs() map[string]Hash {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refs := make(map[string]Hash, len(r.refs))
+	for name, hash := range r.refs {
+		if strings.HasPrefix(name, "refs/") {
+			refs[name] = hash
+		}
+	}
+	return refs
+}
+
+// PeelTag follows annotated tags from id to the object they finally name. It
+// reports false when id is not an annotated tag.
+func (r *Repository) PeelTag(id Hash) (Hash, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	isTag := false
+	for _, tag := range r.tags {
+		if tag.ID == id {
+			isTag = true
+			break
+		}
+	}
+	if !isTag {
+		return "", false
+	}
+	peeled := r.peelTagTargetLocked(id)
+	return peeled, peeled != ""
+}
+
+type objectLister struct {
+	repo *Repository
+	seen map[Hash]struct{}
+	// basis holds commits the receiver has, and the trees and blobs of the
+	// have commits.
+	basis map[Hash]struct{}
+	// boundary holds the receiver's commits that listed commits name as
+	// parents.
+	boundary map[Hash]struct{}
+
+	pending   []Hash
+	rootTrees []Hash
+	commits   []Hash
+	tags      []Hash
+	trees     []Hash
+	blobs     []Hash
+}
+
+func (l *objectLister) markHaves(haves []Hash) error {
+	stack := make([]Hash, 0, len(haves))
+	for _, have := range haves {
+		commit, err := l.repo.walkCommit(have)
+		if err != nil {
+			continue
+		}
+		if err := l.markTreeBasis(commit.Tree); err != nil {
+			return err
+		}
+		stack = append(stack, have)
+	}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := l.basis[id]; ok {
+			continue
+		}
+		l.basis[id] = struct{}{}
+		commit, err := l.repo.walkCommit(id)
+		if err != nil {
+			// A shallow or partial basis only costs a larger pack.
+			continue
+		}
+		stack = append(stack, commit.Parents...)
+	}
+	return nil
+}
+
+func (l *objectLister) markTreeBasis(id Hash) error {
+	if _, ok := l.basis[id]; ok {
+		return nil
+	}
+	l.basis[id] = struct{}{}
+	tree, err := l.repo.getTree(id)
+	if err != nil {
+		return err
+	}
+	for _, entry := range tree.Entries {
+		switch {
+		case entry.Mode == gitlinkMode:
+		case entry.Type == ObjectTypeTree:
+			if err := l.markTreeBasis(entry.ID); err != nil {
+				return err
+			}
+		default:
+			l.basis[entry.ID] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (l *objectLister) addWant(id Hash) error {
+	for {
+		if _, ok := l.seen[id]; ok {
+			return nil
+		}
+		if _, ok := l.basis[id]; ok {
+			return nil
+		}
+		data, objectType, err := l.repo.readObjectData(id, 0)
+		if err != nil {
+			return fmt.Errorf("want %s: %w", id, err)
+		}
+		switch objectType {
+		case ObjectTypeCommit:
+			l.pending = append(l.pending, id)
+			return nil
+		case ObjectTypeTree:
+			return l.addTree(id)
+		case ObjectTypeBlob:
+			l.seen[id] = struct{}{}
+			l.blobs = append(l.blobs, id)
+			return nil
+		case ObjectTypeTag:
+			object, err := parseObject(id, objectType, data)
+			if err != nil {
+				return err
+			}
+			l.seen[id] = struct{}{}
+			l.tags = append(l.tags, id)
+			id = object.(*Tag).Object
+		default:
+			return fmt.Errorf("want %s: unexpected object type %s", id, objectType)
+		}
+	}
+}
+
+// walkCommits lists commits reachable from the pending wants, stopping at the
+// receiver's commits, and queues their root trees.
+func (l *objectLister) walkCommits() error {
+	stack := make([]Hash, 0, len(l.pending))
+	for i := len(l.pending) - 1; i >= 0; i-- {
+		stack = append(stack, l.pending[i])
+	}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := l.seen[id]; ok {
+			continue
+		}
+		if _, ok := l.basis[id]; ok {
+			l.boundary[id] = struct{}{}
+			continue
+		}
+		commit, err := l.repo.walkCommit(id)
+		if err != nil {
+			return err
+		}
+		l.seen[id] = struct{}{}
+		l.commits = append(l.commits, id)
+		l.rootTrees = append(l.rootTrees, commit.Tree)
+		for i := len(commit.Parents) - 1; i >= 0; i-- {
+			stack = append(stack, commit.Parents[i])
+		}
+	}
+	return nil
+}
+
+func (l *objectLister) addTree(id Hash) error {
+	if _, ok := l.seen[id]; ok {
+		return nil
+	}
+	if _, ok := l.basis[id]; ok {
+		return nil
+	}
+	tree, err := l.repo.getTree(id)
+	if err != nil {
+		return err
+	}
+	l.seen[id] = struct{}{}
+	l.trees = append(l.trees, id)
+	for _, entry := range tree.Entries {
+		switch {
+		case entry.Mode == gitlinkMode:
+		case entry.Type == ObjectTypeTree:
+			if err := l.addTree(entry.ID); err != nil {
+				return err
+			}
+		default:
+			if _, ok := l.seen[entry.ID]; ok {
+				continue
+			}
+			if _, ok := l.basis[entry.ID]; ok {
+				continue
+			}
+			l.seen[entry.ID] = struct{}{}
+			l.blobs = append(l.blobs, entry.ID)
+		}
+	}
+	return nil
+}
+
+// includeTags adds annotated tags whose target is already listed.
+func (l *objectLister) includeTags() {
+	l.repo.mu.RLock()
+	tags := append([]*Tag(nil), l.repo.tags...)
+	l.repo.mu.RUnlock()
+
+	for added := true; added; {
+		added = false
+		for _, tag := range tags {
+			if _, ok := l.seen[tag.ID]; ok {
+				continue
+			}
+			if _, ok := l.seen[tag.Object]; !ok {
+				continue
+			}
+			l.seen[tag.ID] = struct{}{}
+			l.tags = append(l.tags, tag.ID)
+			added = true // a tag of this tag may now qualify
+		}
+	}
+}
+
+// walkCommit returns the commit id, reading it from the object store when it
+// was not loaded with the repository.
+func (r *Repository) walkCommit(id Hash) (*Commit, error) {
+	if commit, err := r.getCommit(id); err == nil {
+		return commit, nil
+	}
+	object, err := r.readObject(id)
+	if err != nil {
+		return nil, err
+	}
+	commit, ok := object.(*Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a commit", id)
+	}
+	return commit, nil
+}
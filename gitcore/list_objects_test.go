@@ -0,0 +1,60 @@
+package gitcore
+
+import (
+	"slices"
+	"testing"
+)
+
+func sortedHashes(hashes []Hash) []Hash {
+	out := slices.Clone(hashes)
+	slices.Sort(out)
+	return out
+}
+
+func TestListObjectsMatchesRevList(t *testing.T) {
+	dir := newPackFixture(t)
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	head := repo.Head()
+	first := Hash(mustRunGit(t, dir, "rev-parse", "HEAD~1"))
+	tag := repo.Refs()["refs/tags/v1"]
+
+	got, err := repo.ListObjects(ListObjectsOptions{Wants: []Hash{head, tag}})
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if want := gitObjectSet(t, dir, "HEAD", "v1"); !slices.Equal(sortedHashes(got), want) {
+		t.Fatalf("ListObjects(HEAD, v1) = %v, want %v", sortedHashes(got), want)
+	}
+	if got[0] != head {
+		t.Fatalf("first listed object = %s, want the wanted commit", got[0])
+	}
+
+	got, err = repo.ListObjects(ListObjectsOptions{Wants: []Hash{head}, Haves: []Hash{first}})
+	if err != nil {
+		t.Fatalf("ListObjects() with haves error = %v", err)
+	}
+	if want := gitObjectSet(t, dir, "HEAD", "^HEAD~1"); !slices.Equal(sortedHashes(got), want) {
+		t.Fatalf("ListObjects(HEAD ^HEAD~1) = %v, want %v", sortedHashes(got), want)
+	}
+
+	// The client has the tagged commit, but include-tag still sends the tag
+	// when its target is in the pack.
+	got, err = repo.ListObjects(ListObjectsOptions{Wants: []Hash{first}, IncludeTags: true})
+	if err != nil {
+		t.Fatalf("ListObjects() with tags error = %v", err)
+	}
+	if !slices.Contains(got, tag) {
+		t.Fatalf("ListObjects(IncludeTags) = %v, missing tag %s", got, tag)
+	}
+	if peeled, ok := repo.PeelTag(tag); !ok || peeled != first {
+		t.Fatalf("PeelTag(v1) = %s, %v, want %s", peeled, ok, first)
+	}
+	if _, ok := repo.PeelTag(head); ok {
+		t.Fatal("PeelTag(commit) reported a tag")
+	}
+}
@@ -150,6 +150,9 @@ func (r *Repository) loadMailmap() error {
 	for _, c := range r.commits {
 		r.mailmap.resolve(&c.Author)
 		r.mailmap.resolve(&c.Committer)
+		for i := range c.CoAuthors {
+			r.mailmap.resolve(&c.CoAuthors[i])
+		}
 	}
 	for _, t := range r.tags {
 		r.mailmap.resolve(&t.Tagger)
@@ -137,6 +137,7 @@ func TestLoadMailmapIntegration(t *testing.T) {
 		ID:        Hash("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
 		Author:    Signature{Name: "Old Author", Email: "old@example.com", When: now},
 		Committer: Signature{Name: "Old Committer", Email: "old@example.com", When: now},
+		CoAuthors: []Signature{{Name: "Old Pair", Email: "old@example.com", When: now}},
 	}
 	tag := &Tag{
 		ID:     Hash("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"),
@@ -161,6 +162,9 @@ func TestLoadMailmapIntegration(t *testing.T) {
 	if commit.Committer.Name != "Canonical Name" || commit.Committer.Email != "canonical@example.com" {
 		t.Fatalf("unexpected committer after loadMailmap: %+v", commit.Committer)
 	}
+	if commit.CoAuthors[0].Name != "Canonical Name" || commit.CoAuthors[0].Email != "canonical@example.com" {
+		t.Fatalf("unexpected co-author after loadMailmap: %+v", commit.CoAuthors[0])
+	}
 	if tag.Tagger.Name != "Canonical Name" || tag.Tagger.Email != "canonical@example.com" {
 		t.Fatalf("unexpected tagger after loadMailmap: %+v", tag.Tagger)
 	}
@@ -1,10 +1,14 @@
 package gitcore
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 )
 
+// ErrNoMergeBase reports two commits whose histories never meet.
+var ErrNoMergeBase = errors.New("no common ancestor")
+
 // MergeBase finds a single best common ancestor of two commits.
 // Some histories have multiple equally valid best common ancestors. In those
 // cases, callers that need the full graph-derived result set should use
@@ -38,12 +42,12 @@ func MergeBases(repo *Repository, ours, theirs Hash) ([]Hash, error) {
 
 	common := collectCommonAncestors(commits, ours, theirs)
 	if len(common) == 0 {
-		return nil, fmt.Errorf("no common ancestor between %s and %s", ours.Short(), theirs.Short())
+		return nil, fmt.Errorf("%w between %s and %s", ErrNoMergeBase, ours.Short(), theirs.Short())
 	}
 
 	bases := bestCommonAncestors(commits, common)
 	if len(bases) == 0 {
-		return nil, fmt.Errorf("no common ancestor between %s and %s", ours.Short(), theirs.Short())
+		return nil, fmt.Errorf("%w between %s and %s", ErrNoMergeBase, ours.Short(), theirs.Short())
 	}
 
 	slices.SortFunc(bases, compareHashes)
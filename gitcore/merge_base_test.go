@@ -1,6 +1,7 @@
 package gitcore
 
 import (
+	"errors"
 	"slices"
 	"testing"
 	"time"
@@ -72,8 +73,8 @@ func TestMergeBase_NoCommonAncestor(t *testing.T) {
 		hashB: repo.commits[1],
 	}
 
-	if _, err := MergeBase(repo, hashA, hashB); err == nil {
-		t.Fatal("MergeBase() error = nil, want error")
+	if _, err := MergeBase(repo, hashA, hashB); !errors.Is(err, ErrNoMergeBase) {
+		t.Fatalf("MergeBase() error = %v, want ErrNoMergeBase", err)
 	}
 }
 
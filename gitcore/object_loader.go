@@ -34,6 +34,10 @@ func (r *Repository) loadObjects() error {
 
 		object, err := loadObjectForTraversal(r, ref)
 		if err != nil {
+			if _, ok := r.prerequisites[ref]; ok {
+				// A bundle's history stops at its prerequisites.
+				continue
+			}
 			return fmt.Errorf("error traversing object: %w", err)
 		}
 
@@ -49,6 +49,8 @@ func parseCommitBody(body []byte, id Hash) (*Commit, error) {
 			break
 		}
 	}
+	commit.Trailers = parseTrailers(commit.Message)
+	commit.CoAuthors = coAuthorsFromTrailers(commit.Trailers, commit.Author)
 
 	return commit, nil
 }
@@ -75,6 +75,9 @@ func (r *Repository) readLooseObjectRaw(id Hash) (header string, content []byte,
 	if _, err := NewHash(string(id)); err != nil {
 		return "", nil, fmt.Errorf("invalid object hash %q: %w", id, err)
 	}
+	if r.bundle {
+		return "", nil, os.ErrNotExist
+	}
 
 	path := filepath.Join(r.gitDir, "objects", string(id)[:2], string(id)[2:])
 
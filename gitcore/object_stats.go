@@ -0,0 +1,61 @@
+package gitcore
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ObjectStats summarizes how a repository's objects are stored, in the
+// spirit of git count-objects.
+type ObjectStats struct {
+	Packs         int `json:"packs"`
+	PackedObjects int `json:"packedObjects"`
+	LooseObjects  int `json:"looseObjects"`
+}
+
+// ObjectStats counts pack files, the distinct objects they hold, and loose
+// objects on disk. Loose objects are counted by listing the object fan-out
+// directories, so the result reflects the disk at call time.
+func (r *Repository) ObjectStats() ObjectStats {
+	r.mu.RLock()
+	stats := ObjectStats{
+		Packs:         len(r.packIndices),
+		PackedObjects: len(r.packLocations),
+	}
+	gitDir := r.gitDir
+	r.mu.RUnlock()
+
+	if gitDir == "" {
+		return stats
+	}
+	objectsDir := filepath.Join(gitDir, "objects")
+	dirs, err := os.ReadDir(objectsDir)
+	if err != nil {
+		return stats
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 || !isHexString(dir.Name()) {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(objectsDir, dir.Name()))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && len(entry.Name()) == 38 && isHexString(entry.Name()) {
+				stats.LooseObjects++
+			}
+		}
+	}
+	return stats
+}
+
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
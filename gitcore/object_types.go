@@ -104,17 +104,20 @@ type Object interface {
 // It is used for resolving delta base objects during pack file reading.
 type ObjectResolver func(id Hash, depth int) (data []byte, objectType ObjectType, err error)
 
-// Commit represents a Git commit object.
+// Commit represents a Git commit object. Trailers are parsed from the end of
+// Message, and CoAuthors from its Co-authored-by trailers.
 // See: https://git-scm.com/book/en/v2/Git-Internals-Git-Objects
 type Commit struct {
-	ID                Hash      `json:"hash"`
-	Tree              Hash      `json:"tree"`
-	Parents           []Hash    `json:"parents"`
-	Author            Signature `json:"author"`
-	Committer         Signature `json:"committer"`
-	Message           string    `json:"message"`
-	BranchLabel       string    `json:"branchLabel,omitempty"`
-	BranchLabelSource string    `json:"branchLabelSource,omitempty"`
+	ID                Hash        `json:"hash"`
+	Tree              Hash        `json:"tree"`
+	Parents           []Hash      `json:"parents"`
+	Author            Signature   `json:"author"`
+	Committer         Signature   `json:"committer"`
+	Message           string      `json:"message"`
+	Trailers          []Trailer   `json:"trailers,omitempty"`
+	CoAuthors         []Signature `json:"coAuthors,omitempty"`
+	BranchLabel       string      `json:"branchLabel,omitempty"`
+	BranchLabelSource string      `json:"branchLabelSource,omitempty"`
 }
 
 // Type returns the ObjectType for a Commit.
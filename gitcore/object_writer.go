@@ -0,0 +1,107 @@
+package gitcore
+
+import (
+	"compress/zlib"
+	"crypto/sha1" // #nosec G505 -- Git object IDs are SHA-1
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// objectHeader returns the "<type> <size>\x00" prefix that git hashes and
+// stores in front of an object's content.
+func objectHeader(objectType ObjectType, size int) []byte {
+	header := make([]byte, 0, 16)
+	header = append(header, objectType.String()...)
+	header = append(header, ' ')
+	header = strconv.AppendInt(header, int64(size), 10)
+	return append(header, 0)
+}
+
+func checkWholeObjectType(objectType ObjectType) error {
+	switch objectType {
+	case ObjectTypeCommit, ObjectTypeTree, ObjectTypeBlob, ObjectTypeTag:
+		return nil
+	}
+	return fmt.Errorf("cannot encode %s object", objectType)
+}
+
+// HashObject returns the ID git gives an object with this type and content,
+// as `git hash-object -t <type>` prints it.
+func HashObject(objectType ObjectType, data []byte) Hash {
+	h := sha1.New() // #nosec G401 -- Git object IDs are SHA-1
+	h.Write(objectHeader(objectType, len(data)))
+	h.Write(data)
+	return Hash(hex.EncodeToString(h.Sum(nil)))
+}
+
+// EncodeLooseObject writes the object in the zlib-compressed form git keeps
+// under .git/objects and returns its ID.
+// See: https://git-scm.com/book/en/v2/Git-Internals-Git-Objects#_object_storage
+func EncodeLooseObject(w io.Writer, objectType ObjectType, data []byte) (Hash, error) {
+	if err := checkWholeObjectType(objectType); err != nil {
+		return "", err
+	}
+	zw := zlib.NewWriter(w)
+	if _, err := zw.Write(objectHeader(objectType, len(data))); err != nil {
+		return "", err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return HashObject(objectType, data), nil
+}
+
+// WriteLooseObject stores the object in the repository's object directory
+// and returns its ID. An object that is already stored loose is left alone.
+// The file is written under a temporary name and renamed into place, so
+// readers never see a partial object.
+func (r *Repository) WriteLooseObject(objectType ObjectType, data []byte) (Hash, error) {
+	if r.bundle {
+		return "", fmt.Errorf("cannot write objects into a bundle")
+	}
+	if err := checkWholeObjectType(objectType); err != nil {
+		return "", err
+	}
+	id := HashObject(objectType, data)
+	dir := filepath.Join(r.gitDir, "objects", string(id)[:2])
+	path := filepath.Join(dir, string(id)[2:])
+	if _, err := os.Stat(path); err == nil {
+		return id, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("create object directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "tmp_obj_")
+	if err != nil {
+		return "", fmt.Errorf("create object file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := EncodeLooseObject(tmp, objectType, data); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("write object %s: %w", id, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("write object %s: %w", id, err)
+	}
+	// Objects are immutable; git stores them read-only too.
+	if err := os.Chmod(tmp.Name(), 0o444); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("store object %s: %w", id, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,99 @@
+package gitcore
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHashObjectMatchesGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	content := []byte("hello, objects\n")
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	want := mustRunGit(t, dir, "hash-object", "--no-filters", path)
+	if got := HashObject(ObjectTypeBlob, content); string(got) != want {
+		t.Fatalf("HashObject(blob) = %s, want %s", got, want)
+	}
+	// The well-known ID of the empty tree.
+	if got := HashObject(ObjectTypeTree, nil); got != "4b825dc642cb6eb9a060e54bf8d69288fbee4904" {
+		t.Fatalf("HashObject(empty tree) = %s", got)
+	}
+}
+
+func TestEncodeLooseObjectRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	id, err := EncodeLooseObject(&buf, ObjectTypeBlob, []byte("data"))
+	if err != nil {
+		t.Fatalf("EncodeLooseObject() error = %v", err)
+	}
+	raw, err := readCompressedData(&buf)
+	if err != nil {
+		t.Fatalf("readCompressedData() error = %v", err)
+	}
+	if string(raw) != "blob 4\x00data" {
+		t.Fatalf("decoded loose object = %q", raw)
+	}
+	if id != HashObject(ObjectTypeBlob, []byte("data")) {
+		t.Fatalf("EncodeLooseObject() id = %s", id)
+	}
+	if _, err := EncodeLooseObject(&buf, ObjectTypeRefDelta, nil); err == nil {
+		t.Fatal("EncodeLooseObject() accepted a delta type")
+	}
+}
+
+func TestWriteLooseObjectIsReadableByGit(t *testing.T) {
+	dir := newPackFixture(t)
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	tree := mustRunGit(t, dir, "rev-parse", "HEAD^{tree}")
+	commit := []byte("tree " + tree + "\n" +
+		"parent " + string(repo.Head()) + "\n" +
+		"author Test <test@example.com> 1700000000 +0000\n" +
+		"committer Test <test@example.com> 1700000000 +0000\n\nwritten by gitcore\n")
+	objects := []struct {
+		objectType ObjectType
+		data       []byte
+	}{
+		{ObjectTypeBlob, []byte("a new blob\n")},
+		{ObjectTypeCommit, commit},
+	}
+	for _, obj := range objects {
+		id, err := repo.WriteLooseObject(obj.objectType, obj.data)
+		if err != nil {
+			t.Fatalf("WriteLooseObject(%s) error = %v", obj.objectType, err)
+		}
+		if got := mustRunGit(t, dir, "cat-file", "-t", string(id)); got != obj.objectType.String() {
+			t.Fatalf("git cat-file -t %s = %s, want %s", id, got, obj.objectType)
+		}
+		if got := mustRunGit(t, dir, "cat-file", obj.objectType.String(), string(id)); got != strings.TrimSpace(string(obj.data)) {
+			t.Fatalf("git cat-file %s = %q, want %q", id, got, obj.data)
+		}
+		data, objectType, err := repo.readObjectData(id, 0)
+		if err != nil || objectType != obj.objectType || !bytes.Equal(data, obj.data) {
+			t.Fatalf("readObjectData(%s) = %q, %s, %v", id, data, objectType, err)
+		}
+		// Writing it again is a no-op.
+		if again, err := repo.WriteLooseObject(obj.objectType, obj.data); err != nil || again != id {
+			t.Fatalf("second WriteLooseObject() = %s, %v", again, err)
+		}
+	}
+	mustRunGit(t, dir, "fsck", "--strict", "--no-dangling")
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, ".git", "objects", "*", "tmp_obj_*"))
+	if err != nil || len(leftovers) != 0 {
+		t.Fatalf("temporary object files left behind: %v", leftovers)
+	}
+}
@@ -0,0 +1,142 @@
+package gitcore
+
+import "bytes"
+
+const (
+	// deltaBlockSize is the length of the base chunks a delta can match on.
+	deltaBlockSize = 16
+	// deltaHashMultiplier drives the polynomial rolling hash over a block.
+	deltaHashMultiplier uint32 = 0x01000193
+	// maxDeltaCopySize is the most a single copy instruction can describe
+	// with its three size bytes.
+	maxDeltaCopySize = 0xffffff
+	// maxDeltaInsertSize is the most an insert instruction can carry.
+	maxDeltaInsertSize = 0x7f
+)
+
+// deltaHashOut removes the oldest byte of a block from its rolling hash.
+var deltaHashOut = func() uint32 {
+	h := uint32(1)
+	for range deltaBlockSize - 1 {
+		h *= deltaHashMultiplier
+	}
+	return h
+}()
+
+// deltaIndex locates the blocks of a base object so deltas against it can be
+// computed for any number of targets.
+type deltaIndex struct {
+	base   []byte
+	blocks map[uint32]int
+}
+
+func newDeltaIndex(base []byte) *deltaIndex {
+	ix := &deltaIndex{base: base, blocks: make(map[uint32]int, len(base)/deltaBlockSize)}
+	// Walk backwards so the earliest of several equal blocks is kept.
+	for off := (len(base)/deltaBlockSize - 1) * deltaBlockSize; off >= 0; off -= deltaBlockSize {
+		ix.blocks[hashDeltaBlock(base[off:off+deltaBlockSize])] = off
+	}
+	return ix
+}
+
+func hashDeltaBlock(block []byte) uint32 {
+	var h uint32
+	for _, b := range block {
+		h = h*deltaHashMultiplier + uint32(b)
+	}
+	return h
+}
+
+// delta encodes target as copies from the base and literal inserts. It gives
+// up and returns nil once the delta grows past maxSize; zero means no limit.
+// See: https://git-scm.com/docs/pack-format#_deltified_representation
+func (ix *deltaIndex) delta(target []byte, maxSize int) []byte {
+	base := ix.base
+	out := appendDeltaSize(nil, len(base))
+	out = appendDeltaSize(out, len(target))
+
+	pending := 0 // start of the bytes not yet covered by an instruction
+	var h uint32
+	hashed := false
+	for i := 0; i+deltaBlockSize <= len(target); {
+		if !hashed {
+			h = hashDeltaBlock(target[i : i+deltaBlockSize])
+			hashed = true
+		}
+		if off, ok := ix.blocks[h]; ok && bytes.Equal(base[off:off+deltaBlockSize], target[i:i+deltaBlockSize]) {
+			start, baseStart := i, off
+			for start > pending && baseStart > 0 && target[start-1] == base[baseStart-1] {
+				start--
+				baseStart--
+			}
+			end, baseEnd := i+deltaBlockSize, off+deltaBlockSize
+			for end < len(target) && baseEnd < len(base) && target[end] == base[baseEnd] {
+				end++
+				baseEnd++
+			}
+			out = appendDeltaInsert(out, target[pending:start])
+			out = appendDeltaCopy(out, baseStart, end-start)
+			if maxSize > 0 && len(out) > maxSize {
+				return nil
+			}
+			i, pending, hashed = end, end, false
+			continue
+		}
+		if i+deltaBlockSize < len(target) {
+			h = (h-uint32(target[i])*deltaHashOut)*deltaHashMultiplier + uint32(target[i+deltaBlockSize])
+		}
+		i++
+	}
+	out = appendDeltaInsert(out, target[pending:])
+	if maxSize > 0 && len(out) > maxSize {
+		return nil
+	}
+	return out
+}
+
+// appendDeltaSize appends a size in the little-endian base-128 form of the
+// delta header.
+func appendDeltaSize(out []byte, n int) []byte {
+	for n >= 0x80 {
+		out = append(out, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(out, byte(n))
+}
+
+func appendDeltaInsert(out []byte, data []byte) []byte {
+	for len(data) > 0 {
+		n := min(len(data), maxDeltaInsertSize)
+		out = append(out, byte(n))
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
+
+// appendDeltaCopy appends copy instructions, each naming only the non-zero
+// bytes of its offset and size.
+func appendDeltaCopy(out []byte, offset, size int) []byte {
+	for size > 0 {
+		n := min(size, maxDeltaCopySize)
+		cmdAt := len(out)
+		cmd := byte(0x80)
+		out = append(out, 0)
+		for i := range 4 {
+			if b := byte(offset >> (8 * i)); b != 0 {
+				cmd |= 1 << i
+				out = append(out, b)
+			}
+		}
+		for i := range 3 {
+			if b := byte(n >> (8 * i)); b != 0 {
+				cmd |= 0x10 << i
+				out = append(out, b)
+			}
+		}
+		out[cmdAt] = cmd
+		offset += n
+		size -= n
+	}
+	return out
+}
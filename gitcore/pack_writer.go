@@ -0,0 +1,409 @@
+package gitcore
+
+import (
+	"bufio"
+	"cmp"
+	"compress/zlib"
+	"crypto/sha1" // #nosec G505 -- Git pack checksums use SHA-1
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+const (
+	defaultPackWindow = 10
+	defaultPackDepth  = 50
+	// minDeltaTargetSize skips objects too small to gain from a delta.
+	minDeltaTargetSize = 50
+	// maxDeltaSourceSize keeps large blobs out of the delta search, which
+	// holds window objects and their indexes in memory.
+	maxDeltaSourceSize = 16 << 20
+)
+
+// PackOptions configures Repository.WritePack.
+type PackOptions struct {
+	// Window is how many similar objects are tried as delta bases for each
+	// object. Zero means 10, as for git; a negative window stores every object
+	// whole.
+	Window int
+	// MaxDepth limits how many deltas deep an object may be. Zero means 50.
+	MaxDepth int
+}
+
+// PackedObject records where an object was written in a pack, which is what
+// the pack's index holds.
+type PackedObject struct {
+	ID     Hash
+	Offset int64
+	CRC32  uint32
+}
+
+// WrittenPack describes a pack produced by Repository.WritePack.
+type WrittenPack struct {
+	// Checksum is the pack's trailing SHA-1, which git also uses to name it.
+	Checksum Hash
+	Objects  []PackedObject
+	// Deltas counts the objects stored as OFS_DELTA.
+	Deltas int
+}
+
+// packOutput forwards pack bytes while feeding the pack checksum and the
+// CRC-32 of the current entry.
+type packOutput struct {
+	w      io.Writer
+	sum    hash.Hash
+	crc    hash.Hash32
+	offset int64
+}
+
+func (o *packOutput) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.sum.Write(p[:n])
+	o.crc.Write(p[:n])
+	o.offset += int64(n)
+	return n, err
+}
+
+// PackWriter writes a version 2 pack file.
+// See: https://git-scm.com/docs/pack-format
+type PackWriter struct {
+	out     *packOutput
+	zw      *zlib.Writer
+	count   uint32
+	objects []PackedObject
+	offsets map[Hash]int64
+}
+
+// NewPackWriter writes a pack header announcing count objects to w. Exactly
+// count objects must then be written before Close.
+func NewPackWriter(w io.Writer, count int) (*PackWriter, error) {
+	if count < 0 || uint64(count) > 1<<32-1 {
+		return nil, fmt.Errorf("invalid pack object count %d", count)
+	}
+	pw := &PackWriter{
+		out: &packOutput{
+			w:   w,
+			sum: sha1.New(), // #nosec G401 -- Git pack checksums use SHA-1
+			crc: crc32.NewIEEE(),
+		},
+		count:   uint32(count),
+		objects: make([]PackedObject, 0, count),
+		offsets: make(map[Hash]int64, count),
+	}
+	var header [12]byte
+	copy(header[:4], "PACK")
+	binary.BigEndian.PutUint32(header[4:8], 2)
+	binary.BigEndian.PutUint32(header[8:], pw.count)
+	if _, err := pw.out.Write(header[:]); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// WriteObject appends a whole, undeltified object and returns its ID.
+func (pw *PackWriter) WriteObject(objectType ObjectType, data []byte) (Hash, error) {
+	if err := checkWholeObjectType(objectType); err != nil {
+		return "", err
+	}
+	id := HashObject(objectType, data)
+	return id, pw.writeEntry(id, packObjectHeader(objectType, len(data)), data)
+}
+
+// WriteOfsDelta appends object id as a delta against base, which must
+// already be in the pack. delta is in the format applyDelta reads.
+func (pw *PackWriter) WriteOfsDelta(id, base Hash, delta []byte) error {
+	baseOffset, ok := pw.offsets[base]
+	if !ok {
+		return fmt.Errorf("delta base %s is not earlier in the pack", base)
+	}
+	header := packObjectHeader(ObjectTypeOfsDelta, len(delta))
+	header = append(header, packDeltaOffset(pw.out.offset-baseOffset)...)
+	return pw.writeEntry(id, header, delta)
+}
+
+func (pw *PackWriter) writeEntry(id Hash, header, data []byte) error {
+	if uint32(len(pw.objects)) == pw.count {
+		return fmt.Errorf("pack already holds its %d objects", pw.count)
+	}
+	if _, ok := pw.offsets[id]; ok {
+		return fmt.Errorf("object %s is already in the pack", id)
+	}
+	offset := pw.out.offset
+	pw.out.crc.Reset()
+	if _, err := pw.out.Write(header); err != nil {
+		return err
+	}
+	if pw.zw == nil {
+		pw.zw = zlib.NewWriter(pw.out)
+	} else {
+		pw.zw.Reset(pw.out)
+	}
+	if _, err := pw.zw.Write(data); err != nil {
+		return err
+	}
+	if err := pw.zw.Close(); err != nil {
+		return err
+	}
+	pw.offsets[id] = offset
+	pw.objects = append(pw.objects, PackedObject{ID: id, Offset: offset, CRC32: pw.out.crc.Sum32()})
+	return nil
+}
+
+// Objects returns the entries written so far, in pack order.
+func (pw *PackWriter) Objects() []PackedObject {
+	return slices.Clone(pw.objects)
+}
+
+// Close writes the trailing checksum and returns it, which is also the name
+// git gives the pack.
+func (pw *PackWriter) Close() (Hash, error) {
+	if written := uint32(len(pw.objects)); written != pw.count {
+		return "", fmt.Errorf("pack announced %d objects but %d were written", pw.count, written)
+	}
+	trailer := pw.out.sum.Sum(nil)
+	if _, err := pw.out.w.Write(trailer); err != nil {
+		return "", err
+	}
+	return Hash(hex.EncodeToString(trailer)), nil
+}
+
+// packObjectHeader encodes an object's type and inflated size: the type in
+// bits 4-6 of the first byte and the size as a little-endian base-128 number
+// starting in its low four bits.
+func packObjectHeader(objectType ObjectType, size int) []byte {
+	header := make([]byte, 0, 10)
+	b := byte(objectType)<<4 | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		header = append(header, b|0x80)
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	return append(header, b)
+}
+
+// packDeltaOffset encodes how far back an OFS_DELTA base starts, in the
+// big-endian base-128 form readOffsetDelta decodes, where each continuation
+// adds one to the value so no offset has two encodings.
+func packDeltaOffset(distance int64) []byte {
+	var buf [10]byte
+	pos := len(buf) - 1
+	buf[pos] = byte(distance & 0x7f)
+	for distance >>= 7; distance != 0; distance >>= 7 {
+		distance--
+		pos--
+		buf[pos] = 0x80 | byte(distance&0x7f)
+	}
+	return buf[pos:]
+}
+
+// WritePackIndex writes the version 2 .idx file for a pack with the given
+// checksum and objects.
+// See: https://git-scm.com/docs/pack-format#_version_2_pack_idx_files_support_packs_larger_than_4_gib_and
+func WritePackIndex(w io.Writer, checksum Hash, objects []PackedObject) error {
+	packSum, err := hex.DecodeString(string(checksum))
+	if err != nil || len(packSum) != sha1.Size {
+		return fmt.Errorf("invalid pack checksum %q", checksum)
+	}
+	sorted := slices.Clone(objects)
+	slices.SortFunc(sorted, func(a, b PackedObject) int { return cmp.Compare(a.ID, b.ID) })
+	names := make([][]byte, len(sorted))
+	var fanout [256]uint32
+	for i, obj := range sorted {
+		name, err := hex.DecodeString(string(obj.ID))
+		if err != nil || len(name) != sha1.Size {
+			return fmt.Errorf("invalid object id %q", obj.ID)
+		}
+		if i > 0 && obj.ID == sorted[i-1].ID {
+			return fmt.Errorf("object %s is listed twice", obj.ID)
+		}
+		names[i] = name
+		fanout[name[0]]++
+	}
+	for i := 1; i < len(fanout); i++ {
+		fanout[i] += fanout[i-1]
+	}
+
+	sum := sha1.New() // #nosec G401 -- Git index checksums use SHA-1
+	bw := bufio.NewWriter(io.MultiWriter(w, sum))
+	put32 := func(v uint32) {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], v)
+		_, _ = bw.Write(b[:])
+	}
+	_, _ = bw.Write([]byte{packIndexV2Magic0, packIndexV2Magic1, packIndexV2Magic2, packIndexV2Magic3})
+	put32(2)
+	for _, n := range fanout {
+		put32(n)
+	}
+	for _, name := range names {
+		_, _ = bw.Write(name)
+	}
+	for _, obj := range sorted {
+		put32(obj.CRC32)
+	}
+	var large []uint64
+	for _, obj := range sorted {
+		if uint64(obj.Offset) < uint64(packIndexLargeOffsetFlag) {
+			put32(uint32(obj.Offset))
+			continue
+		}
+		put32(packIndexLargeOffsetFlag | uint32(len(large)))
+		large = append(large, uint64(obj.Offset))
+	}
+	for _, off := range large {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], off)
+		_, _ = bw.Write(b[:])
+	}
+	_, _ = bw.Write(packSum)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	_, err = w.Write(sum.Sum(nil))
+	return err
+}
+
+// packCandidate is an object being placed in a pack.
+type packCandidate struct {
+	id         Hash
+	objectType ObjectType
+	size       int
+	// base is the index of the delta base, or -1 for a whole object.
+	base  int
+	delta []byte
+	depth int
+}
+
+// WritePack writes the objects to w as a pack. Unless opts disables it, each
+// object is compared with the preceding objects of its type in a sliding
+// window of similar sizes and stored as an OFS_DELTA when that is smaller.
+// A delta's base is always written before it.
+func (r *Repository) WritePack(w io.Writer, objects []Hash, opts PackOptions) (*WrittenPack, error) {
+	candidates := make([]packCandidate, len(objects))
+	for i, id := range objects {
+		data, objectType, err := r.readObjectData(id, 0)
+		if err != nil {
+			return nil, err
+		}
+		candidates[i] = packCandidate{id: id, objectType: objectType, size: len(data), base: -1}
+	}
+	if opts.Window >= 0 {
+		if err := r.findDeltas(candidates, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	pw, err := NewPackWriter(w, len(candidates))
+	if err != nil {
+		return nil, err
+	}
+	written := make([]bool, len(candidates))
+	deltas := 0
+	var emit func(i int) error
+	emit = func(i int) error {
+		if written[i] {
+			return nil
+		}
+		written[i] = true
+		c := &candidates[i]
+		if c.base >= 0 {
+			if err := emit(c.base); err != nil {
+				return err
+			}
+			deltas++
+			return pw.WriteOfsDelta(c.id, candidates[c.base].id, c.delta)
+		}
+		data, objectType, err := r.readObjectData(c.id, 0)
+		if err != nil {
+			return err
+		}
+		_, err = pw.WriteObject(objectType, data)
+		return err
+	}
+	for i := range candidates {
+		if err := emit(i); err != nil {
+			return nil, err
+		}
+	}
+	checksum, err := pw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &WrittenPack{Checksum: checksum, Objects: pw.objects, Deltas: deltas}, nil
+}
+
+// findDeltas picks delta bases the way git's pack-objects does: objects are
+// sorted by type and then by decreasing size, and each is tried against the
+// window of objects just before it, keeping the smallest delta.
+func (r *Repository) findDeltas(candidates []packCandidate, opts PackOptions) error {
+	window := opts.Window
+	if window == 0 {
+		window = defaultPackWindow
+	}
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultPackDepth
+	}
+
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		ca, cb := &candidates[a], &candidates[b]
+		if ca.objectType != cb.objectType {
+			return int(ca.objectType) - int(cb.objectType)
+		}
+		return cb.size - ca.size
+	})
+
+	type windowEntry struct {
+		index int
+		data  []byte
+		ix    *deltaIndex
+	}
+	var slots []windowEntry
+	for _, i := range order {
+		c := &candidates[i]
+		if c.size > maxDeltaSourceSize {
+			continue
+		}
+		data, _, err := r.readObjectData(c.id, 0)
+		if err != nil {
+			return err
+		}
+		if c.size >= minDeltaTargetSize {
+			for s := len(slots) - 1; s >= 0; s-- {
+				slot := &slots[s]
+				base := &candidates[slot.index]
+				if base.objectType != c.objectType || base.depth >= maxDepth || base.size < c.size/32 {
+					continue
+				}
+				maxSize := c.size/2 - 20
+				if c.delta != nil {
+					maxSize = len(c.delta) - 1
+				}
+				if maxSize <= 0 {
+					break
+				}
+				if slot.ix == nil {
+					slot.ix = newDeltaIndex(slot.data)
+				}
+				if delta := slot.ix.delta(data, maxSize); delta != nil {
+					c.base, c.delta, c.depth = slot.index, delta, base.depth+1
+				}
+			}
+		}
+		if len(slots) == window {
+			copy(slots, slots[1:])
+			slots = slots[:window-1]
+		}
+		slots = append(slots, windowEntry{index: i, data: data})
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package gitcore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newPackFixture builds a repository with two commits, a nested directory, a
+// branch, and an annotated tag, and returns its path.
+func newPackFixture(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	mustRunGit(t, dir, "init", "-q", "-b", "main")
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("README.md", "hello\n")
+	write("src/lib/a.go", "package lib\n")
+	mustRunGit(t, dir, "add", "-A")
+	mustRunGit(t, dir, "commit", "-q", "-m", "first")
+	mustRunGit(t, dir, "tag", "-a", "v1", "-m", "release v1")
+	write("src/lib/a.go", "package lib\n\nconst A = 1\n")
+	write("docs/guide.md", "guide\n")
+	mustRunGit(t, dir, "add", "-A")
+	mustRunGit(t, dir, "commit", "-q", "-m", "second")
+	mustRunGit(t, dir, "branch", "feature", "HEAD~1")
+	return dir
+}
+
+// gitObjectSet returns the hashes `git rev-list --objects` prints for args.
+func gitObjectSet(t *testing.T, dir string, args ...string) []Hash {
+	t.Helper()
+	out := mustRunGit(t, dir, append([]string{"rev-list", "--objects"}, args...)...)
+	var hashes []Hash
+	for _, line := range strings.Split(out, "\n") {
+		if id, _, _ := strings.Cut(line, " "); id != "" {
+			hashes = append(hashes, Hash(id))
+		}
+	}
+	slices.Sort(hashes)
+	return hashes
+}
+
+// newDeltaFixture builds a repository whose history edits one large file a
+// line at a time, so most of its blobs and trees delta well.
+func newDeltaFixture(t *testing.T) string {
+	t.Helper()
+	dir := newPackFixture(t)
+	lines := make([]string, 300)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %03d of a file that changes a little in every commit", i)
+	}
+	for commit := range 6 {
+		lines[commit*40] = fmt.Sprintf("edited in commit %d", commit)
+		content := strings.Join(lines, "\n") + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		mustRunGit(t, dir, "add", "-A")
+		mustRunGit(t, dir, "commit", "-q", "-m", fmt.Sprintf("edit %d", commit))
+	}
+	return dir
+}
+
+// writePackFiles writes a pack of every object reachable from HEAD and its
+// index into a new bare repository and returns the repository and pack paths.
+func writePackFiles(t *testing.T, repo *Repository, opts PackOptions) (string, string, *WrittenPack) {
+	t.Helper()
+	objects := gitObjectSet(t, repo.workDir, "HEAD")
+	var pack bytes.Buffer
+	written, err := repo.WritePack(&pack, objects, opts)
+	if err != nil {
+		t.Fatalf("WritePack() error = %v", err)
+	}
+	if len(written.Objects) != len(objects) {
+		t.Fatalf("WritePack() wrote %d objects, want %d", len(written.Objects), len(objects))
+	}
+	var idx bytes.Buffer
+	if err := WritePackIndex(&idx, written.Checksum, written.Objects); err != nil {
+		t.Fatalf("WritePackIndex() error = %v", err)
+	}
+
+	dest := t.TempDir()
+	mustRunGit(t, dest, "init", "-q", "--bare")
+	base := filepath.Join(dest, "objects", "pack", "pack-"+string(written.Checksum))
+	if err := os.WriteFile(base+".pack", pack.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".idx", idx.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mustRunGit(t, dest, "update-ref", "refs/heads/main", string(repo.Head()))
+	return dest, base + ".pack", written
+}
+
+func TestWritePackRoundTripsThroughGit(t *testing.T) {
+	dir := newDeltaFixture(t)
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	dest, packPath, written := writePackFiles(t, repo, PackOptions{})
+	if written.Deltas == 0 {
+		t.Fatal("WritePack() stored no deltas for a history of small edits")
+	}
+
+	out := mustRunGit(t, dest, "verify-pack", "-v", strings.TrimSuffix(packPath, ".pack")+".idx")
+	if !strings.Contains(out, fmt.Sprintf("non delta: %d objects", len(written.Objects)-written.Deltas)) {
+		t.Fatalf("git verify-pack -v output does not report %d deltas:\n%s", written.Deltas, out)
+	}
+	mustRunGit(t, dest, "fsck", "--strict", "--no-dangling")
+
+	// git index-pack derives the same index from the pack alone.
+	gitIdx := filepath.Join(t.TempDir(), "git.idx")
+	mustRunGit(t, dest, "index-pack", "-o", gitIdx, packPath)
+	want, err := os.ReadFile(gitIdx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(strings.TrimSuffix(packPath, ".pack") + ".idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("WritePackIndex() output differs from git index-pack")
+	}
+
+	// And gitcore reads every object back from the written pack.
+	copyRepo, err := NewRepository(dest)
+	if err != nil {
+		t.Fatalf("NewRepository(copy) error = %v", err)
+	}
+	t.Cleanup(func() { _ = copyRepo.Close() })
+	for _, obj := range written.Objects {
+		wantData, wantType, err := repo.readObjectData(obj.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotData, gotType, err := copyRepo.readObjectData(obj.ID, 0)
+		if err != nil {
+			t.Fatalf("read %s from written pack: %v", obj.ID, err)
+		}
+		if gotType != wantType || !bytes.Equal(gotData, wantData) {
+			t.Fatalf("object %s read back as %s of %d bytes, want %s of %d bytes", obj.ID, gotType, len(gotData), wantType, len(wantData))
+		}
+	}
+}
+
+func TestWritePackWithoutDeltas(t *testing.T) {
+	dir := newDeltaFixture(t)
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	dest, _, written := writePackFiles(t, repo, PackOptions{Window: -1})
+	if written.Deltas != 0 {
+		t.Fatalf("WritePack(Window: -1) stored %d deltas", written.Deltas)
+	}
+	mustRunGit(t, dest, "fsck", "--strict", "--no-dangling")
+}
+
+func TestWritePackRespectsMaxDepth(t *testing.T) {
+	dir := newDeltaFixture(t)
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	dest, packPath, _ := writePackFiles(t, repo, PackOptions{MaxDepth: 1})
+	out := mustRunGit(t, dest, "verify-pack", "-v", strings.TrimSuffix(packPath, ".pack")+".idx")
+	if strings.Contains(out, "chain length = 2") {
+		t.Fatalf("git verify-pack reports chains longer than MaxDepth:\n%s", out)
+	}
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	base := []byte(strings.Repeat("abcdefghijklmnopqrstuvwxyz0123456789\n", 200))
+	tests := []struct {
+		name   string
+		target []byte
+	}{
+		{name: "identical", target: base},
+		{name: "edited middle", target: bytes.Replace(base, []byte("klmnop"), []byte("KLMNOP!"), 3)},
+		{name: "prefix and suffix", target: append(append([]byte("header\n"), base...), "trailer\n"...)},
+		{name: "unrelated", target: bytes.Repeat([]byte("zyx"), 300)},
+		{name: "shorter than a block", target: []byte("tiny")},
+		{name: "empty", target: nil},
+		{name: "long copy", target: bytes.Repeat(base, 20)},
+	}
+	ix := newDeltaIndex(base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := ix.delta(tt.target, 0)
+			got, err := applyDelta(base, delta)
+			if err != nil {
+				t.Fatalf("applyDelta() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.target) {
+				t.Fatalf("applyDelta() = %d bytes, want %d", len(got), len(tt.target))
+			}
+		})
+	}
+
+	if delta := ix.delta(base, 0); len(delta) > 16 {
+		t.Errorf("delta of identical content = %d bytes, want a single copy", len(delta))
+	}
+	if delta := ix.delta(bytes.Repeat([]byte("zyx"), 300), 100); delta != nil {
+		t.Errorf("delta over maxSize = %d bytes, want nil", len(delta))
+	}
+}
+
+func TestAppendDeltaCopyLargeSizes(t *testing.T) {
+	base := bytes.Repeat([]byte{7}, 0x10000+10)
+	var delta []byte
+	delta = appendDeltaSize(delta, len(base))
+	delta = appendDeltaSize(delta, 0x10000+5)
+	delta = appendDeltaCopy(delta, 0, 0x10000)
+	delta = appendDeltaCopy(delta, 5, 5)
+	got, err := applyDelta(base, delta)
+	if err != nil || len(got) != 0x10000+5 {
+		t.Fatalf("applyDelta() = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestPackDeltaOffsetMatchesReader(t *testing.T) {
+	for _, distance := range []int64{1, 127, 128, 255, 16383, 16384, 1 << 20, 1<<35 + 3} {
+		if got, want := packDeltaOffset(distance), encodeDeltaOffset(distance); !bytes.Equal(got, want) {
+			t.Errorf("packDeltaOffset(%d) = %x, want %x", distance, got, want)
+		}
+	}
+}
+
+func TestPackWriterEnforcesCount(t *testing.T) {
+	pw, err := NewPackWriter(&bytes.Buffer{}, 1)
+	if err != nil {
+		t.Fatalf("NewPackWriter() error = %v", err)
+	}
+	if _, err := pw.Close(); err == nil {
+		t.Fatal("Close() succeeded with a missing object")
+	}
+	if _, err := pw.WriteObject(ObjectTypeOfsDelta, nil); err == nil {
+		t.Fatal("WriteObject() accepted a delta type")
+	}
+	if err := pw.WriteOfsDelta(Hash(strings.Repeat("a", 40)), Hash(strings.Repeat("b", 40)), nil); err == nil {
+		t.Fatal("WriteOfsDelta() accepted a base missing from the pack")
+	}
+	id, err := pw.WriteObject(ObjectTypeBlob, []byte("x"))
+	if err != nil {
+		t.Fatalf("WriteObject() error = %v", err)
+	}
+	if id != HashObject(ObjectTypeBlob, []byte("x")) {
+		t.Fatalf("WriteObject() id = %s", id)
+	}
+	if _, err := pw.WriteObject(ObjectTypeBlob, []byte("y")); err == nil {
+		t.Fatal("WriteObject() accepted more objects than announced")
+	}
+}
+
+func TestPackObjectHeader(t *testing.T) {
+	tests := []struct {
+		size int
+		want []byte
+	}{
+		{size: 5, want: []byte{0x35}},
+		{size: 15, want: []byte{0x3f}},
+		{size: 16, want: []byte{0xb0, 0x01}},
+		{size: 1000, want: []byte{0xb8, 0x3e}},
+	}
+	for _, tt := range tests {
+		if got := packObjectHeader(ObjectTypeBlob, tt.size); !bytes.Equal(got, tt.want) {
+			t.Errorf("packObjectHeader(blob, %d) = %x, want %x", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestWritePackIndexLargeOffsets(t *testing.T) {
+	objects := []PackedObject{
+		{ID: Hash(strings.Repeat("b", 40)), Offset: 12, CRC32: 1},
+		{ID: Hash(strings.Repeat("a", 40)), Offset: 5 << 30, CRC32: 2},
+	}
+	var idx bytes.Buffer
+	if err := WritePackIndex(&idx, Hash(strings.Repeat("c", 40)), objects); err != nil {
+		t.Fatalf("WritePackIndex() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "pack-test.idx")
+	if err := os.WriteFile(path, idx.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewPackIndex(path)
+	if err != nil {
+		t.Fatalf("NewPackIndex() error = %v", err)
+	}
+	for _, obj := range objects {
+		if off, ok := loaded.FindObject(obj.ID); !ok || off != obj.Offset {
+			t.Errorf("FindObject(%s) = %d, %v, want %d", obj.ID, off, ok, obj.Offset)
+		}
+	}
+	if err := WritePackIndex(&idx, "", objects); err == nil {
+		t.Error("WritePackIndex() accepted an empty checksum")
+	}
+	if err := WritePackIndex(&idx, Hash(strings.Repeat("c", 40)), append(objects, objects[0])); err == nil {
+		t.Error("WritePackIndex() accepted a duplicate object")
+	}
+}
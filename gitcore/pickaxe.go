@@ -0,0 +1,241 @@
+package gitcore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+const defaultPickaxeWorkers = 8
+
+// PickaxeOptions selects commits by the content they change, like the -S and
+// -G options of git log. Exactly one of String and Regex must be set.
+// See: https://git-scm.com/docs/git-log#Documentation/git-log.txt--Sltstringgt
+type PickaxeOptions struct {
+	// String selects commits that change the number of occurrences of String (-S).
+	String string
+	// Regex selects commits whose added or removed lines match Regex (-G).
+	Regex string
+	// PickaxeRegex treats String as a regular expression (--pickaxe-regex).
+	PickaxeRegex bool
+	// IgnoreCase matches without regard to letter case.
+	IgnoreCase bool
+	// Pathspecs limits the comparison to paths equal to, beneath, or glob-matching any entry.
+	Pathspecs []string
+	// Workers bounds the number of commits examined concurrently.
+	Workers int
+}
+
+// Enabled reports whether any pickaxe pattern is set.
+func (o PickaxeOptions) Enabled() bool {
+	return o.String != "" || o.Regex != ""
+}
+
+// Pickaxe matches commits against a compiled -S or -G pattern.
+type Pickaxe struct {
+	repo    *Repository
+	opts    PickaxeOptions
+	matcher *regexp.Regexp
+	literal []byte
+}
+
+// NewPickaxe validates opts and compiles its pattern once so it can be applied
+// to many commits.
+func NewPickaxe(repo *Repository, opts PickaxeOptions) (*Pickaxe, error) {
+	switch {
+	case opts.String != "" && opts.Regex != "":
+		return nil, errors.New("pickaxe: -S and -G cannot be used together")
+	case !opts.Enabled():
+		return nil, errors.New("pickaxe: empty pattern")
+	}
+
+	p := &Pickaxe{repo: repo, opts: opts}
+	pattern := opts.Regex
+	if opts.String != "" {
+		pattern = opts.String
+		if !opts.PickaxeRegex {
+			if !opts.IgnoreCase {
+				p.literal = []byte(opts.String)
+				return p, nil
+			}
+			pattern = regexp.QuoteMeta(pattern)
+		}
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pickaxe: invalid pattern: %w", err)
+	}
+	p.matcher = re
+	return p, nil
+}
+
+// Match reports whether commit changes content selected by the pickaxe. The
+// commit is compared against its first parent, or the empty tree for a root
+// commit. Merge commits never match, as with git log without -m.
+func (p *Pickaxe) Match(commit *Commit) (bool, error) {
+	if len(commit.Parents) > 1 {
+		return false, nil
+	}
+
+	var parentTree Hash
+	if len(commit.Parents) == 1 {
+		parent, err := p.repo.GetCommit(commit.Parents[0])
+		if err != nil {
+			return false, fmt.Errorf("pickaxe: reading parent of %s: %w", commit.ID, err)
+		}
+		parentTree = parent.Tree
+	}
+
+	// Large commits such as vendoring or mass edits are where a string is
+	// most likely to appear or disappear, so the diff is not capped.
+	entries, err := treeDiffAll(p.repo, parentTree, commit.Tree)
+	if err != nil {
+		return false, fmt.Errorf("pickaxe: diffing %s: %w", commit.ID, err)
+	}
+
+	for _, entry := range entries {
+		if entry.OldMode == "160000" || entry.NewMode == "160000" {
+			continue
+		}
+		if !matchesGrepPathspec(p.opts.Pathspecs, entry.Path) &&
+			(entry.OldPath == "" || !matchesGrepPathspec(p.opts.Pathspecs, entry.OldPath)) {
+			continue
+		}
+		matched, err := p.matchEntry(entry)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *Pickaxe) matchEntry(entry DiffEntry) (bool, error) {
+	if entry.OldHash == entry.NewHash {
+		return false, nil
+	}
+
+	oldContent, err := p.readBlob(entry.OldHash)
+	if err != nil {
+		return false, err
+	}
+	newContent, err := p.readBlob(entry.NewHash)
+	if err != nil {
+		return false, err
+	}
+	if len(oldContent) > maxBlobSize || len(newContent) > maxBlobSize ||
+		IsBinaryContent(oldContent) || IsBinaryContent(newContent) {
+		return false, nil
+	}
+
+	if p.opts.String != "" {
+		return p.countOccurrences(oldContent) != p.countOccurrences(newContent), nil
+	}
+
+	// Most changes never touch the pattern; skip the line diff unless
+	// either side could contribute a matching line.
+	if !p.matcher.Match(oldContent) && !p.matcher.Match(newContent) {
+		return false, nil
+	}
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	for _, e := range computeEdits(oldLines, newLines) {
+		switch e.Type {
+		case editDelete:
+			if p.matcher.MatchString(oldLines[e.OldLine]) {
+				return true, nil
+			}
+		case editInsert:
+			if p.matcher.MatchString(newLines[e.NewLine]) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (p *Pickaxe) readBlob(hash Hash) ([]byte, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	content, err := p.repo.GetBlob(hash)
+	if err != nil {
+		return nil, fmt.Errorf("pickaxe: reading blob %s: %w", hash, err)
+	}
+	return content, nil
+}
+
+func (p *Pickaxe) countOccurrences(content []byte) int {
+	if p.literal != nil {
+		return bytes.Count(content, p.literal)
+	}
+	return len(p.matcher.FindAllIndex(content, -1))
+}
+
+// Scan examines commits concurrently and calls fn for each one, in the given
+// order, with whether it matched. Reporting non-matches as well lets callers
+// show progress through long histories. If fn returns an error the scan stops
+// and that error is returned.
+func (p *Pickaxe) Scan(commits []*Commit, fn func(commit *Commit, matched bool) error) error {
+	workers := p.opts.Workers
+	if workers <= 0 {
+		workers = defaultPickaxeWorkers
+	}
+
+	type pickaxeOutcome struct {
+		matched bool
+		err     error
+	}
+
+	outcomes := make([]chan pickaxeOutcome, len(commits))
+	for i := range outcomes {
+		outcomes[i] = make(chan pickaxeOutcome, 1)
+	}
+
+	stop := make(chan struct{})
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				matched, err := p.Match(commits[i])
+				outcomes[i] <- pickaxeOutcome{matched: matched, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range commits {
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var err error
+	for i, commit := range commits {
+		outcome := <-outcomes[i]
+		if outcome.err == nil {
+			outcome.err = fn(commit, outcome.matched)
+		}
+		if outcome.err != nil {
+			err = outcome.err
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,139 @@
+package gitcore
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupPickaxeTestRepo(t *testing.T) (*Repository, map[string]Hash) {
+	t.Helper()
+	repo := setupTestRepo(t)
+
+	tree := func(a, b string) Hash {
+		docs := createTree(t, repo, []TreeEntry{{ID: createBlob(t, repo, []byte(b)), Name: "b.md", Mode: "100644", Type: ObjectTypeBlob}})
+		return createTree(t, repo, []TreeEntry{
+			{ID: createBlob(t, repo, []byte(a)), Name: "a.txt", Mode: "100644", Type: ObjectTypeBlob},
+			{ID: docs, Name: "docs", Mode: "040000", Type: ObjectTypeTree},
+		})
+	}
+
+	hashes := map[string]Hash{
+		"root":    Hash(strings.Repeat("1", 40)),
+		"add":     Hash(strings.Repeat("2", 40)),
+		"reorder": Hash(strings.Repeat("3", 40)),
+		"docs":    Hash(strings.Repeat("4", 40)),
+		"merge":   Hash(strings.Repeat("5", 40)),
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(name string, offset int, treeHash Hash, parents ...string) {
+		c := &Commit{ID: hashes[name], Tree: treeHash, Committer: Signature{When: base.Add(time.Duration(offset) * time.Hour)}}
+		for _, parent := range parents {
+			c.Parents = append(c.Parents, hashes[parent])
+		}
+		repo.commits = append(repo.commits, c)
+		repo.commitMap[c.ID] = c
+	}
+
+	commit("root", 0, tree("foo\n", "hello\n"))
+	commit("add", 1, tree("foo\nBar\n", "hello\n"), "root")
+	commit("reorder", 2, tree("Bar\nfoo\n", "hello\n"), "add")
+	commit("docs", 3, tree("Bar\nfoo\n", "hello bar\n"), "reorder")
+	commit("merge", 4, tree("Bar\nfoo\nbar\n", "hello bar\n"), "docs", "add")
+	repo.head = hashes["merge"]
+	repo.refs["refs/heads/main"] = hashes["merge"]
+	return repo, hashes
+}
+
+func TestRevListPickaxe(t *testing.T) {
+	repo, hashes := setupPickaxeTestRepo(t)
+
+	tests := []struct {
+		name string
+		opts PickaxeOptions
+		want []string
+	}{
+		{name: "string counts occurrences", opts: PickaxeOptions{String: "Bar"}, want: []string{"add"}},
+		{name: "string ignore case", opts: PickaxeOptions{String: "bar", IgnoreCase: true}, want: []string{"docs", "add"}},
+		{name: "string as regex", opts: PickaxeOptions{String: "^h", PickaxeRegex: true}, want: []string{"root"}},
+		{name: "regex matches moved lines", opts: PickaxeOptions{Regex: "foo|Bar"}, want: []string{"reorder", "add", "root"}},
+		{name: "pathspec", opts: PickaxeOptions{String: "bar", IgnoreCase: true, Pathspecs: []string{"docs"}}, want: []string{"docs"}},
+		{name: "glob pathspec", opts: PickaxeOptions{Regex: "hello", Pathspecs: []string{"*.md"}}, want: []string{"docs", "root"}},
+		{name: "single worker", opts: PickaxeOptions{String: "foo", Workers: 1}, want: []string{"root"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commits, err := repo.RevList(RevListOptions{Revision: "HEAD", Pickaxe: tc.opts})
+			if err != nil {
+				t.Fatalf("RevList() error = %v", err)
+			}
+			got := make([]Hash, len(commits))
+			for i, commit := range commits {
+				got[i] = commit.ID
+			}
+			want := make([]Hash, len(tc.want))
+			for i, name := range tc.want {
+				want[i] = hashes[name]
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("RevList() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPickaxeMatchesCommitsPastDiffLimit(t *testing.T) {
+	repo := setupTestRepo(t)
+	entries := make([]TreeEntry, maxDiffEntries+1)
+	for i := range entries {
+		content := fmt.Sprintf("file %d\n", i)
+		if i == maxDiffEntries {
+			content = "needle\n"
+		}
+		entries[i] = TreeEntry{ID: createBlob(t, repo, []byte(content)), Name: fmt.Sprintf("f%04d.txt", i), Mode: "100644", Type: ObjectTypeBlob}
+	}
+	commit := &Commit{ID: Hash(strings.Repeat("1", 40)), Tree: createTree(t, repo, entries)}
+	repo.commits = append(repo.commits, commit)
+	repo.commitMap[commit.ID] = commit
+
+	if _, err := TreeDiff(repo, "", commit.Tree, ""); err == nil {
+		t.Fatal("TreeDiff() error = nil, want the diff limit")
+	}
+	for _, opts := range []PickaxeOptions{{String: "needle"}, {Regex: "need"}} {
+		p, err := NewPickaxe(repo, opts)
+		if err != nil {
+			t.Fatalf("NewPickaxe(%+v) error = %v", opts, err)
+		}
+		if matched, err := p.Match(commit); err != nil || !matched {
+			t.Fatalf("Match(%+v) = %v, %v; want a match", opts, matched, err)
+		}
+	}
+}
+
+func TestNewPickaxeErrors(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	tests := []struct {
+		name string
+		opts PickaxeOptions
+	}{
+		{name: "empty", opts: PickaxeOptions{}},
+		{name: "both patterns", opts: PickaxeOptions{String: "a", Regex: "b"}},
+		{name: "invalid regex", opts: PickaxeOptions{Regex: "("}},
+		{name: "invalid pickaxe regex", opts: PickaxeOptions{String: "(", PickaxeRegex: true}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewPickaxe(repo, tc.opts); err == nil {
+				t.Fatal("NewPickaxe() error = nil, want error")
+			}
+		})
+	}
+
+	if _, err := NewPickaxe(repo, PickaxeOptions{String: "("}); err != nil {
+		t.Fatalf("NewPickaxe(literal) error = %v, want nil", err)
+	}
+}
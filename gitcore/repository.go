@@ -21,6 +21,11 @@ type Repository struct {
 	packLocations map[Hash]PackLocation
 	mailmap       *Mailmap
 
+	// bundle marks a repository opened from a bundle file, whose
+	// prerequisite commits are named but not stored.
+	bundle        bool
+	prerequisites map[Hash]struct{}
+
 	head         Hash
 	headRef      string
 	headDetached bool
@@ -33,8 +38,12 @@ type Repository struct {
 }
 
 // NewRepository opens a Git repository starting from path, which can be the
-// working directory, the .git directory, or any child directory.
+// working directory, the .git directory, or any child directory. A path to a
+// bundle file opens the bundle read-only.
 func NewRepository(path string) (*Repository, error) {
+	if isBundleFile(path) {
+		return openBundle(path)
+	}
 	gitDir, workDir, err := findGitDirectory(path)
 	if err != nil {
 		return nil, err
@@ -102,6 +111,10 @@ func (r *Repository) Close() error {
 		defer r.packReadersMu.Unlock()
 
 		for path, reader := range r.packReaders {
+			if reader.file == nil {
+				// Bundle packs are read into memory, not mapped.
+				continue
+			}
 			if err := unmapPackData(reader.data); err != nil && closeErr == nil {
 				closeErr = fmt.Errorf("unmap pack file %s: %w", path, err)
 			}
@@ -245,6 +245,45 @@ func (r *Repository) TagNames() []string {
 	return result
 }
 
+// TagRef is a tag peeled to the commit it names. Tagger is zero for
+// lightweight tags.
+type TagRef struct {
+	Name      string
+	Commit    Hash
+	Annotated bool
+	Tagger    Signature
+}
+
+// TagRefs returns the tags that name commits, sorted by name.
+func (r *Repository) TagRefs() []TagRef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tagObjects := make(map[Hash]*Tag, len(r.tags))
+	for _, tag := range r.tags {
+		tagObjects[tag.ID] = tag
+	}
+	result := make([]TagRef, 0)
+	for ref, target := range r.refs {
+		name, ok := strings.CutPrefix(ref, "refs/tags/")
+		if !ok {
+			continue
+		}
+		tag := TagRef{Name: name, Commit: target}
+		if tagObject, annotated := tagObjects[target]; annotated {
+			tag.Commit = r.peelTagTargetLocked(target)
+			tag.Annotated = true
+			tag.Tagger = tagObject.Tagger
+		}
+		if _, ok := r.commitMap[tag.Commit]; !ok {
+			continue
+		}
+		result = append(result, tag)
+	}
+	slices.SortFunc(result, func(a, b TagRef) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
 // LsTree resolves a commit revision and returns the entries in its root tree.
 func (r *Repository) LsTree(opts LsTreeOptions) ([]TreeEntry, error) {
 	hash, err := r.ResolveRevision(opts.Revision)
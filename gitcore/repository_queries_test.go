@@ -235,6 +235,29 @@ func TestRepositoryAccessDescriptionAndTagNames(t *testing.T) {
 	}
 }
 
+func TestRepositoryAccessTagRefs(t *testing.T) {
+	repo := newRepoSkeleton(t)
+	commit1, commit2 := mustHash(t, testHash1), mustHash(t, testHash2)
+	tagID, blobID := mustHash(t, testHash3), mustHash(t, testHash4)
+	repo.commitMap[commit1] = &Commit{ID: commit1}
+	repo.commitMap[commit2] = &Commit{ID: commit2}
+	tagger := Signature{Name: "Rel", Email: "rel@example.com"}
+	repo.tags = []*Tag{{ID: tagID, Object: commit2, ObjType: ObjectTypeCommit, Name: "v1.1", Tagger: tagger}}
+	repo.refs["refs/tags/v1.0"] = commit1
+	repo.refs["refs/tags/v1.1"] = tagID
+	repo.refs["refs/tags/blob"] = blobID
+	repo.refs["refs/heads/main"] = commit2
+
+	got := repo.TagRefs()
+	want := []TagRef{
+		{Name: "v1.0", Commit: commit1},
+		{Name: "v1.1", Commit: commit2, Annotated: true, Tagger: tagger},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("TagRefs() = %+v, want %+v", got, want)
+	}
+}
+
 func TestLsTreeReturnsRootTreeEntriesForCommitRevision(t *testing.T) {
 	repo := newRepoSkeleton(t)
 
@@ -461,3 +484,25 @@ func TestRepositoryQueryHelpersAndResolveTreeAtPath(t *testing.T) {
 		t.Fatalf("resolveTreeAtPath(bad root) error = %v", err)
 	}
 }
+
+func TestObjectStats(t *testing.T) {
+	repo := setupTestRepo(t)
+	createBlob(t, repo, []byte("one\n"))
+	createBlob(t, repo, []byte("two\n"))
+	repo.packIndices = []*PackIndex{{}}
+	repo.packLocations[mustHash(t, testHash1)] = PackLocation{}
+
+	// Stray files in objects/ are not objects.
+	if err := os.MkdirAll(filepath.Join(repo.gitDir, "objects", "info"), 0o755); err != nil {
+		t.Fatalf("MkdirAll(info): %v", err)
+	}
+	writeTextFile(t, filepath.Join(repo.gitDir, "objects", "info", "packs"), "P pack-x.pack\n")
+
+	want := ObjectStats{Packs: 1, PackedObjects: 1, LooseObjects: 2}
+	if got := repo.ObjectStats(); got != want {
+		t.Fatalf("ObjectStats() = %+v, want %+v", got, want)
+	}
+	if got := NewEmptyRepository().ObjectStats(); got != (ObjectStats{}) {
+		t.Fatalf("empty ObjectStats() = %+v", got)
+	}
+}
@@ -2,7 +2,9 @@ package gitcore
 
 import (
 	"container/heap"
+	"errors"
 	"fmt"
+	"maps"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,12 +22,20 @@ const (
 	RevListOrderDate
 )
 
+// ErrSymmetricDifference reports an "A...B" revision range, which RevList
+// does not support.
+var ErrSymmetricDifference = errors.New("symmetric difference ranges are not supported")
+
 // RevListOptions configures revision traversal and filtering.
 type RevListOptions struct {
 	All      bool
 	Revision string
+	// Exclude drops commits reachable from these revisions, like ^rev.
+	Exclude  []string
 	NoMerges bool
 	Order    RevListOrder
+	// Pickaxe, when enabled, keeps only commits whose changes match it.
+	Pickaxe PickaxeOptions
 }
 
 // RevList returns commits reachable from the requested start points.
@@ -38,18 +48,76 @@ func (r *Repository) RevList(opts RevListOptions) ([]*Commit, error) {
 		return nil, nil
 	}
 
-	ordered := orderRevListCommits(r.Commits(), starts, opts.Order)
-	if !opts.NoMerges {
+	commits, err := r.revListVisibleCommits(opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	ordered := orderRevListCommits(commits, starts, opts.Order)
+	if opts.NoMerges {
+		filtered := ordered[:0]
+		for _, commit := range ordered {
+			if len(commit.Parents) <= 1 {
+				filtered = append(filtered, commit)
+			}
+		}
+		ordered = filtered
+	}
+	if !opts.Pickaxe.Enabled() {
 		return ordered, nil
 	}
 
-	filtered := ordered[:0]
-	for _, commit := range ordered {
-		if len(commit.Parents) <= 1 {
-			filtered = append(filtered, commit)
+	pickaxe, err := NewPickaxe(r, opts.Pickaxe)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Commit
+	err = pickaxe.Scan(ordered, func(commit *Commit, ok bool) error {
+		if ok {
+			matched = append(matched, commit)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// RevRange is a parsed revision range: the commits reachable from To but not
+// from From. From is empty for a single revision.
+type RevRange struct {
+	From string
+	To   string
+}
+
+// ParseRevRange parses "<from>..<to>" or a single revision. Either end of a
+// range, and an empty single revision, defaults to HEAD. "<from>...<to>" is
+// rejected with ErrSymmetricDifference.
+func ParseRevRange(spec string) (RevRange, error) {
+	if strings.Contains(spec, "...") {
+		return RevRange{}, ErrSymmetricDifference
+	}
+	from, to, isRange := strings.Cut(spec, "..")
+	if !isRange {
+		return RevRange{To: orHead(spec)}, nil
+	}
+	return RevRange{From: orHead(from), To: orHead(to)}, nil
+}
+
+// Options returns the RevList options that select the range.
+func (rr RevRange) Options() RevListOptions {
+	opts := RevListOptions{Revision: rr.To}
+	if rr.From != "" {
+		opts.Exclude = []string{rr.From}
 	}
-	return filtered, nil
+	return opts
+}
+
+func orHead(revision string) string {
+	if revision == "" {
+		return "HEAD"
+	}
+	return revision
 }
 
 // ResolveRevision resolves a branch, tag, HEAD, or commit prefix to a commit hash.
@@ -164,6 +232,28 @@ func (r *Repository) revListStartPoints(opts RevListOptions) ([]Hash, error) {
 	return starts, nil
 }
 
+// revListVisibleCommits returns the commit map with commits reachable from
+// the excluded revisions removed, so traversal stops at them.
+func (r *Repository) revListVisibleCommits(exclude []string) (map[Hash]*Commit, error) {
+	commits := r.Commits()
+	if len(exclude) == 0 {
+		return commits, nil
+	}
+	hidden := make([]Hash, 0, len(exclude))
+	for _, revision := range exclude {
+		hash, err := r.ResolveRevision(revision)
+		if err != nil {
+			return nil, err
+		}
+		hidden = append(hidden, hash)
+	}
+	visible := maps.Clone(commits)
+	for _, commit := range chronologicalRevListCommits(commits, hidden) {
+		delete(visible, commit.ID)
+	}
+	return visible, nil
+}
+
 func orderRevListCommits(commits map[Hash]*Commit, starts []Hash, mode RevListOrder) []*Commit {
 	reachable := chronologicalRevListCommits(commits, starts)
 
@@ -1,6 +1,7 @@
 package gitcore
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -263,6 +264,64 @@ func TestRevListAllDedupesRefsAndFiltersMerges(t *testing.T) {
 	}
 }
 
+func TestParseRevRange(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    RevRange
+		wantErr error
+	}{
+		{spec: "", want: RevRange{To: "HEAD"}},
+		{spec: "main", want: RevRange{To: "main"}},
+		{spec: "v1..v2", want: RevRange{From: "v1", To: "v2"}},
+		{spec: "v1..", want: RevRange{From: "v1", To: "HEAD"}},
+		{spec: "..v2", want: RevRange{From: "HEAD", To: "v2"}},
+		{spec: "v1...v2", wantErr: ErrSymmetricDifference},
+	}
+	for _, tt := range tests {
+		got, err := ParseRevRange(tt.spec)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Fatalf("ParseRevRange(%q) = %+v, %v; want %+v, %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRevListExcludesRangeStart(t *testing.T) {
+	root := Hash("1111111111111111111111111111111111111111")
+	base := Hash("2222222222222222222222222222222222222222")
+	side := Hash("3333333333333333333333333333333333333333")
+	merge := Hash("4444444444444444444444444444444444444444")
+
+	now := time.Unix(1_700_000_000, 0)
+	repo := &Repository{
+		refs: map[string]Hash{
+			"refs/heads/main": merge,
+			"refs/tags/v1":    base,
+		},
+		commitMap: map[Hash]*Commit{
+			root:  {ID: root, Committer: Signature{When: now.Add(-4 * time.Hour)}},
+			base:  {ID: base, Parents: []Hash{root}, Committer: Signature{When: now.Add(-3 * time.Hour)}},
+			side:  {ID: side, Parents: []Hash{root}, Committer: Signature{When: now.Add(-2 * time.Hour)}},
+			merge: {ID: merge, Parents: []Hash{base, side}, Committer: Signature{When: now.Add(-1 * time.Hour)}},
+		},
+	}
+
+	rng, err := ParseRevRange("v1..main")
+	if err != nil {
+		t.Fatalf("ParseRevRange() error = %v", err)
+	}
+	got, err := repo.RevList(rng.Options())
+	if err != nil {
+		t.Fatalf("RevList(v1..main) error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != merge || got[1].ID != side {
+		t.Fatalf("RevList(v1..main) = %v, want merge and side", got)
+	}
+
+	if _, err := repo.RevList(RevListOptions{Revision: "main", Exclude: []string{"missing"}}); err == nil {
+		t.Fatal("RevList(Exclude missing) error = nil, want unknown revision")
+	}
+}
+
 func TestRevListTopoAndDateOrderPreserveTopology(t *testing.T) {
 	root := Hash("1111111111111111111111111111111111111111")
 	left := Hash("2222222222222222222222222222222222222222")
@@ -1,7 +1,6 @@
 package gitcore
 
 import (
-	"crypto/sha1" // #nosec G505 -- Git requires SHA-1 for blob hashing
 	"encoding/json"
 	"fmt"
 	"io/fs"
@@ -372,13 +371,7 @@ func flattenTree(repo *Repository, treeHash Hash, prefix string) (map[string]tre
 }
 
 func hashBlobContent(content []byte) Hash {
-	header := fmt.Sprintf("blob %d\x00", len(content))
-
-	h := sha1.New() // #nosec G401
-	h.Write([]byte(header))
-	h.Write(content)
-
-	return Hash(fmt.Sprintf("%x", h.Sum(nil)))
+	return HashObject(ObjectTypeBlob, content)
 }
 
 func compareTreeAndIndex(headFile treeFile, entry IndexEntry) (ChangeType, bool) {
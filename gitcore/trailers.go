@@ -0,0 +1,172 @@
+package gitcore
+
+import (
+	"strings"
+	"time"
+)
+
+// Trailer is one "Key: value" line from the trailer block that ends a commit
+// message. Folded continuation lines are joined to the value with a space.
+// See: https://git-scm.com/docs/git-interpret-trailers
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// gitGeneratedTrailerPrefixes are the lines git itself appends. A block
+// containing one is accepted with as few as 25% trailer lines.
+var gitGeneratedTrailerPrefixes = []string{"Signed-off-by: ", "(cherry picked from commit "}
+
+// parseTrailers extracts the trailers from message following git's
+// interpret-trailers rules: the trailer block is the last paragraph, never
+// the subject paragraph, and it must consist entirely of trailers and their
+// continuation lines, or be at least 25% trailers including one that git
+// generates.
+func parseTrailers(message string) []Trailer {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+
+	titleEnd := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			titleEnd = i
+			break
+		}
+	}
+	if titleEnd < 0 {
+		return nil
+	}
+
+	end := len(lines)
+	for end > titleEnd && (strings.TrimSpace(lines[end-1]) == "" || strings.HasPrefix(lines[end-1], "#")) {
+		end--
+	}
+	start := end
+	for start > titleEnd+1 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	block := lines[start:end]
+	if len(block) == 0 {
+		return nil
+	}
+
+	trailerLines, nonTrailerLines, continuations := 0, 0, 0
+	recognized := false
+	for _, line := range block {
+		for _, prefix := range gitGeneratedTrailerPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				recognized = true
+			}
+		}
+		switch {
+		case strings.HasPrefix(line, "#"):
+		case trailerLines > 0 && startsWithSpace(line):
+			continuations++
+		case trailerSeparator(line) > 0:
+			trailerLines++
+			continuations = 0
+		case startsWithSpace(line):
+			continuations++
+		default:
+			nonTrailerLines += 1 + continuations
+			continuations = 0
+		}
+	}
+	if trailerLines == 0 || (nonTrailerLines > 0 && !(recognized && trailerLines*3 >= nonTrailerLines)) {
+		return nil
+	}
+
+	var trailers []Trailer
+	inTrailer := false
+	for _, line := range block {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if startsWithSpace(line) {
+			if inTrailer {
+				last := &trailers[len(trailers)-1]
+				last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(line))
+			}
+			continue
+		}
+		sep := trailerSeparator(line)
+		if inTrailer = sep > 0; !inTrailer {
+			continue
+		}
+		trailers = append(trailers, Trailer{
+			Key:   strings.TrimSpace(line[:sep]),
+			Value: strings.TrimSpace(line[sep+1:]),
+		})
+	}
+	return trailers
+}
+
+// trailerSeparator returns the index of the colon ending a trailer key, or
+// -1. Keys are letters, digits, and hyphens, optionally followed by spaces
+// before the colon.
+func trailerSeparator(line string) int {
+	spaces := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ':':
+			if i == 0 {
+				return -1
+			}
+			return i
+		case !spaces && (c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'):
+		case i > 0 && (c == ' ' || c == '\t'):
+			spaces = true
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+func startsWithSpace(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// TrailerValues returns the values of c's trailers whose key equals key,
+// ignoring case, in message order.
+func (c *Commit) TrailerValues(key string) []string {
+	var values []string
+	for _, t := range c.Trailers {
+		if strings.EqualFold(t.Key, key) {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// coAuthorsFromTrailers parses the "Name <email>" identities in
+// Co-authored-by trailers, dated like the author since trailers carry no
+// time. Malformed identities and repeats of the author are skipped.
+func coAuthorsFromTrailers(trailers []Trailer, author Signature) []Signature {
+	var coAuthors []Signature
+	seen := map[string]bool{strings.ToLower(author.Email): true}
+	for _, t := range trailers {
+		if !strings.EqualFold(t.Key, "Co-authored-by") {
+			continue
+		}
+		sig, ok := parseTrailerIdentity(t.Value, author.When)
+		if !ok || seen[strings.ToLower(sig.Email)] {
+			continue
+		}
+		seen[strings.ToLower(sig.Email)] = true
+		coAuthors = append(coAuthors, sig)
+	}
+	return coAuthors
+}
+
+func parseTrailerIdentity(value string, when time.Time) (Signature, bool) {
+	open := strings.LastIndexByte(value, '<')
+	if open < 0 || !strings.HasSuffix(value, ">") {
+		return Signature{}, false
+	}
+	email := strings.TrimSpace(value[open+1 : len(value)-1])
+	if email == "" {
+		return Signature{}, false
+	}
+	return Signature{Name: strings.TrimSpace(value[:open]), Email: email, When: when}, true
+}
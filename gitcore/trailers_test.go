@@ -0,0 +1,95 @@
+package gitcore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []Trailer
+	}{
+		{
+			name:    "subject only",
+			message: "Signed-off-by: A <a@example.com>",
+		},
+		{
+			name:    "trailer block",
+			message: "Fix bug\n\nExplain the fix.\n\nSigned-off-by: A <a@example.com>\nCo-authored-by: B <b@example.com>\nReviewed-by : C <c@example.com>",
+			want: []Trailer{
+				{Key: "Signed-off-by", Value: "A <a@example.com>"},
+				{Key: "Co-authored-by", Value: "B <b@example.com>"},
+				{Key: "Reviewed-by", Value: "C <c@example.com>"},
+			},
+		},
+		{
+			name:    "folded value",
+			message: "Fix bug\n\nNote: first line\n  second line\nAcked-by: D",
+			want:    []Trailer{{Key: "Note", Value: "first line second line"}, {Key: "Acked-by", Value: "D"}},
+		},
+		{
+			name:    "only the last paragraph",
+			message: "Fix bug\n\nFixes: #1\n\nPlain prose.",
+		},
+		{
+			name:    "prose line rejects the block",
+			message: "Fix bug\n\nCo-authored-by: B <b@example.com>\nthanks everyone",
+		},
+		{
+			name:    "git generated trailer allows some prose",
+			message: "Fix bug\n\nthanks everyone\nand more\nSigned-off-by: A <a@example.com>",
+			want:    []Trailer{{Key: "Signed-off-by", Value: "A <a@example.com>"}},
+		},
+		{
+			name:    "too much prose",
+			message: "Fix bug\n\none\ntwo\nthree\nfour\nSigned-off-by: A <a@example.com>",
+		},
+		{
+			name:    "keys cannot contain spaces",
+			message: "Fix bug\n\nSee also: the docs",
+		},
+		{
+			name:    "comments are ignored",
+			message: "Fix bug\n\nReviewed-by: C\n# Please enter the commit message",
+			want:    []Trailer{{Key: "Reviewed-by", Value: "C"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTrailers(tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseTrailers() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommitBodyTrailers(t *testing.T) {
+	body := []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"committer Jane Doe <jane@example.com> 1700000000 +0000\n\n" +
+		"Pair on parser\n\n" +
+		"Co-authored-by: Sam Roe <sam@example.com>\n" +
+		"Co-authored-by: Jane Doe <JANE@example.com>\n" +
+		"co-authored-by: broken\n" +
+		"Signed-off-by: Jane Doe <jane@example.com>\n")
+	commit, err := parseCommitBody(body, "")
+	if err != nil {
+		t.Fatalf("parseCommitBody() error = %v", err)
+	}
+	if len(commit.Trailers) != 4 {
+		t.Fatalf("Trailers = %#v", commit.Trailers)
+	}
+	want := []Signature{{Name: "Sam Roe", Email: "sam@example.com", When: commit.Author.When}}
+	if !reflect.DeepEqual(commit.CoAuthors, want) {
+		t.Fatalf("CoAuthors = %#v, want %#v", commit.CoAuthors, want)
+	}
+	if !commit.CoAuthors[0].When.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("co-author date = %v", commit.CoAuthors[0].When)
+	}
+	if got := commit.TrailerValues("signed-off-by"); !reflect.DeepEqual(got, []string{"Jane Doe <jane@example.com>"}) {
+		t.Fatalf("TrailerValues() = %q", got)
+	}
+}
@@ -220,8 +220,14 @@ func (r *Repository) classifyUpstreamRelation(localHash, upstreamHash Hash) (sta
 }
 
 func countExclusiveCommits(r *Repository, include, exclude Hash) int {
+	return len(exclusiveCommits(r, include, exclude))
+}
+
+// exclusiveCommits returns the commits reachable from include but not from
+// exclude, like git rev-list exclude..include, in no particular order.
+func exclusiveCommits(r *Repository, include, exclude Hash) []Hash {
 	if include == "" || exclude == "" {
-		return 0
+		return nil
 	}
 
 	r.mu.RLock()
@@ -248,7 +254,7 @@ func countExclusiveCommits(r *Repository, include, exclude Hash) int {
 
 	visited := make(map[Hash]struct{})
 	stack = []Hash{include}
-	count := 0
+	var result []Hash
 	for len(stack) > 0 {
 		hash := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
@@ -264,13 +270,13 @@ func countExclusiveCommits(r *Repository, include, exclude Hash) int {
 		if !ok {
 			continue
 		}
-		count++
+		result = append(result, hash)
 		for _, parent := range commit.Parents {
 			stack = append(stack, parent)
 		}
 	}
 
-	return count
+	return result
 }
 
 func parseBranchTrackingFromConfig(config string) map[string]branchTrackingConfig {
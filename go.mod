@@ -6,6 +6,7 @@ require (
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/pterm/pterm v0.12.83
+	golang.org/x/crypto v0.48.0
 	golang.org/x/term v0.40.0
 )
 
@@ -35,7 +35,9 @@ type Response struct {
 	End          string                `json:"end,omitempty"`
 	Velocity     analyticsVelocity     `json:"velocity"`
 	Authors      analyticsAuthors      `json:"authors"`
+	TimeZone     string                `json:"timeZone"`
 	Heatmap      analyticsHeatmap      `json:"heatmap"`
+	WorkPatterns analyticsWorkPatterns `json:"workPatterns"`
 	Merges       analyticsMerges       `json:"merges"`
 	ChangeSize   analyticsChangeSize   `json:"changeSize"`
 	Rework       analyticsRework       `json:"rework"`
@@ -43,6 +45,8 @@ type Response struct {
 	Hotspots     []analyticsHotspot    `json:"hotspots"`
 	Deltas       analyticsDeltas       `json:"deltas"`
 	DiffCoverage analyticsDiffCoverage `json:"diffCoverage"`
+	Coupling     analyticsCoupling     `json:"coupling"`
+	Scope        *analyticsScope       `json:"scope,omitempty"`
 	GeneratedAt  string                `json:"generatedAt"`
 }
 
@@ -141,10 +145,12 @@ type analyticsDiffCoverage struct {
 }
 
 type analyticsCommitEntry struct {
-	Hash    gitcore.Hash
-	TS      time.Time
-	Parents int
-	Author  gitcore.Signature
+	Hash      gitcore.Hash
+	TS        time.Time
+	Committed time.Time
+	Parents   int
+	Author    gitcore.Signature
+	CoAuthors []gitcore.Signature
 }
 
 type analyticsDiffEntry struct {
@@ -156,6 +162,7 @@ type analyticsDiffInsights struct {
 	LargeChangeShare       float64
 	OwnershipConcentration float64
 	Hotspots               []analyticsHotspot
+	Coupling               analyticsCoupling
 }
 
 type analyticsHotspot struct {
@@ -176,6 +183,25 @@ type Query struct {
 	HasRange bool
 	Start    time.Time
 	End      time.Time
+	// Paths limits analytics to commits that change a file under one of these
+	// prefixes, and the file-level sections to those files.
+	Paths []string
+	// Revision limits analytics to commits reachable from a revision, or
+	// selected by an "A..B" range.
+	Revision string
+	// Authors keeps commits whose author or a co-author has a name or email
+	// equal to one of these, lowercased, or an email ending with an
+	// "@domain" entry.
+	Authors []string
+	// Merges is MergesExclude, MergesInclude, or MergesOnly. Empty means
+	// MergesExclude.
+	Merges string
+	// TimeZone buckets the heatmap and per-author hours: TimeZoneUTC,
+	// TimeZoneLocal, or an IANA zone name. Empty means TimeZoneUTC.
+	TimeZone string
+	// Store, when set, keeps per-week commit diffs between builds so only
+	// weeks with new commits are diffed again.
+	Store Store
 }
 
 func Build(repo *gitcore.Repository, q Query) (*Response, error) {
@@ -183,32 +209,47 @@ func Build(repo *gitcore.Repository, q Query) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	zone, loc, err := parseTimeZone(q.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	q.TimeZone = zone
+
+	var selected map[gitcore.Hash]struct{}
+	if q.Revision != "" {
+		if selected, err = revisionCommits(repo, q.Revision); err != nil {
+			return nil, err
+		}
+	}
 
 	commitsMap := repo.Commits()
+	all := make([]analyticsCommitEntry, 0, len(commitsMap))
 	entries := make([]analyticsCommitEntry, 0, len(commitsMap))
 	for h, c := range commitsMap {
 		if c == nil {
 			continue
 		}
-		entries = append(entries, analyticsCommitEntry{
-			Hash:    h,
-			TS:      c.Author.When,
-			Parents: len(c.Parents),
-			Author:  c.Author,
-		})
-	}
-	if len(entries) == 0 {
-		resp := &Response{
-			Period:      canonical,
-			Summary:     []analyticsSummary{},
-			Hotspots:    []analyticsHotspot{},
-			GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		entry := analyticsCommitEntry{
+			Hash:      h,
+			TS:        c.Author.When,
+			Committed: c.Committer.When,
+			Parents:   len(c.Parents),
+			Author:    c.Author,
+			CoAuthors: c.CoAuthors,
 		}
-		if q.HasRange {
-			resp.Start = q.Start.Format(time.RFC3339)
-			resp.End = q.End.Format(time.RFC3339)
+		all = append(all, entry)
+		if selected != nil {
+			if _, ok := selected[h]; !ok {
+				continue
+			}
 		}
-		return resp, nil
+		if len(q.Authors) > 0 && !commitAuthorMatches(c, q.Authors) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return emptyResponse(q, canonical), nil
 	}
 
 	sort.Slice(entries, func(i, j int) bool {
@@ -218,42 +259,36 @@ func Build(repo *gitcore.Repository, q Query) (*Response, error) {
 		return entries[i].TS.Before(entries[j].TS)
 	})
 
+	// Week buckets are built from every commit so stored diffs are shared
+	// by all scopes.
+	diffs := newCommitDiffs(repo, commitsMap, all, q.Store)
+	diffs.paths = q.Paths
+
 	now := time.Now().UTC()
 	windowStart, windowEnd := analyticsCurrentWindow(q, months, entries, now)
-	filtered := filterEntriesForWindow(entries, windowStart, windowEnd)
+	filtered, workEntries := applyMergeMode(filterEntriesByPaths(diffs, filterEntriesForWindow(entries, windowStart, windowEnd)), q.Merges)
 	if len(filtered) == 0 {
-		resp := &Response{
-			Period:      canonical,
-			Summary:     []analyticsSummary{},
-			Hotspots:    []analyticsHotspot{},
-			GeneratedAt: time.Now().UTC().Format(time.RFC3339),
-		}
-		if q.HasRange {
-			resp.Start = q.Start.Format(time.RFC3339)
-			resp.End = q.End.Format(time.RFC3339)
-		}
-		return resp, nil
+		return emptyResponse(q, canonical), nil
 	}
-	workEntries := filterNonMergeEntries(filtered)
 
 	velocity := analyticsVelocity{}
 	if len(workEntries) > 0 {
 		velocity = computeVelocity(workEntries)
 	}
 	authors := computeAuthors(workEntries)
-	heatmap := computeHeatmap(workEntries)
+	heatmap := computeHeatmap(workEntries, loc)
+	patterns := computeWorkPatterns(workEntries, loc)
 	merges := computeMerges(filtered)
-	changeSize, rework, coverage, insights := computeDiffAnalytics(repo, commitsMap, workEntries)
+	changeSize, rework, coverage, insights := computeDiffAnalytics(diffs, workEntries)
 	prevStart, prevEnd := analyticsPreviousWindow(windowStart, windowEnd)
-	previous := filterEntriesForWindow(entries, prevStart, prevEnd)
-	prevWork := filterNonMergeEntries(previous)
+	previous, prevWork := applyMergeMode(filterEntriesByPaths(diffs, filterEntriesForWindow(entries, prevStart, prevEnd)), q.Merges)
 	prevMerges := analyticsMerges{}
 	prevChangeSize := analyticsChangeSize{}
 	prevRework := analyticsRework{}
 	prevInsights := analyticsDiffInsights{}
 	if len(previous) > 0 {
 		prevMerges = computeMerges(previous)
-		prevChangeSize, prevRework, _, prevInsights = computeDiffAnalytics(repo, commitsMap, prevWork)
+		prevChangeSize, prevRework, _, prevInsights = computeDiffAnalytics(diffs, prevWork)
 	}
 	deltas := buildAnalyticsDeltas(
 		rework.AvgRate, prevRework.AvgRate,
@@ -268,7 +303,9 @@ func Build(repo *gitcore.Repository, q Query) (*Response, error) {
 		Period:       canonical,
 		Velocity:     velocity,
 		Authors:      authors,
+		TimeZone:     zone,
 		Heatmap:      heatmap,
+		WorkPatterns: patterns,
 		Merges:       merges,
 		ChangeSize:   changeSize,
 		Rework:       rework,
@@ -276,6 +313,8 @@ func Build(repo *gitcore.Repository, q Query) (*Response, error) {
 		Hotspots:     insights.Hotspots,
 		Deltas:       deltas,
 		DiffCoverage: coverage,
+		Coupling:     insights.Coupling,
+		Scope:        q.scope(),
 		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
 	}
 	if q.HasRange {
@@ -285,11 +324,44 @@ func Build(repo *gitcore.Repository, q Query) (*Response, error) {
 	return resp, nil
 }
 
+func emptyResponse(q Query, canonical string) *Response {
+	resp := &Response{
+		Period:       canonical,
+		TimeZone:     q.TimeZone,
+		WorkPatterns: emptyWorkPatterns(),
+		Summary:      []analyticsSummary{},
+		Hotspots:     []analyticsHotspot{},
+		Coupling:     emptyAnalyticsCoupling(),
+		Scope:        q.scope(),
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	if q.HasRange {
+		resp.Start = q.Start.Format(time.RFC3339)
+		resp.End = q.End.Format(time.RFC3339)
+	}
+	return resp
+}
+
 func CacheKey(repo *gitcore.Repository, queryPart string) string {
 	return "analytics:v1:" + queryPart + ":head:" + string(repo.Head()) + ":count:" + strconv.Itoa(repo.CommitCount())
 }
 
-func ParseQuery(periodRaw string, startRaw string, endRaw string) (Query, error) {
+// ParseQuery validates raw analytics parameters. The returned query's
+// CacheKey identifies the period or date range together with any filters.
+func ParseQuery(p QueryParams) (Query, error) {
+	q, err := parseWindow(p.Period, p.Start, p.End)
+	if err != nil {
+		return Query{}, err
+	}
+	suffix, err := parseScope(p, &q)
+	if err != nil {
+		return Query{}, err
+	}
+	q.CacheKey += suffix
+	return q, nil
+}
+
+func parseWindow(periodRaw string, startRaw string, endRaw string) (Query, error) {
 	periodRaw = strings.TrimSpace(periodRaw)
 	startRaw = strings.TrimSpace(startRaw)
 	endRaw = strings.TrimSpace(endRaw)
@@ -483,6 +555,23 @@ func computeVelocity(entries []analyticsCommitEntry) analyticsVelocity {
 	}
 }
 
+// commitCredits returns the people credited with a commit: its author, then
+// each distinct co-author.
+func commitCredits(author gitcore.Signature, coAuthors []gitcore.Signature) []gitcore.Signature {
+	credits := []gitcore.Signature{author}
+	if len(coAuthors) == 0 {
+		return credits
+	}
+	seen := map[string]bool{ownershipAuthorKey(author): true}
+	for _, sig := range coAuthors {
+		if key := ownershipAuthorKey(sig); !seen[key] {
+			seen[key] = true
+			credits = append(credits, sig)
+		}
+	}
+	return credits
+}
+
 func computeAuthors(entries []analyticsCommitEntry) analyticsAuthors {
 	type agg struct {
 		name  string
@@ -491,18 +580,20 @@ func computeAuthors(entries []analyticsCommitEntry) analyticsAuthors {
 	}
 	byEmail := make(map[string]*agg)
 	for _, e := range entries {
-		email := e.Author.Email
-		if email == "" {
-			email = "unknown"
-		}
-		name := e.Author.Name
-		if name == "" {
-			name = email
-		}
-		if cur, ok := byEmail[email]; ok {
-			cur.count++
-		} else {
-			byEmail[email] = &agg{name: name, email: email, count: 1}
+		for _, sig := range commitCredits(e.Author, e.CoAuthors) {
+			email := sig.Email
+			if email == "" {
+				email = "unknown"
+			}
+			name := sig.Name
+			if name == "" {
+				name = email
+			}
+			if cur, ok := byEmail[email]; ok {
+				cur.count++
+			} else {
+				byEmail[email] = &agg{name: name, email: email, count: 1}
+			}
 		}
 	}
 
@@ -526,16 +617,14 @@ func computeAuthors(entries []analyticsCommitEntry) analyticsAuthors {
 	}
 }
 
-func computeHeatmap(entries []analyticsCommitEntry) analyticsHeatmap {
+// computeHeatmap counts commits by weekday and hour in loc, or in each
+// commit's recorded offset when loc is nil.
+func computeHeatmap(entries []analyticsCommitEntry, loc *time.Location) analyticsHeatmap {
 	var grid [7][24]int
 	max := 0
 	for _, e := range entries {
-		d := e.TS.UTC()
-		jsDay := int(d.Weekday())
-		day := jsDay - 1
-		if jsDay == 0 {
-			day = 6
-		}
+		d := inZone(e.TS, loc)
+		day := heatmapDay(d)
 		hour := d.Hour()
 		grid[day][hour]++
 		if grid[day][hour] > max {
@@ -579,8 +668,7 @@ func computeMerges(entries []analyticsCommitEntry) analyticsMerges {
 }
 
 func computeDiffAnalytics(
-	repo *gitcore.Repository,
-	commitsMap map[gitcore.Hash]*gitcore.Commit,
+	diffs *commitDiffs,
 	filtered []analyticsCommitEntry,
 ) (analyticsChangeSize, analyticsRework, analyticsDiffCoverage, analyticsDiffInsights) {
 	change := analyticsChangeSize{Buckets: make([]analyticsBucket, 0, len(analyticsSizeBuckets))}
@@ -589,7 +677,7 @@ func computeDiffAnalytics(
 	}
 
 	coverage := analyticsDiffCoverage{EligibleCommits: len(filtered)}
-	insights := analyticsDiffInsights{Hotspots: []analyticsHotspot{}}
+	insights := analyticsDiffInsights{Hotspots: []analyticsHotspot{}, Coupling: emptyAnalyticsCoupling()}
 	if len(filtered) == 0 {
 		return change, analyticsRework{}, coverage, insights
 	}
@@ -606,18 +694,7 @@ func computeDiffAnalytics(
 	reworkEntries := make([]analyticsDiffEntry, 0, len(desc))
 	analyzed := make([]analyticsAnalyzedCommit, 0, len(desc))
 	for _, e := range desc {
-		c := commitsMap[e.Hash]
-		if c == nil {
-			continue
-		}
-		var parentTreeHash gitcore.Hash
-		if len(c.Parents) > 0 {
-			if p, ok := commitsMap[c.Parents[0]]; ok && p != nil {
-				parentTreeHash = p.Tree
-			}
-		}
-
-		entries, err := gitcore.TreeDiff(repo, parentTreeHash, c.Tree, "")
+		files, err := diffs.files(e)
 		if err != nil {
 			if strings.Contains(err.Error(), "diff too large") {
 				coverage.TooLargeErrors++
@@ -628,25 +705,26 @@ func computeDiffAnalytics(
 		}
 
 		coverage.AnalyzedCommits++
-		files := make([]string, 0, len(entries))
-		for _, de := range entries {
-			files = append(files, de.Path)
-		}
 		reworkEntries = append(reworkEntries, analyticsDiffEntry{
 			TS:    e.TS.UnixMilli(),
 			Files: files,
 		})
-		size := len(entries)
+		size := len(files)
 		sizes = append(sizes, size)
 		author := e.Author.Email
 		if author == "" {
 			author = "unknown"
 		}
+		var coAuthors []string
+		for _, sig := range commitCredits(e.Author, e.CoAuthors)[1:] {
+			coAuthors = append(coAuthors, sig.Email)
+		}
 		analyzed = append(analyzed, analyticsAnalyzedCommit{
-			TS:     e.TS.UnixMilli(),
-			Author: author,
-			Files:  files,
-			Large:  size > 50,
+			TS:        e.TS.UnixMilli(),
+			Author:    author,
+			CoAuthors: coAuthors,
+			Files:     files,
+			Large:     size > 50,
 		})
 	}
 
@@ -670,14 +748,18 @@ func computeDiffAnalytics(
 
 	rework := computeRework(reworkEntries)
 	insights = computeDiffInsights(analyzed)
+	insights.Coupling = computeCoupling(analyzed)
 	return change, rework, coverage, insights
 }
 
+// analyticsAnalyzedCommit is a diffed commit. Hotspots credit its
+// co-authors' emails alongside Author; coupling sessions follow Author alone.
 type analyticsAnalyzedCommit struct {
-	TS     int64
-	Author string
-	Files  []string
-	Large  bool
+	TS        int64
+	Author    string
+	CoAuthors []string
+	Files     []string
+	Large     bool
 }
 
 type analyticsHotspotAgg struct {
@@ -691,7 +773,7 @@ type analyticsHotspotAgg struct {
 
 func computeDiffInsights(analyzed []analyticsAnalyzedCommit) analyticsDiffInsights {
 	if len(analyzed) == 0 {
-		return analyticsDiffInsights{Hotspots: []analyticsHotspot{}}
+		return analyticsDiffInsights{Hotspots: []analyticsHotspot{}, Coupling: emptyAnalyticsCoupling()}
 	}
 	sort.Slice(analyzed, func(i, j int) bool { return analyzed[i].TS < analyzed[j].TS })
 	moduleAgg := make(map[string]*analyticsHotspotAgg)
@@ -718,6 +800,9 @@ func computeDiffInsights(analyzed []analyticsAnalyzedCommit) analyticsDiffInsigh
 				agg.largeTouches++
 			}
 			agg.authorTouches[c.Author]++
+			for _, coAuthor := range c.CoAuthors {
+				agg.authorTouches[coAuthor]++
+			}
 			if last, ok := lastTouchByFile[file]; ok && c.TS-last <= windowMS {
 				agg.reworkTouches++
 			}
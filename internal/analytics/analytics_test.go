@@ -1,6 +1,8 @@
 package analytics
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -83,3 +85,52 @@ func TestFilterNonMergeEntries(t *testing.T) {
 		}
 	}
 }
+
+func TestCoAuthorCredit(t *testing.T) {
+	h := newHistoryRepo(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	h.commit("alice", "pkg/a.go", "1\n")
+	if err := os.WriteFile(filepath.Join(h.Dir, "pkg", "a.go"), []byte("1\n2\n3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h.git("alice", "add", "-A")
+	h.git("alice", "commit", "-q", "-m", "pair on a\n\nCo-authored-by: Bob <bob@example.com>\nCo-authored-by: alice <alice@example.com>")
+	repo := h.open()
+
+	q, err := ParseQuery(QueryParams{Authors: []string{"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := Build(repo, q)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if resp.Authors.TotalInPeriod != 1 || len(resp.Authors.Authors) != 2 {
+		t.Fatalf("authors filtered to bob = %+v", resp.Authors)
+	}
+
+	q, _ = ParseQuery(QueryParams{})
+	if resp, err = Build(repo, q); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	counts := make(map[string]int)
+	for _, a := range resp.Authors.Authors {
+		counts[a.Email] = a.Count
+	}
+	if resp.Authors.TotalInPeriod != 2 || counts["alice@example.com"] != 2 || counts["bob@example.com"] != 1 {
+		t.Fatalf("authors = %+v", resp.Authors)
+	}
+	if len(resp.Hotspots) != 1 || resp.Hotspots[0].TopAuthor != "alice@example.com" || resp.Hotspots[0].TopAuthorShare != 100 {
+		t.Fatalf("hotspots = %+v", resp.Hotspots)
+	}
+
+	ownership, err := BuildOwnership(repo, OwnershipOptions{})
+	if err != nil {
+		t.Fatalf("BuildOwnership() error = %v", err)
+	}
+	if shares := ownership.Files["pkg/a.go"]; shares["alice@example.com"].Lines != 3 || shares["bob@example.com"].Lines != 2 {
+		t.Fatalf("a.go shares = %+v", shares)
+	}
+	if ownership.Authors["bob@example.com"].Name != "Bob" {
+		t.Fatalf("authors = %+v", ownership.Authors)
+	}
+}
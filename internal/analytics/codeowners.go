@@ -0,0 +1,243 @@
+package analytics
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const analyticsCodeOwnersMaxUnowned = 50
+
+// CodeOwnersDrift compares the owners CODEOWNERS declares with the people
+// who actually change the code.
+type CodeOwnersDrift struct {
+	// File is the CODEOWNERS path in HEAD, or empty when there is none.
+	File              string                    `json:"file"`
+	Errors            []gitcore.CodeOwnersError `json:"errors"`
+	Files             int                       `json:"files"`
+	OwnedFiles        int                       `json:"ownedFiles"`
+	UnownedFiles      int                       `json:"unownedFiles"`
+	Unowned           []codeOwnersUnowned       `json:"unowned"`
+	Owners            []codeOwnersOwner         `json:"owners"`
+	StaleCount        int                       `json:"staleCount"`
+	NeverTouchedCount int                       `json:"neverTouchedCount"`
+	InactiveMonths    int                       `json:"inactiveMonths"`
+	Coverage          analyticsDiffCoverage     `json:"coverage"`
+	GeneratedAt       string                    `json:"generatedAt"`
+}
+
+// codeOwnersUnowned groups files no rule assigns an owner by module, with
+// the people who hold most of their knowledge.
+type codeOwnersUnowned struct {
+	Path            string   `json:"path"`
+	Files           int      `json:"files"`
+	TopContributors []string `json:"topContributors"`
+}
+
+// codeOwnersOwner is one declared owner. Kind is "user", "team", or "email";
+// teams cannot be matched to commit authors, so only their file counts and
+// contributors are reported. Share is the owner's part of the knowledge in
+// the files they own. Stale owners have not committed in InactiveMonths;
+// NeverTouched owners have committed, but never to their own files.
+type codeOwnersOwner struct {
+	Owner           string   `json:"owner"`
+	Kind            string   `json:"kind"`
+	Resolved        []string `json:"resolved"`
+	Files           int      `json:"files"`
+	Lines           int      `json:"lines"`
+	Share           float64  `json:"share"`
+	LastActive      string   `json:"lastActive,omitempty"`
+	Stale           bool     `json:"stale"`
+	NeverTouched    bool     `json:"neverTouched"`
+	TopContributors []string `json:"topContributors"`
+}
+
+// BuildCodeOwnersDrift reports unowned files, stale owners, and owners who
+// never touch their code. co may be nil when the repository has no
+// CODEOWNERS file; inactiveMonths of zero or less means six months.
+func BuildCodeOwnersDrift(o *Ownership, co *gitcore.CodeOwners, inactiveMonths int) *CodeOwnersDrift {
+	if inactiveMonths <= 0 {
+		inactiveMonths = analyticsOwnershipInactiveMonths
+	}
+	cutoff := o.GeneratedAt.AddDate(0, -inactiveMonths, 0)
+	drift := &CodeOwnersDrift{
+		Errors:         []gitcore.CodeOwnersError{},
+		Unowned:        []codeOwnersUnowned{},
+		Owners:         []codeOwnersOwner{},
+		InactiveMonths: inactiveMonths,
+		Coverage:       o.Coverage,
+		GeneratedAt:    o.GeneratedAt.Format(time.RFC3339),
+	}
+	if co != nil {
+		drift.File = co.Path
+		drift.Errors = append(drift.Errors, co.Errors...)
+	}
+
+	files := make([]string, 0, len(o.Files))
+	for file := range o.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	drift.Files = len(files)
+
+	owned := make(map[string][]string)
+	var ownerOrder []string
+	unowned := make(map[string][]string)
+	var moduleOrder []string
+	for _, file := range files {
+		owners := co.Owners(file)
+		if len(owners) == 0 {
+			module := analyticsModuleKey(file)
+			if _, ok := unowned[module]; !ok {
+				moduleOrder = append(moduleOrder, module)
+			}
+			unowned[module] = append(unowned[module], file)
+			drift.UnownedFiles++
+			continue
+		}
+		drift.OwnedFiles++
+		for _, owner := range owners {
+			if _, ok := owned[owner]; !ok {
+				ownerOrder = append(ownerOrder, owner)
+			}
+			owned[owner] = append(owned[owner], file)
+		}
+	}
+
+	for _, module := range moduleOrder {
+		drift.Unowned = append(drift.Unowned, codeOwnersUnowned{
+			Path:            module,
+			Files:           len(unowned[module]),
+			TopContributors: o.mainAuthorNames(unowned[module]),
+		})
+	}
+	sort.SliceStable(drift.Unowned, func(i, j int) bool { return drift.Unowned[i].Files > drift.Unowned[j].Files })
+	if len(drift.Unowned) > analyticsCodeOwnersMaxUnowned {
+		drift.Unowned = drift.Unowned[:analyticsCodeOwnersMaxUnowned]
+	}
+
+	for _, owner := range ownerOrder {
+		entry := o.codeOwner(owner, owned[owner], cutoff)
+		if entry.Stale {
+			drift.StaleCount++
+		}
+		if entry.NeverTouched {
+			drift.NeverTouchedCount++
+		}
+		drift.Owners = append(drift.Owners, entry)
+	}
+	sort.SliceStable(drift.Owners, func(i, j int) bool {
+		if drift.Owners[i].Files != drift.Owners[j].Files {
+			return drift.Owners[i].Files > drift.Owners[j].Files
+		}
+		return drift.Owners[i].Owner < drift.Owners[j].Owner
+	})
+	return drift
+}
+
+// codeOwner measures how much of files a declared owner actually changed.
+func (o *Ownership) codeOwner(owner string, files []string, cutoff time.Time) codeOwnersOwner {
+	kind, keys := o.resolveCodeOwner(owner)
+	entry := codeOwnersOwner{
+		Owner:           owner,
+		Kind:            kind,
+		Resolved:        []string{},
+		Files:           len(files),
+		TopContributors: o.mainAuthorNames(files),
+	}
+	var last time.Time
+	for _, key := range keys {
+		a := o.Authors[key]
+		entry.Resolved = append(entry.Resolved, a.Name)
+		if a.LastActive.After(last) {
+			last = a.LastActive
+		}
+	}
+	if len(keys) == 0 {
+		return entry
+	}
+	entry.LastActive = last.Format(time.RFC3339)
+	entry.Stale = last.Before(cutoff)
+
+	total, mine := 0.0, 0.0
+	for _, file := range files {
+		for author, s := range o.Files[file] {
+			total += s.Knowledge
+			if slices.Contains(keys, author) {
+				mine += s.Knowledge
+				entry.Lines += s.Lines
+			}
+		}
+	}
+	if total > 0 {
+		entry.Share = mine * 100.0 / total
+	}
+	entry.NeverTouched = entry.Lines == 0
+	return entry
+}
+
+// resolveCodeOwner matches a declared owner to commit authors. An email
+// owner matches that email. A @user owner matches authors whose email local
+// part is the user name, whose GitHub noreply address names the user, or
+// whose name without spaces is the user name. Teams never match.
+func (o *Ownership) resolveCodeOwner(owner string) (string, []string) {
+	name, isHandle := strings.CutPrefix(owner, "@")
+	if !isHandle {
+		key := strings.ToLower(owner)
+		if _, ok := o.Authors[key]; ok {
+			return "email", []string{key}
+		}
+		return "email", nil
+	}
+	if strings.Contains(name, "/") {
+		return "team", nil
+	}
+	name = strings.ToLower(name)
+	var keys []string
+	for key, a := range o.Authors {
+		local, domain, _ := strings.Cut(strings.ToLower(a.Email), "@")
+		if domain == "users.noreply.github.com" {
+			if _, handle, ok := strings.Cut(local, "+"); ok {
+				local = handle
+			}
+		}
+		if local == name || strings.ToLower(strings.ReplaceAll(a.Name, " ", "")) == name {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return "user", keys
+}
+
+// mainAuthorNames names the authors who together hold half the knowledge
+// in files.
+func (o *Ownership) mainAuthorNames(files []string) []string {
+	totals := make(map[string]ownershipShare)
+	total := 0.0
+	for _, file := range files {
+		for author, s := range o.Files[file] {
+			t := totals[author]
+			t.Knowledge += s.Knowledge
+			totals[author] = t
+			total += s.Knowledge
+		}
+	}
+	ranked := make([]string, 0, len(totals))
+	for author := range totals {
+		ranked = append(ranked, author)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if totals[ranked[i]].Knowledge != totals[ranked[j]].Knowledge {
+			return totals[ranked[i]].Knowledge > totals[ranked[j]].Knowledge
+		}
+		return ranked[i] < ranked[j]
+	})
+	names := []string{}
+	for _, key := range ownershipMainAuthors(ranked, totals, total) {
+		names = append(names, o.Authors[key].Name)
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func TestBuildCodeOwnersDrift(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	h := newHistoryRepo(t, now.AddDate(-2, 0, 0))
+	h.commit("alice", "pkg/a.go", "a1\na2\n")
+	h.commit("alice", "pkg/b.go", "b1\n")
+	h.When = now.AddDate(0, 0, -3)
+	h.commit("bob", "web/app.js", "app\n")
+	h.commit("carol", "docs/guide.md", "guide\n")
+	h.commit("carol", "CODEOWNERS", "* @org/core\n")
+	repo := h.open()
+
+	ownership, err := BuildOwnership(repo, OwnershipOptions{Now: now})
+	if err != nil {
+		t.Fatalf("BuildOwnership() error = %v", err)
+	}
+	co := gitcore.ParseCodeOwners("CODEOWNERS", []byte(`
+*       @org/core
+/pkg/   @alice bob@example.com
+/web/   @carol @dave
+/docs/
+`))
+
+	drift := BuildCodeOwnersDrift(ownership, co, 0)
+	if drift.File != "CODEOWNERS" || drift.Files != 5 || drift.OwnedFiles != 4 || drift.UnownedFiles != 1 || drift.InactiveMonths != 6 {
+		t.Fatalf("drift = %+v", drift)
+	}
+	if len(drift.Unowned) != 1 || drift.Unowned[0].Path != "docs/" || !slices.Equal(drift.Unowned[0].TopContributors, []string{"carol"}) {
+		t.Fatalf("unowned = %+v", drift.Unowned)
+	}
+
+	want := []struct {
+		owner, kind         string
+		resolved            []string
+		files               int
+		stale, neverTouched bool
+		top                 []string
+	}{
+		{owner: "@alice", kind: "user", resolved: []string{"alice"}, files: 2, stale: true, top: []string{"alice"}},
+		{owner: "bob@example.com", kind: "email", resolved: []string{"bob"}, files: 2, neverTouched: true, top: []string{"alice"}},
+		{owner: "@carol", kind: "user", resolved: []string{"carol"}, files: 1, neverTouched: true, top: []string{"bob"}},
+		{owner: "@dave", kind: "user", resolved: []string{}, files: 1, top: []string{"bob"}},
+		{owner: "@org/core", kind: "team", resolved: []string{}, files: 1, top: []string{"carol"}},
+	}
+	if len(drift.Owners) != len(want) {
+		t.Fatalf("owners = %+v", drift.Owners)
+	}
+	for i, w := range want {
+		got := drift.Owners[i]
+		if got.Owner != w.owner || got.Kind != w.kind || !slices.Equal(got.Resolved, w.resolved) || got.Files != w.files ||
+			got.Stale != w.stale || got.NeverTouched != w.neverTouched || !slices.Equal(got.TopContributors, w.top) {
+			t.Errorf("owner %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if alice := drift.Owners[0]; alice.Lines != 3 || alice.Share != 100 {
+		t.Fatalf("@alice = %+v, want all 3 lines of pkg/", alice)
+	}
+	if drift.StaleCount != 1 || drift.NeverTouchedCount != 2 {
+		t.Fatalf("stale = %d, never touched = %d", drift.StaleCount, drift.NeverTouchedCount)
+	}
+
+	none := BuildCodeOwnersDrift(ownership, nil, 12)
+	if none.File != "" || none.UnownedFiles != 5 || len(none.Owners) != 0 || len(none.Unowned) != 4 {
+		t.Fatalf("drift without CODEOWNERS = %+v", none)
+	}
+}
+
+func TestResolveCodeOwner(t *testing.T) {
+	o := &Ownership{Authors: map[string]ownershipAuthor{
+		"123+evehub@users.noreply.github.com": {Name: "Eve Smith", Email: "123+evehub@users.noreply.github.com"},
+		"eve@corp.example":                    {Name: "Eve Smith", Email: "eve@corp.example"},
+		"frank@example.com":                   {Name: "Frank", Email: "Frank@example.com"},
+	}}
+	tests := []struct {
+		owner string
+		kind  string
+		keys  []string
+	}{
+		{owner: "@evehub", kind: "user", keys: []string{"123+evehub@users.noreply.github.com"}},
+		{owner: "@EveSmith", kind: "user", keys: []string{"123+evehub@users.noreply.github.com", "eve@corp.example"}},
+		{owner: "@eve", kind: "user", keys: []string{"eve@corp.example"}},
+		{owner: "FRANK@example.com", kind: "email", keys: []string{"frank@example.com"}},
+		{owner: "@org/eve", kind: "team"},
+		{owner: "@nobody", kind: "user"},
+	}
+	for _, tt := range tests {
+		kind, keys := o.resolveCodeOwner(tt.owner)
+		if kind != tt.kind || !slices.Equal(keys, tt.keys) {
+			t.Errorf("resolveCodeOwner(%q) = %s %v, want %s %v", tt.owner, kind, keys, tt.kind, tt.keys)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package analytics
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	compositionStoreKeyPrefix = "analytics-blob-lines:v1:"
+
+	// analyticsCompositionMaxSamples caps the number of trees walked. Longer
+	// histories are sampled every few weeks instead of weekly.
+	analyticsCompositionMaxSamples = 260
+	// analyticsCompositionTopSeries is the number of languages and
+	// directories reported by name; the rest are folded into "Other".
+	analyticsCompositionTopSeries = 12
+
+	compositionRootDir     = "(root)"
+	compositionOtherSeries = "Other"
+)
+
+// CompositionOptions configures BuildComposition.
+type CompositionOptions struct {
+	// Store, when set, keeps per-blob line counts between builds.
+	Store Store
+}
+
+// Composition is the size of the codebase over time: lines of code per
+// language and per top-level directory at weekly points along HEAD's
+// first-parent history. Each sample is the last first-parent commit
+// committed by the end of its week. Vendored and generated files are left
+// out using linguist's path rules and generated-code headers, and
+// .gitattributes linguist-vendored, linguist-generated,
+// linguist-documentation, and linguist-language override them. Binary files
+// are not counted.
+type Composition struct {
+	Samples []CompositionSample `json:"samples"`
+	// Languages and Directories hold one line count per sample, largest
+	// at the last sample first.
+	Languages   []CompositionSeries `json:"languages"`
+	Directories []CompositionSeries `json:"directories"`
+	// Excluded counts the files left out of the last sample.
+	Excluded    CompositionExcluded `json:"excluded"`
+	GeneratedAt string              `json:"generatedAt"`
+}
+
+// CompositionSample is one sampled commit: the start of its week in Unix
+// milliseconds and the files and lines counted in its tree.
+type CompositionSample struct {
+	TS     int64  `json:"ts"`
+	Commit string `json:"commit"`
+	Files  int    `json:"files"`
+	Lines  int    `json:"lines"`
+}
+
+// CompositionSeries is the line count of one language or top-level
+// directory at each sample.
+type CompositionSeries struct {
+	Name  string `json:"name"`
+	Lines []int  `json:"lines"`
+}
+
+// CompositionExcluded counts the files left out of a sample, by reason.
+type CompositionExcluded struct {
+	Vendored      int `json:"vendored"`
+	Generated     int `json:"generated"`
+	Documentation int `json:"documentation"`
+	Binary        int `json:"binary"`
+}
+
+// compositionBlob is what a blob contributes wherever it appears. Blobs are
+// content-addressed, so it is computed once per hash.
+type compositionBlob struct {
+	Lines       int    `json:"l"`
+	Binary      bool   `json:"b,omitempty"`
+	Generated   bool   `json:"g,omitempty"`
+	Interpreter string `json:"i,omitempty"`
+}
+
+// compositionCounts is the composition of one tree.
+type compositionCounts struct {
+	files       int
+	lines       int
+	languages   map[string]int
+	directories map[string]int
+	excluded    CompositionExcluded
+}
+
+func newCompositionCounts() *compositionCounts {
+	return &compositionCounts{languages: make(map[string]int), directories: make(map[string]int)}
+}
+
+// add adds the counts of a subtree to tc.
+func (tc *compositionCounts) add(sub *compositionCounts) {
+	tc.files += sub.files
+	tc.lines += sub.lines
+	for lang, lines := range sub.languages {
+		tc.languages[lang] += lines
+	}
+	for dir, lines := range sub.directories {
+		tc.directories[dir] += lines
+	}
+	tc.excluded.Vendored += sub.excluded.Vendored
+	tc.excluded.Generated += sub.excluded.Generated
+	tc.excluded.Documentation += sub.excluded.Documentation
+	tc.excluded.Binary += sub.excluded.Binary
+}
+
+type compositionBuilder struct {
+	repo       *gitcore.Repository
+	store      Store
+	blobs      map[gitcore.Hash]compositionBlob
+	attributes map[string]*gitcore.Attributes
+	// trees memoizes counts by tree hash, path, and the .gitattributes
+	// files above the tree, so directories unchanged between samples are
+	// walked once.
+	trees map[string]*compositionCounts
+}
+
+// BuildComposition samples the trees along HEAD's first-parent history and
+// counts their lines by language and top-level directory.
+func BuildComposition(repo *gitcore.Repository, opts CompositionOptions) (*Composition, error) {
+	composition := &Composition{
+		Samples:     []CompositionSample{},
+		Languages:   []CompositionSeries{},
+		Directories: []CompositionSeries{},
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	commitsMap := repo.Commits()
+	head := commitsMap[repo.Head()]
+	if head == nil {
+		if repo.Head() == "" {
+			return composition, nil
+		}
+		return nil, fmt.Errorf("HEAD commit %s not found", repo.Head())
+	}
+
+	// chain runs newest first and stops where history is cut off.
+	var chain []*gitcore.Commit
+	for c := head; c != nil; {
+		chain = append(chain, c)
+		if len(c.Parents) == 0 {
+			break
+		}
+		c = commitsMap[c.Parents[0]]
+	}
+
+	b := &compositionBuilder{
+		repo:       repo,
+		store:      opts.Store,
+		blobs:      make(map[gitcore.Hash]compositionBlob),
+		attributes: make(map[string]*gitcore.Attributes),
+		trees:      make(map[string]*compositionCounts),
+	}
+	var counts []*compositionCounts
+	for _, week := range compositionWeeks(chain) {
+		c := compositionCommitAt(chain, time.UnixMilli(week).Add(7*24*time.Hour))
+		if c == nil {
+			continue
+		}
+		tc, err := b.walk(c.Tree, "", nil, "")
+		if err != nil {
+			return nil, err
+		}
+		composition.Samples = append(composition.Samples, CompositionSample{TS: week, Commit: string(c.ID), Files: tc.files, Lines: tc.lines})
+		counts = append(counts, tc)
+	}
+	if len(counts) == 0 {
+		return composition, nil
+	}
+
+	composition.Languages = compositionSeries(counts, func(tc *compositionCounts) map[string]int { return tc.languages })
+	composition.Directories = compositionSeries(counts, func(tc *compositionCounts) map[string]int { return tc.directories })
+	composition.Excluded = counts[len(counts)-1].excluded
+	return composition, nil
+}
+
+// compositionWeeks returns the week starts to sample, oldest first, ending
+// with HEAD's week and spaced so there are at most
+// analyticsCompositionMaxSamples of them.
+func compositionWeeks(chain []*gitcore.Commit) []int64 {
+	const weekMS = int64(7 * 24 * time.Hour / time.Millisecond)
+	last := weekStartUTC(chain[0].Committer.When)
+	first := last
+	for _, c := range chain {
+		first = min(first, weekStartUTC(c.Committer.When))
+	}
+	n := (last-first)/weekMS + 1
+	step := (n + analyticsCompositionMaxSamples - 1) / analyticsCompositionMaxSamples
+
+	var weeks []int64
+	for w := last; w >= first; w -= step * weekMS {
+		weeks = append(weeks, w)
+	}
+	for i, j := 0, len(weeks)-1; i < j; i, j = i+1, j-1 {
+		weeks[i], weeks[j] = weeks[j], weeks[i]
+	}
+	return weeks
+}
+
+// compositionCommitAt returns the newest commit in chain committed before
+// end, or nil when there is none.
+func compositionCommitAt(chain []*gitcore.Commit, end time.Time) *gitcore.Commit {
+	for _, c := range chain {
+		if c.Committer.When.Before(end) {
+			return c
+		}
+	}
+	return nil
+}
+
+// compositionSeries ranks the keys of each sample's counts by their lines at
+// the last sample and folds those past analyticsCompositionTopSeries into
+// compositionOtherSeries.
+func compositionSeries(counts []*compositionCounts, pick func(*compositionCounts) map[string]int) []CompositionSeries {
+	last := pick(counts[len(counts)-1])
+	names := make(map[string]struct{})
+	for _, tc := range counts {
+		for name := range pick(tc) {
+			names[name] = struct{}{}
+		}
+	}
+	ranked := analyticsSortedSet(names)
+	sort.SliceStable(ranked, func(i, j int) bool { return last[ranked[i]] > last[ranked[j]] })
+
+	var other []string
+	if len(ranked) > analyticsCompositionTopSeries {
+		ranked, other = ranked[:analyticsCompositionTopSeries], ranked[analyticsCompositionTopSeries:]
+	}
+	series := make([]CompositionSeries, 0, len(ranked)+1)
+	for _, name := range ranked {
+		s := CompositionSeries{Name: name, Lines: make([]int, len(counts))}
+		for i, tc := range counts {
+			s.Lines[i] = pick(tc)[name]
+		}
+		series = append(series, s)
+	}
+	if len(other) > 0 {
+		s := CompositionSeries{Name: compositionOtherSeries, Lines: make([]int, len(counts))}
+		for i, tc := range counts {
+			for _, name := range other {
+				s.Lines[i] += pick(tc)[name]
+			}
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+// walk counts the files under the tree treeHash at prefix. attrs holds the
+// .gitattributes files of the directories above it, root first, and attrsKey
+// identifies them. The result is shared between callers and must not be
+// modified.
+func (b *compositionBuilder) walk(treeHash gitcore.Hash, prefix string, attrs []*gitcore.Attributes, attrsKey string) (*compositionCounts, error) {
+	key := string(treeHash) + "\x00" + prefix + "\x00" + attrsKey
+	if tc, ok := b.trees[key]; ok {
+		return tc, nil
+	}
+	tree, err := b.repo.GetTree(treeHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range tree.Entries {
+		if entry.Name != ".gitattributes" || entry.Type == gitcore.ObjectTypeTree {
+			continue
+		}
+		a, err := b.attributesFile(entry.ID, prefix)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs[:len(attrs):len(attrs)], a)
+		attrsKey += string(entry.ID) + ":" + prefix + ";"
+	}
+
+	tc := newCompositionCounts()
+	for _, entry := range tree.Entries {
+		switch {
+		case entry.Mode == "160000" || entry.Mode == "120000":
+			continue
+		case entry.Type == gitcore.ObjectTypeTree:
+			sub, err := b.walk(entry.ID, prefix+entry.Name+"/", attrs, attrsKey)
+			if err != nil {
+				return nil, err
+			}
+			tc.add(sub)
+		default:
+			if err := b.file(tc, prefix+entry.Name, entry.ID, attrs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b.trees[key] = tc
+	return tc, nil
+}
+
+func (b *compositionBuilder) attributesFile(blobHash gitcore.Hash, dir string) (*gitcore.Attributes, error) {
+	key := string(blobHash) + ":" + dir
+	if a, ok := b.attributes[key]; ok {
+		return a, nil
+	}
+	data, err := b.repo.GetBlob(blobHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s.gitattributes: %w", dir, err)
+	}
+	a := gitcore.ParseAttributes(dir, data)
+	b.attributes[key] = a
+	return a, nil
+}
+
+// file adds the file at filePath to tc unless it is excluded. Paths decide
+// first so vendored files are never read.
+func (b *compositionBuilder) file(tc *compositionCounts, filePath string, blobHash gitcore.Hash, attrs []*gitcore.Attributes) error {
+	states := make(map[string]string)
+	for _, a := range attrs {
+		a.Apply(filePath, states)
+	}
+	if doc, _ := linguistOverride(states, "linguist-documentation"); doc {
+		tc.excluded.Documentation++
+		return nil
+	}
+	vendored, ok := linguistOverride(states, "linguist-vendored")
+	if !ok {
+		vendored = linguistVendored.MatchString(filePath)
+	}
+	if vendored {
+		tc.excluded.Vendored++
+		return nil
+	}
+	generated, generatedSet := linguistOverride(states, "linguist-generated")
+	if !generatedSet && linguistGenerated.MatchString(filePath) {
+		generated = true
+	}
+	if generated {
+		tc.excluded.Generated++
+		return nil
+	}
+
+	blob, err := b.blob(blobHash)
+	if err != nil {
+		return err
+	}
+	switch {
+	case blob.Binary:
+		tc.excluded.Binary++
+		return nil
+	case blob.Generated && !generatedSet:
+		tc.excluded.Generated++
+		return nil
+	}
+
+	lang := states["linguist-language"]
+	switch lang {
+	case "", gitcore.AttributeSet, gitcore.AttributeUnset, gitcore.AttributeUnspecified:
+		lang = detectLanguage(filePath, blob.Interpreter)
+	}
+	dir := compositionRootDir
+	if top, _, nested := strings.Cut(filePath, "/"); nested {
+		dir = top + "/"
+	}
+	tc.files++
+	tc.lines += blob.Lines
+	tc.languages[lang] += blob.Lines
+	tc.directories[dir] += blob.Lines
+	return nil
+}
+
+// blob returns the line count and traits of a blob, from memory, then the
+// store, and reads it only when neither has it.
+func (b *compositionBuilder) blob(blobHash gitcore.Hash) (compositionBlob, error) {
+	if blob, ok := b.blobs[blobHash]; ok {
+		return blob, nil
+	}
+	key := compositionStoreKeyPrefix + string(blobHash)
+	var blob compositionBlob
+	if b.store != nil && b.store.GetJSON(key, &blob) {
+		b.blobs[blobHash] = blob
+		return blob, nil
+	}
+	data, err := b.repo.GetBlob(blobHash)
+	if err != nil {
+		return compositionBlob{}, err
+	}
+	if gitcore.IsBinaryContent(data) {
+		blob.Binary = true
+	} else {
+		blob.Lines = bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			blob.Lines++
+		}
+		blob.Generated = hasGeneratedMarker(data)
+		blob.Interpreter = shebangInterpreter(data)
+	}
+	b.blobs[blobHash] = blob
+	if b.store != nil {
+		_ = b.store.PutJSON(key, blob)
+	}
+	return blob, nil
+}
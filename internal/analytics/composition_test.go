@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func TestBuildComposition(t *testing.T) {
+	// Monday, so each commit batch falls in its own week.
+	h := newHistoryRepo(t, time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	h.commit("alice", "main.go", "package main\n\nfunc main() {}\n")
+	h.commit("alice", "tool", "#!/usr/bin/env python3\nprint('hi')")
+	h.commit("alice", "vendor/lib/lib.go", strings.Repeat("x\n", 100))
+
+	h.When = h.When.Add(7 * 24 * time.Hour)
+	h.commit("bob", "web/app.js", "a\nb\nc\nd\n")
+	h.commit("bob", "api/api.pb.go", "package api\n")
+	h.commit("bob", "gen.go", "// Code generated by stringer. DO NOT EDIT.\n\npackage main\n")
+	h.commit("bob", "logo.png", "\x89PNG\x00\x01")
+	h.commit("bob", ".gitattributes", "*.inc linguist-language=PHP\nvendor/keep/** -linguist-vendored\n")
+	h.commit("bob", "page.inc", "<?php\necho 1;\n")
+	h.commit("bob", "vendor/keep/keep.go", "package keep\n")
+
+	store := &memStore{entries: map[string][]byte{}}
+	composition, err := BuildComposition(h.open(), CompositionOptions{Store: store})
+	if err != nil {
+		t.Fatalf("BuildComposition() error = %v", err)
+	}
+	if len(composition.Samples) != 2 {
+		t.Fatalf("samples = %+v", composition.Samples)
+	}
+	first, second := composition.Samples[0], composition.Samples[1]
+	if first.Files != 2 || first.Lines != 5 || second.Files != 6 || second.Lines != 14 {
+		t.Fatalf("samples = %+v", composition.Samples)
+	}
+	if second.TS-first.TS != (7 * 24 * time.Hour).Milliseconds() {
+		t.Fatalf("sample weeks = %d, %d", first.TS, second.TS)
+	}
+
+	series := func(all []CompositionSeries) map[string][]int {
+		m := make(map[string][]int, len(all))
+		for _, s := range all {
+			m[s.Name] = s.Lines
+		}
+		return m
+	}
+	languages := series(composition.Languages)
+	wantLanguages := map[string][]int{
+		"Go":             {3, 4},
+		"JavaScript":     {0, 4},
+		"Python":         {2, 2},
+		"PHP":            {0, 2},
+		"Git Attributes": {0, 2},
+	}
+	if len(languages) != len(wantLanguages) {
+		t.Fatalf("languages = %v", languages)
+	}
+	for name, want := range wantLanguages {
+		if !slices.Equal(languages[name], want) {
+			t.Fatalf("languages[%q] = %v, want %v", name, languages[name], want)
+		}
+	}
+	// Ties at the last sample are broken by name.
+	if composition.Languages[0].Name != "Go" || composition.Languages[1].Name != "JavaScript" {
+		t.Fatalf("languages not ranked by the last sample: %+v", composition.Languages)
+	}
+
+	directories := series(composition.Directories)
+	if !slices.Equal(directories["(root)"], []int{5, 9}) || !slices.Equal(directories["web/"], []int{0, 4}) ||
+		!slices.Equal(directories["vendor/"], []int{0, 1}) || len(directories) != 3 {
+		t.Fatalf("directories = %v", directories)
+	}
+	if composition.Excluded != (CompositionExcluded{Vendored: 1, Generated: 2, Binary: 1}) {
+		t.Fatalf("excluded = %+v", composition.Excluded)
+	}
+
+	// Every blob read is stored; vendored and path-generated ones are never
+	// read.
+	puts := store.puts
+	if puts != 8 {
+		t.Fatalf("store puts = %d, want 8", puts)
+	}
+	if _, err := BuildComposition(h.open(), CompositionOptions{Store: store}); err != nil || store.puts != puts {
+		t.Fatalf("rebuild = %v, %d puts", err, store.puts-puts)
+	}
+}
+
+func TestCompositionWalkReusesUnchangedSubtrees(t *testing.T) {
+	h := newHistoryRepo(t, time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	h.commit("alice", "lib/lib.go", "package lib\n")
+	h.commit("alice", "main.go", "package main\n")
+	h.commit("alice", ".gitattributes", "*.go linguist-generated\n")
+	repo := h.open()
+
+	b := &compositionBuilder{
+		repo:       repo,
+		blobs:      make(map[gitcore.Hash]compositionBlob),
+		attributes: make(map[string]*gitcore.Attributes),
+		trees:      make(map[string]*compositionCounts),
+	}
+	var roots []*compositionCounts
+	for _, rev := range []string{"HEAD~2", "HEAD~1", "HEAD"} {
+		id, err := repo.ResolveRevision(rev)
+		if err != nil {
+			t.Fatalf("ResolveRevision(%s) error = %v", rev, err)
+		}
+		tc, err := b.walk(repo.Commits()[id].Tree, "", nil, "")
+		if err != nil {
+			t.Fatalf("walk(%s) error = %v", rev, err)
+		}
+		roots = append(roots, tc)
+	}
+
+	// lib/ is walked once for the first two roots and again once the root
+	// .gitattributes applies to it.
+	if len(b.trees) != 5 {
+		t.Fatalf("walked %d trees, want 5", len(b.trees))
+	}
+	if roots[1].lines != 2 || roots[1].directories["lib/"] != 1 || roots[2].lines != 1 || roots[2].excluded.Generated != 2 {
+		t.Fatalf("roots = %+v, %+v", roots[1], roots[2])
+	}
+}
+
+func TestCompositionSeriesFoldsOther(t *testing.T) {
+	last := &compositionCounts{languages: map[string]int{}}
+	for i := range analyticsCompositionTopSeries + 2 {
+		last.languages[string(rune('A'+i))] = 100 - i
+	}
+	series := compositionSeries([]*compositionCounts{last}, func(tc *compositionCounts) map[string]int { return tc.languages })
+	if len(series) != analyticsCompositionTopSeries+1 {
+		t.Fatalf("series = %+v", series)
+	}
+	if series[0].Name != "A" || series[len(series)-1].Name != "Other" || series[len(series)-1].Lines[0] != 88+87 {
+		t.Fatalf("series = %+v", series)
+	}
+}
@@ -0,0 +1,312 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// analyticsCouplingMinSupport is the number of shared changes a pair
+	// needs before it is reported.
+	analyticsCouplingMinSupport = 2
+	// analyticsCouplingMaxFiles skips changes that touch more files than
+	// this: mass renames and reformatting couple everything to everything.
+	analyticsCouplingMaxFiles = 50
+	// analyticsCouplingSessionGap joins an author's commits into one logical
+	// change when each follows the previous within this gap.
+	analyticsCouplingSessionGap = 24 * time.Hour
+	// analyticsCouplingSurprise is the confidence or temporal score, in
+	// percent, at which a pair that crosses module boundaries is flagged.
+	analyticsCouplingSurprise  = 50.0
+	analyticsCouplingTopPairs  = 20
+	analyticsCouplingTopModule = 30
+)
+
+type analyticsCoupling struct {
+	Pairs   []analyticsCouplingPair `json:"pairs"`
+	Modules analyticsCouplingGraph  `json:"modules"`
+	// SurprisingCount counts every flagged pair, not just those in Pairs.
+	SurprisingCount int `json:"surprisingCount"`
+}
+
+// analyticsCouplingPair describes two files that change together. Support
+// counts commits touching both; ConfidenceAB is the percentage of FileA's
+// commits that also touch FileB. A logical change joins one author's commits
+// made in quick succession, so SharedChanges also catches a change split
+// across commits. TemporalScore is the percentage of the logical changes
+// touching either file that touch both.
+type analyticsCouplingPair struct {
+	FileA         string  `json:"fileA"`
+	FileB         string  `json:"fileB"`
+	ModuleA       string  `json:"moduleA"`
+	ModuleB       string  `json:"moduleB"`
+	Support       int     `json:"support"`
+	SharedChanges int     `json:"sharedChanges"`
+	ConfidenceAB  float64 `json:"confidenceAB"`
+	ConfidenceBA  float64 `json:"confidenceBA"`
+	TemporalScore float64 `json:"temporalScore"`
+	CrossModule   bool    `json:"crossModule"`
+	Surprising    bool    `json:"surprising"`
+}
+
+// analyticsCouplingGraph is the module-level coupling graph, shaped for chord
+// and force-directed diagrams.
+type analyticsCouplingGraph struct {
+	Nodes []analyticsCouplingNode `json:"nodes"`
+	Edges []analyticsCouplingEdge `json:"edges"`
+}
+
+type analyticsCouplingNode struct {
+	ID      string `json:"id"`
+	Commits int    `json:"commits"`
+}
+
+type analyticsCouplingEdge struct {
+	Source       string  `json:"source"`
+	Target       string  `json:"target"`
+	Support      int     `json:"support"`
+	ConfidenceST float64 `json:"confidenceST"`
+	ConfidenceTS float64 `json:"confidenceTS"`
+	Surprising   bool    `json:"surprising"`
+}
+
+type analyticsPairKey struct {
+	a, b string
+}
+
+func newAnalyticsPairKey(x, y string) analyticsPairKey {
+	if y < x {
+		x, y = y, x
+	}
+	return analyticsPairKey{a: x, b: y}
+}
+
+func emptyAnalyticsCoupling() analyticsCoupling {
+	return analyticsCoupling{
+		Pairs: []analyticsCouplingPair{},
+		Modules: analyticsCouplingGraph{
+			Nodes: []analyticsCouplingNode{},
+			Edges: []analyticsCouplingEdge{},
+		},
+	}
+}
+
+// countAnalyticsPairs adds one to changes for every item in set and to pairs
+// for every unordered pair of items in set.
+func countAnalyticsPairs(set []string, changes map[string]int, pairs map[analyticsPairKey]int) {
+	for i, x := range set {
+		changes[x]++
+		for _, y := range set[i+1:] {
+			pairs[newAnalyticsPairKey(x, y)]++
+		}
+	}
+}
+
+func analyticsSortedSet(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func analyticsPercent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) * 100.0 / float64(whole)
+}
+
+// computeCoupling finds files and modules that change together in the
+// analyzed commits.
+func computeCoupling(analyzed []analyticsAnalyzedCommit) analyticsCoupling {
+	coupling := emptyAnalyticsCoupling()
+	commits := make([]analyticsAnalyzedCommit, 0, len(analyzed))
+	for _, c := range analyzed {
+		if len(c.Files) > 0 && len(c.Files) <= analyticsCouplingMaxFiles {
+			commits = append(commits, c)
+		}
+	}
+	if len(commits) == 0 {
+		return coupling
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].TS < commits[j].TS })
+
+	fileCommits := make(map[string]int)
+	filePairs := make(map[analyticsPairKey]int)
+	moduleCommits := make(map[string]int)
+	modulePairs := make(map[analyticsPairKey]int)
+	for _, c := range commits {
+		files := append([]string(nil), c.Files...)
+		sort.Strings(files)
+		countAnalyticsPairs(files, fileCommits, filePairs)
+		modules := make(map[string]struct{}, len(files))
+		for _, f := range files {
+			modules[analyticsModuleKey(f)] = struct{}{}
+		}
+		countAnalyticsPairs(analyticsSortedSet(modules), moduleCommits, modulePairs)
+	}
+
+	sessionFiles, sessionPairs := analyticsCouplingSessions(commits)
+
+	// Commits in one logical change count once in sessionPairs, and a change
+	// split across commits only shows there, so candidates come from both.
+	candidates := make(map[analyticsPairKey]struct{}, len(sessionPairs))
+	for key, joint := range sessionPairs {
+		if joint >= analyticsCouplingMinSupport {
+			candidates[key] = struct{}{}
+		}
+	}
+	for key, support := range filePairs {
+		if support >= analyticsCouplingMinSupport {
+			candidates[key] = struct{}{}
+		}
+	}
+
+	pairs := make([]analyticsCouplingPair, 0, len(candidates))
+	for key := range candidates {
+		support, joint := filePairs[key], sessionPairs[key]
+		pair := analyticsCouplingPair{
+			FileA:         key.a,
+			FileB:         key.b,
+			ModuleA:       analyticsModuleKey(key.a),
+			ModuleB:       analyticsModuleKey(key.b),
+			Support:       support,
+			SharedChanges: joint,
+			ConfidenceAB:  analyticsPercent(support, fileCommits[key.a]),
+			ConfidenceBA:  analyticsPercent(support, fileCommits[key.b]),
+			TemporalScore: analyticsPercent(joint, sessionFiles[key.a]+sessionFiles[key.b]-joint),
+		}
+		pair.CrossModule = pair.ModuleA != pair.ModuleB
+		pair.Surprising = pair.CrossModule &&
+			max(pair.ConfidenceAB, pair.ConfidenceBA, pair.TemporalScore) >= analyticsCouplingSurprise
+		if pair.Surprising {
+			coupling.SurprisingCount++
+		}
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].SharedChanges != pairs[j].SharedChanges {
+			return pairs[i].SharedChanges > pairs[j].SharedChanges
+		}
+		if pairs[i].Support != pairs[j].Support {
+			return pairs[i].Support > pairs[j].Support
+		}
+		if pairs[i].TemporalScore != pairs[j].TemporalScore {
+			return pairs[i].TemporalScore > pairs[j].TemporalScore
+		}
+		if pairs[i].FileA != pairs[j].FileA {
+			return pairs[i].FileA < pairs[j].FileA
+		}
+		return pairs[i].FileB < pairs[j].FileB
+	})
+	if len(pairs) > analyticsCouplingTopPairs {
+		pairs = pairs[:analyticsCouplingTopPairs]
+	}
+	coupling.Pairs = pairs
+	coupling.Modules = analyticsCouplingModuleGraph(moduleCommits, modulePairs)
+	return coupling
+}
+
+// analyticsCouplingSessions groups each author's commits into logical changes
+// and counts, per file and per file pair, the changes that touch them. A
+// change ends when the author pauses longer than the session gap or when the
+// next commit would take it past analyticsCouplingMaxFiles, so a steady
+// committer's history is not one endless change. Commits must be sorted by
+// time.
+func analyticsCouplingSessions(commits []analyticsAnalyzedCommit) (map[string]int, map[analyticsPairKey]int) {
+	gapMS := analyticsCouplingSessionGap.Milliseconds()
+	type session struct {
+		lastTS int64
+		files  map[string]struct{}
+	}
+	files := make(map[string]int)
+	pairs := make(map[analyticsPairKey]int)
+	open := make(map[string]*session)
+	for _, c := range commits {
+		s, ok := open[c.Author]
+		if ok {
+			added := 0
+			for _, f := range c.Files {
+				if _, seen := s.files[f]; !seen {
+					added++
+				}
+			}
+			if c.TS-s.lastTS > gapMS || len(s.files)+added > analyticsCouplingMaxFiles {
+				countAnalyticsPairs(analyticsSortedSet(s.files), files, pairs)
+				ok = false
+			}
+		}
+		if !ok {
+			s = &session{files: make(map[string]struct{})}
+			open[c.Author] = s
+		}
+		s.lastTS = c.TS
+		for _, f := range c.Files {
+			s.files[f] = struct{}{}
+		}
+	}
+	authors := make([]string, 0, len(open))
+	for author := range open {
+		authors = append(authors, author)
+	}
+	sort.Strings(authors)
+	for _, author := range authors {
+		countAnalyticsPairs(analyticsSortedSet(open[author].files), files, pairs)
+	}
+	return files, pairs
+}
+
+// analyticsCouplingModuleGraph keeps the most frequently changed modules and
+// the coupling edges between them.
+func analyticsCouplingModuleGraph(moduleCommits map[string]int, modulePairs map[analyticsPairKey]int) analyticsCouplingGraph {
+	graph := analyticsCouplingGraph{
+		Nodes: make([]analyticsCouplingNode, 0, len(moduleCommits)),
+		Edges: []analyticsCouplingEdge{},
+	}
+	for module, n := range moduleCommits {
+		graph.Nodes = append(graph.Nodes, analyticsCouplingNode{ID: module, Commits: n})
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Commits != graph.Nodes[j].Commits {
+			return graph.Nodes[i].Commits > graph.Nodes[j].Commits
+		}
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+	if len(graph.Nodes) > analyticsCouplingTopModule {
+		graph.Nodes = graph.Nodes[:analyticsCouplingTopModule]
+	}
+	kept := make(map[string]struct{}, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		kept[node.ID] = struct{}{}
+	}
+
+	for key, support := range modulePairs {
+		if support < analyticsCouplingMinSupport {
+			continue
+		}
+		_, okA := kept[key.a]
+		_, okB := kept[key.b]
+		if !okA || !okB {
+			continue
+		}
+		edge := analyticsCouplingEdge{
+			Source:       key.a,
+			Target:       key.b,
+			Support:      support,
+			ConfidenceST: analyticsPercent(support, moduleCommits[key.a]),
+			ConfidenceTS: analyticsPercent(support, moduleCommits[key.b]),
+		}
+		edge.Surprising = max(edge.ConfidenceST, edge.ConfidenceTS) >= analyticsCouplingSurprise
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Support != graph.Edges[j].Support {
+			return graph.Edges[i].Support > graph.Edges[j].Support
+		}
+		return strings.Compare(graph.Edges[i].Source+"\x00"+graph.Edges[i].Target, graph.Edges[j].Source+"\x00"+graph.Edges[j].Target) < 0
+	})
+	return graph
+}
@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputeCoupling(t *testing.T) {
+	day := (24 * time.Hour).Milliseconds()
+	hour := time.Hour.Milliseconds()
+	analyzed := []analyticsAnalyzedCommit{
+		{TS: 0, Author: "alice", Files: []string{"internal/server/h.go", "web/app.js"}},
+		{TS: 5 * day, Author: "alice", Files: []string{"web/app.js", "internal/server/h.go"}},
+		{TS: 10 * day, Author: "bob", Files: []string{"internal/server/h.go"}},
+		// bob splits each change to web/a.js and web/b.js over two commits.
+		{TS: 20 * day, Author: "bob", Files: []string{"web/a.js"}},
+		{TS: 20*day + hour, Author: "bob", Files: []string{"web/b.js"}},
+		{TS: 30 * day, Author: "bob", Files: []string{"web/a.js"}},
+		{TS: 30*day + 2*hour, Author: "bob", Files: []string{"web/b.js"}},
+	}
+	var sweep []string
+	for i := range analyticsCouplingMaxFiles + 1 {
+		sweep = append(sweep, fmt.Sprintf("pkg/gen/f%d.go", i))
+	}
+	for i := range 2 {
+		analyzed = append(analyzed, analyticsAnalyzedCommit{TS: int64(40+i) * day, Author: "carol", Files: sweep})
+	}
+
+	coupling := computeCoupling(analyzed)
+	if len(coupling.Pairs) != 2 {
+		t.Fatalf("pairs = %+v, want 2", coupling.Pairs)
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.01 }
+
+	cross := coupling.Pairs[0]
+	if cross.FileA != "internal/server/h.go" || cross.FileB != "web/app.js" || cross.Support != 2 || cross.SharedChanges != 2 {
+		t.Fatalf("first pair = %+v", cross)
+	}
+	if !near(cross.ConfidenceAB, 200.0/3) || !near(cross.ConfidenceBA, 100) || !near(cross.TemporalScore, 200.0/3) {
+		t.Fatalf("first pair scores = %+v", cross)
+	}
+	if cross.ModuleA != "internal/server/" || cross.ModuleB != "web/" || !cross.CrossModule || !cross.Surprising {
+		t.Fatalf("first pair modules = %+v", cross)
+	}
+
+	split := coupling.Pairs[1]
+	if split.FileA != "web/a.js" || split.FileB != "web/b.js" || split.Support != 0 || split.SharedChanges != 2 {
+		t.Fatalf("second pair = %+v", split)
+	}
+	if !near(split.TemporalScore, 100) || split.CrossModule || split.Surprising {
+		t.Fatalf("second pair scores = %+v", split)
+	}
+	if coupling.SurprisingCount != 1 {
+		t.Fatalf("SurprisingCount = %d, want 1", coupling.SurprisingCount)
+	}
+
+	// The sweeping commits are left out, so pkg/gen/ never shows up.
+	nodes := coupling.Modules.Nodes
+	if len(nodes) != 2 || nodes[0] != (analyticsCouplingNode{ID: "web/", Commits: 6}) || nodes[1] != (analyticsCouplingNode{ID: "internal/server/", Commits: 3}) {
+		t.Fatalf("module nodes = %+v", nodes)
+	}
+	edges := coupling.Modules.Edges
+	if len(edges) != 1 {
+		t.Fatalf("module edges = %+v", edges)
+	}
+	if e := edges[0]; e.Source != "internal/server/" || e.Target != "web/" || e.Support != 2 ||
+		!near(e.ConfidenceST, 200.0/3) || !near(e.ConfidenceTS, 100.0/3) || !e.Surprising {
+		t.Fatalf("module edge = %+v", e)
+	}
+}
+
+func TestComputeCouplingSteadyCommitter(t *testing.T) {
+	// One author committing every minute never pauses long enough to end a
+	// logical change, so changes are split once they reach the file cap.
+	minute := time.Minute.Milliseconds()
+	var analyzed []analyticsAnalyzedCommit
+	for i := range 60 {
+		analyzed = append(analyzed, analyticsAnalyzedCommit{
+			TS:     int64(i) * minute,
+			Author: "alice",
+			Files:  []string{"api/x.go", "db/y.go", fmt.Sprintf("docs/n%d.md", i)},
+		})
+	}
+	coupling := computeCoupling(analyzed)
+	pair := coupling.Pairs[0]
+	if pair.FileA != "api/x.go" || pair.FileB != "db/y.go" || pair.Support != 60 || pair.SharedChanges < 2 || pair.TemporalScore != 100 {
+		t.Fatalf("first pair = %+v", pair)
+	}
+}
+
+func TestComputeCouplingEmpty(t *testing.T) {
+	coupling := computeCoupling(nil)
+	if coupling.Pairs == nil || coupling.Modules.Nodes == nil || coupling.Modules.Edges == nil {
+		t.Fatalf("computeCoupling(nil) = %+v, want empty slices", coupling)
+	}
+}
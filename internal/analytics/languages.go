@@ -0,0 +1,194 @@
+package analytics
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// compositionOtherText names text files no rule recognizes.
+const compositionOtherText = "Text"
+
+// languageExtensions maps lowercase file extensions to languages, following
+// the names GitHub linguist uses.
+var languageExtensions = map[string]string{
+	".bash":     "Shell",
+	".c":        "C",
+	".cc":       "C++",
+	".cjs":      "JavaScript",
+	".clj":      "Clojure",
+	".cpp":      "C++",
+	".cs":       "C#",
+	".css":      "CSS",
+	".cts":      "TypeScript",
+	".cxx":      "C++",
+	".dart":     "Dart",
+	".erl":      "Erlang",
+	".ex":       "Elixir",
+	".exs":      "Elixir",
+	".fs":       "F#",
+	".go":       "Go",
+	".gql":      "GraphQL",
+	".gradle":   "Groovy",
+	".graphql":  "GraphQL",
+	".groovy":   "Groovy",
+	".h":        "C",
+	".hcl":      "HCL",
+	".hh":       "C++",
+	".hpp":      "C++",
+	".hs":       "Haskell",
+	".htm":      "HTML",
+	".html":     "HTML",
+	".hxx":      "C++",
+	".java":     "Java",
+	".jl":       "Julia",
+	".js":       "JavaScript",
+	".json":     "JSON",
+	".jsx":      "JavaScript",
+	".kt":       "Kotlin",
+	".kts":      "Kotlin",
+	".less":     "Less",
+	".lua":      "Lua",
+	".m":        "Objective-C",
+	".markdown": "Markdown",
+	".md":       "Markdown",
+	".mjs":      "JavaScript",
+	".ml":       "OCaml",
+	".mli":      "OCaml",
+	".mts":      "TypeScript",
+	".php":      "PHP",
+	".pl":       "Perl",
+	".pm":       "Perl",
+	".proto":    "Protocol Buffer",
+	".ps1":      "PowerShell",
+	".py":       "Python",
+	".r":        "R",
+	".rb":       "Ruby",
+	".rs":       "Rust",
+	".rst":      "reStructuredText",
+	".sass":     "Sass",
+	".scala":    "Scala",
+	".scss":     "SCSS",
+	".sh":       "Shell",
+	".sql":      "SQL",
+	".svelte":   "Svelte",
+	".swift":    "Swift",
+	".tf":       "HCL",
+	".toml":     "TOML",
+	".ts":       "TypeScript",
+	".tsx":      "TSX",
+	".vue":      "Vue",
+	".xml":      "XML",
+	".yaml":     "YAML",
+	".yml":      "YAML",
+	".zig":      "Zig",
+	".zsh":      "Shell",
+}
+
+// languageFilenames maps whole file names to languages.
+var languageFilenames = map[string]string{
+	".gitattributes": "Git Attributes",
+	".gitignore":     "Ignore List",
+	"CMakeLists.txt": "CMake",
+	"Dockerfile":     "Dockerfile",
+	"Gemfile":        "Ruby",
+	"GNUmakefile":    "Makefile",
+	"Makefile":       "Makefile",
+	"Rakefile":       "Ruby",
+	"go.mod":         "Go Module",
+	"makefile":       "Makefile",
+}
+
+// languageInterpreters maps shebang interpreters, without version suffixes,
+// to languages.
+var languageInterpreters = map[string]string{
+	"bash":   "Shell",
+	"dash":   "Shell",
+	"sh":     "Shell",
+	"zsh":    "Shell",
+	"node":   "JavaScript",
+	"deno":   "TypeScript",
+	"perl":   "Perl",
+	"php":    "PHP",
+	"python": "Python",
+	"ruby":   "Ruby",
+	"lua":    "Lua",
+	"pwsh":   "PowerShell",
+}
+
+// linguistVendored and linguistGenerated are a subset of linguist's
+// vendor.yml and generated.rb path rules.
+var (
+	linguistVendored = regexp.MustCompile(`(^|/)(vendor|vendors|node_modules|bower_components|third[-_]?party|external|Godeps/_workspace|\.yarn)/` +
+		`|(^|/)jquery[^/]*\.js$|\.min\.(js|css)$`)
+	linguistGenerated = regexp.MustCompile(`\.pb\.(go|cc|h)$|_pb2(_grpc)?\.py$|\.pb\.gw\.go$|_generated\.go$|\.designer\.cs$|\.(js|css)\.map$` +
+		`|(^|/)(package-lock\.json|yarn\.lock|pnpm-lock\.yaml|go\.sum|Cargo\.lock|Gemfile\.lock|poetry\.lock|composer\.lock)$`)
+)
+
+// detectLanguage names the language of filePath from its name or, failing
+// that, from interpreter, the program its shebang line runs.
+func detectLanguage(filePath, interpreter string) string {
+	name := path.Base(filePath)
+	if lang, ok := languageFilenames[name]; ok {
+		return lang
+	}
+	if lang, ok := languageExtensions[strings.ToLower(path.Ext(name))]; ok {
+		return lang
+	}
+	if lang, ok := languageInterpreters[interpreter]; ok {
+		return lang
+	}
+	return compositionOtherText
+}
+
+// shebangInterpreter returns the program a "#!" first line runs, looking
+// through env and dropping version suffixes so python3.12 becomes python.
+func shebangInterpreter(content []byte) string {
+	line, ok := strings.CutPrefix(string(content[:min(len(content), 256)]), "#!")
+	if !ok {
+		return ""
+	}
+	line, _, _ = strings.Cut(line, "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	program := path.Base(fields[0])
+	if program == "env" {
+		program = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				program = path.Base(f)
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(program, func(r rune) bool { return r == '.' || unicode.IsDigit(r) })
+}
+
+// hasGeneratedMarker reports whether the start of content carries a
+// generated-code header such as Go's "Code generated ... DO NOT EDIT." or
+// "@generated".
+func hasGeneratedMarker(content []byte) bool {
+	head := string(content[:min(len(content), 1024)])
+	if strings.Contains(head, "@generated") {
+		return true
+	}
+	lower := strings.ToLower(head)
+	return strings.Contains(lower, "do not edit") && strings.Contains(lower, "generated")
+}
+
+// linguistOverride resolves a linguist-* attribute: its boolean value when
+// set or unset, or ok false when the attribute says nothing.
+func linguistOverride(states map[string]string, name string) (value, ok bool) {
+	switch states[name] {
+	case gitcore.AttributeSet, "true":
+		return true, true
+	case gitcore.AttributeUnset, "false":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{path: "cmd/main.go", want: "Go"},
+		{path: "web/App.TSX", want: "TSX"},
+		{path: "build/Makefile", want: "Makefile"},
+		{path: "Dockerfile", want: "Dockerfile"},
+		{path: "bin/run", content: "#!/bin/bash\nset -e\n", want: "Shell"},
+		{path: "bin/serve", content: "#!/usr/bin/env -S node --no-warnings\n", want: "JavaScript"},
+		{path: "scripts/gen", content: "#!/usr/local/bin/python3.12\n", want: "Python"},
+		{path: "notes", content: "plain words\n", want: "Text"},
+		{path: "script.py", content: "#!/bin/sh\n", want: "Python"},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.path, shebangInterpreter([]byte(tt.content))); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLinguistPathRules(t *testing.T) {
+	vendored := []string{"vendor/github.com/x/y.go", "web/node_modules/a/index.js", "third_party/zlib/z.c", "static/jquery-3.7.1.js", "assets/app.min.js"}
+	for _, p := range vendored {
+		if !linguistVendored.MatchString(p) {
+			t.Errorf("%q is not vendored", p)
+		}
+	}
+	generated := []string{"api/v1/api.pb.go", "proto/msg_pb2.py", "go.sum", "web/package-lock.json", "dist/app.js.map"}
+	for _, p := range generated {
+		if !linguistGenerated.MatchString(p) {
+			t.Errorf("%q is not generated", p)
+		}
+	}
+	for _, p := range []string{"internal/vendorlib/a.go", "cmd/main.go", "docs/go.summary"} {
+		if linguistVendored.MatchString(p) || linguistGenerated.MatchString(p) {
+			t.Errorf("%q is excluded", p)
+		}
+	}
+}
+
+func TestHasGeneratedMarker(t *testing.T) {
+	for content, want := range map[string]bool{
+		"// Code generated by protoc-gen-go. DO NOT EDIT.\n": true,
+		"/* @generated by relay */\n":                        true,
+		"// Please do not edit the public API lightly.\n":    false,
+		"package main\n": false,
+	} {
+		if got := hasGeneratedMarker([]byte(content)); got != want {
+			t.Errorf("hasGeneratedMarker(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	ownershipStoreKeyPrefix = "analytics-lines:v1:"
+
+	analyticsOwnershipHalfLife       = 180 * 24 * time.Hour
+	analyticsOwnershipInactiveMonths = 6
+	analyticsOwnershipTopOwners      = 5
+	analyticsOwnershipMaxOrphans     = 50
+)
+
+// ErrPathNotFound reports an ownership path that names nothing in HEAD.
+var ErrPathNotFound = errors.New("path not found")
+
+// OwnershipOptions configures BuildOwnership.
+type OwnershipOptions struct {
+	// HalfLife is the age at which a changed line counts half as much toward
+	// knowledge. Zero means 180 days.
+	HalfLife time.Duration
+	// Now anchors recency decay and inactivity. Zero means time.Now.
+	Now time.Time
+	// Store, when set, keeps per-commit line counts between builds.
+	Store Store
+}
+
+// Ownership records who knows each file in HEAD. Knowledge is lines changed,
+// weighted down by age, and follows renames. Co-authors of a commit are
+// credited with its lines as fully as its author. It is built once per HEAD and
+// then viewed a directory at a time.
+type Ownership struct {
+	HalfLifeDays int                                  `json:"halfLifeDays"`
+	GeneratedAt  time.Time                            `json:"generatedAt"`
+	Files        map[string]map[string]ownershipShare `json:"files"`
+	Authors      map[string]ownershipAuthor           `json:"authors"`
+	Coverage     analyticsDiffCoverage                `json:"coverage"`
+}
+
+type ownershipShare struct {
+	Lines     int     `json:"l"`
+	Knowledge float64 `json:"k"`
+}
+
+type ownershipAuthor struct {
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	LastActive time.Time `json:"lastActive"`
+}
+
+// OwnershipResponse is the ownership view of one directory: the directory
+// itself, each of its entries, and the orphaned files below it.
+type OwnershipResponse struct {
+	Path           string                `json:"path"`
+	Directory      ownershipNode         `json:"directory"`
+	Entries        []ownershipNode       `json:"entries"`
+	Orphaned       []ownershipOrphan     `json:"orphaned"`
+	OrphanedCount  int                   `json:"orphanedCount"`
+	HalfLifeDays   int                   `json:"halfLifeDays"`
+	InactiveMonths int                   `json:"inactiveMonths"`
+	Coverage       analyticsDiffCoverage `json:"coverage"`
+	GeneratedAt    string                `json:"generatedAt"`
+}
+
+// ownershipNode summarizes a file or directory. BusFactor is the fewest
+// authors whose knowledge together covers half the total; Orphaned counts
+// files whose main authors are all inactive.
+type ownershipNode struct {
+	Name         string           `json:"name"`
+	Path         string           `json:"path"`
+	Type         string           `json:"type"`
+	Files        int              `json:"files"`
+	LinesChanged int              `json:"linesChanged"`
+	BusFactor    int              `json:"busFactor"`
+	Owners       []ownershipOwner `json:"owners"`
+	Orphaned     int              `json:"orphaned"`
+}
+
+type ownershipOwner struct {
+	Name       string  `json:"name"`
+	Email      string  `json:"email"`
+	Share      float64 `json:"share"`
+	Lines      int     `json:"lines"`
+	LastActive string  `json:"lastActive"`
+	Active     bool    `json:"active"`
+}
+
+type ownershipOrphan struct {
+	Path       string   `json:"path"`
+	Owners     []string `json:"owners"`
+	LastActive string   `json:"lastActive"`
+}
+
+// commitLines is the stored form of one commit's line counts.
+type commitLines struct {
+	Files    []fileLines `json:"files,omitempty"`
+	TooLarge bool        `json:"tooLarge,omitempty"`
+}
+
+type fileLines struct {
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath,omitempty"`
+	Lines   int    `json:"lines"`
+}
+
+// BuildOwnership computes knowledge shares from the non-merge commits
+// reachable from HEAD, newest first up to the diff limit.
+func BuildOwnership(repo *gitcore.Repository, opts OwnershipOptions) (*Ownership, error) {
+	if opts.HalfLife <= 0 {
+		opts.HalfLife = analyticsOwnershipHalfLife
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	o := &Ownership{
+		HalfLifeDays: int(opts.HalfLife / (24 * time.Hour)),
+		GeneratedAt:  opts.Now.UTC(),
+		Files:        make(map[string]map[string]ownershipShare),
+		Authors:      make(map[string]ownershipAuthor),
+	}
+
+	commitsMap := repo.Commits()
+	for _, c := range commitsMap {
+		if c == nil {
+			continue
+		}
+		for _, sig := range commitCredits(c.Author, c.CoAuthors) {
+			key := ownershipAuthorKey(sig)
+			if a, ok := o.Authors[key]; !ok || sig.When.After(a.LastActive) {
+				o.Authors[key] = ownershipAuthor{Name: sig.Name, Email: sig.Email, LastActive: sig.When.UTC()}
+			}
+		}
+	}
+	if repo.Head() == "" {
+		return o, nil
+	}
+
+	reachable, err := repo.RevList(gitcore.RevListOptions{Revision: "HEAD", NoMerges: true})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reachable, func(i, j int) bool { return reachable[i].Author.When.After(reachable[j].Author.When) })
+	o.Coverage.EligibleCommits = len(reachable)
+	if len(reachable) > analyticsDiffCommitMaxLimit {
+		reachable = reachable[:analyticsDiffCommitMaxLimit]
+		o.Coverage.Partial = true
+	}
+
+	halfLifeMS := float64(opts.HalfLife.Milliseconds())
+	for i := len(reachable) - 1; i >= 0; i-- {
+		c := reachable[i]
+		lines, err := loadCommitLines(repo, commitsMap, c, opts.Store)
+		if err != nil {
+			o.Coverage.OtherErrors++
+			continue
+		}
+		if lines.TooLarge {
+			o.Coverage.TooLargeErrors++
+			continue
+		}
+		o.Coverage.AnalyzedCommits++
+		age := float64(max(opts.Now.Sub(c.Author.When).Milliseconds(), 0))
+		decay := math.Exp2(-age / halfLifeMS)
+		credits := commitCredits(c.Author, c.CoAuthors)
+		for _, f := range lines.Files {
+			if f.OldPath != "" {
+				o.moveFile(f.OldPath, f.Path)
+			}
+			if f.Lines == 0 {
+				continue
+			}
+			shares := o.Files[f.Path]
+			if shares == nil {
+				shares = make(map[string]ownershipShare)
+				o.Files[f.Path] = shares
+			}
+			for _, sig := range credits {
+				author := ownershipAuthorKey(sig)
+				s := shares[author]
+				s.Lines += f.Lines
+				s.Knowledge += float64(f.Lines) * decay
+				shares[author] = s
+			}
+		}
+	}
+
+	headFiles, err := ownershipHeadFiles(repo, commitsMap)
+	if err != nil {
+		return nil, err
+	}
+	for file := range o.Files {
+		if _, ok := headFiles[file]; !ok {
+			delete(o.Files, file)
+		}
+	}
+	for file := range headFiles {
+		if _, ok := o.Files[file]; !ok {
+			o.Files[file] = map[string]ownershipShare{}
+		}
+	}
+	return o, nil
+}
+
+// moveFile carries knowledge from a renamed file to its new path.
+func (o *Ownership) moveFile(from, to string) {
+	old, ok := o.Files[from]
+	if !ok {
+		return
+	}
+	delete(o.Files, from)
+	shares := o.Files[to]
+	if shares == nil {
+		o.Files[to] = old
+		return
+	}
+	for author, s := range old {
+		cur := shares[author]
+		cur.Lines += s.Lines
+		cur.Knowledge += s.Knowledge
+		shares[author] = cur
+	}
+}
+
+// Directory returns the ownership view of dir, a path relative to the
+// repository root. Authors who have not committed in inactiveMonths are
+// inactive; zero or less means six months.
+func (o *Ownership) Directory(dir string, inactiveMonths int) (*OwnershipResponse, error) {
+	clean, err := normalizeScopePath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, dir)
+	}
+	if inactiveMonths <= 0 {
+		inactiveMonths = analyticsOwnershipInactiveMonths
+	}
+	cutoff := o.GeneratedAt.AddDate(0, -inactiveMonths, 0)
+
+	var files []string
+	for file := range o.Files {
+		if clean == "" || pathMatches(file, []string{clean}) {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 && clean != "" {
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, clean)
+	}
+	sort.Strings(files)
+
+	resp := &OwnershipResponse{
+		Path:           clean,
+		Entries:        []ownershipNode{},
+		Orphaned:       []ownershipOrphan{},
+		HalfLifeDays:   o.HalfLifeDays,
+		InactiveMonths: inactiveMonths,
+		Coverage:       o.Coverage,
+		GeneratedAt:    o.GeneratedAt.Format(time.RFC3339),
+	}
+
+	// A path naming a file views that file alone.
+	if len(files) == 1 && files[0] == clean {
+		node, orphan := o.node(path.Base(clean), clean, "blob", files, cutoff)
+		resp.Directory = node
+		if orphan != nil {
+			resp.Orphaned = append(resp.Orphaned, *orphan)
+			resp.OrphanedCount = 1
+		}
+		return resp, nil
+	}
+
+	prefix := ""
+	if clean != "" {
+		prefix = clean + "/"
+	}
+	children := make(map[string][]string)
+	var order []string
+	for _, file := range files {
+		name, _, _ := strings.Cut(strings.TrimPrefix(file, prefix), "/")
+		if _, ok := children[name]; !ok {
+			order = append(order, name)
+		}
+		children[name] = append(children[name], file)
+	}
+
+	var orphans []ownershipOrphan
+	for _, name := range order {
+		childPath := prefix + name
+		kind := "tree"
+		if members := children[name]; len(members) == 1 && members[0] == childPath {
+			kind = "blob"
+		}
+		node, _ := o.node(name, childPath, kind, children[name], cutoff)
+		resp.Entries = append(resp.Entries, node)
+	}
+	for _, file := range files {
+		if _, orphan := o.node(path.Base(file), file, "blob", []string{file}, cutoff); orphan != nil {
+			orphans = append(orphans, *orphan)
+		}
+	}
+	sort.SliceStable(resp.Entries, func(i, j int) bool {
+		a, b := resp.Entries[i], resp.Entries[j]
+		if a.Type != b.Type {
+			return a.Type == "tree"
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(orphans, func(i, j int) bool { return orphans[i].LastActive < orphans[j].LastActive })
+
+	resp.Directory, _ = o.node(path.Base(clean), clean, "tree", files, cutoff)
+	if clean == "" {
+		resp.Directory.Name = ""
+	}
+	resp.OrphanedCount = len(orphans)
+	if len(orphans) > analyticsOwnershipMaxOrphans {
+		orphans = orphans[:analyticsOwnershipMaxOrphans]
+	}
+	resp.Orphaned = append(resp.Orphaned, orphans...)
+	return resp, nil
+}
+
+// node totals the knowledge in files. For a single file it also reports the
+// file as orphaned when its main authors are all inactive.
+func (o *Ownership) node(name, nodePath, kind string, files []string, cutoff time.Time) (ownershipNode, *ownershipOrphan) {
+	node := ownershipNode{Name: name, Path: nodePath, Type: kind, Files: len(files), Owners: []ownershipOwner{}}
+	totals := make(map[string]ownershipShare)
+	var orphan *ownershipOrphan
+	for _, file := range files {
+		for author, s := range o.Files[file] {
+			t := totals[author]
+			t.Lines += s.Lines
+			t.Knowledge += s.Knowledge
+			totals[author] = t
+		}
+		if o.orphaned(file, cutoff) {
+			node.Orphaned++
+		}
+	}
+
+	ranked := make([]string, 0, len(totals))
+	total := 0.0
+	for author, s := range totals {
+		ranked = append(ranked, author)
+		total += s.Knowledge
+		node.LinesChanged += s.Lines
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if totals[ranked[i]].Knowledge != totals[ranked[j]].Knowledge {
+			return totals[ranked[i]].Knowledge > totals[ranked[j]].Knowledge
+		}
+		return ranked[i] < ranked[j]
+	})
+	mainAuthors := ownershipMainAuthors(ranked, totals, total)
+	node.BusFactor = len(mainAuthors)
+
+	for i, key := range ranked {
+		if i == analyticsOwnershipTopOwners {
+			break
+		}
+		a := o.Authors[key]
+		share := 0.0
+		if total > 0 {
+			share = totals[key].Knowledge * 100.0 / total
+		}
+		node.Owners = append(node.Owners, ownershipOwner{
+			Name:       a.Name,
+			Email:      a.Email,
+			Share:      share,
+			Lines:      totals[key].Lines,
+			LastActive: a.LastActive.Format(time.RFC3339),
+			Active:     !a.LastActive.Before(cutoff),
+		})
+	}
+
+	if len(files) == 1 && node.Orphaned == 1 {
+		orphan = &ownershipOrphan{Path: nodePath, Owners: make([]string, 0, len(mainAuthors))}
+		var last time.Time
+		for _, key := range mainAuthors {
+			a := o.Authors[key]
+			orphan.Owners = append(orphan.Owners, a.Name)
+			if a.LastActive.After(last) {
+				last = a.LastActive
+			}
+		}
+		orphan.LastActive = last.Format(time.RFC3339)
+	}
+	return node, orphan
+}
+
+// orphaned reports whether every main author of file is inactive. Files with
+// no recorded knowledge are not orphaned.
+func (o *Ownership) orphaned(file string, cutoff time.Time) bool {
+	shares := o.Files[file]
+	if len(shares) == 0 {
+		return false
+	}
+	ranked := make([]string, 0, len(shares))
+	total := 0.0
+	for author, s := range shares {
+		ranked = append(ranked, author)
+		total += s.Knowledge
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if shares[ranked[i]].Knowledge != shares[ranked[j]].Knowledge {
+			return shares[ranked[i]].Knowledge > shares[ranked[j]].Knowledge
+		}
+		return ranked[i] < ranked[j]
+	})
+	mainAuthors := ownershipMainAuthors(ranked, shares, total)
+	if len(mainAuthors) == 0 {
+		return false
+	}
+	for _, key := range mainAuthors {
+		if !o.Authors[key].LastActive.Before(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// ownershipMainAuthors returns the shortest prefix of ranked authors whose
+// knowledge covers half of total.
+func ownershipMainAuthors(ranked []string, shares map[string]ownershipShare, total float64) []string {
+	if total <= 0 {
+		return nil
+	}
+	covered := 0.0
+	for i, key := range ranked {
+		covered += shares[key].Knowledge
+		if covered*2 >= total {
+			return ranked[:i+1]
+		}
+	}
+	return ranked
+}
+
+func ownershipAuthorKey(sig gitcore.Signature) string {
+	if sig.Email != "" {
+		return strings.ToLower(sig.Email)
+	}
+	return strings.ToLower(sig.Name)
+}
+
+// ownershipHeadFiles lists the blobs in HEAD's tree.
+func ownershipHeadFiles(repo *gitcore.Repository, commitsMap map[gitcore.Hash]*gitcore.Commit) (map[string]struct{}, error) {
+	head := commitsMap[repo.Head()]
+	if head == nil {
+		return nil, fmt.Errorf("HEAD commit %s not found", repo.Head())
+	}
+	files := make(map[string]struct{})
+	var walk func(treeHash gitcore.Hash, prefix string) error
+	walk = func(treeHash gitcore.Hash, prefix string) error {
+		tree, err := repo.GetTree(treeHash)
+		if err != nil {
+			return err
+		}
+		for _, entry := range tree.Entries {
+			switch {
+			case entry.Mode == "160000":
+				continue
+			case entry.Type == gitcore.ObjectTypeTree:
+				if err := walk(entry.ID, prefix+entry.Name+"/"); err != nil {
+					return err
+				}
+			default:
+				files[prefix+entry.Name] = struct{}{}
+			}
+		}
+		return nil
+	}
+	if err := walk(head.Tree, ""); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// loadCommitLines returns the lines each file changed in c relative to its
+// first parent, from the store when it has them.
+func loadCommitLines(repo *gitcore.Repository, commitsMap map[gitcore.Hash]*gitcore.Commit, c *gitcore.Commit, store Store) (commitLines, error) {
+	key := ownershipStoreKeyPrefix + string(c.ID)
+	var lines commitLines
+	if store != nil && store.GetJSON(key, &lines) {
+		return lines, nil
+	}
+	lines, err := computeCommitLines(repo, commitsMap, c)
+	if err != nil {
+		return lines, err
+	}
+	if store != nil {
+		_ = store.PutJSON(key, lines)
+	}
+	return lines, nil
+}
+
+func computeCommitLines(repo *gitcore.Repository, commitsMap map[gitcore.Hash]*gitcore.Commit, c *gitcore.Commit) (commitLines, error) {
+	var parentTreeHash gitcore.Hash
+	if len(c.Parents) > 0 {
+		if p, ok := commitsMap[c.Parents[0]]; ok && p != nil {
+			parentTreeHash = p.Tree
+		}
+	}
+	entries, err := gitcore.TreeDiff(repo, parentTreeHash, c.Tree, "")
+	if err != nil {
+		if strings.Contains(err.Error(), "diff too large") {
+			return commitLines{TooLarge: true}, nil
+		}
+		return commitLines{}, err
+	}
+	var lines commitLines
+	for _, e := range entries {
+		if e.Status == gitcore.DiffStatusDeleted {
+			continue
+		}
+		f := fileLines{Path: e.Path}
+		if e.Status == gitcore.DiffStatusRenamed {
+			f.OldPath = e.OldPath
+		}
+		f.Lines = diffEntryLines(repo, e)
+		lines.Files = append(lines.Files, f)
+	}
+	return lines, nil
+}
+
+// diffEntryLines counts the lines added and removed in e. Binary, oversized,
+// and submodule changes count as one line so their authors still register.
+func diffEntryLines(repo *gitcore.Repository, e gitcore.DiffEntry) int {
+	if e.OldHash == e.NewHash {
+		return 0
+	}
+	if e.IsBinary || e.NewMode == "160000" || e.OldMode == "160000" {
+		return 1
+	}
+	diff, err := gitcore.ComputeFileDiff(repo, e.OldHash, e.NewHash, e.Path, 0)
+	if err != nil || diff.IsBinary || diff.Truncated {
+		return 1
+	}
+	n := 0
+	for _, h := range diff.Hunks {
+		for _, l := range h.Lines {
+			if l.Type != gitcore.LineTypeContext {
+				n++
+			}
+		}
+	}
+	return n
+}
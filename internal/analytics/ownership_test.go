@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOwnership(t *testing.T) {
+	longAgo := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	h := newHistoryRepo(t, longAgo)
+	h.commit("alice", "pkg/core/a.go", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+	h.commit("alice", "pkg/core/b.go", "b1\nb2\nb3\nb4\n")
+	h.commit("bob", "pkg/core/b.go", "x1\nx2\nx3\nx4\n")
+	h.commit("dave", "docs/old.md", "gone\n")
+	h.commit("dave", "docs/old.md", "")
+	h.When = now.AddDate(0, 0, -3)
+	h.commit("carol", "web/app.js", "1\n2\n3\n4\n5\n6\n")
+	h.git("carol", "mv", "pkg/core/b.go", "pkg/core/c.go")
+	h.git("carol", "commit", "-q", "-m", "rename b.go")
+	repo := h.open()
+
+	store := &memStore{entries: make(map[string][]byte)}
+	ownership, err := BuildOwnership(repo, OwnershipOptions{Now: now, Store: store})
+	if err != nil {
+		t.Fatalf("BuildOwnership() error = %v", err)
+	}
+	if ownership.Coverage.AnalyzedCommits != 7 || ownership.HalfLifeDays != 180 {
+		t.Fatalf("coverage = %+v, half-life %d days", ownership.Coverage, ownership.HalfLifeDays)
+	}
+	if _, ok := ownership.Files["docs/old.md"]; ok {
+		t.Fatal("deleted file has ownership")
+	}
+	if got := ownership.Files["pkg/core/c.go"]; got["alice@example.com"].Lines != 4 || got["bob@example.com"].Lines != 8 {
+		t.Fatalf("renamed file shares = %+v, want b.go's history", got)
+	}
+
+	root, err := ownership.Directory("", 0)
+	if err != nil {
+		t.Fatalf("Directory(root) error = %v", err)
+	}
+	if root.InactiveMonths != 6 || root.Directory.Files != 3 || root.Directory.LinesChanged != 28 {
+		t.Fatalf("root = %+v", root.Directory)
+	}
+	// Alice changed the most lines, but two years of decay leave carol's
+	// recent work as most of the knowledge.
+	if owners := root.Directory.Owners; root.Directory.BusFactor != 1 || owners[0].Name != "carol" || !owners[0].Active || owners[0].Share < 75 {
+		t.Fatalf("root owners = %+v, bus factor %d", owners, root.Directory.BusFactor)
+	}
+	if len(root.Entries) != 2 || root.Entries[0].Path != "pkg" || root.Entries[0].Type != "tree" || root.Entries[1].Path != "web" {
+		t.Fatalf("root entries = %+v", root.Entries)
+	}
+	if root.OrphanedCount != 2 || root.Orphaned[0].Path != "pkg/core/a.go" || root.Orphaned[1].Path != "pkg/core/c.go" {
+		t.Fatalf("root orphans = %+v", root.Orphaned)
+	}
+	if got := root.Orphaned[1].Owners; len(got) != 1 || got[0] != "bob" {
+		t.Fatalf("c.go main authors = %v, want bob", got)
+	}
+
+	core, err := ownership.Directory("pkg/core/", 12)
+	if err != nil {
+		t.Fatalf("Directory(pkg/core) error = %v", err)
+	}
+	if core.Path != "pkg/core" || core.Directory.Orphaned != 2 || len(core.Entries) != 2 || core.Entries[1].Name != "c.go" || core.Entries[1].Type != "blob" {
+		t.Fatalf("pkg/core = %+v", core)
+	}
+	if owners := core.Directory.Owners; core.Directory.BusFactor != 1 || owners[0].Name != "alice" || owners[0].Lines != 14 || owners[0].Active {
+		t.Fatalf("pkg/core owners = %+v", owners)
+	}
+
+	// Bob has been away less than two years, so 24 months leaves his file
+	// owned.
+	file, err := ownership.Directory("pkg/core/c.go", 24)
+	if err != nil {
+		t.Fatalf("Directory(c.go) error = %v", err)
+	}
+	if file.Directory.Type != "blob" || file.Directory.BusFactor != 1 || file.OrphanedCount != 0 || len(file.Entries) != 0 {
+		t.Fatalf("c.go = %+v", file)
+	}
+
+	for _, missing := range []string{"nope", "../pkg", "pkg/cor"} {
+		if _, err := ownership.Directory(missing, 0); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("Directory(%q) error = %v, want ErrPathNotFound", missing, err)
+		}
+	}
+
+	// A second build reads line counts from the store.
+	if store.puts != 7 {
+		t.Fatalf("store saved %d commits, want 7", store.puts)
+	}
+	again, err := BuildOwnership(repo, OwnershipOptions{Now: now, Store: store})
+	if err != nil {
+		t.Fatalf("BuildOwnership(stored) error = %v", err)
+	}
+	if store.puts != 7 || again.Files["pkg/core/a.go"]["alice@example.com"] != ownership.Files["pkg/core/a.go"]["alice@example.com"] {
+		t.Fatalf("stored build = %d puts, a.go %+v", store.puts, again.Files["pkg/core/a.go"])
+	}
+}
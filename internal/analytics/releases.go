@@ -0,0 +1,328 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	releaseStoreKeyPrefix = "analytics-release-diffstat:v1:"
+
+	analyticsReleaseTopAuthors = 5
+)
+
+// ErrInvalidTagPattern reports a release tag pattern that is not a valid glob.
+var ErrInvalidTagPattern = errors.New("invalid tag pattern")
+
+// ReleaseOptions configures BuildReleases.
+type ReleaseOptions struct {
+	// Pattern is a glob that release tag names must match, such as "v*".
+	// Empty means every tag.
+	Pattern string
+	// Now ends the release frequency series. Zero means time.Now.
+	Now time.Time
+	// Store, when set, keeps diffstats between releases across builds.
+	Store Store
+}
+
+// Releases is the release timeline. A release is a tag matching the pattern,
+// dated by its tagger or, for lightweight tags, its commit's committer. Each
+// commit belongs to the earliest release that contains it, so lead time is
+// the time from authoring a commit to its first release.
+type Releases struct {
+	Pattern     string          `json:"pattern"`
+	Releases    []Release       `json:"releases"`
+	Frequency   []ReleaseMonth  `json:"frequency"`
+	PerMonth    float64         `json:"perMonth"`
+	LeadTime    ReleaseLeadTime `json:"leadTime"`
+	Unreleased  int             `json:"unreleased"`
+	Coverage    ReleaseCoverage `json:"coverage"`
+	GeneratedAt string          `json:"generatedAt"`
+}
+
+// Release summarizes one tag, newest first in Releases. Diffstat compares
+// the release's tree with the previous release's, or with an empty tree for
+// the first release.
+type Release struct {
+	Tag                string          `json:"tag"`
+	Hash               string          `json:"hash"`
+	Date               string          `json:"date"`
+	Annotated          bool            `json:"annotated"`
+	Previous           string          `json:"previous,omitempty"`
+	HoursSincePrevious float64         `json:"hoursSincePrevious"`
+	Commits            int             `json:"commits"`
+	Contributors       int             `json:"contributors"`
+	TopAuthors         []string        `json:"topAuthors"`
+	Diffstat           ReleaseDiffstat `json:"diffstat"`
+	LeadTime           ReleaseLeadTime `json:"leadTime"`
+}
+
+// ReleaseDiffstat counts the files and lines changed between two release
+// trees. TooLarge marks a diff past the tree diff limit, which is left
+// uncounted.
+type ReleaseDiffstat struct {
+	FilesChanged int  `json:"filesChanged"`
+	Additions    int  `json:"additions"`
+	Deletions    int  `json:"deletions"`
+	TooLarge     bool `json:"tooLarge,omitempty"`
+}
+
+// ReleaseLeadTime is the median and 90th percentile time, in hours, from
+// authoring commits to their first release.
+type ReleaseLeadTime struct {
+	MedianHours float64 `json:"medianHours"`
+	P90Hours    float64 `json:"p90Hours"`
+}
+
+// ReleaseMonth counts the releases dated in one calendar month (UTC),
+// labelled "2006-01".
+type ReleaseMonth struct {
+	Month string `json:"month"`
+	Count int    `json:"count"`
+}
+
+// ReleaseCoverage counts releases whose diffstat could not be computed.
+type ReleaseCoverage struct {
+	DiffErrors int `json:"diffErrors"`
+}
+
+// ReleasesCacheKey identifies the release timeline for pattern. Tags can move
+// without new commits, so the key covers every tag as well as HEAD.
+func ReleasesCacheKey(repo *gitcore.Repository, pattern string) string {
+	h := fnv.New64a()
+	for _, tag := range repo.TagRefs() {
+		_, _ = fmt.Fprintf(h, "%s %s %v\n", tag.Name, tag.Commit, tag.Annotated)
+	}
+	return CacheKey(repo, fmt.Sprintf("releases:%x:%s", h.Sum64(), pattern))
+}
+
+// BuildReleases computes the release timeline for the tags matching
+// opts.Pattern.
+func BuildReleases(repo *gitcore.Repository, opts ReleaseOptions) (*Releases, error) {
+	if opts.Pattern != "" {
+		if _, err := path.Match(opts.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTagPattern, opts.Pattern)
+		}
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	commitsMap := repo.Commits()
+
+	type tagged struct {
+		gitcore.TagRef
+		date time.Time
+	}
+	var tags []tagged
+	for _, tag := range repo.TagRefs() {
+		if opts.Pattern != "" {
+			if ok, _ := path.Match(opts.Pattern, tag.Name); !ok {
+				continue
+			}
+		}
+		date := tag.Tagger.When
+		if !tag.Annotated {
+			date = commitsMap[tag.Commit].Committer.When
+		}
+		tags = append(tags, tagged{TagRef: tag, date: date})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].date.Equal(tags[j].date) {
+			return tags[i].date.Before(tags[j].date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	result := &Releases{
+		Pattern:     opts.Pattern,
+		Releases:    []Release{},
+		Frequency:   []ReleaseMonth{},
+		GeneratedAt: opts.Now.UTC().Format(time.RFC3339),
+	}
+	released := make(map[gitcore.Hash]bool)
+	var allLeadHours []float64
+	for i, tag := range tags {
+		r := Release{
+			Tag:        tag.Name,
+			Hash:       string(tag.Commit),
+			Date:       tag.date.UTC().Format(time.RFC3339),
+			Annotated:  tag.Annotated,
+			TopAuthors: []string{},
+		}
+		var previousTree gitcore.Hash
+		if i > 0 {
+			prev := tags[i-1]
+			r.Previous = prev.Name
+			r.HoursSincePrevious = tag.date.Sub(prev.date).Hours()
+			previousTree = commitsMap[prev.Commit].Tree
+		}
+
+		commits := releaseNewCommits(commitsMap, tag.Commit, released)
+		r.Commits = len(commits)
+		authorCommits := make(map[string]int)
+		authorNames := make(map[string]string)
+		leadHours := make([]float64, 0, len(commits))
+		for _, c := range commits {
+			key := ownershipAuthorKey(c.Author)
+			authorCommits[key]++
+			authorNames[key] = c.Author.Name
+			leadHours = append(leadHours, math.Max(tag.date.Sub(c.Author.When).Hours(), 0))
+		}
+		r.Contributors = len(authorCommits)
+		r.TopAuthors = releaseTopAuthors(authorCommits, authorNames)
+		r.LeadTime = releaseLeadTimeOf(leadHours)
+		allLeadHours = append(allLeadHours, leadHours...)
+
+		diffstat, err := loadReleaseDiffstat(repo, previousTree, commitsMap[tag.Commit].Tree, opts.Store)
+		if err != nil {
+			result.Coverage.DiffErrors++
+		}
+		r.Diffstat = diffstat
+		result.Releases = append(result.Releases, r)
+	}
+	result.LeadTime = releaseLeadTimeOf(allLeadHours)
+
+	if head := repo.Head(); head != "" {
+		result.Unreleased = len(releaseNewCommits(commitsMap, head, released))
+	}
+	if len(tags) > 0 {
+		dates := make([]time.Time, len(tags))
+		for i, tag := range tags {
+			dates[i] = tag.date
+		}
+		result.Frequency = releaseFrequency(dates, opts.Now)
+		result.PerMonth = float64(len(tags)) / float64(len(result.Frequency))
+	}
+
+	// Newest releases first.
+	for i, j := 0, len(result.Releases)-1; i < j; i, j = i+1, j-1 {
+		result.Releases[i], result.Releases[j] = result.Releases[j], result.Releases[i]
+	}
+	return result, nil
+}
+
+// releaseNewCommits returns the ancestors of tip, tip included, that are not
+// yet in released, and marks them released.
+func releaseNewCommits(commitsMap map[gitcore.Hash]*gitcore.Commit, tip gitcore.Hash, released map[gitcore.Hash]bool) []*gitcore.Commit {
+	var commits []*gitcore.Commit
+	stack := []gitcore.Hash{tip}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		c := commitsMap[id]
+		if c == nil || released[id] {
+			continue
+		}
+		released[id] = true
+		commits = append(commits, c)
+		stack = append(stack, c.Parents...)
+	}
+	return commits
+}
+
+func releaseTopAuthors(commits map[string]int, names map[string]string) []string {
+	keys := make([]string, 0, len(commits))
+	for key := range commits {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if commits[keys[i]] != commits[keys[j]] {
+			return commits[keys[i]] > commits[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	top := []string{}
+	for i, key := range keys {
+		if i == analyticsReleaseTopAuthors {
+			break
+		}
+		top = append(top, names[key])
+	}
+	return top
+}
+
+// releaseLeadTimeOf returns the median and 90th percentile of hours, using
+// the nearest rank.
+func releaseLeadTimeOf(hours []float64) ReleaseLeadTime {
+	if len(hours) == 0 {
+		return ReleaseLeadTime{}
+	}
+	sorted := append([]float64(nil), hours...)
+	sort.Float64s(sorted)
+	rank := func(p float64) float64 {
+		return sorted[int(p*float64(len(sorted)-1)+0.5)]
+	}
+	return ReleaseLeadTime{MedianHours: rank(0.5), P90Hours: rank(0.9)}
+}
+
+// releaseFrequency counts dates, oldest first, per calendar month (UTC)
+// through now, including months without releases.
+func releaseFrequency(dates []time.Time, now time.Time) []ReleaseMonth {
+	first := dates[0].UTC()
+	now = now.UTC()
+	if now.Before(first) {
+		now = first
+	}
+	index := make(map[string]int)
+	var months []ReleaseMonth
+	for m := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC); !m.After(now); m = m.AddDate(0, 1, 0) {
+		label := m.Format("2006-01")
+		index[label] = len(months)
+		months = append(months, ReleaseMonth{Month: label})
+	}
+	for _, date := range dates {
+		if i, ok := index[date.UTC().Format("2006-01")]; ok {
+			months[i].Count++
+		}
+	}
+	return months
+}
+
+// loadReleaseDiffstat returns the diffstat between two release trees, from
+// the store when it has it.
+func loadReleaseDiffstat(repo *gitcore.Repository, oldTree, newTree gitcore.Hash, store Store) (ReleaseDiffstat, error) {
+	key := releaseStoreKeyPrefix + string(oldTree) + ":" + string(newTree)
+	var stat ReleaseDiffstat
+	if store != nil && store.GetJSON(key, &stat) {
+		return stat, nil
+	}
+	entries, err := gitcore.TreeDiff(repo, oldTree, newTree, "")
+	if err != nil {
+		if !strings.Contains(err.Error(), "diff too large") {
+			return ReleaseDiffstat{}, err
+		}
+		stat.TooLarge = true
+	}
+	for _, e := range entries {
+		stat.FilesChanged++
+		if e.OldHash == e.NewHash || e.IsBinary || e.NewMode == "160000" || e.OldMode == "160000" {
+			continue
+		}
+		diff, err := gitcore.ComputeFileDiff(repo, e.OldHash, e.NewHash, e.Path, 0)
+		if err != nil || diff.IsBinary || diff.Truncated {
+			continue
+		}
+		for _, h := range diff.Hunks {
+			for _, l := range h.Lines {
+				switch l.Type {
+				case gitcore.LineTypeAddition:
+					stat.Additions++
+				case gitcore.LineTypeDeletion:
+					stat.Deletions++
+				}
+			}
+		}
+	}
+	if store != nil {
+		_ = store.PutJSON(key, stat)
+	}
+	return stat, nil
+}
@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildReleases(t *testing.T) {
+	start := time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	h := newHistoryRepo(t, start)
+	h.commit("alice", "a.txt", "1\n")
+	h.commit("bob", "b.txt", "1\n2\n")
+	h.git("alice", "tag", "v1.0")
+	h.commit("alice", "a.txt", "1\nx\n")
+	h.When = start.AddDate(0, 0, 40)
+	h.git("alice", "tag", "-a", "v1.1", "-m", "v1.1")
+	h.git("alice", "tag", "nightly-1")
+	h.commit("carol", "c.txt", "c\n")
+	repo := h.open()
+
+	store := &memStore{entries: make(map[string][]byte)}
+	releases, err := BuildReleases(repo, ReleaseOptions{Pattern: "v*", Now: now, Store: store})
+	if err != nil {
+		t.Fatalf("BuildReleases() error = %v", err)
+	}
+	if len(releases.Releases) != 2 || releases.Unreleased != 1 {
+		t.Fatalf("releases = %+v", releases)
+	}
+
+	latest := releases.Releases[0]
+	if latest.Tag != "v1.1" || !latest.Annotated || latest.Previous != "v1.0" || latest.HoursSincePrevious != 957 {
+		t.Fatalf("latest = %+v", latest)
+	}
+	if latest.Commits != 1 || latest.Contributors != 1 || latest.Diffstat != (ReleaseDiffstat{FilesChanged: 1, Additions: 1}) {
+		t.Fatalf("latest counts = %+v", latest)
+	}
+	if latest.LeadTime != (ReleaseLeadTime{MedianHours: 954, P90Hours: 954}) {
+		t.Fatalf("latest lead time = %+v", latest.LeadTime)
+	}
+
+	first := releases.Releases[1]
+	if first.Tag != "v1.0" || first.Annotated || first.Previous != "" || first.Commits != 2 || first.Contributors != 2 ||
+		!slices.Equal(first.TopAuthors, []string{"alice", "bob"}) {
+		t.Fatalf("first = %+v", first)
+	}
+	if first.Diffstat != (ReleaseDiffstat{FilesChanged: 2, Additions: 3}) || first.LeadTime != (ReleaseLeadTime{MedianHours: 2, P90Hours: 2}) {
+		t.Fatalf("first stats = %+v", first)
+	}
+
+	if releases.LeadTime != (ReleaseLeadTime{MedianHours: 2, P90Hours: 954}) {
+		t.Fatalf("overall lead time = %+v", releases.LeadTime)
+	}
+	wantMonths := []ReleaseMonth{{"2025-01", 1}, {"2025-02", 1}, {"2025-03", 0}, {"2025-04", 0}}
+	if !slices.Equal(releases.Frequency, wantMonths) || releases.PerMonth != 0.5 {
+		t.Fatalf("frequency = %+v, %v per month", releases.Frequency, releases.PerMonth)
+	}
+
+	// Diffstats are read back from the store.
+	if store.puts != 2 {
+		t.Fatalf("store saved %d diffstats, want 2", store.puts)
+	}
+	if _, err := BuildReleases(repo, ReleaseOptions{Pattern: "v*", Now: now, Store: store}); err != nil || store.puts != 2 {
+		t.Fatalf("stored build: puts = %d, err = %v", store.puts, err)
+	}
+
+	// The lightweight nightly tag is dated by its commit, so it ships the
+	// change before v1.1 is tagged.
+	all, err := BuildReleases(repo, ReleaseOptions{Now: now})
+	if err != nil || len(all.Releases) != 3 || all.Releases[1].Tag != "nightly-1" || all.Releases[1].Commits != 1 || all.Releases[0].Commits != 0 {
+		t.Fatalf("all tags = %+v, %v", all, err)
+	}
+
+	if _, err := BuildReleases(repo, ReleaseOptions{Pattern: "v[1"}); !errors.Is(err, ErrInvalidTagPattern) {
+		t.Fatalf("invalid pattern error = %v, want ErrInvalidTagPattern", err)
+	}
+}
@@ -0,0 +1,307 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVSections are the sections WriteCSV can export, in report order.
+var CSVSections = []string{"velocity", "authors", "hotspots"}
+
+// WriteCSV writes one section of the response as CSV with a header row.
+func (r *Response) WriteCSV(w io.Writer, section string) error {
+	cw := csv.NewWriter(w)
+	switch section {
+	case "velocity":
+		_ = cw.Write([]string{"week", "commits", "rolling_avg"})
+		for _, week := range r.Velocity.Weeks {
+			_ = cw.Write([]string{reportDate(week.TS), strconv.Itoa(week.Count), reportFloat(week.Avg)})
+		}
+	case "authors":
+		_ = cw.Write([]string{"name", "email", "commits"})
+		for _, a := range r.Authors.Authors {
+			_ = cw.Write([]string{a.Name, a.Email, strconv.Itoa(a.Count)})
+		}
+	case "hotspots":
+		_ = cw.Write([]string{"path", "risk_score", "status", "churn", "rework_rate", "large_change_share", "top_author", "top_author_share", "recommendation"})
+		for _, h := range r.Hotspots {
+			_ = cw.Write([]string{
+				h.Path, strconv.Itoa(h.RiskScore), h.Status, strconv.Itoa(h.ChurnCount),
+				reportFloat(h.ReworkRate), reportFloat(h.LargeChangeShare),
+				h.TopAuthor, reportFloat(h.TopAuthorShare), h.Recommendation,
+			})
+		}
+	default:
+		return fmt.Errorf("unknown section %q", section)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Markdown renders the response as a report: summary signals, velocity,
+// contributors, hotspots, and working patterns.
+func (r *Response) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Analytics for %s\n\n", r.window())
+	fmt.Fprintf(&b, "%d commits, %.1f per week", r.Velocity.TotalCommits, r.Velocity.AvgPerWeek)
+	if best := r.Velocity.BestWeek; best != nil && best.Count > 0 {
+		fmt.Fprintf(&b, ", best week %s with %d", reportDate(best.TS), best.Count)
+	}
+	fmt.Fprintf(&b, ". %.1f%% merges, median change %d files, %.1f%% rework.\n",
+		r.Merges.MergePercent, r.ChangeSize.Median, r.Rework.AvgRate)
+
+	if len(r.Summary) > 0 {
+		b.WriteString("\n### Signals\n\n| Signal | Current | Change | Status | Next step |\n| --- | ---: | ---: | --- | --- |\n")
+		for _, s := range r.Summary {
+			fmt.Fprintf(&b, "| %s | %.1f%% | %+.1f | %s | %s |\n", s.Label, s.Current, s.Delta, s.Status, reportCell(s.Recommendation))
+		}
+	}
+	if len(r.Authors.Authors) > 0 {
+		b.WriteString("\n### Top Contributors\n\n| Author | Commits |\n| --- | ---: |\n")
+		for _, a := range r.Authors.Authors {
+			fmt.Fprintf(&b, "| %s | %d |\n", reportCell(a.Name), a.Count)
+		}
+	}
+	if len(r.Hotspots) > 0 {
+		b.WriteString("\n### Risk Hotspots\n\n| Path | Risk | Churn | Rework | Top author |\n| --- | ---: | ---: | ---: | --- |\n")
+		for _, h := range r.Hotspots {
+			fmt.Fprintf(&b, "| `%s` | %d (%s) | %d | %.1f%% | %s (%.0f%%) |\n",
+				h.Path, h.RiskScore, h.Status, h.ChurnCount, h.ReworkRate, reportCell(h.TopAuthor), h.TopAuthorShare)
+		}
+	}
+	if skew := r.WorkPatterns.DateSkew; skew.Commits > 0 {
+		after := r.WorkPatterns.AfterHours
+		fmt.Fprintf(&b, "\n### Working Patterns\n\n%.1f%% of commits after hours and %.1f%% on weekends. "+
+			"Commits land a median %.1fh after authoring (p90 %.1fh); %.1f%% were rewritten.\n",
+			after.AfterHoursShare, after.WeekendShare, skew.MedianHours, skew.P90Hours, skew.RewrittenShare)
+	}
+	return b.String()
+}
+
+// WriteHTML writes a self-contained HTML report with inline SVG charts, for
+// sharing where the server is not running.
+func (r *Response) WriteHTML(w io.Writer, title string) error {
+	return reportTemplate.Execute(w, struct {
+		Title    string
+		Window   string
+		R        *Response
+		Velocity reportLineChart
+		Authors  []reportBar
+		Heatmap  []reportCellRect
+	}{
+		Title:    title,
+		Window:   r.window(),
+		R:        r,
+		Velocity: newReportLineChart(r.Velocity.Weeks),
+		Authors:  newReportBars(r.Authors.Authors),
+		Heatmap:  newReportHeatmap(r.Heatmap),
+	})
+}
+
+func (r *Response) window() string {
+	if r.Start != "" && r.End != "" {
+		return r.Start[:min(len(r.Start), 10)] + " to " + r.End[:min(len(r.End), 10)]
+	}
+	if r.Period == "all" {
+		return "all history"
+	}
+	return "the last " + r.Period
+}
+
+func reportDate(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format("2006-01-02")
+}
+
+func reportFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// reportCell keeps a value from breaking a Markdown table row.
+func reportCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+const (
+	reportChartWidth  = 720
+	reportChartHeight = 180
+	reportChartPad    = 24
+	reportBarHeight   = 18
+	reportCellSize    = 22
+)
+
+type reportLineChart struct {
+	Points    string
+	AvgPoints string
+	Max       int
+	First     string
+	Last      string
+}
+
+func newReportLineChart(weeks []analyticsWeekCount) reportLineChart {
+	chart := reportLineChart{}
+	if len(weeks) == 0 {
+		return chart
+	}
+	for _, week := range weeks {
+		chart.Max = max(chart.Max, week.Count)
+	}
+	chart.First, chart.Last = reportDate(weeks[0].TS), reportDate(weeks[len(weeks)-1].TS)
+	plotW := float64(reportChartWidth - 2*reportChartPad)
+	plotH := float64(reportChartHeight - 2*reportChartPad)
+	point := func(i int, v float64) string {
+		x := float64(reportChartPad)
+		if len(weeks) > 1 {
+			x += plotW * float64(i) / float64(len(weeks)-1)
+		}
+		y := float64(reportChartHeight - reportChartPad)
+		if chart.Max > 0 {
+			y -= plotH * v / float64(chart.Max)
+		}
+		return fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	counts := make([]string, len(weeks))
+	avgs := make([]string, len(weeks))
+	for i, week := range weeks {
+		counts[i] = point(i, float64(week.Count))
+		avgs[i] = point(i, week.Avg)
+	}
+	chart.Points, chart.AvgPoints = strings.Join(counts, " "), strings.Join(avgs, " ")
+	return chart
+}
+
+type reportBar struct {
+	Label string
+	Count int
+	Y     int
+	Width float64
+}
+
+func newReportBars(authors []analyticsAuthor) []reportBar {
+	most := 0
+	for _, a := range authors {
+		most = max(most, a.Count)
+	}
+	bars := make([]reportBar, len(authors))
+	for i, a := range authors {
+		bars[i] = reportBar{Label: a.Name, Count: a.Count, Y: i * (reportBarHeight + 4)}
+		if most > 0 {
+			bars[i].Width = float64(reportChartWidth-240) * float64(a.Count) / float64(most)
+		}
+	}
+	return bars
+}
+
+type reportCellRect struct {
+	X, Y    int
+	Opacity float64
+	Title   string
+}
+
+func newReportHeatmap(h analyticsHeatmap) []reportCellRect {
+	days := []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	cells := make([]reportCellRect, 0, 7*24)
+	for day := range 7 {
+		for hour := range 24 {
+			count := h.Grid[day][hour]
+			opacity := 0.08
+			if count > 0 && h.Max > 0 {
+				opacity = 0.2 + 0.8*float64(count)/float64(h.Max)
+			}
+			cells = append(cells, reportCellRect{
+				X:       40 + hour*reportCellSize,
+				Y:       day * reportCellSize,
+				Opacity: opacity,
+				Title:   fmt.Sprintf("%s %02d:00 · %d commits", days[day], hour, count),
+			})
+		}
+	}
+	return cells
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": reportDate,
+	"pct":  func(v float64) string { return reportFloat(v) + "%" },
+	"add":  func(a, b int) int { return a + b },
+	"barsHeight": func(bars []reportBar) int {
+		return len(bars) * (reportBarHeight + 4)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.5 system-ui, sans-serif; color: #1f2328; max-width: 780px; margin: 32px auto; padding: 0 16px; }
+h1 { font-size: 22px; margin-bottom: 0; }
+h2 { font-size: 16px; margin-top: 32px; border-bottom: 1px solid #d8dce2; padding-bottom: 4px; }
+.muted { color: #57606a; }
+.stats { display: flex; gap: 24px; flex-wrap: wrap; }
+.stat b { display: block; font-size: 20px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eaeef2; }
+td.num, th.num { text-align: right; }
+.risk { color: #cf222e; } .watch { color: #9a6700; } .ok { color: #1a7f37; }
+svg text { font: 11px system-ui, sans-serif; fill: #57606a; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">Analytics for {{.Window}}, generated {{.R.GeneratedAt}}.</p>
+<div class="stats">
+<div class="stat"><b>{{.R.Velocity.TotalCommits}}</b>commits</div>
+<div class="stat"><b>{{printf "%.1f" .R.Velocity.AvgPerWeek}}</b>per week</div>
+<div class="stat"><b>{{pct .R.Merges.MergePercent}}</b>merges</div>
+<div class="stat"><b>{{.R.ChangeSize.Median}}</b>median files changed</div>
+<div class="stat"><b>{{pct .R.Rework.AvgRate}}</b>rework</div>
+</div>
+{{with .R.Summary}}
+<h2>Signals</h2>
+<table>
+<tr><th>Signal</th><th class="num">Current</th><th class="num">Change</th><th>Next step</th></tr>
+{{range .}}<tr><td class="{{.Status}}">{{.Label}}</td><td class="num">{{pct .Current}}</td><td class="num">{{printf "%+.1f" .Delta}}</td><td>{{.Recommendation}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Velocity}}{{if .Points}}
+<h2>Commit Velocity</h2>
+<svg width="720" height="180" viewBox="0 0 720 180" role="img" aria-label="Weekly commits">
+<line x1="24" y1="156" x2="696" y2="156" stroke="#d8dce2"/>
+<polyline points="{{.Points}}" fill="none" stroke="#0ea5e9" stroke-width="2"/>
+<polyline points="{{.AvgPoints}}" fill="none" stroke="#57606a" stroke-width="1" stroke-dasharray="4 3"/>
+<text x="24" y="174">{{.First}}</text>
+<text x="696" y="174" text-anchor="end">{{.Last}}</text>
+<text x="24" y="16">{{.Max}} commits / week</text>
+</svg>
+{{end}}{{end}}
+{{with .Authors}}
+<h2>Top Contributors</h2>
+<svg width="720" height="{{barsHeight .}}" viewBox="0 0 720 {{barsHeight .}}" role="img" aria-label="Commits per author">
+{{range .}}<text x="0" y="{{add .Y 13}}">{{.Label}}</text>
+<rect x="180" y="{{.Y}}" width="{{printf "%.1f" .Width}}" height="18" rx="3" fill="#0ea5e9"/>
+<text x="{{printf "%.1f" .Width}}" y="{{add .Y 13}}" dx="186">{{.Count}}</text>
+{{end}}</svg>
+{{end}}
+<h2>Activity Heatmap <span class="muted">({{.R.TimeZone}})</span></h2>
+<svg width="580" height="160" viewBox="0 0 580 160" role="img" aria-label="Commits by weekday and hour">
+<text x="0" y="15">Mon</text><text x="0" y="81">Thu</text><text x="0" y="147">Sun</text>
+{{range .Heatmap}}<rect x="{{.X}}" y="{{.Y}}" width="20" height="20" rx="3" fill="#0ea5e9" fill-opacity="{{printf "%.2f" .Opacity}}"><title>{{.Title}}</title></rect>
+{{end}}</svg>
+{{with .R.Hotspots}}
+<h2>Risk Hotspots</h2>
+<table>
+<tr><th>Path</th><th class="num">Risk</th><th class="num">Churn</th><th class="num">Rework</th><th>Top author</th><th>Recommendation</th></tr>
+{{range .}}<tr><td><code>{{.Path}}</code></td><td class="num {{.Status}}">{{.RiskScore}}</td><td class="num">{{.ChurnCount}}</td><td class="num">{{pct .ReworkRate}}</td><td>{{.TopAuthor}} ({{printf "%.0f" .TopAuthorShare}}%)</td><td>{{.Recommendation}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .R.WorkPatterns}}{{if .DateSkew.Commits}}
+<h2>Working Patterns</h2>
+<p>{{pct .AfterHours.AfterHoursShare}} of commits after hours and {{pct .AfterHours.WeekendShare}} on weekends.
+Commits land a median {{printf "%.1f" .DateSkew.MedianHours}}h after authoring (p90 {{printf "%.1f" .DateSkew.P90Hours}}h); {{pct .DateSkew.RewrittenShare}} were rewritten.</p>
+{{end}}{{end}}
+</body>
+</html>
+`))
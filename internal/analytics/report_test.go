@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newReportResponse(t *testing.T) *Response {
+	t.Helper()
+	h := newHistoryRepo(t, time.Now().UTC().AddDate(0, 0, -20))
+	h.commit("alice", "main.go", "package main\n")
+	h.commit("bob", "main.go", "package main\n\nfunc main() {}\n")
+	h.commit("alice", "lib|pipe.go", "package main\n")
+
+	q, err := ParseQuery(QueryParams{Period: "3m"})
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	resp, err := Build(h.open(), q)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return resp
+}
+
+func TestResponseWriteCSV(t *testing.T) {
+	resp := newReportResponse(t)
+
+	tests := []struct {
+		section string
+		header  string
+		rows    int
+	}{
+		{section: "velocity", header: "week,commits,rolling_avg", rows: len(resp.Velocity.Weeks)},
+		{section: "authors", header: "name,email,commits", rows: 2},
+		{section: "hotspots", header: "path,risk_score,status", rows: len(resp.Hotspots)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.section, func(t *testing.T) {
+			var b strings.Builder
+			if err := resp.WriteCSV(&b, tt.section); err != nil {
+				t.Fatalf("WriteCSV() error = %v", err)
+			}
+			if !strings.HasPrefix(b.String(), tt.header) {
+				t.Fatalf("header = %q", b.String())
+			}
+			records, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+			if err != nil {
+				t.Fatalf("csv: %v", err)
+			}
+			if len(records)-1 != tt.rows {
+				t.Fatalf("rows = %d, want %d", len(records)-1, tt.rows)
+			}
+		})
+	}
+
+	var b strings.Builder
+	if err := resp.WriteCSV(&b, "heatmap"); err == nil {
+		t.Fatal("WriteCSV(heatmap) error = nil")
+	}
+	b.Reset()
+	_ = resp.WriteCSV(&b, "authors")
+	if !strings.Contains(b.String(), "alice,alice@example.com,2") {
+		t.Fatalf("authors csv = %q", b.String())
+	}
+}
+
+func TestResponseMarkdown(t *testing.T) {
+	resp := newReportResponse(t)
+	md := resp.Markdown()
+	for _, want := range []string{
+		"## Analytics for the last 3m",
+		"3 commits",
+		"### Signals",
+		"| alice | 2 |",
+		"### Risk Hotspots",
+		"| `main.go` |",
+		"`lib|pipe.go`",
+		"### Working Patterns",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("Markdown() missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestResponseWriteHTML(t *testing.T) {
+	resp := newReportResponse(t)
+	var b strings.Builder
+	if err := resp.WriteHTML(&b, "<repo> analytics"); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	html := b.String()
+	for _, want := range []string{
+		"<title>&lt;repo&gt; analytics</title>",
+		`<polyline points="`,
+		">alice</text>",
+		"Mon 00:00 · 0 commits",
+		"<code>main.go</code>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("WriteHTML() missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<script") || strings.Contains(html, "src=") {
+		t.Fatal("report should not load external resources")
+	}
+}
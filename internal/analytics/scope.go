@@ -0,0 +1,227 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// Merge modes select which commits count as work. Merge commits always count
+// toward the merges section; a merge's changes are its diff against its first
+// parent.
+const (
+	// MergesExclude leaves merge commits out of work metrics.
+	MergesExclude = "exclude"
+	// MergesInclude counts merge commits as work alongside the rest.
+	MergesInclude = "include"
+	// MergesOnly analyzes merge commits alone.
+	MergesOnly = "only"
+)
+
+// ErrInvalidRevision reports a Query.Revision that does not resolve.
+var ErrInvalidRevision = errors.New("invalid revision")
+
+// analyticsScope echoes the filters a response was built with.
+type analyticsScope struct {
+	Paths    []string `json:"paths,omitempty"`
+	Revision string   `json:"revision,omitempty"`
+	Authors  []string `json:"authors,omitempty"`
+	Merges   string   `json:"merges"`
+}
+
+// QueryParams holds the raw /api/analytics parameters.
+type QueryParams struct {
+	Period   string
+	Start    string
+	End      string
+	Paths    []string
+	Revision string
+	Authors  []string
+	Merges   string
+	TimeZone string
+}
+
+// parseScope validates and canonicalizes the filter and time zone parameters
+// and returns the cache key suffix that identifies them.
+func parseScope(p QueryParams, q *Query) (string, error) {
+	for _, raw := range p.Paths {
+		clean, err := normalizeScopePath(raw)
+		if err != nil {
+			return "", err
+		}
+		if clean != "" && !slices.Contains(q.Paths, clean) {
+			q.Paths = append(q.Paths, clean)
+		}
+	}
+	slices.Sort(q.Paths)
+
+	q.Revision = strings.TrimSpace(p.Revision)
+	if _, err := gitcore.ParseRevRange(q.Revision); err != nil {
+		return "", fmt.Errorf("revision %q: %w", q.Revision, err)
+	}
+
+	for _, raw := range p.Authors {
+		author := strings.ToLower(strings.TrimSpace(raw))
+		if author != "" && !slices.Contains(q.Authors, author) {
+			q.Authors = append(q.Authors, author)
+		}
+	}
+	slices.Sort(q.Authors)
+
+	switch merges := strings.ToLower(strings.TrimSpace(p.Merges)); merges {
+	case "", MergesExclude:
+		q.Merges = MergesExclude
+	case MergesInclude, MergesOnly:
+		q.Merges = merges
+	default:
+		return "", fmt.Errorf("invalid merges mode: %q", p.Merges)
+	}
+
+	zone, _, err := parseTimeZone(p.TimeZone)
+	if err != nil {
+		return "", err
+	}
+	q.TimeZone = zone
+
+	values := url.Values{}
+	values["path"] = q.Paths
+	values["author"] = q.Authors
+	if q.Revision != "" {
+		values.Set("rev", q.Revision)
+	}
+	if q.Merges != MergesExclude {
+		values.Set("merges", q.Merges)
+	}
+	if q.TimeZone != TimeZoneUTC {
+		values.Set("tz", q.TimeZone)
+	}
+	if encoded := values.Encode(); encoded != "" {
+		return "?" + encoded, nil
+	}
+	return "", nil
+}
+
+// normalizeScopePath cleans a path prefix relative to the repository root.
+// A prefix matches the file it names and everything below it.
+func normalizeScopePath(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	clean := path.Clean(strings.TrimLeft(raw, "/"))
+	if clean == "." {
+		return "", nil
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid path: %q", raw)
+	}
+	return clean, nil
+}
+
+func (q Query) hasScope() bool {
+	return len(q.Paths) > 0 || q.Revision != "" || len(q.Authors) > 0 || (q.Merges != "" && q.Merges != MergesExclude)
+}
+
+func (q Query) scope() *analyticsScope {
+	if !q.hasScope() {
+		return nil
+	}
+	merges := q.Merges
+	if merges == "" {
+		merges = MergesExclude
+	}
+	return &analyticsScope{Paths: q.Paths, Revision: q.Revision, Authors: q.Authors, Merges: merges}
+}
+
+// revisionCommits returns the commits a revision or an "A..B" range selects.
+func revisionCommits(repo *gitcore.Repository, revision string) (map[gitcore.Hash]struct{}, error) {
+	rng, err := gitcore.ParseRevRange(revision)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidRevision, revision, err)
+	}
+	commits, err := repo.RevList(rng.Options())
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidRevision, revision, err)
+	}
+	selected := make(map[gitcore.Hash]struct{}, len(commits))
+	for _, c := range commits {
+		selected[c.ID] = struct{}{}
+	}
+	return selected, nil
+}
+
+// authorMatches reports whether sig matches one of the lowercased author
+// filters: a name, an email, or an @domain suffix.
+func authorMatches(sig gitcore.Signature, authors []string) bool {
+	email := strings.ToLower(sig.Email)
+	name := strings.ToLower(sig.Name)
+	for _, author := range authors {
+		if author == email || author == name || (strings.HasPrefix(author, "@") && strings.HasSuffix(email, author)) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitAuthorMatches reports whether c's author or one of its co-authors
+// matches authors.
+func commitAuthorMatches(c *gitcore.Commit, authors []string) bool {
+	if authorMatches(c.Author, authors) {
+		return true
+	}
+	for _, sig := range c.CoAuthors {
+		if authorMatches(sig, authors) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathMatches reports whether file is one of the path prefixes or below one.
+func pathMatches(file string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if file == prefix || strings.HasPrefix(file, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// filterEntriesByPaths keeps commits that change a file under the diffs'
+// path prefixes. Commits whose diff cannot be computed are dropped.
+func filterEntriesByPaths(diffs *commitDiffs, entries []analyticsCommitEntry) []analyticsCommitEntry {
+	if len(diffs.paths) == 0 {
+		return entries
+	}
+	filtered := make([]analyticsCommitEntry, 0, len(entries))
+	for _, e := range entries {
+		if files, err := diffs.files(e); err == nil && len(files) > 0 {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// applyMergeMode returns the commits in scope and the subset that counts as
+// work for the merge mode.
+func applyMergeMode(entries []analyticsCommitEntry, mode string) (scoped, work []analyticsCommitEntry) {
+	switch mode {
+	case MergesInclude:
+		return entries, entries
+	case MergesOnly:
+		merges := make([]analyticsCommitEntry, 0, len(entries))
+		for _, e := range entries {
+			if e.Parents > 1 {
+				merges = append(merges, e)
+			}
+		}
+		return merges, merges
+	default:
+		return entries, filterNonMergeEntries(entries)
+	}
+}
@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// newScopeRepo builds a history with two authors working in two services,
+// a feature branch, and a merge:
+//
+//	main:    pay(alice) - web(bob) - merge(feature)
+//	feature:               \- pay(bob)
+func newScopeRepo(t *testing.T) *gitcore.Repository {
+	h := newHistoryRepo(t, time.Now().UTC().AddDate(0, 0, -10))
+	h.commit("alice", "services/payments/api.go", "package payments\n")
+	h.branch("alice", "feature")
+	h.commit("bob", "services/web/app.go", "package web\n")
+	h.checkout("bob", "feature")
+	h.commit("bob", "services/payments/ledger.go", "package payments\n")
+	h.checkout("bob", "main")
+	h.git("alice", "merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+	return h.open()
+}
+
+func TestParseQueryScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   QueryParams
+		wantKey  string
+		wantErr  bool
+		wantPath []string
+	}{
+		{name: "period only", params: QueryParams{Period: "6m"}, wantKey: "6m"},
+		{
+			name:     "paths are cleaned, deduplicated, and sorted",
+			params:   QueryParams{Paths: []string{"services/web/", "/services/payments", "services/web", "."}},
+			wantKey:  "all?path=services%2Fpayments&path=services%2Fweb",
+			wantPath: []string{"services/payments", "services/web"},
+		},
+		{
+			name:    "revision, authors, and merges",
+			params:  QueryParams{Period: "3m", Revision: "v1..main", Authors: []string{"Bob@Example.com", "@corp.example"}, Merges: "only"},
+			wantKey: "3m?author=%40corp.example&author=bob%40example.com&merges=only&rev=v1..main",
+		},
+		{name: "default merge mode is not keyed", params: QueryParams{Merges: "exclude"}, wantKey: "all"},
+		{name: "default time zone is not keyed", params: QueryParams{TimeZone: "UTC"}, wantKey: "all"},
+		{name: "local time zone", params: QueryParams{Period: "1y", TimeZone: "Local"}, wantKey: "1y?tz=local"},
+		{name: "named time zone", params: QueryParams{TimeZone: "Europe/Berlin"}, wantKey: "all?tz=Europe%2FBerlin"},
+		{name: "range with path", params: QueryParams{Start: "2024-01-01", End: "2024-02-01", Paths: []string{"a"}}, wantKey: "range:20240101-20240201?path=a"},
+		{name: "escaping path", params: QueryParams{Paths: []string{"../secrets"}}, wantErr: true},
+		{name: "bad merges", params: QueryParams{Merges: "sometimes"}, wantErr: true},
+		{name: "bad time zone", params: QueryParams{TimeZone: "Mars/Olympus"}, wantErr: true},
+		{name: "symmetric difference", params: QueryParams{Revision: "a...b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.params)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseQuery() = %+v, want error", q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+			if q.CacheKey != tt.wantKey {
+				t.Fatalf("CacheKey = %q, want %q", q.CacheKey, tt.wantKey)
+			}
+			if tt.wantPath != nil && !slices.Equal(q.Paths, tt.wantPath) {
+				t.Fatalf("Paths = %v, want %v", q.Paths, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestBuildScope(t *testing.T) {
+	repo := newScopeRepo(t)
+	build := func(params QueryParams) *Response {
+		t.Helper()
+		q, err := ParseQuery(params)
+		if err != nil {
+			t.Fatalf("ParseQuery() error = %v", err)
+		}
+		resp, err := Build(repo, q)
+		if err != nil {
+			t.Fatalf("Build(%+v) error = %v", params, err)
+		}
+		return resp
+	}
+	authorCounts := func(resp *Response) map[string]int {
+		counts := make(map[string]int)
+		for _, a := range resp.Authors.Authors {
+			counts[a.Name] = a.Count
+		}
+		return counts
+	}
+
+	unscoped := build(QueryParams{})
+	if unscoped.Scope != nil || unscoped.Authors.TotalInPeriod != 3 || unscoped.Merges.MergeCount != 1 {
+		t.Fatalf("unscoped = scope %+v, %d work commits, %d merges", unscoped.Scope, unscoped.Authors.TotalInPeriod, unscoped.Merges.MergeCount)
+	}
+
+	payments := build(QueryParams{Paths: []string{"services/payments/"}})
+	if got := authorCounts(payments); got["alice"] != 1 || got["bob"] != 1 || len(got) != 2 {
+		t.Fatalf("payments authors = %v", got)
+	}
+	for _, h := range payments.Hotspots {
+		if h.Path != "services/payments/" {
+			t.Fatalf("payments hotspot outside the path: %+v", h)
+		}
+	}
+	if payments.Scope == nil || !slices.Equal(payments.Scope.Paths, []string{"services/payments"}) {
+		t.Fatalf("payments scope = %+v", payments.Scope)
+	}
+
+	// HEAD~1 is the web commit; the feature commit is only on main via the
+	// merge.
+	beforeMerge := build(QueryParams{Revision: "HEAD~1"})
+	if got := authorCounts(beforeMerge); got["alice"] != 1 || got["bob"] != 1 || beforeMerge.Merges.TotalCount != 2 {
+		t.Fatalf("HEAD~1 authors = %v, %d commits", got, beforeMerge.Merges.TotalCount)
+	}
+	sinceFeature := build(QueryParams{Revision: "feature..main"})
+	if got := authorCounts(sinceFeature); got["bob"] != 1 || len(got) != 1 || sinceFeature.Merges.MergeCount != 1 {
+		t.Fatalf("feature..main authors = %v, %d merges", got, sinceFeature.Merges.MergeCount)
+	}
+
+	bob := build(QueryParams{Authors: []string{"BOB@example.com"}})
+	if got := authorCounts(bob); got["bob"] != 2 || len(got) != 1 {
+		t.Fatalf("bob's authors = %v", got)
+	}
+	domain := build(QueryParams{Authors: []string{"@example.com"}})
+	if domain.Authors.TotalInPeriod != 3 {
+		t.Fatalf("@example.com work commits = %d, want 3", domain.Authors.TotalInPeriod)
+	}
+
+	included := build(QueryParams{Merges: MergesInclude})
+	if included.Authors.TotalInPeriod != 4 || authorCounts(included)["alice"] != 2 {
+		t.Fatalf("merges included = %d work commits, authors %v", included.Authors.TotalInPeriod, authorCounts(included))
+	}
+	only := build(QueryParams{Merges: MergesOnly})
+	if only.Authors.TotalInPeriod != 1 || only.Merges.MergeCount != 1 || only.Merges.TotalCount != 1 {
+		t.Fatalf("merges only = %d work commits, merges %+v", only.Authors.TotalInPeriod, only.Merges)
+	}
+	// The merge brings in the ledger change relative to its first parent.
+	if only.ChangeSize.AvgSize != 1 {
+		t.Fatalf("merge change size = %v, want 1", only.ChangeSize.AvgSize)
+	}
+
+	q, err := ParseQuery(QueryParams{Revision: "no-such-branch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Build(repo, q); !errors.Is(err, ErrInvalidRevision) {
+		t.Fatalf("Build(unknown revision) error = %v, want ErrInvalidRevision", err)
+	}
+}
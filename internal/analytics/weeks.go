@@ -0,0 +1,178 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const weekStoreKeyPrefix = "analytics-week:v1:"
+
+var errStoredDiffTooLarge = errors.New("diff too large")
+
+// Store persists the per-commit diff summaries analytics is built from, so a
+// restarted server only diffs commits it has not seen. *diskcache.Cache
+// satisfies it.
+type Store interface {
+	GetJSON(key string, v any) bool
+	PutJSON(key string, v any) error
+}
+
+// weekDiffs is the stored form of one week bucket: the files each non-merge
+// commit of that week changed relative to its first parent.
+type weekDiffs struct {
+	Commits map[gitcore.Hash]weekCommitDiff `json:"commits"`
+}
+
+type weekCommitDiff struct {
+	Files    []string `json:"files,omitempty"`
+	TooLarge bool     `json:"tooLarge,omitempty"`
+}
+
+type commitDiffResult struct {
+	files []string
+	err   error
+}
+
+// commitDiffs resolves the changed files of each commit. With a Store, diffs
+// are loaded and saved a week at a time. A week is keyed by the IDs of the
+// commits authored in it, so once a week has no new commits its bucket is
+// reused as is and only the current week is recomputed.
+type commitDiffs struct {
+	repo       *gitcore.Repository
+	commitsMap map[gitcore.Hash]*gitcore.Commit
+	store      Store
+	weeks      map[int64][]analyticsCommitEntry
+	loaded     map[int64]map[gitcore.Hash]commitDiffResult
+	// paths, when set, limits the files reported to those under a prefix.
+	// Stored weeks always hold every file, so they serve any path scope.
+	paths []string
+}
+
+func newCommitDiffs(
+	repo *gitcore.Repository,
+	commitsMap map[gitcore.Hash]*gitcore.Commit,
+	entries []analyticsCommitEntry,
+	store Store,
+) *commitDiffs {
+	d := &commitDiffs{repo: repo, commitsMap: commitsMap, store: store}
+	if store == nil {
+		return d
+	}
+	d.weeks = make(map[int64][]analyticsCommitEntry)
+	d.loaded = make(map[int64]map[gitcore.Hash]commitDiffResult)
+	for _, e := range entries {
+		w := weekStartUTC(e.TS)
+		d.weeks[w] = append(d.weeks[w], e)
+	}
+	return d
+}
+
+func (d *commitDiffs) files(e analyticsCommitEntry) ([]string, error) {
+	files, err := d.allFiles(e)
+	if err != nil || len(d.paths) == 0 {
+		return files, err
+	}
+	scoped := make([]string, 0, len(files))
+	for _, file := range files {
+		if pathMatches(file, d.paths) {
+			scoped = append(scoped, file)
+		}
+	}
+	return scoped, nil
+}
+
+func (d *commitDiffs) allFiles(e analyticsCommitEntry) ([]string, error) {
+	if d.store == nil {
+		return d.compute(e.Hash)
+	}
+	w := weekStartUTC(e.TS)
+	week, ok := d.loaded[w]
+	if !ok {
+		week = d.loadWeek(w)
+		d.loaded[w] = week
+	}
+	if r, ok := week[e.Hash]; ok {
+		return r.files, r.err
+	}
+	return d.compute(e.Hash)
+}
+
+func (d *commitDiffs) loadWeek(week int64) map[gitcore.Hash]commitDiffResult {
+	members := d.weeks[week]
+	key := weekStoreKey(week, members)
+	result := make(map[gitcore.Hash]commitDiffResult, len(members))
+
+	var stored weekDiffs
+	if d.store.GetJSON(key, &stored) {
+		for h, c := range stored.Commits {
+			if c.TooLarge {
+				result[h] = commitDiffResult{err: errStoredDiffTooLarge}
+			} else {
+				result[h] = commitDiffResult{files: c.Files}
+			}
+		}
+		return result
+	}
+
+	stored.Commits = make(map[gitcore.Hash]weekCommitDiff, len(members))
+	complete := true
+	for _, m := range members {
+		if m.Parents > 1 {
+			continue
+		}
+		files, err := d.compute(m.Hash)
+		result[m.Hash] = commitDiffResult{files: files, err: err}
+		switch {
+		case err == nil:
+			stored.Commits[m.Hash] = weekCommitDiff{Files: files}
+		case strings.Contains(err.Error(), "diff too large"):
+			stored.Commits[m.Hash] = weekCommitDiff{TooLarge: true}
+		default:
+			// Other failures may be transient, such as a pack being
+			// rewritten mid-read, so the week is not saved.
+			complete = false
+		}
+	}
+	if complete {
+		_ = d.store.PutJSON(key, stored)
+	}
+	return result
+}
+
+func (d *commitDiffs) compute(hash gitcore.Hash) ([]string, error) {
+	c := d.commitsMap[hash]
+	if c == nil {
+		return nil, errors.New("commit not found")
+	}
+	var parentTreeHash gitcore.Hash
+	if len(c.Parents) > 0 {
+		if p, ok := d.commitsMap[c.Parents[0]]; ok && p != nil {
+			parentTreeHash = p.Tree
+		}
+	}
+	entries, err := gitcore.TreeDiff(d.repo, parentTreeHash, c.Tree, "")
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, de := range entries {
+		files = append(files, de.Path)
+	}
+	return files, nil
+}
+
+func weekStoreKey(week int64, members []analyticsCommitEntry) string {
+	hashes := make([]string, 0, len(members))
+	for _, m := range members {
+		hashes = append(hashes, string(m.Hash))
+	}
+	slices.Sort(hashes)
+	sum := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	return weekStoreKeyPrefix + strconv.FormatInt(week, 10) + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+type memStore struct {
+	entries map[string][]byte
+	puts    int
+}
+
+func (m *memStore) GetJSON(key string, v any) bool {
+	data, ok := m.entries[key]
+	return ok && json.Unmarshal(data, v) == nil
+}
+
+func (m *memStore) PutJSON(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m.entries[key] = data
+	m.puts++
+	return nil
+}
+
+// historyRepo builds a repository commit by commit. Each git command runs
+// Step after the previous one, an hour unless a test changes it, as the given
+// author.
+type historyRepo struct {
+	*gittest.Repo
+	t *testing.T
+}
+
+// newHistoryRepo starts an empty repository on branch main whose first
+// command runs an hour after start.
+func newHistoryRepo(t *testing.T, start time.Time) *historyRepo {
+	t.Helper()
+	r := gittest.New(t)
+	r.When, r.Step = start, time.Hour
+	return &historyRepo{Repo: r, t: t}
+}
+
+// git runs a command as author, whose email is author@example.com.
+func (h *historyRepo) git(author string, args ...string) {
+	h.t.Helper()
+	h.Author = author + " <" + author + "@example.com>"
+	h.Git(args...)
+}
+
+// commit writes content to file, or removes file when content is empty, and
+// commits everything as author.
+func (h *historyRepo) commit(author, file, content string) {
+	h.t.Helper()
+	h.Write(file, content)
+	h.git(author, "add", "-A")
+	h.git(author, "commit", "-q", "-m", "edit "+file)
+}
+
+// branch creates a branch named name at HEAD.
+func (h *historyRepo) branch(author, name string) {
+	h.t.Helper()
+	h.git(author, "branch", name)
+}
+
+// checkout switches the work tree to branch name.
+func (h *historyRepo) checkout(author, name string) {
+	h.t.Helper()
+	h.git(author, "checkout", "-q", name)
+}
+
+func (h *historyRepo) open() *gitcore.Repository {
+	return h.Open()
+}
+
+func TestBuildReusesStoredWeeks(t *testing.T) {
+	now := time.Now().UTC()
+	h := newHistoryRepo(t, now)
+	h.Step = 0
+	for i, days := range []int{30, 29, 15, 1} {
+		h.When = now.AddDate(0, 0, -days)
+		h.commit("test", "pkg/file"+string(rune('a'+i))+".go", "package pkg\n")
+	}
+	repo := h.open()
+	store := &memStore{entries: map[string][]byte{}}
+
+	first, err := Build(repo, Query{Period: "all", Store: store})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if store.puts == 0 || store.puts > 4 {
+		t.Fatalf("stored %d week buckets, want between 1 and 4", store.puts)
+	}
+	if first.DiffCoverage.AnalyzedCommits != 4 {
+		t.Fatalf("AnalyzedCommits = %d, want 4", first.DiffCoverage.AnalyzedCommits)
+	}
+
+	// Rewrite every stored bucket so a build that reads them, rather than
+	// diffing again, reports the substituted paths.
+	for key, data := range store.entries {
+		var week weekDiffs
+		if err := json.Unmarshal(data, &week); err != nil {
+			t.Fatal(err)
+		}
+		for h := range week.Commits {
+			week.Commits[h] = weekCommitDiff{Files: []string{"stored/only/path.go"}}
+		}
+		store.entries[key], _ = json.Marshal(week)
+	}
+	puts := store.puts
+	second, err := Build(repo, Query{Period: "all", Store: store})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if store.puts != puts {
+		t.Fatalf("rebuild stored %d more buckets, want 0", store.puts-puts)
+	}
+	if len(second.Hotspots) != 1 || second.Hotspots[0].Path != "stored/only/" {
+		t.Fatalf("hotspots = %+v, want stored bucket paths", second.Hotspots)
+	}
+
+	// Without a store the diffs are computed directly and agree with the
+	// first build.
+	direct, err := Build(repo, Query{Period: "all"})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if direct.ChangeSize.AvgSize != first.ChangeSize.AvgSize || direct.DiffCoverage != first.DiffCoverage {
+		t.Fatalf("direct build %+v differs from stored build %+v", direct.ChangeSize, first.ChangeSize)
+	}
+}
+
+func TestWeekStoreKeyDependsOnMembers(t *testing.T) {
+	a := analyticsCommitEntry{Hash: gitcore.Hash(strings.Repeat("a", 40))}
+	b := analyticsCommitEntry{Hash: gitcore.Hash(strings.Repeat("b", 40))}
+	if weekStoreKey(1, []analyticsCommitEntry{a, b}) != weekStoreKey(1, []analyticsCommitEntry{b, a}) {
+		t.Fatal("week key depends on commit order")
+	}
+	if weekStoreKey(1, []analyticsCommitEntry{a}) == weekStoreKey(1, []analyticsCommitEntry{a, b}) {
+		t.Fatal("week key unchanged by a new commit")
+	}
+}
@@ -0,0 +1,234 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Time zones the heatmap and per-author hours can be bucketed in. Any other
+// value is an IANA zone name such as "Europe/Berlin".
+const (
+	// TimeZoneUTC buckets every commit by its UTC time.
+	TimeZoneUTC = "utc"
+	// TimeZoneLocal buckets each commit by the offset its author recorded,
+	// so 9am means 9am on the author's clock.
+	TimeZoneLocal = "local"
+)
+
+const (
+	// analyticsWorkdayStart and analyticsWorkdayEnd bound working hours on
+	// weekdays; commits outside [start, end) count as after hours.
+	analyticsWorkdayStart = 9
+	analyticsWorkdayEnd   = 18
+	// analyticsRewriteSkew is the committer-minus-author delay above which
+	// a commit counts as rewritten by an amend, rebase, or cherry-pick.
+	analyticsRewriteSkew = time.Minute
+)
+
+// analyticsWorkPatterns describes when people work. After-hours shares always
+// use each commit's recorded offset, since only the author's own clock says
+// whether a commit was made in the evening; per-author hours follow the
+// requested time zone like the heatmap.
+type analyticsWorkPatterns struct {
+	Authors    []analyticsAuthorHours `json:"authors"`
+	AfterHours analyticsAfterHours    `json:"afterHours"`
+	DateSkew   analyticsDateSkew      `json:"dateSkew"`
+}
+
+type analyticsAuthorHours struct {
+	Name  string  `json:"name"`
+	Email string  `json:"email"`
+	Count int     `json:"count"`
+	Hours [24]int `json:"hours"`
+}
+
+// analyticsAfterHours holds the percentage of commits made on weekdays
+// outside working hours, and of those made on weekends, overall and per week.
+type analyticsAfterHours struct {
+	Weeks           []analyticsAfterHoursWeek `json:"weeks"`
+	AfterHoursShare float64                   `json:"afterHoursShare"`
+	WeekendShare    float64                   `json:"weekendShare"`
+}
+
+type analyticsAfterHoursWeek struct {
+	TS              int64   `json:"ts"`
+	Count           int     `json:"count"`
+	AfterHoursShare float64 `json:"afterHoursShare"`
+	WeekendShare    float64 `json:"weekendShare"`
+}
+
+// analyticsDateSkew measures how long after authoring commits were committed.
+// Heavy rebasing shows up as a high RewrittenShare and long tails. A
+// committer date before the author date counts as no skew.
+type analyticsDateSkew struct {
+	Commits        int     `json:"commits"`
+	RewrittenShare float64 `json:"rewrittenShare"`
+	OverDayShare   float64 `json:"overDayShare"`
+	MedianHours    float64 `json:"medianHours"`
+	P90Hours       float64 `json:"p90Hours"`
+	MaxHours       float64 `json:"maxHours"`
+}
+
+// parseTimeZone canonicalizes a time zone parameter. The location is nil
+// for TimeZoneLocal.
+func parseTimeZone(raw string) (string, *time.Location, error) {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToLower(raw) {
+	case "", TimeZoneUTC:
+		return TimeZoneUTC, time.UTC, nil
+	case TimeZoneLocal:
+		return TimeZoneLocal, nil, nil
+	}
+	loc, err := time.LoadLocation(raw)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid time zone: %q", raw)
+	}
+	return loc.String(), loc, nil
+}
+
+// inZone returns ts in loc, or as recorded when loc is nil.
+func inZone(ts time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return ts
+	}
+	return ts.In(loc)
+}
+
+// heatmapDay returns the heatmap row for t, with Monday first.
+func heatmapDay(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+func emptyWorkPatterns() analyticsWorkPatterns {
+	return analyticsWorkPatterns{
+		Authors:    []analyticsAuthorHours{},
+		AfterHours: analyticsAfterHours{Weeks: []analyticsAfterHoursWeek{}},
+	}
+}
+
+func computeWorkPatterns(entries []analyticsCommitEntry, loc *time.Location) analyticsWorkPatterns {
+	patterns := emptyWorkPatterns()
+	if len(entries) == 0 {
+		return patterns
+	}
+	patterns.Authors = computeAuthorHours(entries, loc)
+	patterns.AfterHours = computeAfterHours(entries)
+	patterns.DateSkew = computeDateSkew(entries)
+	return patterns
+}
+
+func computeAuthorHours(entries []analyticsCommitEntry, loc *time.Location) []analyticsAuthorHours {
+	byEmail := make(map[string]*analyticsAuthorHours)
+	for _, e := range entries {
+		hour := inZone(e.TS, loc).Hour()
+		for _, sig := range commitCredits(e.Author, e.CoAuthors) {
+			email := sig.Email
+			if email == "" {
+				email = "unknown"
+			}
+			cur, ok := byEmail[email]
+			if !ok {
+				name := sig.Name
+				if name == "" {
+					name = email
+				}
+				cur = &analyticsAuthorHours{Name: name, Email: email}
+				byEmail[email] = cur
+			}
+			cur.Count++
+			cur.Hours[hour]++
+		}
+	}
+
+	authors := make([]analyticsAuthorHours, 0, len(byEmail))
+	for _, a := range byEmail {
+		authors = append(authors, *a)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Count == authors[j].Count {
+			return authors[i].Email < authors[j].Email
+		}
+		return authors[i].Count > authors[j].Count
+	})
+	if len(authors) > analyticsTopAuthors {
+		authors = authors[:analyticsTopAuthors]
+	}
+	return authors
+}
+
+// isAfterHours reports whether a weekday time falls outside working hours.
+func isAfterHours(t time.Time) bool {
+	return t.Hour() < analyticsWorkdayStart || t.Hour() >= analyticsWorkdayEnd
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+func computeAfterHours(entries []analyticsCommitEntry) analyticsAfterHours {
+	type agg struct{ count, afterHours, weekend int }
+	byWeek := make(map[int64]*agg)
+	var total agg
+	for _, e := range entries {
+		w := weekStartUTC(e.TS)
+		cur, ok := byWeek[w]
+		if !ok {
+			cur = &agg{}
+			byWeek[w] = cur
+		}
+		cur.count++
+		total.count++
+		switch {
+		case isWeekend(e.TS):
+			cur.weekend++
+			total.weekend++
+		case isAfterHours(e.TS):
+			cur.afterHours++
+			total.afterHours++
+		}
+	}
+
+	weeks := make([]analyticsAfterHoursWeek, 0, len(byWeek))
+	for w, a := range byWeek {
+		weeks = append(weeks, analyticsAfterHoursWeek{
+			TS:              w,
+			Count:           a.count,
+			AfterHoursShare: analyticsPercent(a.afterHours, a.count),
+			WeekendShare:    analyticsPercent(a.weekend, a.count),
+		})
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].TS < weeks[j].TS })
+	return analyticsAfterHours{
+		Weeks:           weeks,
+		AfterHoursShare: analyticsPercent(total.afterHours, total.count),
+		WeekendShare:    analyticsPercent(total.weekend, total.count),
+	}
+}
+
+func computeDateSkew(entries []analyticsCommitEntry) analyticsDateSkew {
+	skews := make([]time.Duration, 0, len(entries))
+	rewritten, overDay := 0, 0
+	for _, e := range entries {
+		skew := max(e.Committed.Sub(e.TS), 0)
+		if skew > analyticsRewriteSkew {
+			rewritten++
+		}
+		if skew > 24*time.Hour {
+			overDay++
+		}
+		skews = append(skews, skew)
+	}
+	sort.Slice(skews, func(i, j int) bool { return skews[i] < skews[j] })
+
+	p90 := int(0.9*float64(len(skews)-1) + 0.5)
+	return analyticsDateSkew{
+		Commits:        len(skews),
+		RewrittenShare: analyticsPercent(rewritten, len(skews)),
+		OverDayShare:   analyticsPercent(overDay, len(skews)),
+		MedianHours:    skews[len(skews)/2].Hours(),
+		P90Hours:       skews[p90].Hours(),
+		MaxHours:       skews[len(skews)-1].Hours(),
+	}
+}
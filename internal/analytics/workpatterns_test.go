@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func workPatternEntries() []analyticsCommitEntry {
+	alice := gitcore.Signature{Name: "Alice", Email: "alice@example.com"}
+	bob := gitcore.Signature{Name: "Bob", Email: "bob@example.com"}
+	at := func(offsetHours int, day, hour int) time.Time {
+		zone := time.FixedZone("", offsetHours*3600)
+		return time.Date(2024, time.January, day, hour, 0, 0, 0, zone)
+	}
+	entry := func(author gitcore.Signature, authored time.Time, skew time.Duration, coAuthors ...gitcore.Signature) analyticsCommitEntry {
+		author.When = authored
+		return analyticsCommitEntry{TS: authored, Committed: authored.Add(skew), Author: author, CoAuthors: coAuthors}
+	}
+	return []analyticsCommitEntry{
+		// Monday 10:00 in Tokyo is Monday 01:00 UTC.
+		entry(alice, at(9, 8, 10), 0),
+		// Friday 20:00 in New York is Saturday 01:00 UTC, rebased two days later.
+		entry(alice, at(-5, 12, 20), 48*time.Hour),
+		// Sunday noon, amended half a minute later.
+		entry(alice, at(0, 14, 12), 30*time.Second),
+		// Tuesday 14:00 at +0200, paired with alice.
+		entry(bob, at(2, 16, 14), 2*time.Hour, alice),
+	}
+}
+
+func TestComputeHeatmapTimeZones(t *testing.T) {
+	entries := workPatternEntries()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	tests := []struct {
+		name  string
+		loc   *time.Location
+		cells [][2]int
+	}{
+		{name: "utc", loc: time.UTC, cells: [][2]int{{0, 1}, {5, 1}, {6, 12}, {1, 12}}},
+		{name: "local", loc: nil, cells: [][2]int{{0, 10}, {4, 20}, {6, 12}, {1, 14}}},
+		{name: "named", loc: berlin, cells: [][2]int{{0, 2}, {5, 2}, {6, 13}, {1, 13}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heatmap := computeHeatmap(entries, tt.loc)
+			for _, cell := range tt.cells {
+				if heatmap.Grid[cell[0]][cell[1]] != 1 {
+					t.Fatalf("Grid[%d][%d] = %d, want 1", cell[0], cell[1], heatmap.Grid[cell[0]][cell[1]])
+				}
+			}
+			if heatmap.Max != 1 {
+				t.Fatalf("Max = %d, want 1", heatmap.Max)
+			}
+		})
+	}
+}
+
+func TestComputeWorkPatterns(t *testing.T) {
+	patterns := computeWorkPatterns(workPatternEntries(), nil)
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.01 }
+
+	if len(patterns.Authors) != 2 {
+		t.Fatalf("Authors = %+v", patterns.Authors)
+	}
+	alice, bob := patterns.Authors[0], patterns.Authors[1]
+	if alice.Email != "alice@example.com" || alice.Count != 4 || bob.Count != 1 {
+		t.Fatalf("Authors = %+v", patterns.Authors)
+	}
+	for _, hour := range []int{10, 20, 12, 14} {
+		if alice.Hours[hour] != 1 {
+			t.Fatalf("alice hours = %v, want a commit at %d", alice.Hours, hour)
+		}
+	}
+	if bob.Hours[14] != 1 {
+		t.Fatalf("bob hours = %v", bob.Hours)
+	}
+
+	after := patterns.AfterHours
+	if !near(after.AfterHoursShare, 25) || !near(after.WeekendShare, 25) {
+		t.Fatalf("after hours = %.2f%%, weekend = %.2f%%", after.AfterHoursShare, after.WeekendShare)
+	}
+	if len(after.Weeks) != 2 || after.Weeks[0].Count != 3 || after.Weeks[1].Count != 1 {
+		t.Fatalf("weeks = %+v", after.Weeks)
+	}
+	if !near(after.Weeks[0].AfterHoursShare, 100.0/3) || !near(after.Weeks[0].WeekendShare, 100.0/3) || after.Weeks[1].AfterHoursShare != 0 {
+		t.Fatalf("first week = %+v", after.Weeks[0])
+	}
+
+	skew := patterns.DateSkew
+	if skew.Commits != 4 || !near(skew.RewrittenShare, 50) || !near(skew.OverDayShare, 25) {
+		t.Fatalf("skew shares = %+v", skew)
+	}
+	if skew.MedianHours != 2 || skew.P90Hours != 48 || skew.MaxHours != 48 {
+		t.Fatalf("skew hours = %+v", skew)
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	for raw, want := range map[string]string{"": TimeZoneUTC, "UTC": TimeZoneUTC, " local ": TimeZoneLocal, "Asia/Tokyo": "Asia/Tokyo"} {
+		got, _, err := parseTimeZone(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseTimeZone(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, _, err := parseTimeZone("Nowhere/Special"); err == nil {
+		t.Fatal("parseTimeZone() accepted an unknown zone")
+	}
+}
@@ -0,0 +1,285 @@
+// Package auth guards a GitVista server that is reachable beyond loopback. An
+// Authenticator wraps the whole HTTP handler, including the WebSocket
+// handshake, and accepts either a signed session cookie or credentials checked
+// by one of its providers: a bearer token with one-time login links, HTTP
+// basic auth from an htpasswd file, or an OpenID Connect issuer.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// SessionCookieName is the cookie that carries an authenticated browser session.
+	SessionCookieName = "gitvista_session"
+	// RoutePrefix is the path prefix under which login endpoints are served.
+	RoutePrefix = "/auth/"
+
+	defaultSessionTTL = 12 * time.Hour
+)
+
+// Identity describes an authenticated principal.
+type Identity struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name,omitempty"`
+	// Method is the name of the provider that authenticated the principal.
+	Method string `json:"method"`
+}
+
+// Provider verifies credentials carried by a request.
+type Provider interface {
+	// Authenticate returns the identity proven by credentials on r itself,
+	// such as an Authorization header.
+	Authenticate(r *http.Request) (Identity, bool)
+	// Challenge returns the WWW-Authenticate value sent with 401 responses,
+	// or "" for none.
+	Challenge() string
+}
+
+// LoginProvider is a Provider with login endpoints that start a browser session.
+type LoginProvider interface {
+	Provider
+	// RegisterRoutes mounts the provider's endpoints below RoutePrefix. On a
+	// successful login the provider calls complete, which sets the session
+	// cookie and redirects to next.
+	RegisterRoutes(mux *http.ServeMux, complete func(w http.ResponseWriter, r *http.Request, id Identity, next string))
+	// LoginPath is where unauthenticated browsers are redirected, or "" when
+	// the provider has no interactive login page.
+	LoginPath() string
+}
+
+// Options configures an Authenticator.
+type Options struct {
+	Providers []Provider
+	// SessionKey signs session cookies. A random key is generated when empty,
+	// so sessions do not survive a restart.
+	SessionKey []byte
+	// SessionTTL bounds the lifetime of a session cookie.
+	SessionTTL time.Duration
+	// Public lists exact paths served without authentication, such as health checks.
+	Public []string
+	Logger *slog.Logger
+}
+
+// Authenticator is HTTP middleware that rejects unauthenticated requests.
+type Authenticator struct {
+	providers []Provider
+	key       []byte
+	ttl       time.Duration
+	public    map[string]struct{}
+	logger    *slog.Logger
+	routes    *http.ServeMux
+	now       func() time.Time
+}
+
+// New builds an Authenticator from opts. At least one provider is required.
+func New(opts Options) (*Authenticator, error) {
+	if len(opts.Providers) == 0 {
+		return nil, errors.New("auth: no providers configured")
+	}
+
+	a := &Authenticator{
+		providers: opts.Providers,
+		key:       opts.SessionKey,
+		ttl:       opts.SessionTTL,
+		public:    make(map[string]struct{}, len(opts.Public)),
+		logger:    opts.Logger,
+		routes:    http.NewServeMux(),
+		now:       time.Now,
+	}
+	if len(a.key) == 0 {
+		a.key = make([]byte, 32)
+		if _, err := rand.Read(a.key); err != nil {
+			return nil, fmt.Errorf("auth: generating session key: %w", err)
+		}
+	}
+	if a.ttl <= 0 {
+		a.ttl = defaultSessionTTL
+	}
+	if a.logger == nil {
+		a.logger = slog.Default()
+	}
+	for _, p := range opts.Public {
+		a.public[p] = struct{}{}
+	}
+
+	a.routes.HandleFunc(RoutePrefix+"logout", a.handleLogout)
+	for _, p := range a.providers {
+		if lp, ok := p.(LoginProvider); ok {
+			lp.RegisterRoutes(a.routes, a.completeLogin)
+		}
+	}
+	return a, nil
+}
+
+// Wrap returns a handler that serves login routes itself and forwards only
+// authenticated requests to next, with the Identity stored in the context.
+func (a *Authenticator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, RoutePrefix) {
+			a.routes.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := a.public[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if id, ok := a.sessionIdentity(r); ok {
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+			return
+		}
+		for _, p := range a.providers {
+			if id, ok := p.Authenticate(r); ok {
+				// Browsers cannot attach an Authorization header to the
+				// WebSocket handshake, so header logins also get a cookie.
+				a.setSession(w, r, id)
+				next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+				return
+			}
+		}
+		a.challenge(w, r)
+	})
+}
+
+func (a *Authenticator) challenge(w http.ResponseWriter, r *http.Request) {
+	if isBrowserNavigation(r) {
+		for _, p := range a.providers {
+			if lp, ok := p.(LoginProvider); ok && lp.LoginPath() != "" {
+				http.Redirect(w, r, lp.LoginPath()+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+				return
+			}
+		}
+	}
+	for _, p := range a.providers {
+		if c := p.Challenge(); c != "" {
+			w.Header().Add("WWW-Authenticate", c)
+		}
+	}
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+func isBrowserNavigation(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.URL.Path == "/api" || strings.HasPrefix(r.URL.Path, "/api/") {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func (a *Authenticator) completeLogin(w http.ResponseWriter, r *http.Request, id Identity, next string) {
+	a.logger.Info("Login succeeded", "method", id.Method, "subject", id.Subject)
+	a.setSession(w, r, id)
+	http.Redirect(w, r, SafeRedirect(next), http.StatusFound)
+}
+
+func (a *Authenticator) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// SafeRedirect returns next if it is a path on this server, or "/" otherwise,
+// so login links cannot be used as open redirects.
+func SafeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+type sessionPayload struct {
+	Identity
+	Expires int64 `json:"exp"`
+}
+
+func (a *Authenticator) setSession(w http.ResponseWriter, r *http.Request, id Identity) {
+	payload, err := json.Marshal(sessionPayload{Identity: id, Expires: a.now().Add(a.ttl).Unix()})
+	if err != nil {
+		a.logger.Error("Failed to encode session", "err", err)
+		return
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    encoded + "." + a.sign(encoded),
+		Path:     "/",
+		MaxAge:   int(a.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (a *Authenticator) sessionIdentity(r *http.Request) (Identity, bool) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return Identity{}, false
+	}
+	encoded, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(a.sign(encoded))) {
+		return Identity{}, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Identity{}, false
+	}
+	var payload sessionPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return Identity{}, false
+	}
+	if a.now().Unix() >= payload.Expires {
+		return Identity{}, false
+	}
+	return payload.Identity, true
+}
+
+func (a *Authenticator) sign(value string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the identity stored in ctx by the Authenticator.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// GenerateToken returns a random URL-safe token suitable for bearer
+// credentials and login codes.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("auth: generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAuthenticator(t *testing.T, providers ...Provider) (*Authenticator, http.Handler) {
+	t.Helper()
+	a, err := New(Options{Providers: providers, SessionKey: []byte("test-key"), Public: []string{"/health"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	handler := a.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := IdentityFromContext(r.Context())
+		_, _ = w.Write([]byte("ok:" + id.Subject))
+	}))
+	return a, handler
+}
+
+func serve(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, c := range w.Result().Cookies() {
+		if c.Name == SessionCookieName {
+			return c
+		}
+	}
+	t.Fatalf("response has no %s cookie", SessionCookieName)
+	return nil
+}
+
+func TestNewRequiresProvider(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Fatal("New() error = nil, want error")
+	}
+}
+
+func TestAuthenticatorBearerToken(t *testing.T) {
+	_, handler := newTestAuthenticator(t, NewTokenProvider("s3cret"))
+
+	w := serve(handler, httptest.NewRequest("GET", "/api/repository", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d, want 401", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="GitVista"` {
+		t.Fatalf("WWW-Authenticate = %q", got)
+	}
+
+	r := httptest.NewRequest("GET", "/api/repository", nil)
+	r.Header.Set("Authorization", "Bearer wrong")
+	if w := serve(handler, r); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token status = %d, want 401", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/api/repository", nil)
+	r.Header.Set("Authorization", "bearer s3cret")
+	w = serve(handler, r)
+	if w.Code != http.StatusOK || w.Body.String() != "ok:token" {
+		t.Fatalf("token status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	// The cookie issued alongside lets a browser open the WebSocket.
+	r = httptest.NewRequest("GET", "/api/ws", nil)
+	r.AddCookie(sessionCookie(t, w))
+	if w := serve(handler, r); w.Code != http.StatusOK {
+		t.Fatalf("cookie status = %d, want 200", w.Code)
+	}
+}
+
+func TestAuthenticatorPublicPaths(t *testing.T) {
+	_, handler := newTestAuthenticator(t, NewTokenProvider("s3cret"))
+	if w := serve(handler, httptest.NewRequest("GET", "/health", nil)); w.Code != http.StatusOK {
+		t.Fatalf("/health status = %d, want 200", w.Code)
+	}
+}
+
+func TestAuthenticatorLoginCodeIsSingleUse(t *testing.T) {
+	tokens := NewTokenProvider("")
+	_, handler := newTestAuthenticator(t, tokens)
+	code, err := tokens.IssueLoginCode(time.Minute)
+	if err != nil {
+		t.Fatalf("IssueLoginCode() error = %v", err)
+	}
+
+	link := LoginLink(code, "/?path=README.md")
+	w := serve(handler, httptest.NewRequest("GET", link, nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/?path=README.md" {
+		t.Fatalf("login status = %d, location = %q", w.Code, w.Header().Get("Location"))
+	}
+	cookie := sessionCookie(t, w)
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie = %+v, want HttpOnly and SameSite=Lax", cookie)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookie)
+	if w := serve(handler, r); w.Code != http.StatusOK {
+		t.Fatalf("session status = %d, want 200", w.Code)
+	}
+
+	if w := serve(handler, httptest.NewRequest("GET", link, nil)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused code status = %d, want 401", w.Code)
+	}
+}
+
+func TestAuthenticatorLoginCodeExpires(t *testing.T) {
+	tokens := NewTokenProvider("")
+	_, handler := newTestAuthenticator(t, tokens)
+	code, _ := tokens.IssueLoginCode(time.Minute)
+	tokens.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	if w := serve(handler, httptest.NewRequest("GET", LoginLink(code, ""), nil)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expired code status = %d, want 401", w.Code)
+	}
+}
+
+func TestAuthenticatorRejectsTamperedAndExpiredSessions(t *testing.T) {
+	a, handler := newTestAuthenticator(t, NewTokenProvider("s3cret"))
+	r := httptest.NewRequest("GET", "/api/repository", nil)
+	r.Header.Set("Authorization", "Bearer s3cret")
+	cookie := sessionCookie(t, serve(handler, r))
+
+	tampered := *cookie
+	tampered.Value = strings.Replace(cookie.Value, ".", "x.", 1)
+	r = httptest.NewRequest("GET", "/api/repository", nil)
+	r.AddCookie(&tampered)
+	if w := serve(handler, r); w.Code != http.StatusUnauthorized {
+		t.Fatalf("tampered status = %d, want 401", w.Code)
+	}
+
+	a.now = func() time.Time { return time.Now().Add(defaultSessionTTL + time.Minute) }
+	r = httptest.NewRequest("GET", "/api/repository", nil)
+	r.AddCookie(cookie)
+	if w := serve(handler, r); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expired status = %d, want 401", w.Code)
+	}
+}
+
+func TestAuthenticatorLogout(t *testing.T) {
+	_, handler := newTestAuthenticator(t, NewTokenProvider("s3cret"))
+	w := serve(handler, httptest.NewRequest("GET", "/auth/logout", nil))
+	if w.Code != http.StatusFound || sessionCookie(t, w).MaxAge >= 0 {
+		t.Fatalf("logout status = %d, cookie = %+v", w.Code, sessionCookie(t, w))
+	}
+}
+
+func TestSafeRedirect(t *testing.T) {
+	tests := map[string]string{
+		"":                   "/",
+		"/?path=a#b":         "/?path=a#b",
+		"//evil.example":     "/",
+		"/\\evil.example":    "/",
+		"https://evil.test/": "/",
+	}
+	for in, want := range tests {
+		if got := SafeRedirect(in); got != want {
+			t.Errorf("SafeRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1" // #nosec G505 -- required to verify legacy {SHA} htpasswd entries
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicProvider implements HTTP basic auth against htpasswd entries.
+// Only bcrypt ("htpasswd -B") and legacy {SHA} hashes are supported.
+type BasicProvider struct {
+	users map[string]string
+}
+
+// LoadHtpasswd reads an htpasswd file.
+func LoadHtpasswd(path string) (*BasicProvider, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("htpasswd: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return ParseHtpasswd(f)
+}
+
+// ParseHtpasswd parses htpasswd entries of the form "user:hash", one per
+// line. Blank lines and lines starting with # are ignored.
+func ParseHtpasswd(r io.Reader) (*BasicProvider, error) {
+	p := &BasicProvider{users: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" || hash == "" {
+			return nil, fmt.Errorf("htpasswd: line %d: expected user:hash", lineNo)
+		}
+		if !isBcryptHash(hash) && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("htpasswd: line %d: unsupported hash for %q; regenerate it with htpasswd -B", lineNo, user)
+		}
+		p.users[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("htpasswd: %w", err)
+	}
+	if len(p.users) == 0 {
+		return nil, fmt.Errorf("htpasswd: no users defined")
+	}
+	return p, nil
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Authenticate implements Provider.
+func (p *BasicProvider) Authenticate(r *http.Request) (Identity, bool) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return Identity{}, false
+	}
+	hash, ok := p.users[user]
+	if !ok || !verifyHtpasswdHash(hash, password) {
+		return Identity{}, false
+	}
+	return Identity{Subject: user, Name: user, Method: "basic"}, true
+}
+
+// Challenge implements Provider.
+func (p *BasicProvider) Challenge() string {
+	return `Basic realm="GitVista", charset="UTF-8"`
+}
+
+func verifyHtpasswdHash(hash, password string) bool {
+	if isBcryptHash(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	sum := sha1.Sum([]byte(password)) // #nosec G401 -- legacy {SHA} htpasswd format
+	want := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(want)) == 1
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicProvider(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+	file := "# team\nalice:" + string(hash) + "\n\nbob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"
+	basic, err := ParseHtpasswd(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ParseHtpasswd() error = %v", err)
+	}
+	_, handler := newTestAuthenticator(t, basic)
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		want     int
+	}{
+		{name: "bcrypt", user: "alice", password: "hunter2", want: http.StatusOK},
+		{name: "sha", user: "bob", password: "password", want: http.StatusOK},
+		{name: "wrong password", user: "alice", password: "nope", want: http.StatusUnauthorized},
+		{name: "unknown user", user: "mallory", password: "hunter2", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/ws", nil)
+			r.SetBasicAuth(tt.user, tt.password)
+			if w := serve(handler, r); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	w := serve(handler, httptest.NewRequest("GET", "/", nil))
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic ") {
+		t.Fatalf("WWW-Authenticate = %q, want Basic challenge", w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestParseHtpasswdErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":      "# nobody\n",
+		"no hash":    "alice\n",
+		"apr1":       "alice:$apr1$abc$def\n",
+		"crypt":      "alice:rl0ZlXiDzdkOE\n",
+		"blank user": ":{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n",
+	}
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseHtpasswd(strings.NewReader(file)); err == nil {
+				t.Fatal("ParseHtpasswd() error = nil, want error")
+			}
+		})
+	}
+}
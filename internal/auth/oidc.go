@@ -0,0 +1,517 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oidcLoginTTL   = 10 * time.Minute
+	oidcClockSkew  = time.Minute
+	oidcMaxPending = 1024
+	oidcMaxBody    = 1 << 20
+)
+
+// OIDCConfig configures sign-in through an OpenID Connect issuer using the
+// authorization code flow with PKCE.
+type OIDCConfig struct {
+	// Issuer is the issuer URL; its discovery document is fetched from
+	// Issuer + "/.well-known/openid-configuration".
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered with the issuer. When empty it
+	// is derived from each login request as <scheme>://<host>/auth/oidc/callback.
+	RedirectURL string
+	// Scopes defaults to openid, email, and profile.
+	Scopes []string
+	// AllowedEmails restricts sign-in to these addresses or, for entries
+	// starting with "@", to these domains. Empty allows any account the
+	// issuer authenticates.
+	AllowedEmails []string
+	HTTPClient    *http.Client
+	Logger        *slog.Logger
+}
+
+// OIDCProvider signs browsers in through an OpenID Connect issuer. It does
+// not accept bearer credentials; API clients should use a TokenProvider.
+type OIDCProvider struct {
+	cfg       OIDCConfig
+	discovery oidcDiscovery
+	client    *http.Client
+	logger    *slog.Logger
+	now       func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]oidcPendingLogin
+	keys    map[string]crypto.PublicKey
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcPendingLogin struct {
+	nonce       string
+	verifier    string
+	redirectURL string
+	next        string
+	expires     time.Time
+}
+
+// NewOIDCProvider fetches the issuer's discovery document and signing keys.
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig) (*OIDCProvider, error) {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, errors.New("oidc: issuer and client ID are required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	} else if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+
+	p := &OIDCProvider{
+		cfg:     cfg,
+		client:  cfg.HTTPClient,
+		logger:  cfg.Logger,
+		now:     time.Now,
+		pending: make(map[string]oidcPendingLogin),
+	}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if p.logger == nil {
+		p.logger = slog.Default()
+	}
+
+	if err := p.getJSON(ctx, cfg.Issuer+"/.well-known/openid-configuration", &p.discovery); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if strings.TrimSuffix(p.discovery.Issuer, "/") != cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", p.discovery.Issuer, cfg.Issuer)
+	}
+	if p.discovery.AuthorizationEndpoint == "" || p.discovery.TokenEndpoint == "" || p.discovery.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is missing endpoints")
+	}
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Authenticate implements Provider. OIDC sessions are carried by the
+// Authenticator's cookie, so requests never authenticate on their own.
+func (p *OIDCProvider) Authenticate(*http.Request) (Identity, bool) {
+	return Identity{}, false
+}
+
+// Challenge implements Provider.
+func (p *OIDCProvider) Challenge() string {
+	return ""
+}
+
+// LoginPath implements LoginProvider.
+func (p *OIDCProvider) LoginPath() string {
+	return RoutePrefix + "oidc/login"
+}
+
+// RegisterRoutes implements LoginProvider.
+func (p *OIDCProvider) RegisterRoutes(mux *http.ServeMux, complete func(http.ResponseWriter, *http.Request, Identity, string)) {
+	mux.HandleFunc(RoutePrefix+"oidc/login", p.handleLogin)
+	mux.HandleFunc(RoutePrefix+"oidc/callback", func(w http.ResponseWriter, r *http.Request) {
+		id, next, err := p.handleCallback(r)
+		if err != nil {
+			p.logger.Warn("OIDC login failed", "err", err)
+			http.Error(w, "Sign-in failed", http.StatusUnauthorized)
+			return
+		}
+		complete(w, r, id, next)
+	})
+}
+
+func (p *OIDCProvider) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state, err1 := GenerateToken()
+	nonce, err2 := GenerateToken()
+	verifier, err3 := GenerateToken()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	redirectURL := p.cfg.RedirectURL
+	if redirectURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		redirectURL = scheme + "://" + r.Host + RoutePrefix + "oidc/callback"
+	}
+
+	p.mu.Lock()
+	now := p.now()
+	for s, pending := range p.pending {
+		if !now.Before(pending.expires) {
+			delete(p.pending, s)
+		}
+	}
+	if len(p.pending) >= oidcMaxPending {
+		p.mu.Unlock()
+		http.Error(w, "Too many sign-in attempts in progress", http.StatusServiceUnavailable)
+		return
+	}
+	p.pending[state] = oidcPendingLogin{
+		nonce:       nonce,
+		verifier:    verifier,
+		redirectURL: redirectURL,
+		next:        SafeRedirect(r.URL.Query().Get("next")),
+		expires:     now.Add(oidcLoginTTL),
+	}
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+
+	target := p.discovery.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + q.Encode()
+	} else {
+		target += "?" + q.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (p *OIDCProvider) handleCallback(r *http.Request) (Identity, string, error) {
+	query := r.URL.Query()
+	state := query.Get("state")
+
+	p.mu.Lock()
+	pending, ok := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !ok || !p.now().Before(pending.expires) {
+		return Identity{}, "", errors.New("unknown or expired state")
+	}
+	if e := query.Get("error"); e != "" {
+		return Identity{}, "", fmt.Errorf("issuer returned %s: %s", e, query.Get("error_description"))
+	}
+	code := query.Get("code")
+	if code == "" {
+		return Identity{}, "", errors.New("missing authorization code")
+	}
+
+	rawIDToken, err := p.exchangeCode(r.Context(), code, pending)
+	if err != nil {
+		return Identity{}, "", err
+	}
+	claims, err := p.verifyIDToken(r.Context(), rawIDToken, pending.nonce)
+	if err != nil {
+		return Identity{}, "", err
+	}
+	if !p.emailAllowed(claims) {
+		return Identity{}, "", fmt.Errorf("account %q is not allowed", claims.Email)
+	}
+
+	name := claims.Email
+	if name == "" {
+		name = claims.Name
+	}
+	return Identity{Subject: claims.Subject, Name: name, Method: "oidc"}, pending.next, nil
+}
+
+func (p *OIDCProvider) exchangeCode(ctx context.Context, code string, pending oidcPendingLogin) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", pending.redirectURL)
+	form.Set("code_verifier", pending.verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req) // #nosec G704 -- endpoint comes from the configured issuer's discovery document
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxBody)).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return body.IDToken, nil
+}
+
+type oidcClaims struct {
+	Issuer        string       `json:"iss"`
+	Subject       string       `json:"sub"`
+	Audience      oidcAudience `json:"aud"`
+	Expires       int64        `json:"exp"`
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified *bool        `json:"email_verified"`
+	Name          string       `json:"name"`
+}
+
+// oidcAudience decodes the aud claim, which may be a string or an array.
+type oidcAudience []string
+
+func (a *oidcAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = oidcAudience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (oidcClaims, error) {
+	var claims oidcClaims
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return claims, fmt.Errorf("id_token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, fmt.Errorf("id_token signature: %w", err)
+	}
+	key, err := p.signingKey(ctx, header.Kid)
+	if err != nil {
+		return claims, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return claims, err
+	}
+
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return claims, fmt.Errorf("id_token claims: %w", err)
+	}
+	switch {
+	case strings.TrimSuffix(claims.Issuer, "/") != p.cfg.Issuer:
+		return claims, fmt.Errorf("id_token issuer %q does not match", claims.Issuer)
+	case !slices.Contains(claims.Audience, p.cfg.ClientID):
+		return claims, errors.New("id_token audience does not include client ID")
+	case p.now().After(time.Unix(claims.Expires, 0).Add(oidcClockSkew)):
+		return claims, errors.New("id_token has expired")
+	case claims.Nonce != nonce:
+		return claims, errors.New("id_token nonce does not match")
+	case claims.Subject == "":
+		return claims, errors.New("id_token has no subject")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) emailAllowed(claims oidcClaims) bool {
+	if len(p.cfg.AllowedEmails) == 0 {
+		return true
+	}
+	if claims.Email == "" || (claims.EmailVerified != nil && !*claims.EmailVerified) {
+		return false
+	}
+	email := strings.ToLower(claims.Email)
+	for _, allowed := range p.cfg.AllowedEmails {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if email == allowed || (strings.HasPrefix(allowed, "@") && strings.HasSuffix(email, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *OIDCProvider) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	lookup := func() (crypto.PublicKey, bool) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key, true
+			}
+		}
+		key, ok := p.keys[kid]
+		return key, ok
+	}
+	if key, ok := lookup(); ok {
+		return key, nil
+	}
+	// The issuer may have rotated its keys since they were last fetched.
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := lookup(); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no signing key %q", kid)
+}
+
+func (p *OIDCProvider) refreshKeys(ctx context.Context) error {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.discovery.JWKSURI, &set); err != nil {
+		return fmt.Errorf("oidc: fetching keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			exponent := 0
+			for _, b := range e {
+				exponent = exponent<<8 | int(b)
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+		case "EC":
+			if jwk.Crv != "P-256" {
+				continue
+			}
+			x, err1 := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if err1 != nil || err2 != nil || len(x) != 32 || len(y) != 32 {
+				continue
+			}
+			key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+			if err != nil {
+				continue
+			}
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return errors.New("oidc: issuer publishes no usable signing keys")
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req) // #nosec G704 -- URL derived from the configured issuer
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxBody)).Decode(v)
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("id_token algorithm does not match key")
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("id_token signature is invalid")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("id_token algorithm does not match key")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return errors.New("id_token signature is invalid")
+		}
+	default:
+		return fmt.Errorf("unsupported id_token algorithm %q", alg)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIssuer is a minimal OpenID Connect issuer that signs in a fixed user
+// without prompting.
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]mockAuthorization
+	claims func(map[string]any)
+}
+
+type mockAuthorization struct {
+	nonce     string
+	challenge string
+	clientID  string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	m := &mockIssuer{t: t, key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("response_type") != "code" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		code, _ := GenerateToken()
+		m.mu.Lock()
+		m.codes[code] = mockAuthorization{nonce: q.Get("nonce"), challenge: q.Get("code_challenge"), clientID: q.Get("client_id")}
+		m.mu.Unlock()
+		callback, _ := url.Parse(q.Get("redirect_uri"))
+		cq := callback.Query()
+		cq.Set("code", code)
+		cq.Set("state", q.Get("state"))
+		callback.RawQuery = cq.Encode()
+		http.Redirect(w, r, callback.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "gitvista" || pass != "client-secret" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		m.mu.Lock()
+		auth, ok := m.codes[r.PostFormValue("code")]
+		delete(m.codes, r.PostFormValue("code"))
+		m.mu.Unlock()
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.challenge {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		claims := map[string]any{
+			"iss":            m.server.URL,
+			"sub":            "user-1",
+			"aud":            []string{auth.clientID},
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          auth.nonce,
+			"email":          "dev@example.com",
+			"email_verified": true,
+		}
+		if m.claims != nil {
+			m.claims(claims)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": m.sign(claims), "token_type": "Bearer"})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		m.t.Fatalf("SignPKCS1v15() error = %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newOIDCTestApp(t *testing.T, issuer *mockIssuer, allowed ...string) (*httptest.Server, *http.Client) {
+	t.Helper()
+	provider, err := NewOIDCProvider(context.Background(), OIDCConfig{
+		Issuer:        issuer.server.URL,
+		ClientID:      "gitvista",
+		ClientSecret:  "client-secret",
+		AllowedEmails: allowed,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCProvider() error = %v", err)
+	}
+	_, handler := newTestAuthenticator(t, provider)
+	app := httptest.NewServer(handler)
+	t.Cleanup(app.Close)
+
+	jar, _ := cookiejar.New(nil)
+	return app, &http.Client{Jar: jar}
+}
+
+func getHTML(t *testing.T, client *http.Client, target string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", target, nil)
+	req.Header.Set("Accept", "text/html")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+	issuer := newMockIssuer(t)
+	app, client := newOIDCTestApp(t, issuer, "@example.com")
+
+	resp := getHTML(t, client, app.URL+"/?path=README.md")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 after sign-in", resp.StatusCode)
+	}
+	if resp.Request.URL.RequestURI() != "/?path=README.md" {
+		t.Fatalf("landed on %s, want /?path=README.md", resp.Request.URL.RequestURI())
+	}
+
+	// API requests and the WebSocket handshake reuse the session cookie.
+	apiResp, err := client.Get(app.URL + "/api/ws")
+	if err != nil {
+		t.Fatalf("GET /api/ws: %v", err)
+	}
+	_ = apiResp.Body.Close()
+	if apiResp.StatusCode != http.StatusOK {
+		t.Fatalf("/api/ws status = %d, want 200", apiResp.StatusCode)
+	}
+}
+
+func TestOIDCAPIRequestsAreNotRedirected(t *testing.T) {
+	issuer := newMockIssuer(t)
+	app, client := newOIDCTestApp(t, issuer)
+
+	resp := getHTML(t, client, app.URL+"/api/repository")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestOIDCRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  func(map[string]any)
+		allowed []string
+	}{
+		{name: "wrong audience", claims: func(c map[string]any) { c["aud"] = "someone-else" }},
+		{name: "expired", claims: func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "nonce mismatch", claims: func(c map[string]any) { c["nonce"] = "replayed" }},
+		{name: "wrong issuer", claims: func(c map[string]any) { c["iss"] = "https://evil.example" }},
+		{name: "email not allowed", allowed: []string{"@corp.example"}},
+		{name: "email unverified", allowed: []string{"@example.com"}, claims: func(c map[string]any) { c["email_verified"] = false }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			issuer.claims = tt.claims
+			app, client := newOIDCTestApp(t, issuer, tt.allowed...)
+
+			resp := getHTML(t, client, app.URL+"/")
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", resp.StatusCode)
+			}
+			if !strings.HasSuffix(resp.Request.URL.Path, "/auth/oidc/callback") {
+				t.Fatalf("ended at %s, want callback", resp.Request.URL)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackRejectsUnknownState(t *testing.T) {
+	issuer := newMockIssuer(t)
+	app, client := newOIDCTestApp(t, issuer)
+
+	resp := getHTML(t, client, app.URL+"/auth/oidc/callback?state=forged&code=x")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestNewOIDCProviderValidatesDiscovery(t *testing.T) {
+	if _, err := NewOIDCProvider(context.Background(), OIDCConfig{ClientID: "x"}); err == nil {
+		t.Fatal("NewOIDCProvider(no issuer) error = nil, want error")
+	}
+
+	issuer := newMockIssuer(t)
+	_, err := NewOIDCProvider(context.Background(), OIDCConfig{Issuer: issuer.server.URL + "/other", ClientID: "x"})
+	if err == nil {
+		t.Fatal("NewOIDCProvider(mismatched issuer) error = nil, want error")
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLoginCodeTTL is how long a one-time login code stays valid.
+const DefaultLoginCodeTTL = 15 * time.Minute
+
+// TokenProvider accepts a static bearer token and one-time login codes.
+// Scripts send the token as "Authorization: Bearer <token>"; browsers open a
+// login link carrying either the token or a one-time code and receive a
+// session cookie.
+type TokenProvider struct {
+	token string
+
+	mu    sync.Mutex
+	codes map[string]time.Time
+	now   func() time.Time
+}
+
+// NewTokenProvider returns a provider for token. An empty token disables
+// bearer authentication, leaving only one-time login codes.
+func NewTokenProvider(token string) *TokenProvider {
+	return &TokenProvider{
+		token: token,
+		codes: make(map[string]time.Time),
+		now:   time.Now,
+	}
+}
+
+// IssueLoginCode creates a code that can be exchanged once, within ttl, for a
+// browser session.
+func (p *TokenProvider) IssueLoginCode(ttl time.Duration) (string, error) {
+	code, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 {
+		ttl = DefaultLoginCodeTTL
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for c, expires := range p.codes {
+		if !now.Before(expires) {
+			delete(p.codes, c)
+		}
+	}
+	p.codes[code] = now.Add(ttl)
+	return code, nil
+}
+
+// Authenticate implements Provider.
+func (p *TokenProvider) Authenticate(r *http.Request) (Identity, bool) {
+	scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || !p.matchesToken(strings.TrimSpace(credentials)) {
+		return Identity{}, false
+	}
+	return Identity{Subject: "token", Method: "token"}, true
+}
+
+// Challenge implements Provider.
+func (p *TokenProvider) Challenge() string {
+	if p.token == "" {
+		return ""
+	}
+	return `Bearer realm="GitVista"`
+}
+
+// LoginPath implements LoginProvider. Token logins only start from a link.
+func (p *TokenProvider) LoginPath() string {
+	return ""
+}
+
+// RegisterRoutes implements LoginProvider by serving the login link endpoint.
+func (p *TokenProvider) RegisterRoutes(mux *http.ServeMux, complete func(http.ResponseWriter, *http.Request, Identity, string)) {
+	mux.HandleFunc(RoutePrefix+"login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		if !p.consumeCode(query.Get("code")) && !p.matchesToken(query.Get("token")) {
+			http.Error(w, "Login link is invalid or has expired", http.StatusUnauthorized)
+			return
+		}
+		complete(w, r, Identity{Subject: "token", Method: "token"}, query.Get("next"))
+	})
+}
+
+func (p *TokenProvider) matchesToken(candidate string) bool {
+	return p.token != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(p.token)) == 1
+}
+
+func (p *TokenProvider) consumeCode(code string) bool {
+	if code == "" {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	expires, ok := p.codes[code]
+	if !ok {
+		return false
+	}
+	delete(p.codes, code)
+	return p.now().Before(expires)
+}
+
+// LoginLink returns the path of a login link for code that lands on next,
+// which should be a path on the same server. Callers may append a URL
+// fragment; browsers carry it across the login redirect.
+func LoginLink(code, next string) string {
+	q := url.Values{}
+	q.Set("code", code)
+	if next != "" && next != "/" {
+		q.Set("next", next)
+	}
+	return RoutePrefix + "login?" + q.Encode()
+}
@@ -0,0 +1,431 @@
+// Package changelog assembles release notes from the Conventional Commit
+// subjects between two revisions.
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// ErrInvalidRange reports a revision range whose ends cannot be resolved.
+var ErrInvalidRange = errors.New("invalid revision range")
+
+// typeTitles gives the section title and order of the well-known commit
+// types. Other types follow in name order, then commits that are not
+// Conventional Commits.
+var typeTitles = []struct{ typ, title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+}
+
+// otherType groups commits whose subjects are not Conventional Commits.
+const otherType = "other"
+
+var (
+	conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: +(\S.*)$`)
+	squashPullRequest   = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+)
+
+// Changelog lists the non-merge commits in From..To grouped by type and
+// scope. Authors are the commits' authors after .mailmap, which gitcore
+// applies when it loads the repository.
+type Changelog struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Commits  int      `json:"commits"`
+	Breaking []Entry  `json:"breaking"`
+	Groups   []Group  `json:"groups"`
+	Authors  []Author `json:"authors"`
+	// PullRequestURL is the prefix PR numbers are linked with, or empty when
+	// the origin remote is not a known forge.
+	PullRequestURL string `json:"pullRequestUrl,omitempty"`
+}
+
+// Group holds one commit type. Scopes are in name order, unscoped first.
+type Group struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Scopes []ScopeGroup `json:"scopes"`
+}
+
+// ScopeGroup holds the entries of one type that share a scope.
+type ScopeGroup struct {
+	Scope   string  `json:"scope"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is one commit. PullRequest is zero when no pull request could be
+// found in the commit's subject or in a merge that brought it in.
+type Entry struct {
+	Hash         string `json:"hash"`
+	Type         string `json:"type"`
+	Scope        string `json:"scope,omitempty"`
+	Description  string `json:"description"`
+	Breaking     bool   `json:"breaking"`
+	BreakingNote string `json:"breakingNote,omitempty"`
+	PullRequest  int    `json:"pullRequest,omitempty"`
+	Author       string `json:"author"`
+	AuthorEmail  string `json:"authorEmail"`
+}
+
+// Author counts one author's commits in the changelog.
+type Author struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// Build assembles the changelog for revRange, either "<from>..<to>" or a
+// single revision meaning all of its history. An empty end means HEAD.
+func Build(repo *gitcore.Repository, revRange string) (*Changelog, error) {
+	rng, err := gitcore.ParseRevRange(revRange)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidRange, revRange, err)
+	}
+	commits, err := repo.RevList(rng.Options())
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidRange, revRange, err)
+	}
+
+	log := &Changelog{
+		From:           rng.From,
+		To:             rng.To,
+		Breaking:       []Entry{},
+		Groups:         []Group{},
+		Authors:        []Author{},
+		PullRequestURL: pullRequestURL(repo.Remotes()["origin"]),
+	}
+	pullRequests := mergedPullRequests(repo.Commits(), commits)
+
+	groups := make(map[string]map[string][]Entry)
+	authors := make(map[string]*Author)
+	for _, c := range commits {
+		if len(c.Parents) > 1 {
+			continue
+		}
+		entry := parseCommit(c)
+		if entry.PullRequest == 0 {
+			entry.PullRequest = pullRequests[c.ID]
+		}
+		log.Commits++
+		if entry.Breaking {
+			log.Breaking = append(log.Breaking, entry)
+		}
+		if groups[entry.Type] == nil {
+			groups[entry.Type] = make(map[string][]Entry)
+		}
+		groups[entry.Type][entry.Scope] = append(groups[entry.Type][entry.Scope], entry)
+
+		key := strings.ToLower(c.Author.Email)
+		if authors[key] == nil {
+			authors[key] = &Author{Name: c.Author.Name, Email: c.Author.Email}
+		}
+		authors[key].Commits++
+	}
+
+	for _, typ := range groupOrder(groups) {
+		group := Group{Type: typ, Title: typeTitle(typ), Scopes: []ScopeGroup{}}
+		scopes := make([]string, 0, len(groups[typ]))
+		for scope := range groups[typ] {
+			scopes = append(scopes, scope)
+		}
+		sort.Strings(scopes)
+		for _, scope := range scopes {
+			group.Scopes = append(group.Scopes, ScopeGroup{Scope: scope, Entries: groups[typ][scope]})
+		}
+		log.Groups = append(log.Groups, group)
+	}
+	for _, a := range authors {
+		log.Authors = append(log.Authors, *a)
+	}
+	sort.Slice(log.Authors, func(i, j int) bool {
+		if log.Authors[i].Commits != log.Authors[j].Commits {
+			return log.Authors[i].Commits > log.Authors[j].Commits
+		}
+		return log.Authors[i].Name < log.Authors[j].Name
+	})
+	return log, nil
+}
+
+// parseCommit reads the Conventional Commit fields of c's message. A
+// subject that does not follow the format becomes an "other" entry.
+func parseCommit(c *gitcore.Commit) Entry {
+	subject, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	entry := Entry{
+		Hash:        string(c.ID),
+		Type:        otherType,
+		Description: strings.TrimSpace(subject),
+		Author:      c.Author.Name,
+		AuthorEmail: c.Author.Email,
+	}
+	if m := squashPullRequest.FindStringSubmatchIndex(entry.Description); m != nil {
+		entry.PullRequest, _ = strconv.Atoi(entry.Description[m[2]:m[3]])
+		entry.Description = entry.Description[:m[0]]
+	}
+	if m := conventionalSubject.FindStringSubmatch(entry.Description); m != nil {
+		entry.Type = strings.ToLower(m[1])
+		entry.Scope = strings.TrimSpace(m[2])
+		entry.Breaking = m[3] == "!"
+		entry.Description = m[4]
+	}
+	if note, ok := breakingNote(body); ok {
+		entry.Breaking = true
+		entry.BreakingNote = note
+	}
+	return entry
+}
+
+// breakingNote returns the text of a "BREAKING CHANGE:" footer, including
+// any lines that continue it.
+func breakingNote(body string) (string, bool) {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		var rest string
+		var ok bool
+		if rest, ok = strings.CutPrefix(line, "BREAKING CHANGE:"); !ok {
+			rest, ok = strings.CutPrefix(line, "BREAKING-CHANGE:")
+		}
+		if !ok {
+			continue
+		}
+		note := []string{strings.TrimSpace(rest)}
+		for _, next := range lines[i+1:] {
+			if strings.TrimSpace(next) == "" {
+				break
+			}
+			note = append(note, strings.TrimSpace(next))
+		}
+		return strings.TrimSpace(strings.Join(note, " ")), true
+	}
+	return "", false
+}
+
+// mergedPullRequests finds the pull request each commit was merged through.
+// A merge's pull request applies to the commits its second parent brings
+// in; the newest merge wins when several bring in the same commit. Walks
+// stop at commits outside the range.
+func mergedPullRequests(commitsMap map[gitcore.Hash]*gitcore.Commit, commits []*gitcore.Commit) map[gitcore.Hash]int {
+	inRange := make(map[gitcore.Hash]bool, len(commits))
+	for _, c := range commits {
+		inRange[c.ID] = true
+	}
+	result := make(map[gitcore.Hash]int)
+	for _, merge := range commits {
+		if len(merge.Parents) < 2 {
+			continue
+		}
+		number := parsePullRequestNumber(merge.Message)
+		if number == 0 {
+			continue
+		}
+		mainline := make(map[gitcore.Hash]bool)
+		walkAncestors(commitsMap, merge.Parents[0], func(id gitcore.Hash) bool {
+			if mainline[id] || !inRange[id] {
+				return false
+			}
+			mainline[id] = true
+			return true
+		})
+		for _, side := range merge.Parents[1:] {
+			walkAncestors(commitsMap, side, func(id gitcore.Hash) bool {
+				if mainline[id] || !inRange[id] {
+					return false
+				}
+				if _, ok := result[id]; ok {
+					return false
+				}
+				result[id] = number
+				return true
+			})
+		}
+	}
+	return result
+}
+
+// walkAncestors visits start and its ancestors until visit returns false
+// for a commit, which stops the walk along that line.
+func walkAncestors(commitsMap map[gitcore.Hash]*gitcore.Commit, start gitcore.Hash, visit func(gitcore.Hash) bool) {
+	stack := []gitcore.Hash{start}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		c := commitsMap[id]
+		if c == nil || !visit(id) {
+			continue
+		}
+		stack = append(stack, c.Parents...)
+	}
+}
+
+// parsePullRequestNumber extracts the pull request number from the merge
+// messages GitHub, GitLab, and Bitbucket write. It returns zero when there
+// is none.
+func parsePullRequestNumber(message string) int {
+	first, rest, _ := strings.Cut(message, "\n")
+	var digits string
+	switch {
+	case strings.HasPrefix(first, "Merge pull request #"):
+		digits = strings.TrimPrefix(first, "Merge pull request #")
+	case strings.Contains(first, "(pull request #"):
+		_, digits, _ = strings.Cut(first, "(pull request #")
+	default:
+		// GitLab: "Merge branch 'x' into 'main'" with "See merge request
+		// group/project!123" in the body.
+		for _, line := range strings.Split(rest, "\n") {
+			if ref, ok := strings.CutPrefix(strings.TrimSpace(line), "See merge request "); ok {
+				if bang := strings.LastIndexByte(ref, '!'); bang >= 0 {
+					digits = ref[bang+1:]
+				}
+			}
+		}
+	}
+	end := 0
+	for end < len(digits) && digits[end] >= '0' && digits[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(digits[:end])
+	return n
+}
+
+// pullRequestURL returns the web prefix for pull requests of a GitHub or
+// GitLab remote, or "" for any other remote.
+func pullRequestURL(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(remote), "/"), ".git")
+	var host, repoPath string
+	switch {
+	case strings.HasPrefix(remote, "https://"), strings.HasPrefix(remote, "http://"):
+		_, rest, _ := strings.Cut(remote, "://")
+		host, repoPath, _ = strings.Cut(rest, "/")
+		if at := strings.LastIndexByte(host, '@'); at >= 0 {
+			host = host[at+1:]
+		}
+	case strings.HasPrefix(remote, "ssh://"):
+		rest := strings.TrimPrefix(remote, "ssh://")
+		host, repoPath, _ = strings.Cut(rest, "/")
+		if at := strings.LastIndexByte(host, '@'); at >= 0 {
+			host = host[at+1:]
+		}
+		host, _, _ = strings.Cut(host, ":")
+	case strings.Contains(remote, "@") && strings.Contains(remote, ":"):
+		// scp-like syntax: git@github.com:owner/repo
+		_, rest, _ := strings.Cut(remote, "@")
+		host, repoPath, _ = strings.Cut(rest, ":")
+	}
+	if repoPath == "" {
+		return ""
+	}
+	switch host {
+	case "github.com":
+		return "https://github.com/" + repoPath + "/pull/"
+	case "gitlab.com":
+		return "https://gitlab.com/" + repoPath + "/-/merge_requests/"
+	}
+	return ""
+}
+
+func groupOrder(groups map[string]map[string][]Entry) []string {
+	var order []string
+	known := make(map[string]bool, len(typeTitles))
+	for _, t := range typeTitles {
+		known[t.typ] = true
+		if _, ok := groups[t.typ]; ok {
+			order = append(order, t.typ)
+		}
+	}
+	var extra []string
+	for typ := range groups {
+		if !known[typ] && typ != otherType {
+			extra = append(extra, typ)
+		}
+	}
+	sort.Strings(extra)
+	order = append(order, extra...)
+	if _, ok := groups[otherType]; ok {
+		order = append(order, otherType)
+	}
+	return order
+}
+
+func typeTitle(typ string) string {
+	for _, t := range typeTitles {
+		if t.typ == typ {
+			return t.title
+		}
+	}
+	if typ == otherType {
+		return "Other Changes"
+	}
+	return typ
+}
+
+// Markdown renders the changelog with one section per type, breaking changes
+// first. Scoped entries carry their scope in bold; pull requests are linked
+// when the forge is known.
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	title := c.To
+	if c.From != "" {
+		title = c.From + ".." + c.To
+	}
+	fmt.Fprintf(&b, "## Changes in %s\n", title)
+	if c.Commits == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	if len(c.Breaking) > 0 {
+		b.WriteString("\n### Breaking Changes\n\n")
+		for _, e := range c.Breaking {
+			note := e.BreakingNote
+			if note == "" {
+				note = e.Description
+			}
+			c.writeEntry(&b, e, note)
+		}
+	}
+	for _, g := range c.Groups {
+		fmt.Fprintf(&b, "\n### %s\n\n", g.Title)
+		for _, s := range g.Scopes {
+			for _, e := range s.Entries {
+				c.writeEntry(&b, e, e.Description)
+			}
+		}
+	}
+
+	b.WriteString("\n### Contributors\n\n")
+	for _, a := range c.Authors {
+		fmt.Fprintf(&b, "- %s (%d)\n", a.Name, a.Commits)
+	}
+	return b.String()
+}
+
+func (c *Changelog) writeEntry(b *strings.Builder, e Entry, text string) {
+	b.WriteString("- ")
+	if e.Scope != "" {
+		fmt.Fprintf(b, "**%s:** ", e.Scope)
+	}
+	b.WriteString(text)
+	if e.PullRequest != 0 {
+		if c.PullRequestURL != "" {
+			fmt.Fprintf(b, " ([#%d](%s%d))", e.PullRequest, c.PullRequestURL, e.PullRequest)
+		} else {
+			fmt.Fprintf(b, " (#%d)", e.PullRequest)
+		}
+	}
+	fmt.Fprintf(b, " (%s)\n", gitcore.Hash(e.Hash).Short())
+}
@@ -0,0 +1,138 @@
+package changelog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+func TestBuild(t *testing.T) {
+	fixture := gittest.New(t)
+	commit := func(author, file, message string) {
+		t.Helper()
+		fixture.Author = author
+		fixture.Write(file, message+"\n")
+		fixture.Commit(message)
+	}
+	alice, bob, bobby, carol := "Alice <alice@example.com>", "Bob <bob@example.com>", "bobby <bob@old.example>", "Carol <carol@example.com>"
+
+	fixture.Git("remote", "add", "origin", "git@github.com:acme/widget.git")
+	fixture.Write(".mailmap", bob+" <bob@old.example>\n")
+	commit(alice, "init.txt", "chore: init")
+	fixture.Git("tag", "v1.0")
+	commit(alice, "api.txt", "feat(api)!: drop v1 endpoints\n\nBREAKING CHANGE: clients must\nuse /v2.")
+	fixture.Git("checkout", "-q", "-b", "feature")
+	commit(bob, "ui.txt", "fix(ui): align buttons")
+	commit(bobby, "docs.txt", "docs: explain buttons")
+	fixture.Author = alice
+	fixture.Git("checkout", "-q", "main")
+	fixture.Git("merge", "-q", "--no-ff", "feature", "-m", "Merge pull request #42 from acme/feature")
+	commit(carol, "readme.txt", "Add readme (#7)")
+	commit(carol, "perf.txt", "perf(core): faster startup (#8)")
+	repo := fixture.Open()
+
+	log, err := Build(repo, "v1.0..")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if log.From != "v1.0" || log.To != "HEAD" || log.Commits != 5 || log.PullRequestURL != "https://github.com/acme/widget/pull/" {
+		t.Fatalf("changelog = %+v", log)
+	}
+
+	var types []string
+	entries := make(map[string]Entry)
+	for _, g := range log.Groups {
+		types = append(types, g.Type)
+		for _, s := range g.Scopes {
+			for _, e := range s.Entries {
+				if e.Scope != s.Scope || e.Type != g.Type {
+					t.Fatalf("entry %+v filed under %s/%s", e, g.Type, s.Scope)
+				}
+				entries[e.Description] = e
+			}
+		}
+	}
+	if strings.Join(types, ",") != "feat,fix,perf,docs,other" {
+		t.Fatalf("group order = %v", types)
+	}
+	for desc, want := range map[string]Entry{
+		"drop v1 endpoints": {Scope: "api", Breaking: true, BreakingNote: "clients must use /v2.", Author: "Alice"},
+		"align buttons":     {Scope: "ui", PullRequest: 42, Author: "Bob"},
+		"explain buttons":   {PullRequest: 42, Author: "Bob"},
+		"Add readme":        {PullRequest: 7, Author: "Carol"},
+		"faster startup":    {Scope: "core", PullRequest: 8, Author: "Carol"},
+	} {
+		got, ok := entries[desc]
+		if !ok || got.Scope != want.Scope || got.Breaking != want.Breaking || got.BreakingNote != want.BreakingNote ||
+			got.PullRequest != want.PullRequest || got.Author != want.Author {
+			t.Errorf("entry %q = %+v, want %+v", desc, got, want)
+		}
+	}
+	if len(log.Breaking) != 1 || len(log.Authors) != 3 || log.Authors[0].Name != "Bob" || log.Authors[0].Commits != 2 {
+		t.Fatalf("breaking = %+v, authors = %+v", log.Breaking, log.Authors)
+	}
+
+	md := log.Markdown()
+	for _, want := range []string{
+		"## Changes in v1.0..HEAD\n",
+		"### Breaking Changes\n\n- **api:** clients must use /v2. (",
+		"### Bug Fixes\n\n- **ui:** align buttons ([#42](https://github.com/acme/widget/pull/42)) (",
+		"### Other Changes\n\n- Add readme ([#7](https://github.com/acme/widget/pull/7)) (",
+		"### Contributors\n\n- Bob (2)\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q:\n%s", want, md)
+		}
+	}
+
+	if _, err := Build(repo, "v1.0..nope"); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("Build(unknown) error = %v, want ErrInvalidRange", err)
+	}
+	if _, err := Build(repo, "v1.0...HEAD"); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("Build(symmetric) error = %v, want ErrInvalidRange", err)
+	}
+	empty, err := Build(repo, "HEAD..HEAD")
+	if err != nil || empty.Commits != 0 || !strings.Contains(empty.Markdown(), "No changes.") {
+		t.Fatalf("Build(empty) = %+v, %v", empty, err)
+	}
+}
+
+func TestParsePullRequestNumber(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int
+	}{
+		{"Merge pull request #123 from acme/feature\n\nAdd things", 123},
+		{"Merged in feature/x (pull request #45)\n\nApproved-by: someone", 45},
+		{"Merge branch 'feature' into 'main'\n\nAdd things\n\nSee merge request group/project!678", 678},
+		{"Merge branch 'feature'", 0},
+		{"Merge pull request #abc from acme/feature", 0},
+	}
+	for _, tt := range tests {
+		if got := parsePullRequestNumber(tt.message); got != tt.want {
+			t.Errorf("parsePullRequestNumber(%q) = %d, want %d", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestPullRequestURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"git@github.com:acme/widget.git", "https://github.com/acme/widget/pull/"},
+		{"https://github.com/acme/widget", "https://github.com/acme/widget/pull/"},
+		{"https://token@github.com/acme/widget.git", "https://github.com/acme/widget/pull/"},
+		{"ssh://git@gitlab.com:22/group/sub/project.git", "https://gitlab.com/group/sub/project/-/merge_requests/"},
+		{"https://git.example.com/acme/widget.git", ""},
+		{"/srv/git/widget.git", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := pullRequestURL(tt.remote); got != tt.want {
+			t.Errorf("pullRequestURL(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
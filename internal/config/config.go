@@ -0,0 +1,272 @@
+// Package config reads GitVista configuration files.
+//
+// Files use a subset of TOML: tables, dotted keys, strings, integers, floats,
+// and booleans. Values are kept as text so callers parse them as the type each
+// setting needs and can report the line a bad value came from.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RepoFileName is the per-repository configuration file, read from the root
+// of the working tree.
+const RepoFileName = ".gitvista.toml"
+
+// File is a parsed configuration file.
+type File struct {
+	Path string
+	// Values maps dotted keys such as "watcher.debounce" to their values.
+	Values map[string]Value
+}
+
+// Value is a scalar read from a file.
+type Value struct {
+	// Text is the value with string quoting and escapes removed.
+	Text string
+	Line int
+}
+
+// SyntaxError reports a line that could not be parsed.
+type SyntaxError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Msg)
+}
+
+// Location returns "path:line" for the value of key, for error messages and
+// source reporting.
+func (f *File) Location(key string) string {
+	return fmt.Sprintf("%s:%d", f.Path, f.Values[key].Line)
+}
+
+// Load reads and parses the file at path. A missing file is not an error: Load
+// returns nil.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- configuration paths are chosen by the user.
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+// UserFile returns the path of the user's configuration file,
+// $XDG_CONFIG_HOME/gitvista/config.toml, falling back to the platform's
+// configuration directory when XDG_CONFIG_HOME is unset.
+func UserFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, "gitvista", "config.toml"), nil
+}
+
+// RepoFile returns the path of the per-repository configuration file for the
+// repository containing start, or "" when start is not inside a working tree.
+func RepoFile(start string) string {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+	if filepath.Base(dir) == ".git" {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return filepath.Join(dir, RepoFileName)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Parse parses data as a configuration file read from path.
+func Parse(path string, data []byte) (*File, error) {
+	f := &File{Path: path, Values: make(map[string]Value)}
+	table := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		fail := func(format string, args ...any) error {
+			return &SyntaxError{Path: path, Line: lineNo, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if strings.HasPrefix(line, "[[") {
+				return nil, fail("arrays of tables are not supported")
+			}
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, fail("unterminated table header")
+			}
+			if rest := strings.TrimSpace(line[end+1:]); rest != "" && rest[0] != '#' {
+				return nil, fail("unexpected text after table header")
+			}
+			name, err := parseKey(line[1:end])
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			table = name
+			continue
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, fail("expected key = value")
+		}
+		key, err := parseKey(line[:eq])
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		if table != "" {
+			key = table + "." + key
+		}
+		text, err := parseValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fail("%s: %v", key, err)
+		}
+		if prev, ok := f.Values[key]; ok {
+			return nil, fail("%s is already set on line %d", key, prev.Line)
+		}
+		f.Values[key] = Value{Text: text, Line: lineNo}
+	}
+	return f, nil
+}
+
+// parseKey parses a bare, possibly dotted key such as "cache.ttl".
+func parseKey(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("missing key")
+	}
+	parts := strings.Split(raw, ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return "", fmt.Errorf("invalid key %q", raw)
+		}
+		for _, r := range part {
+			if !isBareKeyRune(r) {
+				return "", fmt.Errorf("invalid key %q", raw)
+			}
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func isBareKeyRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// parseValue parses a scalar value and an optional trailing comment.
+func parseValue(raw string) (string, error) {
+	if raw == "" {
+		return "", errors.New("missing value")
+	}
+	var text, rest string
+	switch raw[0] {
+	case '"':
+		var err error
+		if text, rest, err = parseBasicString(raw); err != nil {
+			return "", err
+		}
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		text, rest = raw[1:end+1], raw[end+2:]
+	case '[', '{':
+		return "", errors.New("arrays and inline tables are not supported")
+	default:
+		text, rest, _ = strings.Cut(raw, "#")
+		text = strings.TrimSpace(text)
+		rest = ""
+		if !isScalar(text) {
+			return "", fmt.Errorf("invalid value %q (strings must be quoted)", text)
+		}
+		text = strings.ReplaceAll(text, "_", "")
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+		return "", errors.New("unexpected text after value")
+	}
+	return text, nil
+}
+
+func isScalar(text string) bool {
+	if text == "true" || text == "false" {
+		return true
+	}
+	digits := strings.ReplaceAll(text, "_", "")
+	if _, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(digits, 64)
+	return err == nil
+}
+
+// parseBasicString parses a double-quoted string, returning its contents and
+// the text after the closing quote.
+func parseBasicString(raw string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		switch c {
+		case '"':
+			return b.String(), raw[i+1:], nil
+		case '\\':
+			i++
+			if i >= len(raw) {
+				return "", "", errors.New("unterminated string")
+			}
+			switch raw[i] {
+			case '"', '\\':
+				b.WriteByte(raw[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u':
+				if i+4 >= len(raw) {
+					return "", "", errors.New("invalid \\u escape")
+				}
+				n, err := strconv.ParseUint(raw[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", "", errors.New("invalid \\u escape")
+				}
+				b.WriteRune(rune(n))
+				i += 4
+			default:
+				return "", "", fmt.Errorf("invalid escape \\%c", raw[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := []byte(`# GitVista settings
+[server]
+port = 9_090   # trailing comment
+host = "0.0.0.0"
+
+[watcher]
+debounce = '250ms'
+"status_poll_interval" = "ignored"
+`)
+	_, err := Parse("config.toml", data)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 8 {
+		t.Fatalf("Parse() error = %v, want syntax error on line 8", err)
+	}
+
+	data = []byte(`# GitVista settings
+[server]
+port = 9_090   # trailing comment
+host = "0.0.0.0"
+
+[watcher]
+debounce = '250ms'
+bootstrap.batch_pause = "1\"sé"
+enabled = true
+`)
+	f, err := Parse("config.toml", data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string]Value{
+		"server.port":                   {Text: "9090", Line: 3},
+		"server.host":                   {Text: "0.0.0.0", Line: 4},
+		"watcher.debounce":              {Text: "250ms", Line: 7},
+		"watcher.bootstrap.batch_pause": {Text: "1\"sé", Line: 8},
+		"watcher.enabled":               {Text: "true", Line: 9},
+	}
+	if len(f.Values) != len(want) {
+		t.Fatalf("Values = %+v, want %+v", f.Values, want)
+	}
+	for key, v := range want {
+		if got := f.Values[key]; got != v {
+			t.Errorf("Values[%q] = %+v, want %+v", key, got, v)
+		}
+	}
+	if got := f.Location("server.host"); got != "config.toml:4" {
+		t.Errorf("Location() = %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"unquoted string":   "level = debug",
+		"missing value":     "level =",
+		"missing equals":    "level",
+		"duplicate key":     "a = 1\na = 2",
+		"unterminated":      `a = "x`,
+		"bad escape":        `a = "\q"`,
+		"array":             "a = [1, 2]",
+		"array of tables":   "[[a]]",
+		"text after value":  `a = "x" y`,
+		"bad table header":  "[a",
+		"empty key segment": "a..b = 1",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var syntaxErr *SyntaxError
+			if _, err := Parse("f.toml", []byte(data)); !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want SyntaxError", data, err)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "absent.toml"))
+	if f != nil || err != nil {
+		t.Fatalf("Load() = %v, %v, want nil, nil", f, err)
+	}
+}
+
+func TestRepoFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(root, RepoFileName)
+	for _, start := range []string{root, sub, filepath.Join(root, ".git")} {
+		if got := RepoFile(start); got != want {
+			t.Errorf("RepoFile(%q) = %q, want %q", start, got, want)
+		}
+	}
+}
+
+func TestUserFileHonorsXDGConfigHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	got, err := UserFile()
+	if err != nil || got != filepath.Join(dir, "gitvista", "config.toml") {
+		t.Fatalf("UserFile() = %q, %v", got, err)
+	}
+}
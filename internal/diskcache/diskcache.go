@@ -0,0 +1,384 @@
+// Package diskcache is a persistent, content-addressed cache for results that
+// never change once computed, such as the diff of a commit against its parent.
+//
+// Entries are files named after the SHA-256 of their key, so callers key them
+// by the object IDs they were derived from. Writes go to a temporary file that
+// is renamed into place, which lets several GitVista processes share one
+// cache directory without readers ever seeing a partial entry. When the cache
+// grows past its size limit the least recently read entries are evicted.
+package diskcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// formatVersion is written into every entry header. Entries written by
+	// an incompatible build are treated as misses and removed.
+	formatVersion = 1
+
+	// DefaultMaxBytes bounds the cache when Options.MaxBytes is unset.
+	DefaultMaxBytes = 256 << 20
+
+	entriesDir = "entries"
+	lockFile   = "prune.lock"
+	tmpPrefix  = ".tmp-"
+
+	// staleAfter is how old a prune lock or an orphaned temporary file must
+	// be before it is assumed to belong to a process that died.
+	staleAfter = 2 * time.Minute
+)
+
+// ErrTooLarge is returned by Put for a value that could never fit the cache.
+var ErrTooLarge = errors.New("diskcache: entry exceeds cache size limit")
+
+// Options configures a Cache.
+type Options struct {
+	// MaxBytes is the size limit of the cache directory. Zero means
+	// DefaultMaxBytes.
+	MaxBytes int64
+}
+
+// Stats reports cache activity since Open and the size of the cache as last
+// observed. Entries and Bytes include entries written by other processes only
+// after the next prune.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+// Cache is a persistent key-value cache rooted at a directory. It is safe for
+// concurrent use by multiple goroutines and multiple processes.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries int
+	bytes   int64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	pruning   atomic.Bool
+}
+
+// Open creates dir if needed and returns a cache rooted there.
+func Open(dir string, opts Options) (*Cache, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if err := os.MkdirAll(filepath.Join(dir, entriesDir), 0o755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	c := &Cache{dir: dir, maxBytes: opts.MaxBytes}
+	files, _ := c.scan()
+	for _, f := range files {
+		c.entries++
+		c.bytes += f.size
+	}
+	return c, nil
+}
+
+// Dir picks the cache directory for the repository at gitDir. It prefers
+// gitvista/cache inside the git directory, so the cache travels with the
+// repository, and falls back to the user cache directory ($XDG_CACHE_HOME on
+// Linux) when the git directory is not writable.
+func Dir(gitDir string) (string, error) {
+	inRepo := filepath.Join(gitDir, "gitvista", "cache")
+	if writable(inRepo) {
+		return inRepo, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache directory: %w", err)
+	}
+	abs, err := filepath.Abs(gitDir)
+	if err != nil {
+		abs = gitDir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(base, "gitvista", "repos", hex.EncodeToString(sum[:8])), nil
+}
+
+func writable(dir string) bool {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false
+	}
+	f, err := os.CreateTemp(dir, tmpPrefix+"probe-*")
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+	return true
+}
+
+// Path returns the directory the cache is rooted at.
+func (c *Cache) Path() string {
+	return c.dir
+}
+
+// Get returns the value stored under key. A hit marks the entry as recently
+// used so it survives eviction longer.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	path := c.entryPath(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	value, ok := decodeEntry(key, data)
+	if !ok {
+		if os.Remove(path) == nil {
+			c.account(-1, -int64(len(data)))
+		}
+		c.misses.Add(1)
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	c.hits.Add(1)
+	return value, true
+}
+
+// Put stores value under key, replacing any previous value.
+func (c *Cache) Put(key string, value []byte) error {
+	data := encodeEntry(key, value)
+	if int64(len(data)) > c.maxBytes {
+		return ErrTooLarge
+	}
+	path := c.entryPath(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, tmpPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+
+	var replaced int64 = -1
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if replaced >= 0 {
+		c.account(0, int64(len(data))-replaced)
+	} else {
+		c.account(1, int64(len(data)))
+	}
+
+	c.mu.Lock()
+	over := c.bytes > c.maxBytes
+	c.mu.Unlock()
+	if over {
+		c.Prune()
+	}
+	return nil
+}
+
+// GetJSON decodes the value stored under key into v.
+func (c *Cache) GetJSON(key string, v any) bool {
+	data, ok := c.Get(key)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// PutJSON stores the JSON encoding of v under key.
+func (c *Cache) PutJSON(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	return c.Put(key, data)
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	entries, size := c.entries, c.bytes
+	c.mu.Unlock()
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   entries,
+		Bytes:     size,
+	}
+}
+
+// Prune evicts the least recently used entries until the cache is below 90%
+// of its size limit, leaving headroom so the next few writes do not prune
+// again. Only one process prunes at a time; others skip while it runs.
+func (c *Cache) Prune() {
+	if !c.pruning.CompareAndSwap(false, true) {
+		return
+	}
+	defer c.pruning.Store(false)
+
+	unlock, ok := c.lock()
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	files, orphans := c.scan()
+	for _, name := range orphans {
+		_ = os.Remove(name)
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	target := c.maxBytes / 10 * 9
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	kept := len(files)
+	for _, f := range files {
+		if total <= target {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		total -= f.size
+		kept--
+		c.evictions.Add(1)
+	}
+
+	c.mu.Lock()
+	c.entries, c.bytes = kept, total
+	c.mu.Unlock()
+}
+
+func (c *Cache) account(entries int, size int64) {
+	c.mu.Lock()
+	c.entries += entries
+	c.bytes += size
+	c.mu.Unlock()
+}
+
+// lock takes the cross-process prune lock. A lock left behind by a process
+// that died is broken once it is older than staleAfter.
+func (c *Cache) lock() (func(), bool) {
+	path := filepath.Join(c.dir, lockFile)
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, true
+		}
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) < staleAfter {
+			return nil, false
+		}
+		_ = os.Remove(path)
+	}
+	return nil, false
+}
+
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// scan lists the entry files and any temporary files abandoned by writers
+// that died before renaming them.
+func (c *Cache) scan() (files []cacheFile, orphans []string) {
+	root := filepath.Join(c.dir, entriesDir)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), tmpPrefix) {
+			if time.Since(info.ModTime()) > staleAfter {
+				orphans = append(orphans, path)
+			}
+			return nil
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return files, orphans
+}
+
+func (c *Cache) entryPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, entriesDir, name[:2], name[2:])
+}
+
+// An entry is a header line, the key, and the value:
+//
+//	gitvista-cache <format> <crc32 of value> <value length>\n<key>\n<value>
+//
+// The key guards against hash collisions and the checksum against entries
+// truncated by a crash.
+func encodeEntry(key string, value []byte) []byte {
+	header := fmt.Sprintf("gitvista-cache %d %08x %d\n%s\n", formatVersion, crc32.ChecksumIEEE(value), len(value), key)
+	data := make([]byte, 0, len(header)+len(value))
+	data = append(data, header...)
+	return append(data, value...)
+}
+
+func decodeEntry(key string, data []byte) ([]byte, bool) {
+	line, rest, ok := bytes.Cut(data, []byte{'\n'})
+	if !ok {
+		return nil, false
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) != 4 || fields[0] != "gitvista-cache" || fields[1] != strconv.Itoa(formatVersion) {
+		return nil, false
+	}
+	storedKey, value, ok := bytes.Cut(rest, []byte{'\n'})
+	if !ok || string(storedKey) != key {
+		return nil, false
+	}
+	length, err := strconv.Atoi(fields[3])
+	if err != nil || length != len(value) {
+		return nil, false
+	}
+	if fields[2] != fmt.Sprintf("%08x", crc32.ChecksumIEEE(value)) {
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,204 @@
+package diskcache
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachePutGet(t *testing.T) {
+	c, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, ok := c.Get("commit-diff:abc"); ok {
+		t.Fatal("Get() hit on an empty cache")
+	}
+	if err := c.Put("commit-diff:abc", []byte(`{"files":2}`)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, ok := c.Get("commit-diff:abc")
+	if !ok || string(got) != `{"files":2}` {
+		t.Fatalf("Get() = %q, %v", got, ok)
+	}
+
+	// A second cache on the same directory, as another process would open,
+	// sees the entry.
+	other, err := Open(c.Path(), Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, ok := other.Get("commit-diff:abc"); !ok {
+		t.Fatal("entry not visible to a second cache")
+	}
+	if stats := other.Stats(); stats.Entries != 1 || stats.Hits != 1 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+
+	var v struct{ Files int }
+	if err := c.PutJSON("json", struct{ Files int }{Files: 3}); err != nil {
+		t.Fatalf("PutJSON() error = %v", err)
+	}
+	if !c.GetJSON("json", &v) || v.Files != 3 {
+		t.Fatalf("GetJSON() = %+v", v)
+	}
+}
+
+func TestCacheRejectsForeignAndCorruptEntries(t *testing.T) {
+	c, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	tests := map[string][]byte{
+		"old format": []byte("gitvista-cache 0 00000000 0\nk\n"),
+		"other key":  encodeEntry("another", []byte("v")),
+		"truncated":  encodeEntry("k", []byte("value"))[:32],
+		"bad crc":    bytes.Replace(encodeEntry("k", []byte("value")), []byte("value"), []byte("valuf"), 1),
+	}
+	for name, data := range tests {
+		path := c.entryPath("k")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := c.Get("k"); ok {
+			t.Errorf("%s: Get() hit", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s: rejected entry was not removed", name)
+		}
+	}
+}
+
+func TestCachePruneEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := Open(t.TempDir(), Options{MaxBytes: 4096})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	value := bytes.Repeat([]byte("x"), 900)
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 4; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if err := c.Put(key, value); err != nil {
+			t.Fatalf("Put(%s) error = %v", key, err)
+		}
+		stamp := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(c.entryPath(key), stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading k0 makes it the most recently used entry.
+	if _, ok := c.Get("k0"); !ok {
+		t.Fatal("Get(k0) missed")
+	}
+	if err := c.Put("k4", value); err != nil {
+		t.Fatalf("Put(k4) error = %v", err)
+	}
+
+	stats := c.Stats()
+	if stats.Bytes > 4096 || stats.Evictions == 0 {
+		t.Fatalf("Stats() after prune = %+v", stats)
+	}
+	if _, ok := c.Get("k1"); ok {
+		t.Error("least recently used entry k1 survived the prune")
+	}
+	for _, key := range []string{"k0", "k4"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("recently used entry %s was evicted", key)
+		}
+	}
+	if err := c.Put("huge", bytes.Repeat([]byte("x"), 5000)); err != ErrTooLarge {
+		t.Fatalf("Put(huge) error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestCachePruneSkipsWhileLocked(t *testing.T) {
+	c, err := Open(t.TempDir(), Options{MaxBytes: 1024})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	unlock, ok := c.lock()
+	if !ok {
+		t.Fatal("lock() failed on a fresh cache")
+	}
+	if _, ok := c.lock(); ok {
+		t.Fatal("lock() succeeded while held")
+	}
+	unlock()
+
+	// A lock abandoned by a dead process is broken once stale.
+	lockPath := filepath.Join(c.Path(), lockFile)
+	if err := os.WriteFile(lockPath, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-2 * staleAfter)
+	if err := os.Chtimes(lockPath, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	unlock, ok = c.lock()
+	if !ok {
+		t.Fatal("lock() did not break a stale lock")
+	}
+	unlock()
+}
+
+func TestCacheConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	caches := make([]*Cache, 4)
+	for i := range caches {
+		c, err := Open(dir, Options{MaxBytes: 64 << 10})
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		caches[i] = c
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range caches {
+		wg.Add(1)
+		go func(i int, c *Cache) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("k%d", j%50)
+				want := bytes.Repeat([]byte(key), 20)
+				if err := c.Put(key, want); err != nil {
+					t.Errorf("Put() error = %v", err)
+					return
+				}
+				if got, ok := c.Get(key); ok && !bytes.Equal(got, want) {
+					t.Errorf("Get(%s) returned a torn value", key)
+					return
+				}
+			}
+		}(i, c)
+	}
+	wg.Wait()
+}
+
+func TestDirFallsBackWhenRepositoryIsReadOnly(t *testing.T) {
+	gitDir := t.TempDir()
+	dir, err := Dir(gitDir)
+	if err != nil || dir != filepath.Join(gitDir, "gitvista", "cache") {
+		t.Fatalf("Dir() = %q, %v", dir, err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	readOnly := t.TempDir()
+	if err := os.Chmod(readOnly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(readOnly, 0o755) })
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir, err = Dir(readOnly)
+	if err != nil || filepath.Dir(filepath.Dir(dir)) != filepath.Join(os.Getenv("XDG_CACHE_HOME"), "gitvista") {
+		t.Fatalf("Dir() = %q, %v", dir, err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package githttp serves a repository read-only over the Git smart HTTP
+// protocol, so `git clone` and `git fetch` work against a GitVista server.
+// Only protocol version 2 and its ls-refs and fetch commands are implemented;
+// pushes are refused.
+// See: https://git-scm.com/docs/gitprotocol-http and https://git-scm.com/docs/gitprotocol-v2
+package githttp
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// RoutePrefix is where the handler is mounted. Any path below it names the
+// served repository, so both /git/ and /git/project.git can be cloned.
+const RoutePrefix = "/git/"
+
+const (
+	uploadPackService = "git-upload-pack"
+	// maxRequestBytes bounds a decoded upload-pack request, which is mostly
+	// want and have lines.
+	maxRequestBytes = 16 << 20
+)
+
+// Handler answers Git smart HTTP requests for one repository.
+type Handler struct {
+	repo   func() *gitcore.Repository
+	agent  string
+	logger *slog.Logger
+}
+
+// NewHandler returns a handler that serves whatever repository repo returns at
+// the time of each request. version is advertised in the agent capability.
+func NewHandler(repo func() *gitcore.Repository, version string, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{repo: repo, agent: "gitvista/" + version, logger: logger}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch path := r.URL.Path; {
+	case strings.HasSuffix(path, "/info/refs"):
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.serveInfoRefs(w, r)
+	case strings.HasSuffix(path, "/"+uploadPackService):
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.serveUploadPack(w, r)
+	case strings.HasSuffix(path, "/git-receive-pack"):
+		http.Error(w, "repository is read-only", http.StatusForbidden)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveInfoRefs sends the capability advertisement that opens every session.
+// Clients that did not ask for protocol version 2 get an error they will show
+// to the user instead of a v0 ref advertisement.
+func (h *Handler) serveInfoRefs(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service != uploadPackService {
+		if service == "git-receive-pack" {
+			http.Error(w, "repository is read-only", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "only smart HTTP clones are supported", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+	w.Header().Set("Cache-Control", "no-cache")
+	if !wantsProtocolV2(r) {
+		_ = writeLine(w, "# service=%s", uploadPackService)
+		_ = writeFlush(w)
+		_ = writeLine(w, "ERR GitVista only serves Git protocol version 2; retry with git -c protocol.version=2")
+		return
+	}
+	_ = writeLine(w, "version 2")
+	_ = writeLine(w, "agent=%s", h.agent)
+	_ = writeLine(w, "ls-refs")
+	_ = writeLine(w, "fetch")
+	_ = writeLine(w, "object-format=sha1")
+	_ = writeFlush(w)
+}
+
+// wantsProtocolV2 reports whether the Git-Protocol header asks for version 2.
+func wantsProtocolV2(r *http.Request) bool {
+	for _, header := range r.Header.Values("Git-Protocol") {
+		for _, param := range strings.Split(header, ":") {
+			if strings.TrimSpace(param) == "version=2" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// request is one protocol v2 command with its arguments.
+type request struct {
+	command string
+	args    []string
+}
+
+func readRequest(p *pktReader) (*request, error) {
+	req := &request{}
+	for section := 0; ; {
+		kind, line, err := p.readLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) && section == 0 && req.command == "" {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		switch kind {
+		case packetFlush:
+			if req.command == "" {
+				return nil, io.EOF
+			}
+			return req, nil
+		case packetDelim:
+			section++
+		case packetResponseEnd:
+			return nil, errPacketFormat
+		default:
+			if section > 0 {
+				req.args = append(req.args, line)
+			} else if command, ok := strings.CutPrefix(line, "command="); ok {
+				req.command = command
+			}
+			// Other capability lines, like agent= and object-format=, need
+			// no answer from a server with a single agent and hash.
+		}
+	}
+}
+
+func (h *Handler) serveUploadPack(w http.ResponseWriter, r *http.Request) {
+	body := io.Reader(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "invalid gzip request body", http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = gz.Close() }()
+		body = io.LimitReader(gz, maxRequestBytes)
+	}
+
+	req, err := readRequest(newPktReader(body))
+	if errors.Is(err, io.EOF) {
+		// A bare flush ends the session without a command.
+		return
+	}
+	if err != nil {
+		http.Error(w, "invalid upload-pack request", http.StatusBadRequest)
+		return
+	}
+
+	repo := h.repo()
+	w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+	w.Header().Set("Cache-Control", "no-cache")
+	if repo == nil {
+		_ = writeLine(w, "ERR repository is not available")
+		return
+	}
+
+	switch req.command {
+	case "ls-refs":
+		err = lsRefs(w, repo, req.args)
+	case "fetch":
+		err = fetch(w, repo, req.args)
+	default:
+		err = writeLine(w, "ERR unknown command %q", req.command)
+	}
+	if err != nil {
+		h.logger.Warn("git upload-pack failed", "command", req.command, "err", err)
+	}
+}
+
+// errorLine reports a protocol error to the client, which prints it as a
+// remote error.
+func errorLine(w io.Writer, err error) error {
+	if werr := writeLine(w, "ERR %s", err); werr != nil {
+		return werr
+	}
+	return err
+}
+
+func parseHash(arg, value string) (gitcore.Hash, error) {
+	id, err := gitcore.NewHash(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q", arg, value)
+	}
+	return id, nil
+}
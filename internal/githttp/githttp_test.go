@@ -0,0 +1,156 @@
+package githttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+// newCloneServer serves a fresh fixture repository and returns it, the server
+// URL, and a function that reloads the served repository.
+func newCloneServer(t *testing.T) (*gittest.Repo, string, func()) {
+	t.Helper()
+	src := gittest.New(t)
+	src.Write("README.md", "hello\n")
+	src.Commit("first")
+	src.Git("tag", "-a", "v1", "-m", "release v1")
+	src.Git("checkout", "-q", "-b", "feature")
+	src.Write("src/feature.go", "package src\n")
+	src.Commit("feature")
+	src.Git("checkout", "-q", "main")
+	src.Write("docs/guide.md", "guide\n")
+	src.Commit("docs")
+
+	var current atomic.Pointer[gitcore.Repository]
+	reload := func() {
+		repo, err := gitcore.NewRepository(src.Dir)
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		current.Store(repo)
+	}
+	reload()
+
+	mux := http.NewServeMux()
+	mux.Handle(RoutePrefix, NewHandler(current.Load, "test", nil))
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return src, ts.URL + RoutePrefix + "repo.git", reload
+}
+
+func TestCloneAndFetch(t *testing.T) {
+	src, url, reload := newCloneServer(t)
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	gittest.Run(t, t.TempDir(), "-c", "protocol.version=2", "clone", "-q", url, dest)
+
+	for _, ref := range []string{"HEAD", "main", "feature", "v1", "v1^{commit}"} {
+		want := src.Git("rev-parse", ref)
+		remoteRef := ref
+		if ref == "main" || ref == "feature" {
+			remoteRef = "origin/" + ref
+		}
+		if got := gittest.Run(t, dest, "rev-parse", remoteRef); got != want {
+			t.Errorf("clone rev-parse %s = %s, want %s", remoteRef, got, want)
+		}
+	}
+	if got := gittest.Run(t, dest, "symbolic-ref", "HEAD"); got != "refs/heads/main" {
+		t.Errorf("clone HEAD = %s, want refs/heads/main", got)
+	}
+	gittest.Run(t, dest, "fsck", "--strict", "--no-dangling")
+
+	// An incremental fetch negotiates with the clone's commits as haves.
+	src.Write("docs/guide.md", "guide\nmore\n")
+	src.Commit("update docs")
+	src.Git("tag", "-a", "v2", "-m", "release v2")
+	reload()
+	gittest.Run(t, dest, "fetch", "-q", "--tags", "origin")
+	if got, want := gittest.Run(t, dest, "rev-parse", "origin/main"), src.Git("rev-parse", "main"); got != want {
+		t.Errorf("fetched origin/main = %s, want %s", got, want)
+	}
+	if got, want := gittest.Run(t, dest, "rev-parse", "v2"), src.Git("rev-parse", "v2"); got != want {
+		t.Errorf("fetched v2 = %s, want %s", got, want)
+	}
+	gittest.Run(t, dest, "fsck", "--strict", "--no-dangling")
+}
+
+func TestPushIsRefused(t *testing.T) {
+	_, url, _ := newCloneServer(t)
+	resp, err := http.Get(url + "/info/refs?service=git-receive-pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("receive-pack advertisement status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestInfoRefsRequiresProtocolV2(t *testing.T) {
+	h := NewHandler(func() *gitcore.Repository { return nil }, "test", nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/git/info/refs?service=git-upload-pack", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if body := w.Body.String(); !strings.Contains(body, "ERR GitVista only serves Git protocol version 2") {
+		t.Fatalf("v0 advertisement = %q, want protocol error", body)
+	}
+
+	r.Header.Set("Git-Protocol", "version=2")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	want := "000eversion 2\n0018agent=gitvista/test\n000cls-refs\n000afetch\n0017object-format=sha1\n0000"
+	if got := w.Body.String(); got != want {
+		t.Fatalf("v2 advertisement = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/x-git-upload-pack-advertisement" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+}
+
+func TestPktReaderAndSideband(t *testing.T) {
+	var buf bytes.Buffer
+	_ = writeLine(&buf, "command=fetch")
+	_ = writeDelim(&buf)
+	_ = writeLine(&buf, "done")
+	_ = writeFlush(&buf)
+	req, err := readRequest(newPktReader(&buf))
+	if err != nil {
+		t.Fatalf("readRequest() error = %v", err)
+	}
+	if req.command != "fetch" || len(req.args) != 1 || req.args[0] != "done" {
+		t.Fatalf("readRequest() = %+v", req)
+	}
+
+	if _, err := readRequest(newPktReader(strings.NewReader("0003"))); err == nil {
+		t.Fatal("readRequest() accepted a 3-byte packet")
+	}
+
+	buf.Reset()
+	payload := bytes.Repeat([]byte("x"), maxSidebandData+10)
+	if _, err := newSidebandWriter(&buf, bandData).Write(payload); err != nil {
+		t.Fatal(err)
+	}
+	p := newPktReader(&buf)
+	var got []byte
+	for {
+		kind, data, err := p.read()
+		if err != nil {
+			break
+		}
+		if kind != packetData || data[0] != bandData || len(data) > maxPacketData {
+			t.Fatalf("sideband packet kind=%d band=%d len=%d", kind, data[0], len(data))
+		}
+		got = append(got, data[1:]...)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("sideband round trip returned %d bytes, want %d", len(got), len(payload))
+	}
+}
@@ -0,0 +1,137 @@
+package githttp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// maxPacketData is the largest payload of one pkt-line.
+	maxPacketData = 65516
+	// maxSidebandData leaves room in a pkt-line for the band byte.
+	maxSidebandData = maxPacketData - 1
+
+	bandData  = 1
+	bandError = 3
+)
+
+// packetKind tells payload lines apart from the special zero-length packets.
+// See: https://git-scm.com/docs/gitprotocol-common#_pkt_line_format
+type packetKind int
+
+const (
+	packetData packetKind = iota
+	packetFlush
+	packetDelim
+	packetResponseEnd
+)
+
+var errPacketFormat = errors.New("malformed pkt-line")
+
+type pktReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newPktReader(r io.Reader) *pktReader {
+	return &pktReader{r: bufio.NewReader(r)}
+}
+
+// read returns the next packet. The payload is only valid until the next call.
+func (p *pktReader) read() (packetKind, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(p.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return 0, nil, errPacketFormat
+	}
+	switch n {
+	case 0:
+		return packetFlush, nil, nil
+	case 1:
+		return packetDelim, nil, nil
+	case 2:
+		return packetResponseEnd, nil, nil
+	case 3:
+		return 0, nil, errPacketFormat
+	}
+	size := int(n) - 4
+	if cap(p.buf) < size {
+		p.buf = make([]byte, size)
+	}
+	p.buf = p.buf[:size]
+	if _, err := io.ReadFull(p.r, p.buf); err != nil {
+		return 0, nil, err
+	}
+	return packetData, p.buf, nil
+}
+
+// readLine returns the next data packet as text without its trailing newline.
+func (p *pktReader) readLine() (packetKind, string, error) {
+	kind, data, err := p.read()
+	if err != nil || kind != packetData {
+		return kind, "", err
+	}
+	if n := len(data); n > 0 && data[n-1] == '\n' {
+		data = data[:n-1]
+	}
+	return kind, string(data), nil
+}
+
+// writePacket writes data as one pkt-line.
+func writePacket(w io.Writer, data []byte) error {
+	if len(data) > maxPacketData {
+		return fmt.Errorf("pkt-line payload of %d bytes is too long", len(data))
+	}
+	if _, err := fmt.Fprintf(w, "%04x", len(data)+4); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// writeLine writes a text pkt-line terminated by a newline.
+func writeLine(w io.Writer, format string, args ...any) error {
+	return writePacket(w, []byte(fmt.Sprintf(format, args...)+"\n"))
+}
+
+func writeFlush(w io.Writer) error {
+	_, err := io.WriteString(w, "0000")
+	return err
+}
+
+func writeDelim(w io.Writer) error {
+	_, err := io.WriteString(w, "0001")
+	return err
+}
+
+// sidebandWriter frames everything written to it as pkt-lines on one band of
+// the side-band-64k multiplexing used by the packfile section.
+type sidebandWriter struct {
+	w    io.Writer
+	band byte
+	buf  []byte
+}
+
+func newSidebandWriter(w io.Writer, band byte) *sidebandWriter {
+	return &sidebandWriter{w: w, band: band, buf: make([]byte, 0, maxPacketData)}
+}
+
+func (s *sidebandWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), maxSidebandData)
+		s.buf = append(append(s.buf[:0], s.band), p[:n]...)
+		if err := writePacket(s.w, s.buf); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
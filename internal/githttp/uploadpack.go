@@ -0,0 +1,190 @@
+package githttp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// lsRefs answers the ls-refs command with HEAD followed by every ref matching
+// the requested prefixes.
+// See: https://git-scm.com/docs/gitprotocol-v2#_ls_refs
+func lsRefs(w io.Writer, repo *gitcore.Repository, args []string) error {
+	var peel, symrefs bool
+	var prefixes []string
+	for _, arg := range args {
+		switch {
+		case arg == "peel":
+			peel = true
+		case arg == "symrefs":
+			symrefs = true
+		case strings.HasPrefix(arg, "ref-prefix "):
+			prefixes = append(prefixes, strings.TrimPrefix(arg, "ref-prefix "))
+		}
+	}
+	matches := func(name string) bool {
+		if len(prefixes) == 0 {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if head := repo.Head(); head != "" && matches("HEAD") {
+		line := string(head) + " HEAD"
+		if ref := repo.HeadRef(); symrefs && ref != "" {
+			line += " symref-target:" + ref
+		}
+		if err := writeLine(w, "%s", line); err != nil {
+			return err
+		}
+	}
+
+	refs := repo.Refs()
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		if matches(name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		line := string(refs[name]) + " " + name
+		if peel {
+			if peeled, ok := repo.PeelTag(refs[name]); ok {
+				line += " peeled:" + string(peeled)
+			}
+		}
+		if err := writeLine(w, "%s", line); err != nil {
+			return err
+		}
+	}
+	return writeFlush(w)
+}
+
+type fetchArgs struct {
+	wants      []gitcore.Hash
+	haves      []gitcore.Hash
+	done       bool
+	includeTag bool
+	ofsDelta   bool
+}
+
+func parseFetchArgs(args []string) (*fetchArgs, error) {
+	fa := &fetchArgs{}
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, " ")
+		switch name {
+		case "want":
+			id, err := parseHash(name, value)
+			if err != nil {
+				return nil, err
+			}
+			fa.wants = append(fa.wants, id)
+		case "have":
+			id, err := parseHash(name, value)
+			if err != nil {
+				return nil, err
+			}
+			fa.haves = append(fa.haves, id)
+		case "done":
+			fa.done = true
+		case "include-tag":
+			fa.includeTag = true
+		case "ofs-delta":
+			fa.ofsDelta = true
+		case "thin-pack", "no-progress":
+			// Deltas never refer outside the pack and no progress is sent,
+			// so these need nothing from us.
+		default:
+			// Shallow, filter, and ref-in-want requests are not advertised.
+			return nil, fmt.Errorf("unsupported fetch argument %q", name)
+		}
+	}
+	if len(fa.wants) == 0 {
+		return nil, fmt.Errorf("fetch without any want")
+	}
+	return fa, nil
+}
+
+// fetch answers the fetch command. Until the client sends done, each request
+// is a negotiation round: the server acknowledges the haves it shares and
+// sends the pack once there is a common base.
+// See: https://git-scm.com/docs/gitprotocol-v2#_fetch
+func fetch(w io.Writer, repo *gitcore.Repository, args []string) error {
+	fa, err := parseFetchArgs(args)
+	if err != nil {
+		return errorLine(w, err)
+	}
+	for _, want := range fa.wants {
+		if !repo.HasObject(want) {
+			return errorLine(w, fmt.Errorf("upload-pack: not our ref %s", want))
+		}
+	}
+	var common []gitcore.Hash
+	for _, have := range fa.haves {
+		if repo.HasObject(have) {
+			common = append(common, have)
+		}
+	}
+
+	if !fa.done {
+		if err := writeLine(w, "acknowledgments"); err != nil {
+			return err
+		}
+		if len(common) == 0 {
+			if err := writeLine(w, "NAK"); err != nil {
+				return err
+			}
+		}
+		for _, have := range common {
+			if err := writeLine(w, "ACK %s", have); err != nil {
+				return err
+			}
+		}
+		if len(common) == 0 && len(fa.haves) > 0 {
+			// Let the client walk further back for a common commit.
+			return writeFlush(w)
+		}
+		if err := writeLine(w, "ready"); err != nil {
+			return err
+		}
+		if err := writeDelim(w); err != nil {
+			return err
+		}
+	}
+
+	objects, err := repo.ListObjects(gitcore.ListObjectsOptions{
+		Wants:       fa.wants,
+		Haves:       common,
+		IncludeTags: fa.includeTag,
+	})
+	if err != nil {
+		return errorLine(w, err)
+	}
+	if err := writeLine(w, "packfile"); err != nil {
+		return err
+	}
+	// Buffer so pack data goes out in full-size pkt-lines.
+	data := bufio.NewWriterSize(newSidebandWriter(w, bandData), maxSidebandData)
+	opts := gitcore.PackOptions{}
+	if !fa.ofsDelta {
+		opts.Window = -1
+	}
+	if _, err := repo.WritePack(data, objects, opts); err != nil {
+		_, _ = newSidebandWriter(w, bandError).Write([]byte("error: " + err.Error() + "\n"))
+		return err
+	}
+	if err := data.Flush(); err != nil {
+		return err
+	}
+	return writeFlush(w)
+}
@@ -0,0 +1,126 @@
+// Package gittest builds throwaway git repositories for tests by running the
+// git CLI with a fixed identity and no user or system configuration.
+//
+// Tests that use it are skipped when git is not installed.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+// DefaultAuthor is the identity commands run as unless Repo.Author is set.
+const DefaultAuthor = "Test <test@example.com>"
+
+// Repo is a work tree under construction.
+type Repo struct {
+	// Dir is the work tree.
+	Dir string
+	// Author is the "Name <email>" identity used for both author and
+	// committer.
+	Author string
+	// When, if set, is the author and committer date. Step advances it
+	// before each command.
+	When time.Time
+	Step time.Duration
+
+	t testing.TB
+}
+
+// New initializes an empty repository on branch main.
+func New(t testing.TB) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	r := &Repo{Dir: t.TempDir(), Author: DefaultAuthor, t: t}
+	r.Git("init", "-q", "-b", "main")
+	return r
+}
+
+// Git runs git in the work tree and returns its trimmed output.
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	if !r.When.IsZero() {
+		r.When = r.When.Add(r.Step)
+	}
+	env := identity(r.Author)
+	if !r.When.IsZero() {
+		stamp := r.When.Format(time.RFC3339)
+		env = append(env, "GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp)
+	}
+	return run(r.t, r.Dir, env, args)
+}
+
+// Write writes content to the slash-separated path name, or removes the file
+// when content is empty.
+func (r *Repo) Write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.Dir, filepath.FromSlash(name))
+	if content == "" {
+		if err := os.Remove(path); err != nil {
+			r.t.Fatal(err)
+		}
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// Commit stages every change in the work tree and commits it.
+func (r *Repo) Commit(message string) {
+	r.t.Helper()
+	r.Git("add", "-A")
+	r.Git("commit", "-q", "-m", message)
+}
+
+// Open loads the repository with gitcore and closes it when the test ends.
+func (r *Repo) Open() *gitcore.Repository {
+	r.t.Helper()
+	repo, err := gitcore.NewRepository(r.Dir)
+	if err != nil {
+		r.t.Fatalf("NewRepository() error = %v", err)
+	}
+	r.t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+// Run runs git in dir as DefaultAuthor and returns its trimmed output, for
+// commands outside a Repo such as clones.
+func Run(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	return run(t, dir, identity(DefaultAuthor), args)
+}
+
+func identity(author string) []string {
+	name, email, _ := strings.Cut(author, " <")
+	email = strings.TrimSuffix(email, ">")
+	return []string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email,
+	}
+}
+
+func run(t testing.TB, dir string, env, args []string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null", "GIT_TERMINAL_PROMPT=0",
+	), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
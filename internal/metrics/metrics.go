@@ -0,0 +1,346 @@
+// Package metrics implements the small subset of Prometheus instrumentation
+// GitVista needs: labelled counters, gauges, and histograms, collectors that
+// read a value at scrape time, and the text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are latency buckets in seconds suited to HTTP handlers.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// family is one named metric with its HELP and TYPE lines.
+type family struct {
+	name   string
+	help   string
+	kind   metricType
+	labels []string
+	write  func(w *bufio.Writer, f *family)
+}
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	names    map[string]struct{}
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+func (r *Registry) register(f *family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.names[f.name]; dup {
+		panic("metrics: duplicate metric " + f.name)
+	}
+	r.names[f.name] = struct{}{}
+	r.families = append(r.families, f)
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+// Inc adds one.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add adds v, which must not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	addFloat(&c.bits, v)
+}
+
+// Value returns the current count.
+func (c *Counter) Value() float64 { return math.Float64frombits(c.bits.Load()) }
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set replaces the value.
+func (g *Gauge) Set(v float64) { g.bits.Store(math.Float64bits(v)) }
+
+// Add adds v, which may be negative.
+func (g *Gauge) Add(v float64) { addFloat(&g.bits, v) }
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+func addFloat(bits *atomic.Uint64, v float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	upper   []float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(upper []float64) *Histogram {
+	return &Histogram{upper: upper, buckets: make([]uint64, len(upper))}
+}
+
+// Observe records v.
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.upper, v)
+	h.mu.Lock()
+	if i < len(h.buckets) {
+		h.buckets[i]++
+	}
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// vec maps label values to one child metric each.
+type vec[T any] struct {
+	labels   []string
+	newChild func() *T
+	mu       sync.Mutex
+	children map[string]*vecChild[T]
+}
+
+type vecChild[T any] struct {
+	values []string
+	metric *T
+}
+
+func newVec[T any](labels []string, newChild func() *T) *vec[T] {
+	return &vec[T]{labels: labels, newChild: newChild, children: make(map[string]*vecChild[T])}
+}
+
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values, want %d", len(values), len(v.labels)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	child, ok := v.children[key]
+	if !ok {
+		child = &vecChild[T]{values: slices.Clone(values), metric: v.newChild()}
+		v.children[key] = child
+	}
+	return child.metric
+}
+
+// snapshot returns the children sorted by label values for stable output.
+func (v *vec[T]) snapshot() []*vecChild[T] {
+	v.mu.Lock()
+	out := make([]*vecChild[T], 0, len(v.children))
+	for _, child := range v.children {
+		out = append(out, child)
+	}
+	v.mu.Unlock()
+	slices.SortFunc(out, func(a, b *vecChild[T]) int { return slices.Compare(a.values, b.values) })
+	return out
+}
+
+// CounterVec is a family of counters partitioned by labels.
+type CounterVec struct{ v *vec[Counter] }
+
+// With returns the counter for the given label values, creating it on first use.
+func (c *CounterVec) With(values ...string) *Counter { return c.v.with(values) }
+
+// GaugeVec is a family of gauges partitioned by labels.
+type GaugeVec struct{ v *vec[Gauge] }
+
+// With returns the gauge for the given label values, creating it on first use.
+func (g *GaugeVec) With(values ...string) *Gauge { return g.v.with(values) }
+
+// HistogramVec is a family of histograms partitioned by labels.
+type HistogramVec struct{ v *vec[Histogram] }
+
+// With returns the histogram for the given label values, creating it on first use.
+func (h *HistogramVec) With(values ...string) *Histogram { return h.v.with(values) }
+
+// NewCounterVec registers a counter family.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{v: newVec(labels, func() *Counter { return new(Counter) })}
+	r.register(&family{name: name, help: help, kind: typeCounter, labels: labels, write: func(w *bufio.Writer, f *family) {
+		for _, child := range c.v.snapshot() {
+			writeSample(w, f.name, f.labels, child.values, child.metric.Value())
+		}
+	}})
+	return c
+}
+
+// NewGaugeVec registers a gauge family.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{v: newVec(labels, func() *Gauge { return new(Gauge) })}
+	r.register(&family{name: name, help: help, kind: typeGauge, labels: labels, write: func(w *bufio.Writer, f *family) {
+		for _, child := range g.v.snapshot() {
+			writeSample(w, f.name, f.labels, child.values, child.metric.Value())
+		}
+	}})
+	return g
+}
+
+// NewHistogramVec registers a histogram family with the given bucket upper
+// bounds, which must be sorted ascending.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !slices.IsSorted(buckets) {
+		panic("metrics: histogram buckets must be sorted")
+	}
+	upper := slices.Clone(buckets)
+	h := &HistogramVec{v: newVec(labels, func() *Histogram { return newHistogram(upper) })}
+	r.register(&family{name: name, help: help, kind: typeHistogram, labels: labels, write: func(w *bufio.Writer, f *family) {
+		bucketLabels := append(slices.Clone(f.labels), "le")
+		for _, child := range h.v.snapshot() {
+			hist := child.metric
+			hist.mu.Lock()
+			counts := slices.Clone(hist.buckets)
+			count, sum := hist.count, hist.sum
+			hist.mu.Unlock()
+
+			var cumulative uint64
+			for i, bound := range hist.upper {
+				cumulative += counts[i]
+				writeSample(w, f.name+"_bucket", bucketLabels, append(slices.Clone(child.values), formatFloat(bound)), float64(cumulative))
+			}
+			writeSample(w, f.name+"_bucket", bucketLabels, append(slices.Clone(child.values), "+Inf"), float64(count))
+			writeSample(w, f.name+"_sum", f.labels, child.values, sum)
+			writeSample(w, f.name+"_count", f.labels, child.values, float64(count))
+		}
+	}})
+	return h
+}
+
+// CollectFunc reports samples at scrape time by calling emit once per label
+// combination.
+type CollectFunc func(emit func(value float64, labelValues ...string))
+
+// NewGaugeFunc registers a gauge family whose values are read by collect on
+// every scrape.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect CollectFunc) {
+	r.registerFunc(name, help, typeGauge, labels, collect)
+}
+
+// NewCounterFunc registers a counter family whose values are read by collect
+// on every scrape. The values must never decrease.
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect CollectFunc) {
+	r.registerFunc(name, help, typeCounter, labels, collect)
+}
+
+func (r *Registry) registerFunc(name, help string, kind metricType, labels []string, collect CollectFunc) {
+	r.register(&family{name: name, help: help, kind: kind, labels: labels, write: func(w *bufio.Writer, f *family) {
+		collect(func(value float64, labelValues ...string) {
+			writeSample(w, f.name, f.labels, labelValues, value)
+		})
+	}})
+}
+
+// WriteText writes every family in the Prometheus text exposition format.
+func (r *Registry) WriteText(out io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	w := bufio.NewWriter(out)
+	for _, f := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+		f.write(w, f)
+	}
+	return w.Flush()
+}
+
+// Handler serves the registry for Prometheus to scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		_ = r.WriteText(w)
+	})
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(value))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("app_requests_total", "Requests served.", "route", "code")
+	requests.With("/api/x", "200").Inc()
+	requests.With("/api/x", "200").Add(2)
+	requests.With("/api/a", "500").Inc()
+
+	latency := r.NewHistogramVec("app_latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	latency.With("/api/x").Observe(0.05)
+	latency.With("/api/x").Observe(0.1)
+	latency.With("/api/x").Observe(3)
+
+	queue := r.NewGaugeVec("app_queue", "Queue \"depth\"\nin items.")
+	queue.With().Set(4)
+	queue.With().Add(-1)
+
+	r.NewGaugeFunc("app_clients", "Connected clients.", []string{"session"}, func(emit func(float64, ...string)) {
+		emit(2, `de"fault`)
+	})
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := `# HELP app_requests_total Requests served.
+# TYPE app_requests_total counter
+app_requests_total{route="/api/a",code="500"} 1
+app_requests_total{route="/api/x",code="200"} 3
+# HELP app_latency_seconds Latency.
+# TYPE app_latency_seconds histogram
+app_latency_seconds_bucket{route="/api/x",le="0.1"} 2
+app_latency_seconds_bucket{route="/api/x",le="1"} 2
+app_latency_seconds_bucket{route="/api/x",le="+Inf"} 3
+app_latency_seconds_sum{route="/api/x"} 3.15
+app_latency_seconds_count{route="/api/x"} 3
+# HELP app_queue Queue "depth"\nin items.
+# TYPE app_queue gauge
+app_queue 3
+# HELP app_clients Connected clients.
+# TYPE app_clients gauge
+app_clients{session="de\"fault"} 2
+`
+	if out.String() != want {
+		t.Fatalf("WriteText() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "x")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a duplicate did not panic")
+		}
+	}()
+	r.NewGaugeVec("dup_total", "x")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("up_total", "x").With().Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("status = %d, content type = %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "up_total 1\n") {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,272 @@
+// Package search evaluates GitVista's commit search language against a full
+// repository. It mirrors the qualifier grammar implemented by web/searchQuery.js
+// so a query typed into the graph search box means the same thing when it is
+// answered by the server.
+package search
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseError describes a malformed qualifier. Parsing never fails outright;
+// offending tokens are reported and otherwise ignored.
+type ParseError struct {
+	Token   string `json:"token"`
+	Message string `json:"message"`
+}
+
+// Query is a parsed search string. Multiple values for one qualifier are OR'd;
+// different qualifiers are AND'd. Text values are lowercased except pickaxe
+// strings, which are matched case-sensitively like git log -S.
+type Query struct {
+	Raw              string
+	TextTerms        []string
+	NegatedTextTerms []string
+	Authors          []string
+	NegatedAuthors   []string
+	Hashes           []string
+	NegatedHashes    []string
+	After            time.Time
+	Before           time.Time
+	// Merge is "only", "exclude", or empty.
+	Merge           string
+	NegateMerge     bool
+	Branch          string
+	NegateBranch    bool
+	Messages        []string
+	NegatedMessages []string
+	Tags            []string
+	NegatedTags     []string
+	Files           []string
+	NegatedFiles    []string
+	Paths           []string
+	NegatedPaths    []string
+	Pickaxes        []string
+	NegatedPickaxes []string
+	Trailers        []TrailerFilter
+	NegatedTrailers []TrailerFilter
+	Errors          []ParseError
+}
+
+// TrailerFilter matches commits with a trailer whose key equals Key and, when
+// Value is set, whose value contains Value. Both are lowercased.
+type TrailerFilter struct {
+	Key   string
+	Value string
+}
+
+var knownQualifiers = map[string]struct{}{
+	"author": {}, "hash": {}, "after": {}, "before": {}, "merge": {}, "branch": {},
+	"message": {}, "tag": {}, "file": {}, "path": {}, "pickaxe": {}, "trailer": {},
+}
+
+var (
+	relativeDatePattern = regexp.MustCompile(`^(?i)(\d+)([dwmy])$`)
+	isoDatePattern      = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2}(T[\d:]+Z?)?)?)?$`)
+)
+
+// Parse converts a raw search string into a Query. Relative dates such as
+// "7d" are resolved against now.
+func Parse(raw string, now time.Time) Query {
+	q := Query{Raw: strings.TrimSpace(raw)}
+
+	for _, token := range tokenize(q.Raw) {
+		negated := false
+		working := token
+		if rest, ok := strings.CutPrefix(working, "-"); ok && rest != "" {
+			if qualifier, _, hasColon := strings.Cut(rest, ":"); hasColon && qualifier != "" {
+				if _, known := knownQualifiers[strings.ToLower(qualifier)]; known {
+					negated = true
+					working = rest
+				}
+			} else if !hasColon {
+				negated = true
+				working = rest
+			}
+		}
+
+		if qualifier, value, ok := strings.Cut(working, ":"); ok && qualifier != "" {
+			qualifier = strings.ToLower(qualifier)
+			if _, known := knownQualifiers[qualifier]; known {
+				q.applyQualifier(token, qualifier, value, negated, now)
+				continue
+			}
+			// Unrecognized qualifiers are treated as bare text.
+		}
+
+		if lower := strings.ToLower(working); lower != "" {
+			if negated {
+				q.NegatedTextTerms = append(q.NegatedTextTerms, lower)
+			} else {
+				q.TextTerms = append(q.TextTerms, lower)
+			}
+		}
+	}
+	return q
+}
+
+func (q *Query) applyQualifier(token, qualifier, value string, negated bool, now time.Time) {
+	appendValue := func(positive, negative *[]string, v string) {
+		if v == "" {
+			return
+		}
+		if negated {
+			*negative = append(*negative, v)
+		} else {
+			*positive = append(*positive, v)
+		}
+	}
+
+	switch qualifier {
+	case "author":
+		appendValue(&q.Authors, &q.NegatedAuthors, strings.ToLower(value))
+	case "hash":
+		appendValue(&q.Hashes, &q.NegatedHashes, strings.ToLower(value))
+	case "message":
+		appendValue(&q.Messages, &q.NegatedMessages, strings.ToLower(value))
+	case "tag":
+		appendValue(&q.Tags, &q.NegatedTags, strings.ToLower(value))
+	case "file":
+		appendValue(&q.Files, &q.NegatedFiles, strings.ToLower(value))
+	case "path":
+		appendValue(&q.Paths, &q.NegatedPaths, strings.ToLower(value))
+	case "pickaxe":
+		appendValue(&q.Pickaxes, &q.NegatedPickaxes, value)
+	case "trailer":
+		if value == "" {
+			return
+		}
+		key, want, _ := strings.Cut(strings.ToLower(value), "=")
+		if key = strings.TrimSpace(key); key == "" {
+			q.Errors = append(q.Errors, ParseError{
+				Token:   token,
+				Message: `Missing trailer key — use trailer:key or trailer:key=value`,
+			})
+			return
+		}
+		filter := TrailerFilter{Key: key, Value: strings.TrimSpace(want)}
+		if negated {
+			q.NegatedTrailers = append(q.NegatedTrailers, filter)
+		} else {
+			q.Trailers = append(q.Trailers, filter)
+		}
+	case "after", "before":
+		if negated {
+			other := "before:"
+			if qualifier == "before" {
+				other = "after:"
+			}
+			q.Errors = append(q.Errors, ParseError{
+				Token:   token,
+				Message: "Negating date qualifiers is not supported — use " + other + " instead",
+			})
+			return
+		}
+		if value == "" {
+			return
+		}
+		parsed, ok := parseDate(value, now)
+		if !ok {
+			q.Errors = append(q.Errors, ParseError{
+				Token:   token,
+				Message: `Invalid date "` + value + `" — use ISO (2024-01-15) or relative (7d, 2w, 3m, 1y)`,
+			})
+			return
+		}
+		if qualifier == "after" {
+			q.After = parsed
+		} else {
+			q.Before = parsed
+		}
+	case "merge":
+		if value != "only" && value != "exclude" {
+			q.Errors = append(q.Errors, ParseError{
+				Token:   token,
+				Message: `Unknown merge filter "` + value + `" — use merge:only or merge:exclude`,
+			})
+			return
+		}
+		q.Merge = value
+		q.NegateMerge = negated
+	case "branch":
+		if value != "" {
+			q.Branch = value
+			q.NegateBranch = negated
+		}
+	}
+}
+
+// IsEmpty reports whether the query has no criteria. Parse errors alone do
+// not make a query non-empty.
+func (q Query) IsEmpty() bool {
+	return len(q.TextTerms) == 0 && len(q.NegatedTextTerms) == 0 &&
+		len(q.Authors) == 0 && len(q.NegatedAuthors) == 0 &&
+		len(q.Hashes) == 0 && len(q.NegatedHashes) == 0 &&
+		q.After.IsZero() && q.Before.IsZero() &&
+		q.Merge == "" && q.Branch == "" &&
+		len(q.Messages) == 0 && len(q.NegatedMessages) == 0 &&
+		len(q.Tags) == 0 && len(q.NegatedTags) == 0 &&
+		len(q.Trailers) == 0 && len(q.NegatedTrailers) == 0 &&
+		!q.needsDiff() && !q.needsPickaxe()
+}
+
+func (q Query) needsDiff() bool {
+	return len(q.Files) > 0 || len(q.NegatedFiles) > 0 || len(q.Paths) > 0 || len(q.NegatedPaths) > 0
+}
+
+func (q Query) needsPickaxe() bool {
+	return len(q.Pickaxes) > 0 || len(q.NegatedPickaxes) > 0
+}
+
+// tokenize splits on spaces while keeping double-quoted runs together, so
+// `author:"Jane Doe"` yields the single token `author:Jane Doe`.
+func tokenize(raw string) []string {
+	var tokens []string
+	var b strings.Builder
+	inQuote := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ' ' && !inQuote:
+			if b.Len() > 0 {
+				tokens = append(tokens, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		tokens = append(tokens, b.String())
+	}
+	return tokens
+}
+
+// parseDate accepts ISO dates ("2024-01-15", optionally with a time) and
+// relative shorthand ("7d", "2w", "3m", "1y", with months as 30 days and
+// years as 365 days).
+func parseDate(raw string, now time.Time) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if m := relativeDatePattern.FindStringSubmatch(raw); m != nil {
+		amount, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		days := map[string]int{"d": 1, "w": 7, "m": 30, "y": 365}[strings.ToLower(m[2])]
+		return now.Add(-time.Duration(amount*days) * 24 * time.Hour), true
+	}
+
+	if !isoDatePattern.MatchString(raw) {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006", "2006-01", "2006-01-02", "2006-01-02T15:04:05Z", "2006-01-02T15:04:05", "2006-01-02T15:04Z", "2006-01-02T15:04"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	q := Parse(`fix author:"Jane Doe" -author:bot hash:ABC -message:wip tag:v1 file:Main.go -path:vendor pickaxe:loadConfig -pickaxe:"TODO later" -draft foo:bar`, now)
+	checks := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"TextTerms", q.TextTerms, []string{"fix", "foo:bar"}},
+		{"NegatedTextTerms", q.NegatedTextTerms, []string{"draft"}},
+		{"Authors", q.Authors, []string{"jane doe"}},
+		{"NegatedAuthors", q.NegatedAuthors, []string{"bot"}},
+		{"Hashes", q.Hashes, []string{"abc"}},
+		{"NegatedMessages", q.NegatedMessages, []string{"wip"}},
+		{"Tags", q.Tags, []string{"v1"}},
+		{"Files", q.Files, []string{"main.go"}},
+		{"NegatedPaths", q.NegatedPaths, []string{"vendor"}},
+		{"Pickaxes", q.Pickaxes, []string{"loadConfig"}},
+		{"NegatedPickaxes", q.NegatedPickaxes, []string{"TODO later"}},
+	}
+	for _, c := range checks {
+		if !slices.Equal(c.got, c.want) {
+			t.Errorf("%s = %q, want %q", c.name, c.got, c.want)
+		}
+	}
+	if q.IsEmpty() || len(q.Errors) != 0 {
+		t.Fatalf("IsEmpty() = %v, Errors = %v", q.IsEmpty(), q.Errors)
+	}
+}
+
+func TestParseDatesMergeAndBranch(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	q := Parse("after:2w before:2025-06-01 -merge:only -branch:main", now)
+	if want := now.Add(-14 * 24 * time.Hour); !q.After.Equal(want) {
+		t.Fatalf("After = %v, want %v", q.After, want)
+	}
+	if want := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC); !q.Before.Equal(want) {
+		t.Fatalf("Before = %v, want %v", q.Before, want)
+	}
+	if q.Merge != "only" || !q.NegateMerge || q.Branch != "main" || !q.NegateBranch {
+		t.Fatalf("merge/branch = %q %v %q %v", q.Merge, q.NegateMerge, q.Branch, q.NegateBranch)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{"after:yesterday", "-before:7d", "merge:sometimes"}
+	for _, raw := range tests {
+		q := Parse(raw, time.Now())
+		if len(q.Errors) != 1 || q.Errors[0].Token != raw {
+			t.Errorf("Parse(%q).Errors = %v, want one error", raw, q.Errors)
+		}
+		if !q.IsEmpty() {
+			t.Errorf("Parse(%q).IsEmpty() = false, want true", raw)
+		}
+	}
+
+	if q := Parse("   ", time.Now()); !q.IsEmpty() {
+		t.Fatal("Parse(blank).IsEmpty() = false")
+	}
+}
+
+func TestParseTrailer(t *testing.T) {
+	q := Parse(`trailer:Co-authored-by=Bob -trailer:"Reviewed-by = Jane Doe" trailer:signed-off-by trailer:=x`, time.Now())
+	if want := []TrailerFilter{{Key: "co-authored-by", Value: "bob"}, {Key: "signed-off-by"}}; !slices.Equal(q.Trailers, want) {
+		t.Fatalf("Trailers = %+v, want %+v", q.Trailers, want)
+	}
+	if want := []TrailerFilter{{Key: "reviewed-by", Value: "jane doe"}}; !slices.Equal(q.NegatedTrailers, want) {
+		t.Fatalf("NegatedTrailers = %+v, want %+v", q.NegatedTrailers, want)
+	}
+	if len(q.Errors) != 1 || q.Errors[0].Token != "trailer:=x" || q.IsEmpty() {
+		t.Fatalf("Errors = %v, IsEmpty() = %v", q.Errors, q.IsEmpty())
+	}
+}
@@ -0,0 +1,532 @@
+package search
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	// DefaultLimit is the page size used when Options.Limit is not positive.
+	DefaultLimit     = 50
+	defaultWorkers   = 8
+	maxHighlightTerm = 256
+)
+
+// Options controls paging through search results.
+type Options struct {
+	// Cursor is the number of commits, in search order, already examined by
+	// earlier pages. Pass the previous Result.NextCursor to continue.
+	Cursor int
+	// Limit bounds the number of hits returned.
+	Limit int
+	// Workers bounds the number of commits whose diffs are examined
+	// concurrently for file:, path:, and pickaxe: qualifiers.
+	Workers int
+	// Deadline, when set, stops a search that needs diffs at the first batch
+	// boundary after it passes; at least one batch is always examined. The
+	// page then holds the hits found so far and NextCursor resumes the scan.
+	Deadline time.Time
+}
+
+// Highlight marks a matched span within one field of a hit. Offsets are in
+// UTF-16 code units so browsers can slice the field directly.
+type Highlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Hit is a matching commit with the spans that caused it to match.
+type Hit struct {
+	Commit     *gitcore.Commit `json:"commit"`
+	Highlights []Highlight     `json:"highlights,omitempty"`
+}
+
+// Result is one page of search results.
+type Result struct {
+	Hits []Hit `json:"hits"`
+	// Total is the number of commits in the searched history.
+	Total int `json:"total"`
+	// Matches counts every matching commit when the query needs no diffs, or
+	// is -1 when counting would mean diffing the whole history.
+	Matches int `json:"matches"`
+	// NextCursor resumes the search after this page, or is 0 when the
+	// history has been exhausted.
+	NextCursor int `json:"nextCursor,omitempty"`
+	// Partial reports that Options.Deadline cut the page short, so it may
+	// hold fewer than Limit hits even though NextCursor is set.
+	Partial bool         `json:"partial,omitempty"`
+	Errors  []ParseError `json:"errors,omitempty"`
+}
+
+// Index is a repository's history in search order. Building it sorts every
+// commit, so callers that page through results should keep one Index per
+// loaded repository rather than calling Run for each page.
+type Index struct {
+	repo    *gitcore.Repository
+	commits []*gitcore.Commit
+	trees   map[gitcore.Hash]gitcore.Hash
+}
+
+// NewIndex orders repo's commits by committer date, newest first, matching
+// the order the web client requests bulk diff stats in.
+func NewIndex(repo *gitcore.Repository) *Index {
+	byHash := repo.Commits()
+	ix := &Index{
+		repo:    repo,
+		commits: make([]*gitcore.Commit, 0, len(byHash)),
+		trees:   make(map[gitcore.Hash]gitcore.Hash, len(byHash)),
+	}
+	for hash, commit := range byHash {
+		if commit != nil {
+			ix.commits = append(ix.commits, commit)
+			ix.trees[hash] = commit.Tree
+		}
+	}
+	slices.SortFunc(ix.commits, func(a, b *gitcore.Commit) int {
+		if a.Committer.When.Equal(b.Committer.When) {
+			return strings.Compare(string(a.ID), string(b.ID))
+		}
+		if a.Committer.When.After(b.Committer.When) {
+			return -1
+		}
+		return 1
+	})
+	return ix
+}
+
+// Repo returns the repository the index was built from.
+func (ix *Index) Repo() *gitcore.Repository {
+	return ix.repo
+}
+
+// Run evaluates q against every commit in repo. It builds a fresh Index, so
+// it suits one-off searches.
+func Run(repo *gitcore.Repository, q Query, opts Options) (*Result, error) {
+	return NewIndex(repo).Run(q, opts)
+}
+
+// Run evaluates q against every indexed commit, newest committer date first,
+// and returns one page of hits. Metadata predicates are checked first so
+// diffs are only computed for commits that could still match.
+func (ix *Index) Run(q Query, opts Options) (*Result, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	commits := ix.commits
+	result := &Result{Hits: []Hit{}, Total: len(commits), Matches: -1, Errors: q.Errors}
+	if q.IsEmpty() {
+		result.Matches = 0
+		return result, nil
+	}
+
+	m, err := newMatcher(ix, q)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := min(max(opts.Cursor, 0), len(commits))
+	if !m.expensive() {
+		matches := 0
+		for i, commit := range commits {
+			if !m.matchMetadata(commit) {
+				continue
+			}
+			matches++
+			if i >= cursor && len(result.Hits) < limit {
+				result.Hits = append(result.Hits, m.hit(commit))
+				if len(result.Hits) == limit {
+					result.NextCursor = i + 1
+				}
+			}
+		}
+		result.Matches = matches
+		if result.NextCursor >= len(commits) {
+			result.NextCursor = 0
+		}
+		return result, nil
+	}
+
+	// Examine candidates in batches so diffs run concurrently while results
+	// stay in history order and work stops soon after the page fills.
+	batchSize := workers * 4
+	for start := cursor; start < len(commits); {
+		if start > cursor && !opts.Deadline.IsZero() && time.Now().After(opts.Deadline) {
+			result.NextCursor = start
+			result.Partial = true
+			return result, nil
+		}
+		batch := make([]int, 0, batchSize)
+		next := start
+		for ; next < len(commits) && len(batch) < batchSize; next++ {
+			if m.matchMetadata(commits[next]) {
+				batch = append(batch, next)
+			}
+		}
+
+		matched, err := m.matchContentBatch(commits, batch, workers)
+		if err != nil {
+			return nil, err
+		}
+		for j, idx := range batch {
+			if !matched[j] {
+				continue
+			}
+			result.Hits = append(result.Hits, m.hit(commits[idx]))
+			if len(result.Hits) == limit {
+				if idx+1 < len(commits) {
+					result.NextCursor = idx + 1
+				}
+				return result, nil
+			}
+		}
+		start = next
+	}
+	return result, nil
+}
+
+type matcher struct {
+	repo  *gitcore.Repository
+	q     Query
+	trees map[gitcore.Hash]gitcore.Hash
+
+	reachable       map[gitcore.Hash]struct{}
+	tagged          map[gitcore.Hash]struct{}
+	negatedTagged   map[gitcore.Hash]struct{}
+	pickaxes        []*gitcore.Pickaxe
+	negatedPickaxes []*gitcore.Pickaxe
+}
+
+func newMatcher(ix *Index, q Query) (*matcher, error) {
+	repo := ix.repo
+	m := &matcher{repo: repo, q: q, trees: ix.trees}
+
+	if q.Branch != "" {
+		m.reachable = make(map[gitcore.Hash]struct{})
+		if tip, ok := lookupBranch(repo, q.Branch); ok {
+			reachable, err := repo.RevList(gitcore.RevListOptions{Revision: string(tip)})
+			if err != nil {
+				return nil, fmt.Errorf("search: branch %s: %w", q.Branch, err)
+			}
+			for _, commit := range reachable {
+				m.reachable[commit.ID] = struct{}{}
+			}
+		}
+	}
+
+	if len(q.Tags) > 0 || len(q.NegatedTags) > 0 {
+		tags := repo.Tags()
+		m.tagged = taggedCommits(tags, q.Tags)
+		m.negatedTagged = taggedCommits(tags, q.NegatedTags)
+	}
+
+	var err error
+	if m.pickaxes, err = compilePickaxes(repo, q.Pickaxes); err != nil {
+		return nil, err
+	}
+	if m.negatedPickaxes, err = compilePickaxes(repo, q.NegatedPickaxes); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// lookupBranch resolves a branch name the way the web matcher does: as a full
+// ref, then as a local branch, then as a remote-tracking branch.
+func lookupBranch(repo *gitcore.Repository, name string) (gitcore.Hash, bool) {
+	branches := repo.GraphBranches()
+	for _, ref := range []string{name, "refs/heads/" + name, "refs/remotes/" + name} {
+		if hash, ok := branches[ref]; ok {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// taggedCommits returns nil when patterns is empty so callers can tell an
+// inactive filter from one that matches nothing.
+func taggedCommits(tags map[string]string, patterns []string) map[gitcore.Hash]struct{} {
+	if len(patterns) == 0 {
+		return nil
+	}
+	set := make(map[gitcore.Hash]struct{})
+	for name, hash := range tags {
+		lower := strings.ToLower(name)
+		for _, pattern := range patterns {
+			if strings.Contains(lower, pattern) {
+				set[gitcore.Hash(hash)] = struct{}{}
+				break
+			}
+		}
+	}
+	return set
+}
+
+func compilePickaxes(repo *gitcore.Repository, terms []string) ([]*gitcore.Pickaxe, error) {
+	pickaxes := make([]*gitcore.Pickaxe, 0, len(terms))
+	for _, term := range terms {
+		p, err := gitcore.NewPickaxe(repo, gitcore.PickaxeOptions{String: term})
+		if err != nil {
+			return nil, fmt.Errorf("search: %w", err)
+		}
+		pickaxes = append(pickaxes, p)
+	}
+	return pickaxes, nil
+}
+
+func (m *matcher) expensive() bool {
+	return m.q.needsDiff() || m.q.needsPickaxe()
+}
+
+// matchMetadata applies every predicate that needs only the commit object.
+func (m *matcher) matchMetadata(commit *gitcore.Commit) bool {
+	q := m.q
+	message := strings.ToLower(commit.Message)
+	name := strings.ToLower(commit.Author.Name)
+	email := strings.ToLower(commit.Author.Email)
+	hash := strings.ToLower(string(commit.ID))
+
+	matchesText := func(term string) bool {
+		return strings.Contains(message, term) || strings.Contains(name, term) ||
+			strings.Contains(email, term) || strings.HasPrefix(hash, term)
+	}
+	for _, term := range q.TextTerms {
+		if !matchesText(term) {
+			return false
+		}
+	}
+	if slices.ContainsFunc(q.NegatedTextTerms, matchesText) {
+		return false
+	}
+
+	matchesAuthor := func(a string) bool { return strings.Contains(name, a) || strings.Contains(email, a) }
+	if len(q.Authors) > 0 && !slices.ContainsFunc(q.Authors, matchesAuthor) {
+		return false
+	}
+	if slices.ContainsFunc(q.NegatedAuthors, matchesAuthor) {
+		return false
+	}
+
+	matchesHash := func(h string) bool { return strings.HasPrefix(hash, h) }
+	if len(q.Hashes) > 0 && !slices.ContainsFunc(q.Hashes, matchesHash) {
+		return false
+	}
+	if slices.ContainsFunc(q.NegatedHashes, matchesHash) {
+		return false
+	}
+
+	when := commit.Author.When
+	if !q.After.IsZero() && (when.IsZero() || when.Before(q.After)) {
+		return false
+	}
+	if !q.Before.IsZero() && (when.IsZero() || when.After(q.Before)) {
+		return false
+	}
+
+	matchesMessage := func(s string) bool { return strings.Contains(message, s) }
+	if len(q.Messages) > 0 && !slices.ContainsFunc(q.Messages, matchesMessage) {
+		return false
+	}
+	if slices.ContainsFunc(q.NegatedMessages, matchesMessage) {
+		return false
+	}
+
+	matchesTrailer := func(f TrailerFilter) bool {
+		return slices.ContainsFunc(commit.Trailers, func(t gitcore.Trailer) bool {
+			return strings.EqualFold(t.Key, f.Key) && strings.Contains(strings.ToLower(t.Value), f.Value)
+		})
+	}
+	if len(q.Trailers) > 0 && !slices.ContainsFunc(q.Trailers, matchesTrailer) {
+		return false
+	}
+	if slices.ContainsFunc(q.NegatedTrailers, matchesTrailer) {
+		return false
+	}
+
+	if q.Merge != "" {
+		mode := q.Merge
+		if q.NegateMerge {
+			mode = map[string]string{"only": "exclude", "exclude": "only"}[mode]
+		}
+		isMerge := len(commit.Parents) > 1
+		if (mode == "only" && !isMerge) || (mode == "exclude" && isMerge) {
+			return false
+		}
+	}
+
+	if m.tagged != nil {
+		if _, ok := m.tagged[commit.ID]; !ok {
+			return false
+		}
+	}
+	if _, ok := m.negatedTagged[commit.ID]; ok {
+		return false
+	}
+
+	if m.reachable != nil {
+		_, ok := m.reachable[commit.ID]
+		if ok == q.NegateBranch {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *matcher) matchContentBatch(commits []*gitcore.Commit, batch []int, workers int) ([]bool, error) {
+	matched := make([]bool, len(batch))
+	errs := make([]error, len(batch))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for j, idx := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			matched[j], errs[j] = m.matchContent(commits[idx])
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matched, nil
+}
+
+// matchContent applies file:, path:, and pickaxe: predicates. Commits whose
+// diff is too large to compute are treated like commits without diff stats in
+// the browser: positive file filters reject them, negated ones keep them.
+func (m *matcher) matchContent(commit *gitcore.Commit) (bool, error) {
+	if m.q.needsDiff() {
+		var parentTree gitcore.Hash
+		if len(commit.Parents) > 0 {
+			parentTree = m.trees[commit.Parents[0]]
+		}
+		entries, err := gitcore.TreeDiff(m.repo, parentTree, commit.Tree, "")
+		var files []string
+		switch {
+		case err == nil:
+			files = make([]string, len(entries))
+			for i, entry := range entries {
+				files[i] = strings.ToLower(entry.Path)
+			}
+		case strings.Contains(err.Error(), "diff too large"):
+			if len(m.q.Files) > 0 || len(m.q.Paths) > 0 {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("search: diffing %s: %w", commit.ID, err)
+		}
+		if files != nil && !m.matchFiles(files) {
+			return false, nil
+		}
+	}
+
+	if len(m.pickaxes) > 0 {
+		ok, err := anyPickaxe(m.pickaxes, commit)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if len(m.negatedPickaxes) > 0 {
+		ok, err := anyPickaxe(m.negatedPickaxes, commit)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (m *matcher) matchFiles(files []string) bool {
+	matchesFile := func(f string) bool {
+		return slices.ContainsFunc(files, func(p string) bool { return path.Base(p) == f })
+	}
+	matchesPath := func(prefix string) bool {
+		return slices.ContainsFunc(files, func(p string) bool { return p == prefix || strings.HasPrefix(p, prefix+"/") })
+	}
+
+	if len(m.q.Files) > 0 && !slices.ContainsFunc(m.q.Files, matchesFile) {
+		return false
+	}
+	if slices.ContainsFunc(m.q.NegatedFiles, matchesFile) {
+		return false
+	}
+	if len(m.q.Paths) > 0 && !slices.ContainsFunc(m.q.Paths, matchesPath) {
+		return false
+	}
+	return !slices.ContainsFunc(m.q.NegatedPaths, matchesPath)
+}
+
+func anyPickaxe(pickaxes []*gitcore.Pickaxe, commit *gitcore.Commit) (bool, error) {
+	for _, p := range pickaxes {
+		ok, err := p.Match(commit)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// hit collects highlight spans for the positive text-like terms that
+// selected commit.
+func (m *matcher) hit(commit *gitcore.Commit) Hit {
+	h := Hit{Commit: commit}
+	add := func(field, value string, terms []string, prefixOnly bool) {
+		for _, term := range terms {
+			h.Highlights = append(h.Highlights, highlightTerm(field, value, term, prefixOnly)...)
+		}
+	}
+
+	authorTerms := slices.Concat(m.q.TextTerms, m.q.Authors)
+	add("message", commit.Message, slices.Concat(m.q.TextTerms, m.q.Messages), false)
+	add("author", commit.Author.Name, authorTerms, false)
+	add("email", commit.Author.Email, authorTerms, false)
+	add("hash", string(commit.ID), slices.Concat(m.q.TextTerms, m.q.Hashes), true)
+	return h
+}
+
+func highlightTerm(field, value, term string, prefixOnly bool) []Highlight {
+	if term == "" || len(term) > maxHighlightTerm {
+		return nil
+	}
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+	var spans []Highlight
+	for _, loc := range re.FindAllStringIndex(value, -1) {
+		if prefixOnly && loc[0] != 0 {
+			break
+		}
+		spans = append(spans, Highlight{
+			Field: field,
+			Start: utf16Len(value[:loc[0]]),
+			End:   utf16Len(value[:loc[1]]),
+		})
+		if prefixOnly {
+			break
+		}
+	}
+	return spans
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
@@ -0,0 +1,203 @@
+package search
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+type fixtureCommit struct {
+	message string
+	author  string
+	files   map[string]string
+}
+
+// newSearchFixture commits each entry in order, one hour apart, and returns
+// the loaded repository along with the commit hashes keyed by message.
+func newSearchFixture(t *testing.T, commits []fixtureCommit) (*gitcore.Repository, map[string]gitcore.Hash) {
+	t.Helper()
+	fixture := gittest.New(t)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, c := range commits {
+		for name, content := range c.files {
+			fixture.Write(name, content)
+		}
+		fixture.Author = c.author + " <" + c.author + "@example.com>"
+		fixture.When = base.Add(time.Duration(i) * time.Hour)
+		fixture.Commit(c.message)
+	}
+	fixture.Git("tag", "v1.0.0", "HEAD~1")
+	repo := fixture.Open()
+
+	hashes := make(map[string]gitcore.Hash)
+	for _, commit := range repo.Commits() {
+		hashes[commit.Message] = commit.ID
+	}
+	return repo, hashes
+}
+
+func searchFixture(t *testing.T) (*gitcore.Repository, map[string]gitcore.Hash) {
+	return newSearchFixture(t, []fixtureCommit{
+		{message: "initial import", author: "alice", files: map[string]string{"README.md": "hello", "cmd/main.go": "package main"}},
+		{message: "add loadConfig", author: "bob", files: map[string]string{"internal/config/config.go": "func loadConfig() {}"}},
+		{message: "fix typo in docs", author: "alice", files: map[string]string{"README.md": "hello world"}},
+		{message: "call loadConfig from main", author: "carol", files: map[string]string{"cmd/main.go": "package main\n// loadConfig()"}},
+	})
+}
+
+func hitMessages(result *Result) []string {
+	messages := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		messages[i] = hit.Commit.Message
+	}
+	return messages
+}
+
+func TestRun(t *testing.T) {
+	repo, _ := searchFixture(t)
+	now := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query       string
+		want        []string
+		wantMatches int
+	}{
+		{query: "loadconfig", want: []string{"call loadConfig from main", "add loadConfig"}, wantMatches: 2},
+		{query: "author:alice -typo", want: []string{"initial import"}, wantMatches: 1},
+		{query: "tag:v1", want: []string{"fix typo in docs"}, wantMatches: 1},
+		{query: "after:2025-01-01T01:30:00Z", want: []string{"call loadConfig from main", "fix typo in docs"}, wantMatches: 2},
+		{query: "branch:main merge:exclude author:carol", want: []string{"call loadConfig from main"}, wantMatches: 1},
+		{query: "file:readme.md", want: []string{"fix typo in docs", "initial import"}, wantMatches: -1},
+		{query: "path:internal", want: []string{"add loadConfig"}, wantMatches: -1},
+		{query: "-path:cmd author:alice", want: []string{"fix typo in docs"}, wantMatches: -1},
+		{query: "pickaxe:loadConfig()", want: []string{"call loadConfig from main", "add loadConfig"}, wantMatches: -1},
+		{query: "pickaxe:loadConfig -path:internal", want: []string{"call loadConfig from main"}, wantMatches: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := Run(repo, Parse(tt.query, now), Options{})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := hitMessages(result); !slices.Equal(got, tt.want) {
+				t.Fatalf("hits = %q, want %q", got, tt.want)
+			}
+			if result.Matches != tt.wantMatches || result.Total != 4 {
+				t.Fatalf("Matches = %d, Total = %d", result.Matches, result.Total)
+			}
+		})
+	}
+}
+
+func TestRunPaging(t *testing.T) {
+	repo, _ := searchFixture(t)
+
+	// One query is answered from commit metadata alone, the other needs diffs.
+	for _, raw := range []string{"-hash:zz", "-path:nothing"} {
+		q := Parse(raw, time.Now())
+		var all []string
+		cursor := 0
+		for page := 0; page < 10; page++ {
+			result, err := Run(repo, q, Options{Cursor: cursor, Limit: 1, Workers: 1})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			all = append(all, hitMessages(result)...)
+			if result.NextCursor == 0 {
+				break
+			}
+			cursor = result.NextCursor
+		}
+		if len(all) != 4 {
+			t.Fatalf("%q: paged hits = %q, want all 4 commits", raw, all)
+		}
+	}
+}
+
+func TestIndexRunDeadline(t *testing.T) {
+	var commits []fixtureCommit
+	for i := range 6 {
+		name := string(rune('a' + i))
+		commits = append(commits, fixtureCommit{message: "edit " + name, author: "alice", files: map[string]string{"src/" + name + ".go": name}})
+	}
+	repo, _ := newSearchFixture(t, commits)
+	ix := NewIndex(repo)
+	q := Parse("path:src", time.Now())
+
+	// A passed deadline still examines one batch of four commits, then hands
+	// back a cursor for the rest.
+	past := time.Now().Add(-time.Second)
+	first, err := ix.Run(q, Options{Workers: 1, Deadline: past})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !first.Partial || first.NextCursor != 4 || len(first.Hits) != 4 {
+		t.Fatalf("first page = %+v", first)
+	}
+	rest, err := ix.Run(q, Options{Workers: 1, Cursor: first.NextCursor, Deadline: past})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if rest.Partial || rest.NextCursor != 0 || !slices.Equal(hitMessages(rest), []string{"edit b", "edit a"}) {
+		t.Fatalf("second page = %+v", rest)
+	}
+}
+
+func TestRunHighlights(t *testing.T) {
+	repo, hashes := searchFixture(t)
+	prefix := string(hashes["add loadConfig"])[:6]
+
+	result, err := Run(repo, Parse("LOADCONFIG author:bo hash:"+prefix, time.Now()), Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Hits) != 1 {
+		t.Fatalf("hits = %q", hitMessages(result))
+	}
+	want := []Highlight{
+		{Field: "message", Start: 4, End: 14},
+		{Field: "author", Start: 0, End: 2},
+		{Field: "email", Start: 0, End: 2},
+		{Field: "hash", Start: 0, End: 6},
+	}
+	if got := result.Hits[0].Highlights; !slices.Equal(got, want) {
+		t.Fatalf("Highlights = %+v, want %+v", got, want)
+	}
+
+	if got := highlightTerm("message", "héllo wörld", "wö", false); !slices.Equal(got, []Highlight{{Field: "message", Start: 6, End: 8}}) {
+		t.Fatalf("highlightTerm(non-ASCII) = %+v", got)
+	}
+}
+
+func TestRunTrailers(t *testing.T) {
+	repo, _ := newSearchFixture(t, []fixtureCommit{
+		{message: "solo work", author: "alice", files: map[string]string{"a.txt": "1"}},
+		{message: "pair work\n\nCo-authored-by: Bob <bob@example.com>\nReviewed-by: Carol <carol@example.com>", author: "alice", files: map[string]string{"a.txt": "2"}},
+		{message: "signed work\n\nSigned-off-by: Dave <dave@example.com>", author: "dave", files: map[string]string{"a.txt": "3"}},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "trailer:co-authored-by=bob", want: []string{"pair work\n\nCo-authored-by: Bob <bob@example.com>\nReviewed-by: Carol <carol@example.com>"}},
+		{query: "trailer:Signed-off-by", want: []string{"signed work\n\nSigned-off-by: Dave <dave@example.com>"}},
+		{query: "trailer:reviewed-by=dave", want: []string{}},
+		{query: "-trailer:reviewed-by -trailer:signed-off-by", want: []string{"solo work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := Run(repo, Parse(tt.query, time.Now()), Options{})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := hitMessages(result); !slices.Equal(got, tt.want) {
+				t.Fatalf("hits = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
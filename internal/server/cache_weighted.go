@@ -0,0 +1,392 @@
+package server
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache namespaces. Keys are assigned to a namespace by prefix, and each
+// namespace has its own share of the byte budget.
+const (
+	CacheNamespaceDiff      = "diff"
+	CacheNamespaceAnalytics = "analytics"
+	CacheNamespaceTree      = "tree"
+)
+
+const (
+	defaultCacheBytes = 256 << 20 // 256 MiB
+	// cacheEntryOverhead approximates the map, list, and header memory each
+	// entry costs on top of its value.
+	cacheEntryOverhead = 128
+)
+
+// defaultCacheQuotaShares are the fractions of the byte budget each namespace
+// may fill. They add up to more than one, so a busy namespace can use room
+// the others leave idle without being able to push them out entirely.
+var defaultCacheQuotaShares = map[string]float64{
+	CacheNamespaceDiff:      0.6,
+	CacheNamespaceAnalytics: 0.3,
+	CacheNamespaceTree:      0.2,
+}
+
+// WeightedCacheOptions configures a WeightedCache.
+type WeightedCacheOptions struct {
+	// MaxBytes is the total budget. Zero means 256 MiB.
+	MaxBytes int64
+	// MaxEntries additionally caps the number of entries. Zero means no cap.
+	MaxEntries int
+	// TTL expires entries this long after they are stored. Zero means
+	// entries only leave the cache by eviction or Clear.
+	TTL time.Duration
+	// Quotas caps the bytes of each namespace. Nil means DefaultCacheQuotas;
+	// a namespace missing from the map is bounded only by MaxBytes.
+	Quotas map[string]int64
+	// Cost estimates the bytes an entry holds. Nil means the length of its
+	// JSON encoding.
+	Cost func(key string, val any) int64
+	// Namespace assigns a key to a namespace. Nil means by key prefix:
+	// "analytics" and "tree:" keys have their own namespaces, everything
+	// else is a diff.
+	Namespace func(key string) string
+}
+
+// DefaultCacheQuotas splits maxBytes between the diff, analytics, and tree
+// namespaces.
+func DefaultCacheQuotas(maxBytes int64) map[string]int64 {
+	quotas := make(map[string]int64, len(defaultCacheQuotaShares))
+	for ns, share := range defaultCacheQuotaShares {
+		quotas[ns] = int64(float64(maxBytes) * share)
+	}
+	return quotas
+}
+
+type weightedEntry struct {
+	key     string
+	ns      string
+	val     any
+	cost    int64
+	used    uint64
+	expires time.Time
+}
+
+type cacheNamespace struct {
+	order *list.List // front is most recently used
+	bytes int64
+	stats CacheStats
+}
+
+// WeightedCache is a least-recently-used cache bounded by the estimated size
+// of its entries rather than their number. Each namespace is kept in its own
+// recency list so a namespace over quota evicts only its own entries; when the
+// total budget is exceeded the least recently used entry of any namespace goes.
+type WeightedCache struct {
+	mu   sync.Mutex
+	opts WeightedCacheOptions
+	// defaultQuotas records that Quotas were derived from MaxBytes, so
+	// SetLimits rescales them.
+	defaultQuotas bool
+	items         map[string]*list.Element
+	spaces        map[string]*cacheNamespace
+	bytes         int64
+	clock         uint64
+	now           func() time.Time
+}
+
+// NewWeightedCache constructs a WeightedCache, filling in defaults for unset
+// options.
+func NewWeightedCache(opts WeightedCacheOptions) *WeightedCache {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultCacheBytes
+	}
+	defaultQuotas := opts.Quotas == nil
+	if defaultQuotas {
+		opts.Quotas = DefaultCacheQuotas(opts.MaxBytes)
+	}
+	if opts.Cost == nil {
+		opts.Cost = jsonCost
+	}
+	if opts.Namespace == nil {
+		opts.Namespace = cacheNamespaceForKey
+	}
+	c := &WeightedCache{
+		opts:          opts,
+		defaultQuotas: defaultQuotas,
+		items:         make(map[string]*list.Element),
+		spaces:        make(map[string]*cacheNamespace),
+		now:           time.Now,
+	}
+	// Known namespaces exist up front so their stats report zeros rather
+	// than being absent until first use.
+	for ns := range opts.Quotas {
+		c.space(ns)
+	}
+	return c
+}
+
+// Get returns the value stored under key. Expired entries are removed and
+// reported as misses.
+func (c *WeightedCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.space(c.opts.Namespace(key)).stats.Misses++
+		return nil, false
+	}
+	e, _ := elem.Value.(*weightedEntry)
+	sp := c.spaces[e.ns]
+	if c.expired(e) {
+		c.remove(elem)
+		sp.stats.Expired++
+		sp.stats.Misses++
+		return nil, false
+	}
+	sp.stats.Hits++
+	c.clock++
+	e.used = c.clock
+	sp.order.MoveToFront(elem)
+	return e.val, true
+}
+
+// Put stores val under key, evicting entries until it fits. A value larger
+// than its namespace quota or the whole budget is not cached.
+func (c *WeightedCache) Put(key string, val any) {
+	// Estimating the cost may encode the value, so do it before locking.
+	cost := c.opts.Cost(key, val) + cacheEntryOverhead
+	ns := c.opts.Namespace(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	sp := c.space(ns)
+	quota := c.quota(ns)
+	if cost > quota || cost > c.opts.MaxBytes {
+		return
+	}
+	for sp.bytes+cost > quota {
+		c.evict(sp.order.Back())
+	}
+	for c.bytes+cost > c.opts.MaxBytes || (c.opts.MaxEntries > 0 && len(c.items) >= c.opts.MaxEntries) {
+		c.evict(c.oldest())
+	}
+
+	c.clock++
+	e := &weightedEntry{key: key, ns: ns, val: val, cost: cost, used: c.clock}
+	if c.opts.TTL > 0 {
+		e.expires = c.now().Add(c.opts.TTL)
+	}
+	c.items[key] = sp.order.PushFront(e)
+	sp.bytes += cost
+	c.bytes += cost
+}
+
+// SetLimits changes the byte budget, entry cap, and TTL, evicting entries
+// until the cache fits. Non-positive maxBytes means 256 MiB. A new TTL applies
+// to entries stored afterwards.
+func (c *WeightedCache) SetLimits(maxBytes int64, maxEntries int, ttl time.Duration) {
+	if maxBytes <= 0 {
+		maxBytes = defaultCacheBytes
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts.MaxBytes = maxBytes
+	c.opts.MaxEntries = maxEntries
+	c.opts.TTL = ttl
+	if c.defaultQuotas {
+		c.opts.Quotas = DefaultCacheQuotas(maxBytes)
+	}
+	for ns, sp := range c.spaces {
+		for sp.bytes > c.quota(ns) {
+			c.evict(sp.order.Back())
+		}
+	}
+	for c.bytes > c.opts.MaxBytes || (c.opts.MaxEntries > 0 && len(c.items) > c.opts.MaxEntries) {
+		c.evict(c.oldest())
+	}
+}
+
+// Clear removes every entry. Counters are kept.
+func (c *WeightedCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	for _, sp := range c.spaces {
+		sp.order.Init()
+		sp.bytes = 0
+	}
+	c.bytes = 0
+}
+
+// Len returns the number of entries in the cache.
+func (c *WeightedCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Stats returns counters summed over all namespaces.
+func (c *WeightedCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total CacheStats
+	for _, sp := range c.spaces {
+		total.Hits += sp.stats.Hits
+		total.Misses += sp.stats.Misses
+		total.Evictions += sp.stats.Evictions
+		total.Expired += sp.stats.Expired
+	}
+	total.Entries = len(c.items)
+	total.Bytes = c.bytes
+	return total
+}
+
+// NamespaceStats returns counters for each namespace.
+func (c *WeightedCache) NamespaceStats() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]CacheStats, len(c.spaces))
+	for ns, sp := range c.spaces {
+		stats := sp.stats
+		stats.Entries = sp.order.Len()
+		stats.Bytes = sp.bytes
+		out[ns] = stats
+	}
+	return out
+}
+
+func (c *WeightedCache) space(ns string) *cacheNamespace {
+	sp, ok := c.spaces[ns]
+	if !ok {
+		sp = &cacheNamespace{order: list.New()}
+		c.spaces[ns] = sp
+	}
+	return sp
+}
+
+func (c *WeightedCache) quota(ns string) int64 {
+	if q, ok := c.opts.Quotas[ns]; ok {
+		return q
+	}
+	return c.opts.MaxBytes
+}
+
+func (c *WeightedCache) expired(e *weightedEntry) bool {
+	return !e.expires.IsZero() && !c.now().Before(e.expires)
+}
+
+// oldest returns the least recently used entry across namespaces. Must be
+// called with mu held.
+func (c *WeightedCache) oldest() *list.Element {
+	var oldest *list.Element
+	var oldestUsed uint64 = math.MaxUint64
+	for _, sp := range c.spaces {
+		back := sp.order.Back()
+		if back == nil {
+			continue
+		}
+		if e, _ := back.Value.(*weightedEntry); e.used < oldestUsed {
+			oldest, oldestUsed = back, e.used
+		}
+	}
+	return oldest
+}
+
+// evict removes elem, counting it as expired or evicted. Must be called with
+// mu held.
+func (c *WeightedCache) evict(elem *list.Element) {
+	e, _ := elem.Value.(*weightedEntry)
+	if c.expired(e) {
+		c.spaces[e.ns].stats.Expired++
+	} else {
+		c.spaces[e.ns].stats.Evictions++
+	}
+	c.remove(elem)
+}
+
+func (c *WeightedCache) remove(elem *list.Element) {
+	e, _ := elem.Value.(*weightedEntry)
+	sp := c.spaces[e.ns]
+	sp.order.Remove(elem)
+	sp.bytes -= e.cost
+	c.bytes -= e.cost
+	delete(c.items, e.key)
+}
+
+func cacheNamespaceForKey(key string) string {
+	switch {
+	case strings.HasPrefix(key, "analytics"):
+		return CacheNamespaceAnalytics
+	case strings.HasPrefix(key, "tree:"):
+		return CacheNamespaceTree
+	default:
+		return CacheNamespaceDiff
+	}
+}
+
+// jsonCost estimates an entry's memory from the length of its JSON encoding,
+// which tracks the strings and slices that dominate cached responses.
+func jsonCost(_ string, val any) int64 {
+	switch v := val.(type) {
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	}
+	var w byteCounter
+	if err := json.NewEncoder(&w).Encode(val); err != nil {
+		return 0
+	}
+	return w.n
+}
+
+type byteCounter struct{ n int64 }
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	b.n += int64(len(p))
+	return len(p), nil
+}
+
+// ParseByteSize parses a byte count such as "1048576", "512MiB", "64MB", or
+// "1G". Single-letter and IEC suffixes are powers of 1024; SI suffixes are
+// powers of 1000.
+func ParseByteSize(raw string) (int64, error) {
+	s := strings.TrimSpace(raw)
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", raw)
+	}
+	multipliers := map[string]int64{
+		"": 1, "b": 1,
+		"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+		"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+		"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	}
+	m, ok := multipliers[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", raw, unit)
+	}
+	if n > math.MaxInt64/m {
+		return 0, fmt.Errorf("invalid byte size %q: too large", raw)
+	}
+	return n * m, nil
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixedCost makes each entry cost its value's length, plus the overhead the
+// cache adds, so tests can reason about exact budgets.
+func fixedCost(_ string, val any) int64 {
+	s, _ := val.(string)
+	return int64(len(s))
+}
+
+func TestWeightedCache_EvictsByBytes(t *testing.T) {
+	c := NewWeightedCache(WeightedCacheOptions{
+		MaxBytes: 3 * (100 + cacheEntryOverhead),
+		Quotas:   map[string]int64{},
+		Cost:     fixedCost,
+	})
+	value := strings.Repeat("x", 100)
+	c.Put("a", value)
+	c.Put("b", value)
+	c.Put("c", value)
+	c.Get("a") // "b" is now the least recently used
+	c.Put("d", value)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry survived")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("entry %q was evicted", key)
+		}
+	}
+	stats := c.Stats()
+	if stats.Entries != 3 || stats.Bytes != 3*(100+cacheEntryOverhead) || stats.Evictions != 1 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+
+	// One large entry can displace several small ones.
+	c.Put("big", strings.Repeat("x", 250))
+	if got := c.Len(); got != 2 {
+		t.Fatalf("Len() after large put = %d, want 2", got)
+	}
+
+	// A value larger than the whole budget is not cached at all.
+	c.Put("huge", strings.Repeat("x", 1000))
+	if _, ok := c.Get("huge"); ok {
+		t.Fatal("entry larger than the budget was cached")
+	}
+	if got := c.Len(); got != 2 {
+		t.Fatalf("Len() after oversized put = %d, want 2", got)
+	}
+}
+
+func TestWeightedCache_NamespaceQuotas(t *testing.T) {
+	entry := int64(100 + cacheEntryOverhead)
+	c := NewWeightedCache(WeightedCacheOptions{
+		MaxBytes: 10 * entry,
+		Quotas:   map[string]int64{CacheNamespaceAnalytics: 2 * entry},
+		Cost:     fixedCost,
+	})
+	value := strings.Repeat("x", 100)
+	c.Put("commit-diff:v1:a", value)
+	for _, period := range []string{"all", "3m", "6m", "1y"} {
+		c.Put("analytics:v1:"+period, value)
+	}
+
+	stats := c.NamespaceStats()
+	if got := stats[CacheNamespaceAnalytics]; got.Entries != 2 || got.Bytes != 2*entry || got.Evictions != 2 {
+		t.Fatalf("analytics stats = %+v, want 2 entries within quota", got)
+	}
+	if got := stats[CacheNamespaceDiff]; got.Entries != 1 || got.Evictions != 0 {
+		t.Fatalf("diff stats = %+v; analytics must not evict other namespaces", got)
+	}
+	if _, ok := c.Get("analytics:v1:1y"); !ok {
+		t.Fatal("most recent analytics entry was evicted")
+	}
+}
+
+func TestWeightedCache_TTL(t *testing.T) {
+	now := time.Date(2026, time.March, 3, 12, 0, 0, 0, time.UTC)
+	c := NewWeightedCache(WeightedCacheOptions{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.Put("a", "1")
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("entry expired before its TTL")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("entry outlived its TTL")
+	}
+	if stats := c.Stats(); stats.Expired != 1 || stats.Entries != 0 || stats.Bytes != 0 {
+		t.Fatalf("Stats() after expiry = %+v", stats)
+	}
+}
+
+func TestWeightedCache_MaxEntriesAndClear(t *testing.T) {
+	c := NewWeightedCache(WeightedCacheOptions{MaxEntries: 2})
+	c.Put("a", "1")
+	c.Put("b", "2")
+	c.Put("a", "updated") // replacing an entry does not evict another
+	c.Put("c", "3")
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("entry cap not enforced")
+	}
+	if v, ok := c.Get("a"); !ok || v != "updated" {
+		t.Fatalf("Get(a) = %v, %v", v, ok)
+	}
+
+	c.Clear()
+	stats := c.Stats()
+	if stats.Entries != 0 || stats.Bytes != 0 || stats.Hits != 1 || stats.Evictions != 1 {
+		t.Fatalf("Stats() after Clear = %+v", stats)
+	}
+}
+
+func TestWeightedCache_DefaultCostTracksSize(t *testing.T) {
+	c := NewWeightedCache(WeightedCacheOptions{})
+	c.Put("commit-diff:v1:small", commitDiffResponse{CommitHash: "a"})
+	small := c.Stats().Bytes
+	entries := make([]commitDiffEntryResponse, 1000)
+	for i := range entries {
+		entries[i] = commitDiffEntryResponse{Path: strings.Repeat("p", 50), Status: "modified"}
+	}
+	c.Put("commit-diff:v1:large", commitDiffResponse{CommitHash: "b", Entries: entries})
+	if large := c.Stats().Bytes - small; large < 50*1000 {
+		t.Fatalf("large response cost %d bytes, want at least %d", large, 50*1000)
+	}
+}
+
+func TestWeightedCache_ConcurrentAccess(t *testing.T) {
+	c := NewWeightedCache(WeightedCacheOptions{MaxBytes: 64 << 10, TTL: time.Millisecond})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := range 500 {
+				key := []string{"commit-diff:", "analytics:", "tree:"}[i%3] + strings.Repeat("k", id+i%7)
+				c.Put(key, strings.Repeat("v", i))
+				c.Get(key)
+				if i%100 == 0 {
+					c.Clear()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if stats := c.Stats(); stats.Bytes < 0 || stats.Bytes > 64<<10 {
+		t.Fatalf("Stats() = %+v, bytes out of bounds", stats)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int64
+	}{
+		{raw: "1048576", want: 1 << 20},
+		{raw: "512MiB", want: 512 << 20},
+		{raw: "512m", want: 512 << 20},
+		{raw: "64MB", want: 64_000_000},
+		{raw: "2 GiB", want: 2 << 30},
+		{raw: "10k", want: 10 << 10},
+	}
+	for _, tt := range tests {
+		if got, err := ParseByteSize(tt.raw); err != nil || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", tt.raw, got, err, tt.want)
+		}
+	}
+	for _, raw := range []string{"", "MiB", "-1", "12TB", "99999999999G"} {
+		if _, err := ParseByteSize(raw); err == nil {
+			t.Errorf("ParseByteSize(%q) succeeded, want error", raw)
+		}
+	}
+}
@@ -120,17 +120,27 @@ func buildRepositoryResponse(repo *gitcore.Repository, ctx context.Context) repo
 }
 
 func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
-	treeHash, repo, _, ok := s.extractHashParam(w, r, "/api/tree/")
+	treeHash, repo, session, ok := s.extractHashParam(w, r, "/api/tree/")
 	if !ok {
 		return
 	}
 
+	cacheKey := "tree:" + string(treeHash)
+	if cached, ok := session.cache.Get(cacheKey); ok {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cached); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
 	tree, err := repo.GetTree(treeHash)
 	if err != nil {
 		s.logger.Error("Failed to load tree", "hash", treeHash, "err", err)
 		http.Error(w, "Tree not found", http.StatusNotFound)
 		return
 	}
+	session.cache.Put(cacheKey, tree)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(tree); err != nil {
@@ -176,6 +186,44 @@ func (s *Server) handleBlob(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleCommitDescribe names a commit after its nearest reachable tag, as
+// shown in commit tooltips. Commits with no tag in their history return an
+// empty describe string rather than an error.
+func (s *Server) handleCommitDescribe(w http.ResponseWriter, r *http.Request) {
+	commitHash, repo, session, ok := s.extractHashParam(w, r, "/api/commit/describe/")
+	if !ok {
+		return
+	}
+
+	cacheKey := "describe:" + string(commitHash)
+	if cached, ok := session.cache.Get(cacheKey); ok {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cached); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if _, err := repo.GetCommit(commitHash); err != nil {
+		http.Error(w, fmt.Sprintf("Commit not found: %s", commitHash), http.StatusNotFound)
+		return
+	}
+
+	response := describeResponse{Hash: string(commitHash)}
+	if name, err := gitcore.Describe(repo, commitHash, gitcore.DescribeOptions{Tags: true, Abbrev: -1}); err == nil {
+		response.Describe = name
+	}
+	if name, err := gitcore.NameRev(repo, commitHash, gitcore.NameRevOptions{TagsOnly: true}); err == nil {
+		response.Contains = strings.TrimPrefix(name, "tags/")
+	}
+	session.cache.Put(cacheKey, response)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
 // isBinaryContent delegates to gitcore.IsBinaryContent to avoid duplication.
 func isBinaryContent(content []byte) bool {
 	return gitcore.IsBinaryContent(content)
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	defaultCompareCommitLimit = 100
+	maxCompareCommitLimit     = 1000
+)
+
+// handleCompare relates two revisions for reviewing a branch before it is
+// pushed: ahead/behind counts, the commits unique to each side, and the
+// three-dot diff of the merge base against head, as in git diff base...head.
+// With a path parameter it instead returns that file's three-dot diff in the
+// same shape as /api/commit/diff/{hash}/file.
+//
+// The commit lists are paged with limit and offset, applied to each side; the
+// ahead and behind counts always cover every commit. The file list stops at
+// the diff entry limit and sets truncated past it.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	baseRef := strings.TrimSpace(query.Get("base"))
+	headRef := strings.TrimSpace(query.Get("head"))
+	if baseRef == "" || headRef == "" {
+		http.Error(w, "Missing base or head parameter", http.StatusBadRequest)
+		return
+	}
+	opts := gitcore.CompareOptions{CommitLimit: parseCompareCommitLimit(query.Get("limit"))}
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		opts.CommitOffset = n
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+
+	base, err := repo.ResolveRevision(baseRef)
+	if err != nil {
+		http.Error(w, "Unknown base revision", http.StatusBadRequest)
+		return
+	}
+	head, err := repo.ResolveRevision(headRef)
+	if err != nil {
+		http.Error(w, "Unknown head revision", http.StatusBadRequest)
+		return
+	}
+
+	// Commit hashes are immutable, so the comparison can be cached by hash
+	// even though the refs it was requested by may move.
+	cacheKey := fmt.Sprintf("compare:%s...%s:%d+%d", base, head, opts.CommitOffset, opts.CommitLimit)
+	var cmp *gitcore.Comparison
+	if cached, ok := session.cache.Get(cacheKey); ok {
+		cmp, _ = cached.(*gitcore.Comparison)
+	}
+	if cmp == nil {
+		cmp, err = gitcore.Compare(repo, base, head, opts)
+		if err != nil {
+			if errors.Is(err, gitcore.ErrNoMergeBase) {
+				http.Error(w, "Revisions have no common ancestor", http.StatusBadRequest)
+				return
+			}
+			s.logger.Error("Failed to compare revisions", "base", baseRef, "head", headRef, "err", err)
+			http.Error(w, "Comparison failed", http.StatusInternalServerError)
+			return
+		}
+		session.cache.Put(cacheKey, cmp)
+	}
+
+	if query.Has("path") {
+		s.handleCompareFileDiff(w, r, repo, cmp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(compareResponse{BaseRef: baseRef, HeadRef: headRef, Comparison: cmp}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleCompareFileDiff(w http.ResponseWriter, r *http.Request, repo *gitcore.Repository, cmp *gitcore.Comparison) {
+	filePath, err := sanitizePath(r.URL.Query().Get("path"))
+	if err != nil || filePath == "" {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	var target *gitcore.ComparisonFile
+	for i := range cmp.Files {
+		if cmp.Files[i].Path == filePath {
+			target = &cmp.Files[i]
+			break
+		}
+	}
+	if target == nil {
+		http.Error(w, "File not changed in comparison", http.StatusNotFound)
+		return
+	}
+
+	fileDiff, err := gitcore.ComputeFileDiff(repo, target.OldHash, target.NewHash, filePath, parseDiffContextLines(r))
+	if err != nil {
+		s.logger.Error("Failed to compute compare file diff", "path", filePath, "err", err)
+		http.Error(w, "File diff computation failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diffFileResponse{
+		Path:      fileDiff.Path,
+		Status:    target.Status.String(),
+		OldHash:   string(fileDiff.OldHash),
+		NewHash:   string(fileDiff.NewHash),
+		IsBinary:  fileDiff.IsBinary,
+		Truncated: fileDiff.Truncated,
+		Hunks:     fileDiff.Hunks,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func parseCompareCommitLimit(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n <= 0 {
+		return defaultCompareCommitLimit
+	}
+	return min(n, maxCompareCommitLimit)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+func TestHandleCompare(t *testing.T) {
+	fixture := gittest.New(t)
+	fixture.Write("a.txt", "one\n")
+	fixture.Commit("initial")
+	fixture.Git("checkout", "-q", "-b", "feature")
+	fixture.Write("a.txt", "one\ntwo\n")
+	fixture.Commit("feature work")
+	fixture.Git("checkout", "-q", "main")
+	fixture.Write("b.txt", "main\n")
+	fixture.Commit("main work")
+	repo := fixture.Open()
+
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	for range 2 { // the second request is served from the cache
+		w := httptest.NewRecorder()
+		s.handleCompare(w, requestWithSession("GET", "/api/compare?base=main&head=feature", session))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			BaseRef string `json:"baseRef"`
+			HeadRef string `json:"headRef"`
+			gitcore.Comparison
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.BaseRef != "main" || resp.HeadRef != "feature" || resp.Ahead != 1 || resp.Behind != 1 {
+			t.Fatalf("resp = %+v", resp)
+		}
+		if len(resp.AheadCommits) != 1 || resp.AheadCommits[0].Message != "feature work" {
+			t.Fatalf("aheadCommits = %+v", resp.AheadCommits)
+		}
+		if len(resp.Files) != 1 || resp.Files[0].Path != "a.txt" || resp.Files[0].Insertions != 1 {
+			t.Fatalf("files = %+v, want a.txt with one insertion", resp.Files)
+		}
+		if want := (gitcore.DiffStats{FilesChanged: 1, Insertions: 1}); resp.Stats != want {
+			t.Fatalf("stats = %+v, want %+v", resp.Stats, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleCompare(w, requestWithSession("GET", "/api/compare?base=main&head=feature&limit=1&offset=1", session))
+	if w.Code != http.StatusOK {
+		t.Fatalf("paged status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var paged gitcore.Comparison
+	if err := json.NewDecoder(w.Body).Decode(&paged); err != nil {
+		t.Fatalf("decode paged: %v", err)
+	}
+	if paged.Ahead != 1 || paged.Behind != 1 || len(paged.AheadCommits) != 0 || len(paged.BehindCommits) != 0 {
+		t.Fatalf("paged = %d/%d with %d/%d commits, want counts 1/1 and no commits past the offset",
+			paged.Ahead, paged.Behind, len(paged.AheadCommits), len(paged.BehindCommits))
+	}
+}
+
+func TestHandleCompare_FileDiff(t *testing.T) {
+	fixture := gittest.New(t)
+	fixture.Write("a.txt", "one\n")
+	fixture.Commit("initial")
+	fixture.Git("checkout", "-q", "-b", "feature")
+	fixture.Write("a.txt", "one\ntwo\n")
+	fixture.Commit("feature work")
+	repo := fixture.Open()
+
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	w := httptest.NewRecorder()
+	s.handleCompare(w, requestWithSession("GET", "/api/compare?base=main&head=feature&path=a.txt", session))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp diffFileResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Path != "a.txt" || resp.Status != "modified" || len(resp.Hunks) != 1 {
+		t.Fatalf("resp = %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleCompare(w, requestWithSession("GET", "/api/compare?base=main&head=feature&path=missing.txt", session))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing path status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleCompare_BadRequests(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"a.txt": "one\n"})
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{name: "method", method: "POST", target: "/api/compare?base=main&head=main", want: http.StatusMethodNotAllowed},
+		{name: "missing head", method: "GET", target: "/api/compare?base=main", want: http.StatusBadRequest},
+		{name: "bad offset", method: "GET", target: "/api/compare?base=main&head=main&offset=-1", want: http.StatusBadRequest},
+		{name: "unknown base", method: "GET", target: "/api/compare?base=nope&head=main", want: http.StatusBadRequest},
+		{name: "unknown head", method: "GET", target: "/api/compare?base=main&head=nope", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleCompare(w, requestWithSession(tt.method, tt.target, session))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
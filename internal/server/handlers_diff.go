@@ -28,8 +28,8 @@ type bulkDiffStatsResponse struct {
 }
 
 func (s *Server) handleCommitDiffList(w http.ResponseWriter, repo *gitcore.Repository, commitHash gitcore.Hash, session *RepoSession) {
-	cacheKey := string(commitHash)
-	if cached, ok := session.diffCache.Get(cacheKey); ok {
+	cacheKey := commitDiffKeyPrefix + string(commitHash)
+	if cached, ok := getImmutable[commitDiffResponse](session, cacheKey); ok {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(cached); err != nil {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -79,7 +79,7 @@ func (s *Server) handleCommitDiffList(w http.ResponseWriter, repo *gitcore.Repos
 		Stats:          stats,
 	}
 
-	session.diffCache.Put(cacheKey, response)
+	session.putImmutable(cacheKey, response)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -107,7 +107,7 @@ func (s *Server) handleBulkDiffStats(w http.ResponseWriter, r *http.Request) {
 
 	limit := parseBulkDiffStatsLimit(r)
 	cacheKey := "bulk-diffstats:v2:limit:" + strconv.Itoa(limit)
-	if cached, ok := session.diffCache.Get(cacheKey); ok {
+	if cached, ok := session.cache.Get(cacheKey); ok {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(cached); err != nil {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -203,7 +203,7 @@ func (s *Server) handleBulkDiffStats(w http.ResponseWriter, r *http.Request) {
 		SkippedTooLarge: tooLargeErrors,
 		SkippedOther:    otherErrors,
 	}
-	session.diffCache.Put(cacheKey, response)
+	session.cache.Put(cacheKey, response)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -246,8 +246,8 @@ func (s *Server) handleFileDiff(w http.ResponseWriter, r *http.Request, repo *gi
 
 	contextLines := parseDiffContextLines(r)
 
-	cacheKey := string(commitHash) + ":" + filePath + ":ctx" + strconv.Itoa(contextLines)
-	if cached, ok := session.diffCache.Get(cacheKey); ok {
+	cacheKey := fileDiffKeyPrefix + string(commitHash) + ":" + filePath + ":ctx" + strconv.Itoa(contextLines)
+	if cached, ok := getImmutable[diffFileResponse](session, cacheKey); ok {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(cached); err != nil {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -298,7 +298,7 @@ func (s *Server) handleFileDiff(w http.ResponseWriter, r *http.Request, repo *gi
 		Hunks:     fileDiff.Hunks,
 	}
 
-	session.diffCache.Put(cacheKey, response)
+	session.putImmutable(cacheKey, response)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
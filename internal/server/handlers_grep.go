@@ -0,0 +1,113 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	defaultGrepFileLimit = 200
+	maxGrepFileLimit     = 2000
+	maxGrepContextLines  = 10
+)
+
+var errGrepLimitReached = errors.New("grep file limit reached")
+
+// handleGrep streams full-text search results for a revision's tree, or for
+// tracked files in the working tree when rev is omitted. Each matching file is
+// sent as its own NDJSON record followed by a final "done" or "error" record.
+func (s *Server) handleGrep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	pattern := query.Get("q")
+	if pattern == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+
+	revision := strings.TrimSpace(query.Get("rev"))
+	if revision == "" && repo.IsBare() {
+		http.Error(w, "Working tree not available for bare repositories", http.StatusBadRequest)
+		return
+	}
+
+	pathspecs := make([]string, 0, len(query["path"]))
+	for _, raw := range query["path"] {
+		if raw == "" {
+			continue
+		}
+		if err := validatePath(raw); err != nil {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+		pathspecs = append(pathspecs, raw)
+	}
+
+	opts := gitcore.GrepOptions{
+		FixedStrings: parseBoolParam(query.Get("fixed")),
+		IgnoreCase:   parseBoolParam(query.Get("icase")),
+		Pathspecs:    pathspecs,
+		ContextLines: parseGrepContextLines(query.Get("context")),
+	}
+	limit := parseGrepFileLimit(query.Get("limit"))
+
+	stream := newNDJSONStream(w, r)
+	files := 0
+	err := gitcore.GrepFunc(repo, revision, pattern, opts, func(result gitcore.GrepFileResult) error {
+		if files >= limit {
+			return errGrepLimitReached
+		}
+		files++
+		return stream.Send(grepStreamRecord{Type: "file", File: &result})
+	})
+
+	switch {
+	case err == nil || errors.Is(err, errGrepLimitReached):
+		_ = stream.Send(grepStreamRecord{Type: "done", Files: files, Truncated: err != nil})
+	case r.Context().Err() != nil:
+		// Client went away; nothing left to report.
+	default:
+		s.logger.Debug("Grep failed", "rev", revision, "err", err)
+		_ = stream.Send(grepStreamRecord{Type: "error", Error: err.Error(), Files: files})
+	}
+}
+
+func parseBoolParam(raw string) bool {
+	ok, err := strconv.ParseBool(raw)
+	return err == nil && ok
+}
+
+func parseGrepContextLines(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return min(n, maxGrepContextLines)
+}
+
+func parseGrepFileLimit(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n <= 0 {
+		return defaultGrepFileLimit
+	}
+	return min(n, maxGrepFileLimit)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+// newGitFixtureRepo commits files into a fresh repository and loads it with
+// gitcore. Tests are skipped when git is unavailable.
+func newGitFixtureRepo(t *testing.T, files map[string]string) *gitcore.Repository {
+	t.Helper()
+	fixture := gittest.New(t)
+	for name, content := range files {
+		fixture.Write(name, content)
+	}
+	fixture.Commit("initial")
+	return fixture.Open()
+}
+
+func decodeGrepStream(t *testing.T, body string) []grepStreamRecord {
+	t.Helper()
+	var records []grepStreamRecord
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		var record grepStreamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestHandleGrep_StreamsMatches(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{
+		"a.txt":     "alpha\nneedle one\n",
+		"dir/b.txt": "needle two\n",
+		"c.txt":     "nothing here\n",
+	})
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	req := requestWithSession("GET", "/api/grep?q=needle&rev=HEAD", session)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	records := decodeGrepStream(t, w.Body.String())
+	if len(records) != 3 {
+		t.Fatalf("records = %+v, want 2 files and done", records)
+	}
+	if records[0].File.Path != "a.txt" || records[1].File.Path != "dir/b.txt" {
+		t.Fatalf("file order = %q, %q", records[0].File.Path, records[1].File.Path)
+	}
+	if records[2].Type != "done" || records[2].Files != 2 || records[2].Truncated {
+		t.Fatalf("done record = %+v", records[2])
+	}
+}
+
+func TestHandleGrep_LimitAndWorkingTree(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{
+		"a.txt": "needle\n",
+		"b.txt": "needle\n",
+	})
+	if err := os.WriteFile(filepath.Join(repo.WorkDir(), "b.txt"), []byte("changed\n"), 0o600); err != nil {
+		t.Fatalf("write b.txt: %v", err)
+	}
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	req := requestWithSession("GET", "/api/grep?q=needle&limit=1&rev=HEAD", session)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, req)
+	records := decodeGrepStream(t, w.Body.String())
+	if len(records) != 2 || !records[1].Truncated {
+		t.Fatalf("limited records = %+v", records)
+	}
+
+	req = requestWithSession("GET", "/api/grep?q=needle", session)
+	w = httptest.NewRecorder()
+	s.handleGrep(w, req)
+	records = decodeGrepStream(t, w.Body.String())
+	if len(records) != 2 || records[0].File.Path != "a.txt" || records[1].Files != 1 {
+		t.Fatalf("working tree records = %+v", records)
+	}
+}
+
+func TestHandleGrep_BadRequests(t *testing.T) {
+	s := newTestServer(t)
+	session := newTestSession(nil)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{name: "method", method: "POST", target: "/api/grep?q=x", want: http.StatusMethodNotAllowed},
+		{name: "missing pattern", method: "GET", target: "/api/grep", want: http.StatusBadRequest},
+		{name: "traversal pathspec", method: "GET", target: "/api/grep?q=x&rev=HEAD&path=../etc", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleGrep(w, requestWithSession(tt.method, tt.target, session))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	s.handleGrep(w, requestWithSession("GET", "/api/grep?q=x&rev=missing", session))
+	records := decodeGrepStream(t, w.Body.String())
+	if len(records) != 1 || records[0].Type != "error" {
+		t.Fatalf("unknown revision records = %+v", records)
+	}
+}
@@ -2,11 +2,15 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/rybkr/gitvista/gitcore"
 	"github.com/rybkr/gitvista/internal/analytics"
+	"github.com/rybkr/gitvista/internal/changelog"
 	"github.com/rybkr/gitvista/internal/repositoryview"
 )
 
@@ -92,18 +96,24 @@ func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query, err := analytics.ParseQuery(
-		r.URL.Query().Get("period"),
-		r.URL.Query().Get("start"),
-		r.URL.Query().Get("end"),
-	)
+	params := r.URL.Query()
+	query, err := analytics.ParseQuery(analytics.QueryParams{
+		Period:   params.Get("period"),
+		Start:    params.Get("start"),
+		End:      params.Get("end"),
+		Paths:    params["path"],
+		Revision: params.Get("rev"),
+		Authors:  params["author"],
+		Merges:   params.Get("merges"),
+		TimeZone: params.Get("tz"),
+	})
 	if err != nil {
 		http.Error(w, "Invalid analytics query", http.StatusBadRequest)
 		return
 	}
 
 	cacheKey := analytics.CacheKey(repo, query.CacheKey)
-	if cached, ok := session.diffCache.Get(cacheKey); ok {
+	if cached, ok := session.cache.Get(cacheKey); ok {
 		w.Header().Set("Content-Type", "application/json")
 		if encodeErr := json.NewEncoder(w).Encode(cached); encodeErr != nil {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -111,16 +121,370 @@ func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query.Store = session.analyticsStore()
 	response, err := analytics.Build(repo, query)
+	if errors.Is(err, analytics.ErrInvalidRevision) {
+		http.Error(w, "Invalid analytics query", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		s.logger.Error("Failed to build analytics", "query", query.CacheKey, "err", err)
 		http.Error(w, "Failed to build analytics", http.StatusInternalServerError)
 		return
 	}
-	session.diffCache.Put(cacheKey, response)
+	session.cache.Put(cacheKey, response)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+// handleReleases serves the release timeline for tags matching the pattern
+// query parameter, or every tag when it is empty. The timeline diffs each
+// release against the previous one, so it is built in the background once per
+// tag set and pattern; until it is ready the handler answers 202 Accepted with
+// a Retry-After header.
+func (s *Server) handleReleases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	response, err := session.cachedOrBuild(analytics.ReleasesCacheKey(repo, pattern), backgroundBuildWait, func() (any, error) {
+		return analytics.BuildReleases(repo, analytics.ReleaseOptions{Pattern: pattern, Store: session.analyticsStore()})
+	})
+	if errors.Is(err, errBuildPending) {
+		writeBuildPending(w)
+		return
+	}
+	if errors.Is(err, analytics.ErrInvalidTagPattern) {
+		http.Error(w, "Invalid tag pattern", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to build releases", "pattern", pattern, "err", err)
+		http.Error(w, "Failed to build releases", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleComposition serves lines of code per language and top-level
+// directory over HEAD's first-parent history. The composition is built in
+// the background once per HEAD; until it is ready the handler answers 202
+// Accepted with a Retry-After header.
+func (s *Server) handleComposition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	response, err := session.cachedOrBuild(analytics.CacheKey(repo, "composition"), backgroundBuildWait, func() (any, error) {
+		return analytics.BuildComposition(repo, analytics.CompositionOptions{Store: session.analyticsStore()})
+	})
+	if errors.Is(err, errBuildPending) {
+		writeBuildPending(w)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to build composition", "err", err)
+		http.Error(w, "Failed to build composition", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleChangelog renders the changelog for ?range=<from>..<to>, as JSON or,
+// with format=markdown, as Markdown text.
+func (s *Server) handleChangelog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	revRange := r.URL.Query().Get("range")
+	if revRange == "" {
+		http.Error(w, "Missing range parameter", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	log, err := changelog.Build(repo, revRange)
+	if errors.Is(err, changelog.ErrInvalidRange) {
+		http.Error(w, "Invalid revision range", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to build changelog", "range", revRange, "err", err)
+		http.Error(w, "Failed to build changelog", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_, _ = w.Write([]byte(log.Markdown()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(log); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// maxOwnershipInactiveMonths caps the inactivity window the ownership and
+// CODEOWNERS drift endpoints accept.
+const maxOwnershipInactiveMonths = 120
+
+// handleOwnership serves the ownership view of a directory at HEAD. The
+// ownership model is built once per HEAD in the background and cached; each
+// request only aggregates it for the requested path. Until the model is ready
+// the handler answers 202 Accepted with a Retry-After header.
+func (s *Server) handleOwnership(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	inactiveMonths, ok := parseInactiveMonths(r.URL.Query().Get("inactive"))
+	if !ok {
+		http.Error(w, "Invalid inactive months", http.StatusBadRequest)
+		return
+	}
+	ownership, err := s.ownershipModel(session, repo)
+	if errors.Is(err, errBuildPending) {
+		writeBuildPending(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to build ownership", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := ownership.Directory(r.URL.Query().Get("path"), inactiveMonths)
+	if errors.Is(err, analytics.ErrPathNotFound) {
+		http.Error(w, "Path not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to build ownership", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// parseInactiveMonths reads the inactive query parameter. Empty means the
+// analytics default.
+func parseInactiveMonths(raw string) (int, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > maxOwnershipInactiveMonths {
+		return 0, false
+	}
+	return n, true
+}
+
+// ownershipModel returns the ownership model for the session's HEAD. The
+// model diffs much of the history, so it is built in the background on first
+// use; errBuildPending means it is not ready yet.
+func (s *Server) ownershipModel(session *RepoSession, repo *gitcore.Repository) (*analytics.Ownership, error) {
+	model, err := session.cachedOrBuild(analytics.CacheKey(repo, "ownership"), backgroundBuildWait, func() (any, error) {
+		return analytics.BuildOwnership(repo, analytics.OwnershipOptions{Store: session.analyticsStore()})
+	})
+	if err != nil {
+		if !errors.Is(err, errBuildPending) {
+			s.logger.Error("Failed to build ownership", "err", err)
+		}
+		return nil, err
+	}
+	ownership, ok := model.(*analytics.Ownership)
+	if !ok {
+		return nil, fmt.Errorf("ownership cache holds %T", model)
+	}
+	return ownership, nil
+}
+
+// maxCodeOwnersPaths caps how many paths one /api/codeowners request may ask
+// about.
+const maxCodeOwnersPaths = 1000
+
+type codeOwnersMatch struct {
+	Owners  []string `json:"owners"`
+	Pattern string   `json:"pattern"`
+	Line    int      `json:"line"`
+}
+
+type codeOwnersResponse struct {
+	File   string                     `json:"file"`
+	Owners map[string]codeOwnersMatch `json:"owners"`
+	Errors []gitcore.CodeOwnersError  `json:"errors"`
+}
+
+// handleCodeOwners serves the owners CODEOWNERS at HEAD declares for each
+// requested path. Paths no rule matches are left out of the response.
+func (s *Server) handleCodeOwners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	paths := r.URL.Query()["path"]
+	if len(paths) > maxCodeOwnersPaths {
+		http.Error(w, "Too many paths", http.StatusBadRequest)
+		return
+	}
+	for _, p := range paths {
+		if err := validatePath(p); err != nil {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+	}
+
+	response := codeOwnersResponse{Owners: map[string]codeOwnersMatch{}, Errors: []gitcore.CodeOwnersError{}}
+	if head := repo.Head(); head != "" {
+		codeOwners, err := repo.CodeOwners(head)
+		if err != nil {
+			s.logger.Error("Failed to read CODEOWNERS", "err", err)
+			http.Error(w, "Failed to read CODEOWNERS", http.StatusInternalServerError)
+			return
+		}
+		if codeOwners != nil {
+			response.File = codeOwners.Path
+			response.Errors = codeOwners.Errors
+			for _, p := range paths {
+				if rule, ok := codeOwners.Match(p); ok {
+					response.Owners[p] = codeOwnersMatch{Owners: rule.Owners, Pattern: rule.Pattern, Line: rule.Line}
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleCodeOwnersDrift compares CODEOWNERS at HEAD with the cached
+// ownership model, answering 202 Accepted like handleOwnership while the
+// model is built.
+func (s *Server) handleCodeOwnersDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	inactiveMonths, ok := parseInactiveMonths(r.URL.Query().Get("inactive"))
+	if !ok {
+		http.Error(w, "Invalid inactive months", http.StatusBadRequest)
+		return
+	}
+	var codeOwners *gitcore.CodeOwners
+	if head := repo.Head(); head != "" {
+		var err error
+		if codeOwners, err = repo.CodeOwners(head); err != nil {
+			s.logger.Error("Failed to read CODEOWNERS", "err", err)
+			http.Error(w, "Failed to read CODEOWNERS", http.StatusInternalServerError)
+			return
+		}
+	}
+	ownership, err := s.ownershipModel(session, repo)
+	if errors.Is(err, errBuildPending) {
+		writeBuildPending(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to build ownership", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(analytics.BuildCodeOwnersDrift(ownership, codeOwners, inactiveMonths)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
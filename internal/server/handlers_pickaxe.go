@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+const (
+	defaultPickaxeMatchLimit = 1000
+	maxPickaxeMatchLimit     = 10000
+	pickaxeProgressInterval  = 250
+)
+
+var errPickaxeLimitReached = errors.New("pickaxe match limit reached")
+
+// handlePickaxe streams commits whose changes add or remove a string (s=, like
+// git log -S) or touch lines matching a regular expression (g=, like -G).
+// History is walked newest first from rev, or from every branch and tag when
+// rev is omitted. Matches are sent as they are found, interleaved with
+// periodic progress records, and followed by a final "done" or "error" record.
+func (s *Server) handlePickaxe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := gitcore.PickaxeOptions{
+		String:       query.Get("s"),
+		Regex:        query.Get("g"),
+		PickaxeRegex: parseBoolParam(query.Get("regex")),
+		IgnoreCase:   parseBoolParam(query.Get("icase")),
+	}
+	if !opts.Enabled() {
+		http.Error(w, "Missing s or g parameter", http.StatusBadRequest)
+		return
+	}
+	if opts.String != "" && opts.Regex != "" {
+		http.Error(w, "Parameters s and g cannot be used together", http.StatusBadRequest)
+		return
+	}
+	for _, raw := range query["path"] {
+		if raw == "" {
+			continue
+		}
+		if err := validatePath(raw); err != nil {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+		opts.Pathspecs = append(opts.Pathspecs, raw)
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+
+	pickaxe, err := gitcore.NewPickaxe(repo, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	revision := strings.TrimSpace(query.Get("rev"))
+	commits, err := repo.RevList(gitcore.RevListOptions{All: revision == "", Revision: revision})
+	if err != nil {
+		http.Error(w, "Unknown revision", http.StatusBadRequest)
+		return
+	}
+	limit := parsePickaxeMatchLimit(query.Get("limit"))
+
+	stream := newNDJSONStream(w, r)
+	scanned, matches := 0, 0
+	err = pickaxe.Scan(commits, func(commit *gitcore.Commit, matched bool) error {
+		scanned++
+		if matched {
+			if matches >= limit {
+				return errPickaxeLimitReached
+			}
+			matches++
+			if err := stream.Send(pickaxeStreamRecord{Type: "match", Hash: commit.ID}); err != nil {
+				return err
+			}
+		}
+		if scanned%pickaxeProgressInterval == 0 {
+			return stream.Send(pickaxeStreamRecord{Type: "progress", Scanned: scanned, Total: len(commits), Matches: matches})
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil || errors.Is(err, errPickaxeLimitReached):
+		_ = stream.Send(pickaxeStreamRecord{
+			Type:      "done",
+			Scanned:   scanned,
+			Total:     len(commits),
+			Matches:   matches,
+			Truncated: err != nil,
+		})
+	case r.Context().Err() != nil:
+		// Client went away; nothing left to report.
+	default:
+		s.logger.Debug("Pickaxe search failed", "rev", revision, "err", err)
+		_ = stream.Send(pickaxeStreamRecord{Type: "error", Error: err.Error(), Scanned: scanned, Matches: matches})
+	}
+}
+
+func parsePickaxeMatchLimit(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n <= 0 {
+		return defaultPickaxeMatchLimit
+	}
+	return min(n, maxPickaxeMatchLimit)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/internal/gittest"
+)
+
+func decodePickaxeStream(t *testing.T, body string) []pickaxeStreamRecord {
+	t.Helper()
+	var records []pickaxeStreamRecord
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		var record pickaxeStreamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestHandlePickaxe_StreamsMatches(t *testing.T) {
+	fixture := gittest.New(t)
+	fixture.Write("a.txt", "alpha\n")
+	fixture.Commit("initial")
+	fixture.Write("a.txt", "alpha\nneedle\n")
+	fixture.Commit("add needle")
+	fixture.Write("b.txt", "unrelated\n")
+	fixture.Commit("unrelated")
+	repo := fixture.Open()
+	head, err := repo.ResolveRevision("HEAD~1")
+	if err != nil {
+		t.Fatalf("ResolveRevision() error = %v", err)
+	}
+
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	w := httptest.NewRecorder()
+	s.handlePickaxe(w, requestWithSession("GET", "/api/pickaxe?s=needle", session))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	records := decodePickaxeStream(t, w.Body.String())
+	if len(records) != 2 || records[0].Type != "match" || records[0].Hash != head {
+		t.Fatalf("records = %+v, want match for %s", records, head)
+	}
+	if done := records[1]; done.Type != "done" || done.Scanned != 3 || done.Total != 3 || done.Matches != 1 {
+		t.Fatalf("done record = %+v", done)
+	}
+
+	w = httptest.NewRecorder()
+	s.handlePickaxe(w, requestWithSession("GET", "/api/pickaxe?g=NEEDLE&icase=1&rev=HEAD&path=b.txt", session))
+	records = decodePickaxeStream(t, w.Body.String())
+	if len(records) != 1 || records[0].Type != "done" || records[0].Matches != 0 {
+		t.Fatalf("pathspec records = %+v", records)
+	}
+
+	w = httptest.NewRecorder()
+	s.handlePickaxe(w, requestWithSession("GET", "/api/pickaxe?g=.&limit=1", session))
+	records = decodePickaxeStream(t, w.Body.String())
+	if len(records) != 2 || !records[1].Truncated || records[1].Matches != 1 {
+		t.Fatalf("limited records = %+v", records)
+	}
+}
+
+func TestHandlePickaxe_BadRequests(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"a.txt": "alpha\n"})
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{name: "method", method: "POST", target: "/api/pickaxe?s=x", want: http.StatusMethodNotAllowed},
+		{name: "missing pattern", method: "GET", target: "/api/pickaxe", want: http.StatusBadRequest},
+		{name: "both patterns", method: "GET", target: "/api/pickaxe?s=x&g=y", want: http.StatusBadRequest},
+		{name: "invalid regex", method: "GET", target: "/api/pickaxe?g=(", want: http.StatusBadRequest},
+		{name: "traversal pathspec", method: "GET", target: "/api/pickaxe?s=x&path=../etc", want: http.StatusBadRequest},
+		{name: "unknown revision", method: "GET", target: "/api/pickaxe?s=x&rev=missing", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handlePickaxe(w, requestWithSession(tt.method, tt.target, session))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
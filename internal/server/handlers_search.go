@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/search"
+)
+
+const (
+	maxSearchPageLimit = 500
+	// searchScanBudget bounds how long one page may spend diffing commits,
+	// leaving room under apiWriteDeadline to write the response.
+	searchScanBudget = 15 * time.Second
+)
+
+// handleSearch evaluates the graph search language (see web/searchQuery.js)
+// against the full repository rather than the commits loaded in the browser.
+// Results are paged with an opaque cursor: pass nextCursor from one response
+// as cursor in the next request. Queries that diff commits stop after
+// searchScanBudget and return a partial page; keep paging while nextCursor is
+// set.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	raw := strings.TrimSpace(query.Get("q"))
+	if raw == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	cursor := 0
+	if v := query.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		cursor = n
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+	repo := session.Repo()
+	if repo == nil {
+		http.Error(w, "Repository not available", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := session.searchIndex(repo).Run(search.Parse(raw, time.Now()), search.Options{
+		Cursor:   cursor,
+		Limit:    parseSearchPageLimit(query.Get("limit")),
+		Deadline: time.Now().Add(searchScanBudget),
+	})
+	if err != nil {
+		s.logger.Error("Search failed", "query", raw, "err", err)
+		http.Error(w, "Failed to run search", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// searchIndex returns the search index for repo, building it on the first
+// search after each reload.
+func (rs *RepoSession) searchIndex(repo *gitcore.Repository) *search.Index {
+	if ix := rs.searchIdx.Load(); ix != nil && ix.Repo() == repo {
+		return ix
+	}
+	ix := search.NewIndex(repo)
+	rs.searchIdx.Store(ix)
+	return ix
+}
+
+func parseSearchPageLimit(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n <= 0 {
+		return search.DefaultLimit
+	}
+	return min(n, maxSearchPageLimit)
+}
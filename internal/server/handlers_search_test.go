@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rybkr/gitvista/internal/search"
+)
+
+func TestHandleSearch(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"docs/guide.md": "hello\n"})
+	s := newTestServer(t)
+	session := newTestSession(repo)
+
+	w := httptest.NewRecorder()
+	s.handleSearch(w, requestWithSession("GET", "/api/search?q=path:docs+INITIAL&limit=10", session))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var result search.Result
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Commit.Message != "initial" || result.Total != 1 || result.NextCursor != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if want := (search.Highlight{Field: "message", Start: 0, End: 7}); len(result.Hits[0].Highlights) != 1 || result.Hits[0].Highlights[0] != want {
+		t.Fatalf("highlights = %+v, want [%+v]", result.Hits[0].Highlights, want)
+	}
+
+	// Later pages reuse the index built for the loaded repository.
+	ix := session.searchIdx.Load()
+	if ix == nil || ix.Repo() != repo {
+		t.Fatalf("search index = %v, want one for the session repository", ix)
+	}
+	s.handleSearch(httptest.NewRecorder(), requestWithSession("GET", "/api/search?q=path:docs&cursor=1", session))
+	if session.searchIdx.Load() != ix {
+		t.Fatal("second search rebuilt the index")
+	}
+}
+
+func TestHandleSearch_BadRequests(t *testing.T) {
+	s := newTestServer(t)
+	session := newTestSession(nil)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{name: "method", method: "POST", target: "/api/search?q=x", want: http.StatusMethodNotAllowed},
+		{name: "missing query", method: "GET", target: "/api/search", want: http.StatusBadRequest},
+		{name: "invalid cursor", method: "GET", target: "/api/search?q=x&cursor=-1", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleSearch(w, requestWithSession(tt.method, tt.target, session))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -869,6 +869,15 @@ func TestHandleAnalytics(t *testing.T) {
 	if _, ok := response["deltas"]; !ok {
 		t.Error("response missing 'deltas'")
 	}
+	if _, ok := response["coupling"]; !ok {
+		t.Error("response missing 'coupling'")
+	}
+	if _, ok := response["workPatterns"]; !ok {
+		t.Error("response missing 'workPatterns'")
+	}
+	if response["timeZone"] != "utc" {
+		t.Errorf("timeZone = %v, want utc", response["timeZone"])
+	}
 }
 
 func TestHandleAnalytics_InvalidPeriod(t *testing.T) {
@@ -884,6 +893,270 @@ func TestHandleAnalytics_InvalidPeriod(t *testing.T) {
 	}
 }
 
+func TestHandleAnalytics_InvalidScope(t *testing.T) {
+	repo := gitcore.NewEmptyRepository()
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	for _, query := range []string{"merges=sometimes", "path=../etc", "rev=a...b", "rev=no-such-branch", "tz=Mars/Olympus"} {
+		req := requestWithSession("GET", "/api/analytics?"+query, session)
+		w := httptest.NewRecorder()
+		s.handleAnalytics(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status code = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandleOwnership(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"cmd/main.go": "package main\n", "README.md": "# x\n"})
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	req := requestWithSession("GET", "/api/ownership?path=cmd", session)
+	w := httptest.NewRecorder()
+	s.handleOwnership(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		Path      string `json:"path"`
+		Directory struct {
+			Files     int `json:"files"`
+			BusFactor int `json:"busFactor"`
+			Owners    []struct {
+				Email string  `json:"email"`
+				Share float64 `json:"share"`
+			} `json:"owners"`
+		} `json:"directory"`
+		Entries []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Path != "cmd" || response.Directory.Files != 1 || response.Directory.BusFactor != 1 ||
+		len(response.Directory.Owners) != 1 || response.Directory.Owners[0].Email != "test@example.com" ||
+		len(response.Entries) != 1 || response.Entries[0].Name != "main.go" || response.Entries[0].Type != "blob" {
+		t.Fatalf("response = %+v", response)
+	}
+
+	for query, want := range map[string]int{
+		"path=missing":   http.StatusNotFound,
+		"inactive=0":     http.StatusBadRequest,
+		"inactive=month": http.StatusBadRequest,
+		"inactive=12":    http.StatusOK,
+	} {
+		req := requestWithSession("GET", "/api/ownership?"+query, session)
+		w := httptest.NewRecorder()
+		s.handleOwnership(w, req)
+		if w.Code != want {
+			t.Errorf("%s: status code = %d, want %d", query, w.Code, want)
+		}
+	}
+}
+
+func TestHandleReleases(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"README.md": "# x\n"})
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	req := requestWithSession("GET", "/api/releases?pattern=v*", session)
+	w := httptest.NewRecorder()
+	s.handleReleases(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		Pattern    string            `json:"pattern"`
+		Releases   []json.RawMessage `json:"releases"`
+		Unreleased int               `json:"unreleased"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Pattern != "v*" || response.Releases == nil || len(response.Releases) != 0 || response.Unreleased != 1 {
+		t.Fatalf("response = %+v", response)
+	}
+
+	req = requestWithSession("GET", "/api/releases?pattern=v%5B", session)
+	w = httptest.NewRecorder()
+	s.handleReleases(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid pattern status code = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleComposition(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"README.md": "# x\n", "cmd/main.go": "package main\n\nfunc main() {}\n"})
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	req := requestWithSession("GET", "/api/composition", session)
+	w := httptest.NewRecorder()
+	s.handleComposition(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		Samples []struct {
+			Lines int `json:"lines"`
+		} `json:"samples"`
+		Languages []struct {
+			Name  string `json:"name"`
+			Lines []int  `json:"lines"`
+		} `json:"languages"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Samples) != 1 || response.Samples[0].Lines != 4 || len(response.Languages) != 2 || response.Languages[0].Name != "Go" {
+		t.Fatalf("response = %+v", response)
+	}
+
+	req = requestWithSession("POST", "/api/composition", session)
+	w = httptest.NewRecorder()
+	s.handleComposition(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status code = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleChangelog(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"README.md": "# x\n"})
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	req := requestWithSession("GET", "/api/changelog?range=HEAD", session)
+	w := httptest.NewRecorder()
+	s.handleChangelog(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		To      string `json:"to"`
+		Commits int    `json:"commits"`
+		Authors []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.To != "HEAD" || response.Commits != 1 || len(response.Authors) != 1 || response.Authors[0].Name != "Test" {
+		t.Fatalf("response = %+v", response)
+	}
+
+	req = requestWithSession("GET", "/api/changelog?range=HEAD..HEAD&format=markdown", session)
+	w = httptest.NewRecorder()
+	s.handleChangelog(w, req)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") ||
+		!strings.Contains(w.Body.String(), "## Changes in HEAD..HEAD") {
+		t.Fatalf("markdown = %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	for _, query := range []string{"", "?range=HEAD..missing", "?range=HEAD&format=html"} {
+		req = requestWithSession("GET", "/api/changelog"+query, session)
+		w = httptest.NewRecorder()
+		s.handleChangelog(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%q status code = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandleCodeOwners(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{
+		".github/CODEOWNERS": "*  @org/core\n/cmd/  @test bad-owner\n/docs/\n",
+		"cmd/main.go":        "package main\n",
+		"docs/guide.md":      "# guide\n",
+	})
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	req := requestWithSession("GET", "/api/codeowners?path=cmd/main.go&path=README.md&path=docs/guide.md", session)
+	w := httptest.NewRecorder()
+	s.handleCodeOwners(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		File   string `json:"file"`
+		Owners map[string]struct {
+			Owners  []string `json:"owners"`
+			Pattern string   `json:"pattern"`
+			Line    int      `json:"line"`
+		} `json:"owners"`
+		Errors []gitcore.CodeOwnersError `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.File != ".github/CODEOWNERS" || len(response.Errors) != 1 || response.Errors[0].Line != 2 {
+		t.Fatalf("response = %+v", response)
+	}
+	if got := response.Owners["cmd/main.go"]; len(got.Owners) != 1 || got.Owners[0] != "@test" || got.Pattern != "/cmd/" || got.Line != 2 {
+		t.Fatalf("cmd/main.go owners = %+v", got)
+	}
+	if got := response.Owners["README.md"]; len(got.Owners) != 1 || got.Owners[0] != "@org/core" {
+		t.Fatalf("README.md owners = %+v", got)
+	}
+	if got, ok := response.Owners["docs/guide.md"]; !ok || len(got.Owners) != 0 {
+		t.Fatalf("docs/guide.md owners = %+v, %v, want the unowning rule", got, ok)
+	}
+
+	req = requestWithSession("GET", "/api/codeowners?path=../etc/passwd", session)
+	w = httptest.NewRecorder()
+	s.handleCodeOwners(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid path status code = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleCodeOwnersDrift(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{
+		"CODEOWNERS":    "/cmd/  @test @someone\n",
+		"cmd/main.go":   "package main\n",
+		"docs/guide.md": "# guide\n",
+	})
+	session := newTestSession(repo)
+	s := newTestServer(t)
+
+	req := requestWithSession("GET", "/api/codeowners/drift?inactive=12", session)
+	w := httptest.NewRecorder()
+	s.handleCodeOwnersDrift(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		File         string `json:"file"`
+		OwnedFiles   int    `json:"ownedFiles"`
+		UnownedFiles int    `json:"unownedFiles"`
+		Owners       []struct {
+			Owner    string   `json:"owner"`
+			Resolved []string `json:"resolved"`
+		} `json:"owners"`
+		InactiveMonths int `json:"inactiveMonths"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.File != "CODEOWNERS" || response.OwnedFiles != 1 || response.UnownedFiles != 2 || response.InactiveMonths != 12 ||
+		len(response.Owners) != 2 || response.Owners[1].Owner != "@test" || len(response.Owners[1].Resolved) != 1 {
+		t.Fatalf("response = %+v", response)
+	}
+
+	req = requestWithSession("GET", "/api/codeowners/drift?inactive=0", session)
+	w = httptest.NewRecorder()
+	s.handleCodeOwnersDrift(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid inactive status code = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
 func TestHandleAnalytics_RangeQuery(t *testing.T) {
 	repo := gitcore.NewEmptyRepository()
 	session := newTestSession(repo)
@@ -934,3 +1207,29 @@ func TestHandleAnalytics_MethodNotAllowed(t *testing.T) {
 		t.Errorf("status code = %d, want %d", w.Code, http.StatusMethodNotAllowed)
 	}
 }
+
+func TestHandleCommitDescribe_InvalidMethod(t *testing.T) {
+	s := newTestServer(t)
+	session := newTestSession(nil)
+
+	req := requestWithSession("POST", "/api/commit/describe/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", session)
+	w := httptest.NewRecorder()
+	s.handleCommitDescribe(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleCommitDescribe_UnknownCommit(t *testing.T) {
+	s := newTestServer(t)
+	session := newTestSession(nil)
+
+	req := requestWithSession("GET", "/api/commit/describe/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", session)
+	w := httptest.NewRecorder()
+	s.handleCommitDescribe(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -3,6 +3,10 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
 )
 
 // HealthStatus represents the server health check response.
@@ -38,3 +42,145 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		s.logger.Error("Failed to encode health status", "err", err)
 	}
 }
+
+// LivenessStatus is the /livez response.
+type LivenessStatus struct {
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+}
+
+// ReadinessStatus is the /readyz response. Status is "ready" or "unready";
+// Reasons explains an unready result.
+type ReadinessStatus struct {
+	Status        string               `json:"status"`
+	Repo          string               `json:"repo,omitempty"`
+	Reasons       []string             `json:"reasons,omitempty"`
+	UptimeSeconds int64                `json:"uptimeSeconds"`
+	Watcher       WatcherStatus        `json:"watcher"`
+	Reload        *ReloadStatus        `json:"reload,omitempty"`
+	Objects       *gitcore.ObjectStats `json:"objects,omitempty"`
+}
+
+// WatcherStatus reports whether repository changes are being detected.
+type WatcherStatus struct {
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// ReloadStatus reports the outcome of the most recent repository reload.
+type ReloadStatus struct {
+	LastSuccess     *time.Time `json:"lastSuccess,omitempty"`
+	LastDurationMs  int64      `json:"lastDurationMs"`
+	LastError       string     `json:"lastError,omitempty"`
+	InFlightSeconds float64    `json:"inFlightSeconds,omitempty"`
+	Recoveries      int        `json:"recoveries"`
+}
+
+// isProbePath reports whether path is a health probe, which orchestrators
+// call without credentials.
+func isProbePath(path string) bool {
+	return path == "/health" || path == "/livez" || path == "/readyz"
+}
+
+// handleLivez reports that the process is serving requests. It stays healthy
+// while the repository is unready, since restarting would not help.
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeProbe(w, http.StatusOK, LivenessStatus{
+		Status:        "ok",
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+	})
+}
+
+// handleReadyz reports whether the served repository is current: it loaded,
+// its last reload succeeded, no reload is stuck, and the watcher is running.
+// It returns 503 otherwise so load balancers stop routing to the instance.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := s.readiness()
+	code := http.StatusOK
+	if status.Status != "ready" {
+		code = http.StatusServiceUnavailable
+	}
+	s.writeProbe(w, code, status)
+}
+
+func (s *Server) writeProbe(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("Failed to encode probe response", "err", err)
+	}
+}
+
+func (s *Server) readiness() ReadinessStatus {
+	status := ReadinessStatus{
+		Status:        "ready",
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+		Watcher:       WatcherStatus{State: "disabled"},
+	}
+	if s.session == nil {
+		return status
+	}
+
+	if repo := s.session.Repo(); repo != nil {
+		status.Repo = repo.Name()
+	} else {
+		status.Reasons = append(status.Reasons, "repository is not loaded")
+	}
+
+	state, watchErr := s.watcherStatus()
+	status.Watcher = WatcherStatus{State: state, Error: s.probeError(watchErr)}
+	if state != watcherRunning {
+		status.Reasons = append(status.Reasons, "file watcher is "+strings.ReplaceAll(state, "_", " "))
+	}
+
+	health := s.session.Health()
+	status.UptimeSeconds = int64(time.Since(health.StartedAt).Seconds())
+	reload := &ReloadStatus{
+		LastDurationMs: health.LastReloadDuration.Milliseconds(),
+		LastError:      s.probeError(health.LastReloadError),
+		Recoveries:     health.Recoveries,
+	}
+	if !health.LastSuccess.IsZero() {
+		lastSuccess := health.LastSuccess.UTC()
+		reload.LastSuccess = &lastSuccess
+	}
+	if health.ReloadInFlight > 0 {
+		reload.InFlightSeconds = health.ReloadInFlight.Seconds()
+		if health.ReloadInFlight >= health.ReloadDeadline {
+			status.Reasons = append(status.Reasons, "repository reload is stuck")
+		}
+	}
+	if health.LastReloadError != nil {
+		status.Reasons = append(status.Reasons, "last repository reload failed")
+	}
+	status.Reload = reload
+	objects := health.Objects
+	status.Objects = &objects
+
+	if len(status.Reasons) > 0 {
+		status.Status = "unready"
+	}
+	return status
+}
+
+// probeError renders err for the unauthenticated probe endpoints. Errors can
+// embed filesystem paths, so details are withheld when authentication is on.
+func (s *Server) probeError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if s.auth != nil {
+		return "error details withheld; see server logs"
+	}
+	return err.Error()
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/auth"
+)
+
+func getReadiness(t *testing.T, handler http.Handler) (int, ReadinessStatus) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var status ReadinessStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode /readyz: %v (body %q)", err, w.Body.String())
+	}
+	return w.Code, status
+}
+
+func TestHandleLivez(t *testing.T) {
+	s := newTestServer(t)
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var status LivenessStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || status.Status != "ok" {
+		t.Fatalf("body = %q, err = %v", w.Body.String(), err)
+	}
+}
+
+func TestHandleReadyz(t *testing.T) {
+	s := newTestServer(t)
+	reloadErr := errors.New("open /srv/repo/.git/HEAD: no such file")
+	s.session.logger = silentLogger()
+	s.session.reloadFn = func() (*gitcore.Repository, error) { return nil, reloadErr }
+	handler := s.handler()
+
+	code, status := getReadiness(t, handler)
+	if code != http.StatusServiceUnavailable || !slices.Contains(status.Reasons, "file watcher is not started") {
+		t.Fatalf("before watcher: code = %d, status = %+v", code, status)
+	}
+
+	s.setWatcherState(watcherRunning, nil)
+	code, status = getReadiness(t, handler)
+	if code != http.StatusOK || status.Status != "ready" {
+		t.Fatalf("ready: code = %d, status = %+v", code, status)
+	}
+	if status.Reload == nil || status.Reload.LastSuccess == nil || status.Objects == nil {
+		t.Fatalf("ready status lacks reload or object details: %+v", status)
+	}
+
+	s.session.updateRepository()
+	code, status = getReadiness(t, handler)
+	if code != http.StatusServiceUnavailable || status.Reload.LastError != reloadErr.Error() {
+		t.Fatalf("after failed reload: code = %d, status = %+v", code, status)
+	}
+
+	// Error text can carry filesystem paths, so it is withheld once the
+	// server requires authentication. The probe itself stays public.
+	authenticator, err := auth.New(auth.Options{Providers: []auth.Provider{auth.NewTokenProvider("s3cret")}})
+	if err != nil {
+		t.Fatalf("auth.New() error = %v", err)
+	}
+	s.SetAuthenticator(authenticator)
+	code, status = getReadiness(t, s.handler())
+	if code != http.StatusServiceUnavailable || status.Reload.LastError == reloadErr.Error() {
+		t.Fatalf("with auth: code = %d, status = %+v", code, status)
+	}
+}
+
+func TestWatchdogRecoversHungReload(t *testing.T) {
+	hungRepo := gitcore.NewEmptyRepository()
+	freshRepo := gitcore.NewEmptyRepository()
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	var calls atomic.Int32
+
+	rs := NewRepoSession(SessionConfig{
+		ID:     "test",
+		Logger: silentLogger(),
+		ReloadFn: func() (*gitcore.Repository, error) {
+			if calls.Add(1) == 1 {
+				close(entered)
+				<-release
+				return hungRepo, nil
+			}
+			return freshRepo, nil
+		},
+	})
+	rs.reloadDeadline = time.Minute
+
+	hungDone := make(chan struct{})
+	go func() {
+		defer close(hungDone)
+		rs.updateRepository()
+	}()
+	<-entered
+
+	if rs.recoverHungReload(time.Now()) {
+		t.Fatal("reload abandoned before its deadline")
+	}
+	if h := rs.Health(); h.ReloadInFlight <= 0 {
+		t.Fatalf("ReloadInFlight = %v, want > 0", h.ReloadInFlight)
+	}
+	if !rs.recoverHungReload(time.Now().Add(2 * time.Minute)) {
+		t.Fatal("recoverHungReload() = false past the deadline")
+	}
+	if h := rs.Health(); !errors.Is(h.LastReloadError, errReloadDeadline) || h.Recoveries != 1 {
+		t.Fatalf("health after recovery = %+v", h)
+	}
+
+	// A new reload must not queue behind the hung one.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rs.updateRepository()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload after recovery is blocked by the hung reload")
+	}
+	if rs.Repo() != freshRepo || rs.Health().LastReloadError != nil {
+		t.Fatalf("fresh reload not applied: health = %+v", rs.Health())
+	}
+
+	// The abandoned reload finishing late must not clobber the fresh state.
+	close(release)
+	<-hungDone
+	if rs.Repo() != freshRepo {
+		t.Fatal("abandoned reload overwrote the repository")
+	}
+	if rs.Health().LastReloadError != nil {
+		t.Fatal("abandoned reload changed the recorded outcome")
+	}
+}
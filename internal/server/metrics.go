@@ -0,0 +1,205 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rybkr/gitvista/internal/auth"
+	"github.com/rybkr/gitvista/internal/metrics"
+)
+
+var (
+	reloadBuckets  = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	prewarmBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+)
+
+// Metrics holds the Prometheus instruments for a Server and the repository
+// sessions it serves. A nil *Metrics records nothing, so sessions built
+// without one (as in tests) need no special casing.
+type Metrics struct {
+	registry *metrics.Registry
+
+	requestDuration  *metrics.HistogramVec
+	reloadDuration   *metrics.HistogramVec
+	lastReload       *metrics.GaugeVec
+	broadcastDropped *metrics.CounterVec
+	prewarmDuration  *metrics.HistogramVec
+
+	mu       sync.Mutex
+	sessions []*RepoSession
+}
+
+// NewMetrics registers every GitVista metric on a fresh registry.
+func NewMetrics() *Metrics {
+	r := metrics.NewRegistry()
+	m := &Metrics{registry: r}
+
+	m.requestDuration = r.NewHistogramVec("gitvista_http_request_duration_seconds",
+		"HTTP request latency by route pattern, method, and status code. WebSocket connections are excluded.",
+		metrics.DefBuckets, "route", "method", "code")
+	m.reloadDuration = r.NewHistogramVec("gitvista_repository_reload_duration_seconds",
+		"Time taken to reload a repository from disk and broadcast the changes.",
+		reloadBuckets, "session", "result")
+	m.lastReload = r.NewGaugeVec("gitvista_repository_last_reload_timestamp_seconds",
+		"Unix time of the last successful repository reload.", "session")
+	m.broadcastDropped = r.NewCounterVec("gitvista_broadcast_dropped_total",
+		"Update messages dropped because the broadcast queue was full.", "session")
+	m.prewarmDuration = r.NewHistogramVec("gitvista_analytics_prewarm_duration_seconds",
+		"Time taken to precompute analytics for one period.",
+		prewarmBuckets, "session", "period")
+
+	r.NewGaugeFunc("gitvista_websocket_clients", "Connected WebSocket clients.", []string{"session"},
+		m.eachSession(func(rs *RepoSession, emit func(float64, ...string)) {
+			rs.clientsMu.RLock()
+			n := len(rs.clients)
+			rs.clientsMu.RUnlock()
+			emit(float64(n), rs.id)
+		}))
+	r.NewGaugeFunc("gitvista_broadcast_queue_depth", "Update messages waiting to be sent to clients.", []string{"session"},
+		m.eachSession(func(rs *RepoSession, emit func(float64, ...string)) {
+			emit(float64(len(rs.broadcast)), rs.id)
+		}))
+	r.NewGaugeFunc("gitvista_broadcast_queue_capacity", "Size of the broadcast queue.", []string{"session"},
+		m.eachSession(func(rs *RepoSession, emit func(float64, ...string)) {
+			emit(float64(cap(rs.broadcast)), rs.id)
+		}))
+
+	cacheLabels := []string{"session", "cache"}
+	r.NewGaugeFunc("gitvista_cache_entries", "Entries held in a server-side cache.", cacheLabels,
+		m.eachCache(func(stats CacheStats) float64 { return float64(stats.Entries) }))
+	r.NewGaugeFunc("gitvista_cache_bytes", "Estimated bytes held in a server-side cache.", cacheLabels,
+		m.eachCache(func(stats CacheStats) float64 { return float64(stats.Bytes) }))
+	r.NewCounterFunc("gitvista_cache_hits_total", "Cache lookups that found an entry.", cacheLabels,
+		m.eachCache(func(stats CacheStats) float64 { return float64(stats.Hits) }))
+	r.NewCounterFunc("gitvista_cache_misses_total", "Cache lookups that found nothing.", cacheLabels,
+		m.eachCache(func(stats CacheStats) float64 { return float64(stats.Misses) }))
+	r.NewCounterFunc("gitvista_cache_evictions_total", "Cache entries evicted to make room.", cacheLabels,
+		m.eachCache(func(stats CacheStats) float64 { return float64(stats.Evictions) }))
+	r.NewCounterFunc("gitvista_cache_expired_total", "Cache entries dropped after outliving their TTL.", cacheLabels,
+		m.eachCache(func(stats CacheStats) float64 { return float64(stats.Expired) }))
+
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", nil,
+		func(emit func(float64, ...string)) { emit(float64(runtime.NumGoroutine())) })
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return m.registry.Handler()
+}
+
+func (m *Metrics) trackSession(rs *RepoSession) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.sessions = append(m.sessions, rs)
+	m.mu.Unlock()
+}
+
+func (m *Metrics) eachSession(collect func(*RepoSession, func(float64, ...string))) metrics.CollectFunc {
+	return func(emit func(float64, ...string)) {
+		m.mu.Lock()
+		sessions := append([]*RepoSession(nil), m.sessions...)
+		m.mu.Unlock()
+		for _, rs := range sessions {
+			collect(rs, emit)
+		}
+	}
+}
+
+func (m *Metrics) eachCache(value func(CacheStats) float64) metrics.CollectFunc {
+	return m.eachSession(func(rs *RepoSession, emit func(float64, ...string)) {
+		if nc, ok := rs.cache.(interface{ NamespaceStats() map[string]CacheStats }); ok {
+			stats := nc.NamespaceStats()
+			for _, ns := range sortedNamespaces(stats) {
+				emit(value(stats[ns]), rs.id, ns)
+			}
+		} else {
+			emit(value(rs.cache.Stats()), rs.id, "memory")
+		}
+		if rs.diskCache != nil {
+			disk := rs.diskCache.Stats()
+			emit(value(CacheStats{
+				Hits:      disk.Hits,
+				Misses:    disk.Misses,
+				Evictions: disk.Evictions,
+				Entries:   disk.Entries,
+				Bytes:     disk.Bytes,
+			}), rs.id, "disk")
+		}
+	})
+}
+
+func (m *Metrics) observeReload(session string, elapsed time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	result := "ok"
+	switch {
+	case errors.Is(err, errReloadDeadline):
+		result = "timeout"
+	case err != nil:
+		result = "error"
+	default:
+		m.lastReload.With(session).Set(float64(time.Now().Unix()))
+	}
+	m.reloadDuration.With(session, result).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) observeBroadcastDropped(session string) {
+	if m == nil {
+		return
+	}
+	m.broadcastDropped.With(session).Inc()
+}
+
+func (m *Metrics) observePrewarm(session, period string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.prewarmDuration.With(session, period).Observe(elapsed.Seconds())
+}
+
+// instrumentRequests records request latency labelled by the mux pattern that
+// serves the path, which keeps label cardinality bounded.
+func (m *Metrics) instrumentRequests(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeLabel(mux, r)
+		if route == "/api/ws" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sr, r)
+		m.requestDuration.With(route, r.Method, strconv.Itoa(sr.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+func routeLabel(mux *http.ServeMux, r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, auth.RoutePrefix) {
+		return auth.RoutePrefix
+	}
+	if _, pattern := mux.Handler(r); pattern != "" {
+		return pattern
+	}
+	return "other"
+}
+
+// sortedNamespaces returns the namespaces in stats in a stable order.
+func sortedNamespaces(stats map[string]CacheStats) []string {
+	names := make([]string, 0, len(stats))
+	for ns := range stats {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	return names
+}
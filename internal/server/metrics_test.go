@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+)
+
+func scrapeMetrics(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("/metrics status = %d, want 200", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	repo := gitcore.NewEmptyRepository()
+	s := newTestServer(t)
+	s.session.logger = silentLogger()
+	s.session.reloadFn = func() (*gitcore.Repository, error) { return repo, nil }
+	t.Cleanup(s.session.Close)
+	handler := s.handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/tree/", nil))
+	s.session.updateRepository()
+	for range broadcastChannelSize + 1 {
+		s.session.broadcastUpdate(UpdateMessage{Type: messageTypeStatus})
+	}
+
+	body := scrapeMetrics(t, handler)
+	for _, want := range []string{
+		`gitvista_http_request_duration_seconds_count{route="/api/tree/",method="GET",code="400"} 1`,
+		`gitvista_repository_reload_duration_seconds_count{session="default",result="ok"} 1`,
+		`gitvista_broadcast_dropped_total{session="default"} 1`,
+		`gitvista_broadcast_queue_depth{session="default"} 256`,
+		`gitvista_websocket_clients{session="default"} 0`,
+		`gitvista_cache_misses_total{session="default",cache="diff"} `,
+		`gitvista_cache_bytes{session="default",cache="analytics"} `,
+		"# TYPE gitvista_cache_hits_total counter",
+		"# TYPE gitvista_analytics_prewarm_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics is missing %q", want)
+		}
+	}
+}
+
+func TestMetricsRecordsReloadErrors(t *testing.T) {
+	m := NewMetrics()
+	rs := NewRepoSession(SessionConfig{
+		ID:       "broken",
+		ReloadFn: func() (*gitcore.Repository, error) { return nil, errRepoUnavailable },
+		Logger:   silentLogger(),
+		Metrics:  m,
+	})
+	rs.updateRepository()
+
+	body := scrapeMetrics(t, m.Handler())
+	if !strings.Contains(body, `gitvista_repository_reload_duration_seconds_count{session="broken",result="error"} 1`) {
+		t.Fatalf("reload error not recorded:\n%s", body)
+	}
+	if strings.Contains(body, `gitvista_repository_last_reload_timestamp_seconds{session="broken"}`) {
+		t.Fatal("failed reload updated the last reload timestamp")
+	}
+}
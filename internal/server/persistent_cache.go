@@ -0,0 +1,55 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/rybkr/gitvista/internal/analytics"
+	"github.com/rybkr/gitvista/internal/diskcache"
+)
+
+// Keys for results derived only from immutable objects. They are shared by
+// the in-memory and on-disk caches; bump the version when a response type
+// changes shape so stale entries on disk are ignored.
+const (
+	commitDiffKeyPrefix = "commit-diff:v1:"
+	fileDiffKeyPrefix   = "file-diff:v1:"
+)
+
+// getImmutable looks key up in the session's in-memory cache and then on
+// disk, decoding disk hits as T and promoting them into memory.
+func getImmutable[T any](rs *RepoSession, key string) (any, bool) {
+	if cached, ok := rs.cache.Get(key); ok {
+		return cached, true
+	}
+	if rs.diskCache == nil {
+		return nil, false
+	}
+	var value T
+	if !rs.diskCache.GetJSON(key, &value) {
+		return nil, false
+	}
+	rs.cache.Put(key, value)
+	return value, true
+}
+
+// putImmutable caches a result derived only from immutable objects in memory
+// and on disk. Disk failures only cost a recomputation later, so they are
+// logged rather than returned.
+func (rs *RepoSession) putImmutable(key string, value any) {
+	rs.cache.Put(key, value)
+	if rs.diskCache == nil {
+		return
+	}
+	if err := rs.diskCache.PutJSON(key, value); err != nil && !errors.Is(err, diskcache.ErrTooLarge) {
+		rs.logger.Debug("Failed to write persistent cache entry", "key", key, "err", err)
+	}
+}
+
+// analyticsStore returns the disk cache as an analytics week store. It returns
+// a nil interface, not a nil *diskcache.Cache, when the session has none.
+func (rs *RepoSession) analyticsStore() analytics.Store {
+	if rs.diskCache == nil {
+		return nil
+	}
+	return rs.diskCache
+}
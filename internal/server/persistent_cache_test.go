@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/diskcache"
+)
+
+func TestCommitDiffSurvivesRestartViaDiskCache(t *testing.T) {
+	repo := newGitFixtureRepo(t, map[string]string{"a.txt": "one\n"})
+	head := string(repo.Head())
+	dir := t.TempDir()
+	s := newTestServer(t)
+
+	get := func(session *RepoSession, target string) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleCommitDiff(w, requestWithSession("GET", target, session))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, body = %s", target, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	targets := []string{
+		"/api/commit/diff/" + head,
+		"/api/commit/diff/" + head + "/file?path=a.txt",
+	}
+
+	first, err := diskcache.Open(dir, diskcache.Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	session := newDiskCacheSession(repo, first)
+	before := first.Stats()
+	want := make([]string, len(targets))
+	for i, target := range targets {
+		want[i] = get(session, target)
+	}
+	if stats := first.Stats(); stats.Entries != before.Entries+len(targets) {
+		t.Fatalf("disk cache stats = %+v, want %d new entries over %+v", stats, len(targets), before)
+	}
+
+	// A restarted server starts with an empty memory cache and reads the
+	// diffs back from disk.
+	second, err := diskcache.Open(dir, diskcache.Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	restarted := newDiskCacheSession(repo, second)
+	before = second.Stats()
+	for i, target := range targets {
+		if got := get(restarted, target); got != want[i] {
+			t.Fatalf("GET %s after restart = %s, want %s", target, got, want[i])
+		}
+	}
+	if stats := second.Stats(); stats.Hits != before.Hits+uint64(len(targets)) {
+		t.Fatalf("disk cache stats after restart = %+v, want %d new hits over %+v", stats, len(targets), before)
+	}
+}
+
+// newDiskCacheSession returns a session backed by cache once its analytics
+// prewarm, which also reads and writes cache, has finished.
+func newDiskCacheSession(repo *gitcore.Repository, cache *diskcache.Cache) *RepoSession {
+	session := NewRepoSession(SessionConfig{
+		ID:          "test",
+		InitialRepo: repo,
+		ReloadFn:    func() (*gitcore.Repository, error) { return repo, nil },
+		Logger:      silentLogger(),
+		DiskCache:   cache,
+	})
+	session.wg.Wait()
+	return session
+}
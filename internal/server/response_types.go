@@ -28,6 +28,12 @@ type blobResponse struct {
 	Content   string `json:"content"`
 }
 
+type describeResponse struct {
+	Hash     string `json:"hash"`
+	Describe string `json:"describe"`
+	Contains string `json:"contains"`
+}
+
 type commitDiffEntryResponse struct {
 	Path    string `json:"path"`
 	OldPath string `json:"oldPath,omitempty"`
@@ -62,6 +68,30 @@ type diffFileResponse struct {
 	Hunks     []gitcore.DiffHunk `json:"hunks"`
 }
 
+type grepStreamRecord struct {
+	Type      string                  `json:"type"`
+	File      *gitcore.GrepFileResult `json:"file,omitempty"`
+	Files     int                     `json:"files,omitempty"`
+	Truncated bool                    `json:"truncated,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+}
+
+type pickaxeStreamRecord struct {
+	Type      string       `json:"type"`
+	Hash      gitcore.Hash `json:"hash,omitempty"`
+	Scanned   int          `json:"scanned,omitempty"`
+	Total     int          `json:"total,omitempty"`
+	Matches   int          `json:"matches,omitempty"`
+	Truncated bool         `json:"truncated,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
 type graphCommitsResponse struct {
 	Commits []*gitcore.Commit `json:"commits"`
 }
+
+type compareResponse struct {
+	BaseRef string `json:"baseRef"`
+	HeadRef string `json:"headRef"`
+	*gitcore.Comparison
+}
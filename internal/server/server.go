@@ -4,6 +4,7 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 	"time"
 
 	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/auth"
+	"github.com/rybkr/gitvista/internal/diskcache"
 )
 
 const (
@@ -38,7 +41,17 @@ type Server struct {
 
 	session     *RepoSession
 	cacheSize   int
+	cacheBytes  int64
+	cacheTTL    time.Duration
 	extraRoutes []func(*http.ServeMux)
+	auth        *auth.Authenticator
+	tlsConfig   *tls.Config
+	metrics     *Metrics
+	startedAt   time.Time
+
+	watcherMu    sync.Mutex
+	watcherState string
+	watcherErr   error
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -57,8 +70,11 @@ func NewServer(repo *gitcore.Repository, addr string, webFS fs.FS) *Server {
 		ReloadFn: func() (*gitcore.Repository, error) {
 			return gitcore.NewRepository(repo.GitDir())
 		},
-		CacheSize: s.cacheSize,
-		Logger:    s.logger,
+		CacheSize:  s.cacheSize,
+		CacheBytes: s.cacheBytes,
+		CacheTTL:   s.cacheTTL,
+		Logger:     s.logger,
+		Metrics:    s.metrics,
 	})
 
 	return s
@@ -74,13 +90,17 @@ func newConfiguredServer(addr string, webFS fs.FS, app AppConfig) *Server {
 	}
 
 	return &Server{
-		addr:      addr,
-		webFS:     webFS,
-		app:       app,
-		logger:    slog.Default(),
-		cacheSize: cacheSize,
-		ctx:       ctx,
-		cancel:    cancel,
+		addr:       addr,
+		webFS:      webFS,
+		app:        app,
+		logger:     slog.Default(),
+		cacheSize:  cacheSize,
+		cacheBytes: readCacheBytes(),
+		cacheTTL:   readCacheTTL(),
+		metrics:    NewMetrics(),
+		startedAt:  time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -102,13 +122,54 @@ func (s *Server) AddRoutes(register func(*http.ServeMux)) {
 	s.extraRoutes = append(s.extraRoutes, register)
 }
 
+// SetAuthenticator requires every request except the health probes to be authenticated
+// by a. It must be called before Start.
+func (s *Server) SetAuthenticator(a *auth.Authenticator) {
+	s.auth = a
+}
+
+// SetTLSConfig makes the server listen for HTTPS, with HTTP/2 negotiated over
+// ALPN. The config must provide a certificate. It must be called before Start.
+func (s *Server) SetTLSConfig(cfg *tls.Config) {
+	s.tlsConfig = cfg
+}
+
+// SetDiskCache persists commit diffs and analytics inputs in c so they
+// survive restarts. It must be called before Start.
+func (s *Server) SetDiskCache(c *diskcache.Cache) {
+	if s.session != nil {
+		s.session.diskCache = c
+	}
+}
+
+// SetTuning changes the watcher, bootstrap, and cache limits. Unlike the
+// other setters it may be called while the server runs, which is how a
+// configuration reload takes effect.
+func (s *Server) SetTuning(t Tuning) {
+	if s.session != nil {
+		s.session.SetTuning(t)
+	}
+}
+
+// Repo returns the repository currently being served, which changes as the
+// session reloads it, or nil when the server has no repository session.
+func (s *Server) Repo() *gitcore.Repository {
+	if s.session == nil {
+		return nil
+	}
+	return s.session.Repo()
+}
+
 // Logger returns the server logger.
 func (s *Server) Logger() *slog.Logger {
 	return s.logger
 }
 
-// CacheSize returns the configured cache size for server-side LRU caches.
+// CacheSize returns the configured entry cap for server-side caches.
 func (s *Server) CacheSize() int {
+	if s.session != nil {
+		return s.session.Tuning().CacheEntries
+	}
 	return s.cacheSize
 }
 
@@ -123,6 +184,28 @@ func readCacheSize() int {
 	return cacheSize
 }
 
+// readCacheBytes reads the cache byte budget from the GITVISTA_CACHE_BYTES
+// env var.
+func readCacheBytes() int64 {
+	if raw := os.Getenv("GITVISTA_CACHE_BYTES"); raw != "" {
+		if n, err := ParseByteSize(raw); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultCacheBytes
+}
+
+// readCacheTTL reads the cache entry lifetime from the GITVISTA_CACHE_TTL env
+// var. Zero means entries do not expire.
+func readCacheTTL() time.Duration {
+	if raw := os.Getenv("GITVISTA_CACHE_TTL"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // Start begins serving and blocks until the server exits or encounters a fatal error.
 // Start starts the HTTP server and blocks until it exits.
 func (s *Server) Start() (err error) {
@@ -142,29 +225,28 @@ func (s *Server) Start() (err error) {
 		s.session.Start()
 	}
 
-	mux := s.newServeMux()
-	for _, register := range s.extraRoutes {
-		register(mux)
-	}
-	mux.Handle("/", s.staticHandler())
-
-	handler := requestLogger(s.logger, mux)
-	handler = securityHeadersMiddleware(handler)
-
-	s.httpServer = s.newHTTPServer(handler)
+	s.httpServer = s.newHTTPServer(s.handler())
 
 	if s.session != nil {
+		s.setWatcherState(watcherStarting, nil)
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
 			if err := s.startWatcher(); err != nil {
+				s.setWatcherState(watcherFailed, err)
 				s.logger.Error("watcher error", "err", err)
 			}
 		}()
 	}
 
-	s.logger.Info("GitVista server starting", "addr", "http://"+s.addr)
-	err = s.httpServer.ListenAndServe()
+	if s.tlsConfig != nil {
+		s.logger.Info("GitVista server starting", "addr", "https://"+s.addr)
+		// Certificates come from TLSConfig, so no files are named here.
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		s.logger.Info("GitVista server starting", "addr", "http://"+s.addr)
+		err = s.httpServer.ListenAndServe()
+	}
 	if err == http.ErrServerClosed {
 		started = true
 		return nil
@@ -175,10 +257,42 @@ func (s *Server) Start() (err error) {
 	return err
 }
 
+// handler assembles the full middleware chain around the route mux.
+func (s *Server) handler() http.Handler {
+	mux := s.newServeMux()
+	for _, register := range s.extraRoutes {
+		register(mux)
+	}
+	mux.Handle("/", s.staticHandler())
+
+	var handler http.Handler = mux
+	if s.auth != nil {
+		// Wrapping the whole mux also covers the /api/ws upgrade request.
+		protected := s.auth.Wrap(mux)
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isProbePath(r.URL.Path) {
+				mux.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+	if s.metrics != nil {
+		handler = s.metrics.instrumentRequests(mux, handler)
+	}
+	handler = requestLogger(s.logger, handler)
+	return securityHeadersMiddleware(handler)
+}
+
 func (s *Server) newServeMux() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/livez", s.handleLivez)
+	mux.HandleFunc("/readyz", s.handleReadyz)
 	mux.HandleFunc("/api/config", s.handleConfig)
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics.Handler())
+	}
 
 	if s.session == nil {
 		return mux
@@ -189,8 +303,19 @@ func (s *Server) newServeMux() *http.ServeMux {
 	mux.HandleFunc("/api/tree/", writeDeadline(withSession(session, s.handleTree)))
 	mux.HandleFunc("/api/blob/", writeDeadline(withSession(session, s.handleBlob)))
 	mux.HandleFunc("/api/commit/diff/", writeDeadline(withSession(session, s.handleCommitDiff)))
+	mux.HandleFunc("/api/commit/describe/", writeDeadline(withSession(session, s.handleCommitDescribe)))
 	mux.HandleFunc("/api/commits/diffstats", writeDeadline(withSession(session, s.handleBulkDiffStats)))
+	mux.HandleFunc("/api/grep", writeDeadline(withSession(session, s.handleGrep)))
+	mux.HandleFunc("/api/pickaxe", writeDeadline(withSession(session, s.handlePickaxe)))
+	mux.HandleFunc("/api/search", writeDeadline(withSession(session, s.handleSearch)))
+	mux.HandleFunc("/api/compare", writeDeadline(withSession(session, s.handleCompare)))
 	mux.HandleFunc("/api/analytics", writeDeadline(withSession(session, s.handleAnalytics)))
+	mux.HandleFunc("/api/releases", writeDeadline(withSession(session, s.handleReleases)))
+	mux.HandleFunc("/api/composition", writeDeadline(withSession(session, s.handleComposition)))
+	mux.HandleFunc("/api/changelog", writeDeadline(withSession(session, s.handleChangelog)))
+	mux.HandleFunc("/api/ownership", writeDeadline(withSession(session, s.handleOwnership)))
+	mux.HandleFunc("/api/codeowners", writeDeadline(withSession(session, s.handleCodeOwners)))
+	mux.HandleFunc("/api/codeowners/drift", writeDeadline(withSession(session, s.handleCodeOwnersDrift)))
 	mux.HandleFunc("/api/index/diff", writeDeadline(withSession(session, s.handleIndexDiff)))
 	mux.HandleFunc("/api/working-tree/diff", writeDeadline(withSession(session, s.handleWorkingTreeDiff)))
 	mux.HandleFunc("/api/graph/summary", writeDeadline(withSession(session, s.handleGraphSummary)))
@@ -268,7 +393,7 @@ func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
 	// WriteTimeout must remain 0 because WebSocket connections are long-lived.
 	// Non-WebSocket handlers enforce per-response write deadlines via the
 	// writeDeadline middleware applied at the route level.
-	return &http.Server{
+	srv := &http.Server{
 		Addr:              s.addr,
 		Handler:           handler,
 		ReadHeaderTimeout: readHeaderTimeout,
@@ -277,6 +402,15 @@ func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
 		IdleTimeout:       120 * time.Second,
 		MaxHeaderBytes:    maxHeaderBytes,
 	}
+	if s.tlsConfig != nil {
+		// Browsers still open the WebSocket over HTTP/1.1, so both stay enabled.
+		srv.TLSConfig = s.tlsConfig.Clone()
+		srv.TLSConfig.MinVersion = max(srv.TLSConfig.MinVersion, tls.VersionTLS12)
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+	}
+	return srv
 }
 
 // Shutdown gracefully shuts down the server and its background work.
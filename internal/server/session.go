@@ -2,19 +2,24 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/diskcache"
 	"github.com/rybkr/gitvista/internal/repositoryview"
+	"github.com/rybkr/gitvista/internal/search"
 )
 
 const (
 	broadcastChannelSize = 256
 )
 
+// Defaults for the bootstrap and force-mode fields of Tuning.
 const (
 	initialBootstrapWindowDays  = 30
 	bootstrapFirstBatchTarget   = 450 * 1024 // bytes (estimated JSON payload)
@@ -27,19 +32,37 @@ const (
 	forceModeMaxCommits = 10000
 )
 
+const (
+	// defaultReloadDeadline bounds how long a repository reload may run
+	// before the watchdog abandons it and starts a fresh one.
+	defaultReloadDeadline = 60 * time.Second
+	watchdogInterval      = 5 * time.Second
+)
+
+var errReloadDeadline = errors.New("repository reload exceeded deadline")
+
 // ReloadFunc returns a freshly-loaded repository, used by updateRepository to reload state from disk.
 type ReloadFunc func() (*gitcore.Repository, error)
 
 // RepoSession holds per-repository state. Each session manages its own cached repository, WebSocket
-// clients, broadcast channel, and LRU caches.
+// clients, broadcast channel, and response cache.
 type RepoSession struct {
 	id       string
 	logger   *slog.Logger
 	reloadFn ReloadFunc
 
-	updateMu sync.Mutex
-	cacheMu  sync.RWMutex
-	cached   struct {
+	// reloadMu guards the reload gate and health fields. Reloads serialize on
+	// reloadGate; a hung reload keeps holding its gate, so the watchdog swaps
+	// in a fresh one rather than leaving later reloads queued behind it.
+	reloadMu       sync.Mutex
+	reloadGate     *sync.Mutex
+	reloadSeq      uint64
+	activeReload   uint64
+	reloadDeadline time.Duration
+	health         sessionHealth
+
+	cacheMu sync.RWMutex
+	cached  struct {
 		repo *gitcore.Repository
 	}
 
@@ -48,7 +71,10 @@ type RepoSession struct {
 
 	broadcast chan UpdateMessage
 
-	diffCache *LRUCache[any]
+	cache     Cache
+	diskCache *diskcache.Cache
+	metrics   *Metrics
+	tuning    atomic.Pointer[Tuning]
 
 	ctx      context.Context
 	cancel   context.CancelFunc
@@ -57,6 +83,40 @@ type RepoSession struct {
 
 	analyticsMu  sync.Mutex
 	analyticsGen uint64
+
+	// builds tracks the background builds started by cachedOrBuild.
+	buildsMu sync.Mutex
+	builds   map[string]*backgroundBuild
+
+	// searchIdx holds the search order of the current repository so paging
+	// through results does not re-sort the whole history.
+	searchIdx atomic.Pointer[search.Index]
+}
+
+// sessionHealth is the reload bookkeeping behind SessionHealth.
+type sessionHealth struct {
+	startedAt     time.Time
+	lastSuccess   time.Time
+	lastDuration  time.Duration
+	lastErr       error
+	inFlightSince time.Time
+	recoveries    int
+	objects       gitcore.ObjectStats
+}
+
+// SessionHealth is a point-in-time view of a session's reload state.
+type SessionHealth struct {
+	StartedAt          time.Time
+	LastSuccess        time.Time
+	LastReloadDuration time.Duration
+	LastReloadError    error
+	// ReloadInFlight is how long the current reload has been running, or
+	// zero when none is.
+	ReloadInFlight time.Duration
+	ReloadDeadline time.Duration
+	// Recoveries counts reloads the watchdog abandoned.
+	Recoveries int
+	Objects    gitcore.ObjectStats
 }
 
 // SessionConfig holds initialization parameters for a RepoSession.
@@ -64,8 +124,24 @@ type SessionConfig struct {
 	ID          string
 	InitialRepo *gitcore.Repository
 	ReloadFn    ReloadFunc
-	CacheSize   int
-	Logger      *slog.Logger
+	// CacheSize caps the number of cached responses.
+	CacheSize int
+	// CacheBytes is the memory budget for cached responses, split between
+	// the diff, analytics, and tree namespaces. Zero means 256 MiB.
+	CacheBytes int64
+	// CacheTTL expires cached responses after this long. Zero means never.
+	CacheTTL time.Duration
+	Logger   *slog.Logger
+	// Tuning sets the watcher, bootstrap, and force-mode limits; the zero
+	// value means DefaultTuning. Its cache fields are ignored in favor of
+	// CacheSize, CacheBytes, and CacheTTL.
+	Tuning Tuning
+	// DiskCache, when set, persists commit diffs and analytics inputs across
+	// restarts. It may be shared with other sessions and processes.
+	DiskCache *diskcache.Cache
+	// Metrics, when set, receives the session's reload, broadcast, cache,
+	// and prewarm measurements.
+	Metrics *Metrics
 }
 
 // NewRepoSession constructs a RepoSession ready to be started.
@@ -84,12 +160,35 @@ func NewRepoSession(cfg SessionConfig) *RepoSession {
 		logger:    cfg.Logger.With("session", cfg.ID),
 		reloadFn:  cfg.ReloadFn,
 		clients:   make(map[*websocket.Conn]*sync.Mutex),
+		builds:    make(map[string]*backgroundBuild),
 		broadcast: make(chan UpdateMessage, broadcastChannelSize),
-		diffCache: NewLRUCache[any](cfg.CacheSize),
+		cache: NewWeightedCache(WeightedCacheOptions{
+			MaxBytes:   cfg.CacheBytes,
+			MaxEntries: cfg.CacheSize,
+			TTL:        cfg.CacheTTL,
+		}),
+		diskCache: cfg.DiskCache,
+		metrics:   cfg.Metrics,
 		ctx:       ctx,
 		cancel:    cancel,
+
+		reloadGate:     new(sync.Mutex),
+		reloadDeadline: defaultReloadDeadline,
+	}
+	tuning := cfg.Tuning
+	if tuning == (Tuning{}) {
+		tuning = DefaultTuning()
 	}
+	tuning.CacheEntries, tuning.CacheBytes, tuning.CacheTTL = cfg.CacheSize, cfg.CacheBytes, cfg.CacheTTL
+	tuning = tuning.withDefaults()
+	rs.tuning.Store(&tuning)
 	rs.cached.repo = cfg.InitialRepo
+	rs.health.startedAt = time.Now()
+	if cfg.InitialRepo != nil {
+		rs.health.lastSuccess = rs.health.startedAt
+		rs.health.objects = cfg.InitialRepo.ObjectStats()
+	}
+	rs.metrics.trackSession(rs)
 	rs.scheduleAnalyticsPrewarm(cfg.InitialRepo)
 
 	return rs
@@ -103,10 +202,30 @@ func (rs *RepoSession) Repo() *gitcore.Repository {
 	return repo
 }
 
-// Start launches the session broadcast goroutine.
+// Start launches the session broadcast and reload watchdog goroutines.
 func (rs *RepoSession) Start() {
-	rs.wg.Add(1)
+	rs.wg.Add(2)
 	go rs.handleBroadcast()
+	go rs.watchReloads()
+}
+
+// Health returns the session's reload state.
+func (rs *RepoSession) Health() SessionHealth {
+	rs.reloadMu.Lock()
+	defer rs.reloadMu.Unlock()
+	h := SessionHealth{
+		StartedAt:          rs.health.startedAt,
+		LastSuccess:        rs.health.lastSuccess,
+		LastReloadDuration: rs.health.lastDuration,
+		LastReloadError:    rs.health.lastErr,
+		ReloadDeadline:     rs.reloadDeadline,
+		Recoveries:         rs.health.recoveries,
+		Objects:            rs.health.objects,
+	}
+	if !rs.health.inFlightSince.IsZero() {
+		h.ReloadInFlight = time.Since(rs.health.inFlightSince)
+	}
+	return h
 }
 
 // Close shuts down the session, waits for goroutines, sends
@@ -150,13 +269,14 @@ func (rs *RepoSession) Close() {
 }
 
 // updateRepository reloads repository state and broadcasts changes to clients.
-// Serialized via updateMu to prevent concurrent reloads from computing
-// incorrect deltas against a stale oldRepo.
+// Reloads are serialized on the reload gate to prevent concurrent reloads from
+// computing incorrect deltas against a stale oldRepo.
 func (rs *RepoSession) updateRepository() {
-	rs.updateMu.Lock()
-	defer rs.updateMu.Unlock()
+	gate, gen := rs.acquireReload()
+	defer gate.Unlock()
 
 	rs.logger.Debug("Updating repository")
+	start := time.Now()
 
 	rs.cacheMu.RLock()
 	oldRepo := rs.cached.repo
@@ -164,6 +284,7 @@ func (rs *RepoSession) updateRepository() {
 
 	newRepo, err := rs.reloadFn()
 	if err != nil {
+		rs.finishReload(gen, time.Since(start), err, nil)
 		rs.logger.Error("Failed to reload repository", "err", err)
 		return
 	}
@@ -175,10 +296,22 @@ func (rs *RepoSession) updateRepository() {
 		delta = repositoryview.DiffRepositories(newRepo, gitcore.NewEmptyRepository())
 	}
 
+	// The swap happens under reloadMu so a reload the watchdog has abandoned
+	// can never overwrite the state of the reload that replaced it.
+	rs.reloadMu.Lock()
+	if rs.activeReload != gen {
+		rs.reloadMu.Unlock()
+		rs.logger.Warn("Discarding result of abandoned repository reload", "elapsed", time.Since(start).Round(time.Millisecond))
+		return
+	}
 	rs.cacheMu.Lock()
 	rs.cached.repo = newRepo
 	rs.cacheMu.Unlock()
-	rs.diffCache.Clear()
+	rs.reloadMu.Unlock()
+
+	objects := newRepo.ObjectStats()
+	defer func() { rs.finishReload(gen, time.Since(start), nil, &objects) }()
+	rs.cache.Clear()
 	rs.scheduleAnalyticsPrewarm(newRepo)
 
 	status := getWorkingTreeStatus(newRepo)
@@ -212,6 +345,97 @@ func (rs *RepoSession) updateRepository() {
 	}
 }
 
+// acquireReload waits for the current reload gate and registers a new active
+// reload. If the watchdog replaced the gate while waiting, it retries on the
+// new one.
+func (rs *RepoSession) acquireReload() (*sync.Mutex, uint64) {
+	for {
+		rs.reloadMu.Lock()
+		gate := rs.reloadGate
+		rs.reloadMu.Unlock()
+
+		gate.Lock()
+		rs.reloadMu.Lock()
+		if gate == rs.reloadGate {
+			rs.reloadSeq++
+			rs.activeReload = rs.reloadSeq
+			rs.health.inFlightSince = time.Now()
+			gen := rs.activeReload
+			rs.reloadMu.Unlock()
+			return gate, gen
+		}
+		rs.reloadMu.Unlock()
+		gate.Unlock()
+	}
+}
+
+// finishReload records the outcome of reload gen unless the watchdog already
+// abandoned it.
+func (rs *RepoSession) finishReload(gen uint64, elapsed time.Duration, err error, objects *gitcore.ObjectStats) {
+	rs.reloadMu.Lock()
+	defer rs.reloadMu.Unlock()
+	if rs.activeReload != gen {
+		return
+	}
+	rs.activeReload = 0
+	rs.health.inFlightSince = time.Time{}
+	rs.health.lastDuration = elapsed
+	rs.health.lastErr = err
+	if err == nil {
+		rs.health.lastSuccess = time.Now()
+	}
+	if objects != nil {
+		rs.health.objects = *objects
+	}
+	rs.metrics.observeReload(rs.id, elapsed, err)
+}
+
+// watchReloads periodically checks for a reload stuck past its deadline.
+func (rs *RepoSession) watchReloads() {
+	defer rs.wg.Done()
+
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if rs.recoverHungReload(now) {
+				// Like watcher-triggered reloads this runs outside wg, so a
+				// reload that hangs again cannot block Close.
+				go rs.updateRepository()
+			}
+		}
+	}
+}
+
+// recoverHungReload abandons the active reload if it has exceeded the
+// deadline, and reports whether it did. The hung goroutine cannot be stopped,
+// but its result will be discarded and it no longer blocks later reloads.
+func (rs *RepoSession) recoverHungReload(now time.Time) bool {
+	rs.reloadMu.Lock()
+	defer rs.reloadMu.Unlock()
+	if rs.activeReload == 0 || rs.health.inFlightSince.IsZero() {
+		return false
+	}
+	elapsed := now.Sub(rs.health.inFlightSince)
+	if elapsed < rs.reloadDeadline {
+		return false
+	}
+
+	rs.activeReload = 0
+	rs.reloadGate = new(sync.Mutex)
+	rs.health.inFlightSince = time.Time{}
+	rs.health.lastErr = errReloadDeadline
+	rs.health.recoveries++
+	rs.metrics.observeReload(rs.id, elapsed, errReloadDeadline)
+	rs.logger.Error("Repository reload hung; abandoning it and reloading again",
+		"elapsed", elapsed.Round(time.Millisecond), "deadline", rs.reloadDeadline)
+	return true
+}
+
 func upstreamTrackingEqual(a, b *gitcore.UpstreamTracking) bool {
 	if a == nil || b == nil {
 		return a == b
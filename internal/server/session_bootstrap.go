@@ -16,7 +16,8 @@ func (rs *RepoSession) broadcastInitialBootstrap(
 	status *WorkingTreeStatus,
 	headInfo *HeadInfo,
 ) {
-	messages := buildBootstrapMessages(delta)
+	tuning := rs.Tuning()
+	messages := buildBootstrapMessages(delta, tuning)
 	if len(messages) == 0 {
 		rs.broadcastUpdate(UpdateMessage{Type: messageTypeGraphDelta, Delta: delta, Status: status, Head: headInfo})
 		return
@@ -30,7 +31,7 @@ func (rs *RepoSession) broadcastInitialBootstrap(
 	for i, msg := range messages {
 		rs.broadcastUpdate(msg)
 		if i < len(messages)-1 {
-			time.Sleep(bootstrapBatchPause)
+			time.Sleep(tuning.BootstrapBatchPause)
 		}
 	}
 	if status != nil {
@@ -41,11 +42,11 @@ func (rs *RepoSession) broadcastInitialBootstrap(
 	}
 }
 
-func buildBootstrapMessages(delta *repositoryview.RepositoryDelta) []UpdateMessage {
+func buildBootstrapMessages(delta *repositoryview.RepositoryDelta, tuning Tuning) []UpdateMessage {
 	if delta == nil {
 		return nil
 	}
-	batches := planInitialCommitBatches(delta)
+	batches := planInitialCommitBatches(delta, tuning)
 	if len(batches) == 0 {
 		return []UpdateMessage{{
 			Type: messageTypeBootstrapComplete,
@@ -90,7 +91,7 @@ func buildBootstrapMessages(delta *repositoryview.RepositoryDelta) []UpdateMessa
 	return messages
 }
 
-func planInitialCommitBatches(delta *repositoryview.RepositoryDelta) [][]*gitcore.Commit {
+func planInitialCommitBatches(delta *repositoryview.RepositoryDelta, tuning Tuning) [][]*gitcore.Commit {
 	if delta == nil || len(delta.AddedCommits) == 0 {
 		return nil
 	}
@@ -141,7 +142,7 @@ func planInitialCommitBatches(delta *repositoryview.RepositoryDelta) [][]*gitcor
 
 	if targetCommit != nil {
 		targetSec := targetCommit.Committer.When.Unix()
-		windowSec := int64(initialBootstrapWindowDays * 24 * 60 * 60)
+		windowSec := int64(tuning.BootstrapWindowDays * 24 * 60 * 60)
 		for _, c := range ordered {
 			if c != nil && absInt64(c.Committer.When.Unix()-targetSec) <= windowSec {
 				priority[c.ID] = struct{}{}
@@ -151,7 +152,7 @@ func planInitialCommitBatches(delta *repositoryview.RepositoryDelta) [][]*gitcor
 
 	priorityOrdered := make([]*gitcore.Commit, 0, len(priority))
 	remaining := make([]*gitcore.Commit, 0, len(ordered)-len(priority))
-	lightweightRemaining := len(ordered) > forceModeMaxCommits
+	lightweightRemaining := len(ordered) > tuning.ForceModeMaxCommits
 	for _, c := range ordered {
 		if c == nil {
 			continue
@@ -163,19 +164,19 @@ func planInitialCommitBatches(delta *repositoryview.RepositoryDelta) [][]*gitcor
 		remaining = append(remaining, makeBootstrapCommit(c, lightweightRemaining))
 	}
 
-	batches := make([][]*gitcore.Commit, 0, int(math.Ceil(float64(len(ordered))/float64(bootstrapMaxCommitsPerBatch)))+1)
+	batches := make([][]*gitcore.Commit, 0, int(math.Ceil(float64(len(ordered))/float64(tuning.BootstrapMaxCommitsPerBatch)))+1)
 	appendBatches := func(commits []*gitcore.Commit, firstTarget int, defaultTarget int) {
 		if len(commits) == 0 {
 			return
 		}
 		target := firstTarget
-		batch := make([]*gitcore.Commit, 0, bootstrapMaxCommitsPerBatch)
+		batch := make([]*gitcore.Commit, 0, tuning.BootstrapMaxCommitsPerBatch)
 		estimated := 0
 		for _, c := range commits {
 			size := estimateBootstrapCommitSize(c)
-			if len(batch) > 0 && (estimated+size > target || len(batch) >= bootstrapMaxCommitsPerBatch) {
+			if len(batch) > 0 && (estimated+size > target || len(batch) >= tuning.BootstrapMaxCommitsPerBatch) {
 				batches = append(batches, batch)
-				batch = make([]*gitcore.Commit, 0, bootstrapMaxCommitsPerBatch)
+				batch = make([]*gitcore.Commit, 0, tuning.BootstrapMaxCommitsPerBatch)
 				estimated = 0
 				target = defaultTarget
 			}
@@ -187,8 +188,8 @@ func planInitialCommitBatches(delta *repositoryview.RepositoryDelta) [][]*gitcor
 		}
 	}
 
-	appendBatches(priorityOrdered, bootstrapFirstBatchTarget, bootstrapBatchTarget)
-	appendBatches(remaining, bootstrapBatchTarget, bootstrapBatchTarget)
+	appendBatches(priorityOrdered, tuning.BootstrapFirstBatchBytes, tuning.BootstrapBatchBytes)
+	appendBatches(remaining, tuning.BootstrapBatchBytes, tuning.BootstrapBatchBytes)
 	return batches
 }
 
@@ -303,22 +304,25 @@ func (rs *RepoSession) scheduleAnalyticsPrewarm(repo *gitcore.Repository) {
 			}
 
 			key := analytics.CacheKey(repo, period)
-			if _, ok := rs.diffCache.Get(key); ok {
+			if _, ok := rs.cache.Get(key); ok {
 				continue
 			}
 
+			start := time.Now()
 			response, err := analytics.Build(repo, analytics.Query{
 				Period:   period,
 				CacheKey: period,
+				Store:    rs.analyticsStore(),
 			})
 			if err != nil {
 				rs.logger.Warn("Analytics prewarm failed", "period", period, "err", err)
 				continue
 			}
+			rs.metrics.observePrewarm(rs.id, period, time.Since(start))
 			if !rs.analyticsGenCurrent(gen) {
 				return
 			}
-			rs.diffCache.Put(key, response)
+			rs.cache.Put(key, response)
 		}
 	}(repo, gen)
 }
@@ -51,7 +51,7 @@ func TestBuildBootstrapMessages_AppendsCompletionPayload(t *testing.T) {
 		Tags:          map[string]string{"v1.0.0": string(hash)},
 	}
 
-	messages := buildBootstrapMessages(delta)
+	messages := buildBootstrapMessages(delta, DefaultTuning())
 	if len(messages) != 2 {
 		t.Fatalf("message count = %d, want 2", len(messages))
 	}
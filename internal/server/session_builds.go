@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// backgroundBuildWait is how long a request waits on a background build
+	// before answering 202 Accepted, so small repositories still answer in
+	// one round trip.
+	backgroundBuildWait = 2 * time.Second
+	// backgroundBuildRetryAfter is the Retry-After sent with 202 Accepted.
+	backgroundBuildRetryAfter = 2 * time.Second
+)
+
+// errBuildPending reports that a background build is still running.
+var errBuildPending = errors.New("build pending")
+
+// backgroundBuild is one run of a build started by cachedOrBuild. value and
+// err are written before done is closed.
+type backgroundBuild struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// cachedOrBuild returns the value cached under key. On a miss it starts build
+// in the background, at most once per key, and waits up to wait for it; if
+// the build is still running it returns errBuildPending and the result is
+// cached for a later request. A failed build is reported to the next request
+// for key, and the request after that starts a fresh attempt.
+func (rs *RepoSession) cachedOrBuild(key string, wait time.Duration, build func() (any, error)) (any, error) {
+	if cached, ok := rs.cache.Get(key); ok {
+		return cached, nil
+	}
+	if err := rs.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rs.buildsMu.Lock()
+	b, running := rs.builds[key]
+	if !running {
+		b = &backgroundBuild{done: make(chan struct{})}
+		rs.builds[key] = b
+		rs.wg.Add(1)
+		go rs.runBackgroundBuild(key, b, build)
+	}
+	rs.buildsMu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-b.done:
+	case <-timer.C:
+		return nil, errBuildPending
+	}
+	if b.err != nil {
+		rs.buildsMu.Lock()
+		if rs.builds[key] == b {
+			delete(rs.builds, key)
+		}
+		rs.buildsMu.Unlock()
+		return nil, b.err
+	}
+	return b.value, nil
+}
+
+// runBackgroundBuild runs build for cachedOrBuild. A successful result is
+// cached and the build forgotten; a failure stays recorded until a request
+// collects it.
+func (rs *RepoSession) runBackgroundBuild(key string, b *backgroundBuild, build func() (any, error)) {
+	defer rs.wg.Done()
+	b.value, b.err = build()
+	if b.err == nil {
+		rs.cache.Put(key, b.value)
+		rs.buildsMu.Lock()
+		delete(rs.builds, key)
+		rs.buildsMu.Unlock()
+	}
+	close(b.done)
+}
+
+// writeBuildPending answers 202 Accepted while a background build runs.
+// Clients retry after the Retry-After delay.
+func writeBuildPending(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(backgroundBuildRetryAfter/time.Second)))
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "building"})
+}
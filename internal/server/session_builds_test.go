@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCachedOrBuild(t *testing.T) {
+	session := newTestSession(nil)
+	defer session.Close()
+
+	release := make(chan struct{})
+	builds := 0
+	build := func() (any, error) {
+		builds++
+		<-release
+		return "model", nil
+	}
+
+	if _, err := session.cachedOrBuild("k", 0, build); !errors.Is(err, errBuildPending) {
+		t.Fatalf("first call error = %v, want errBuildPending", err)
+	}
+	if _, err := session.cachedOrBuild("k", 0, build); !errors.Is(err, errBuildPending) {
+		t.Fatalf("second call error = %v, want errBuildPending", err)
+	}
+	close(release)
+	got, err := session.cachedOrBuild("k", time.Second, build)
+	if err != nil || got != "model" {
+		t.Fatalf("cachedOrBuild() = %v, %v; want model", got, err)
+	}
+	if got, err := session.cachedOrBuild("k", 0, build); err != nil || got != "model" || builds != 1 {
+		t.Fatalf("cached call = %v, %v after %d builds; want model after 1", got, err, builds)
+	}
+
+	// A failure reaches one request; the next one retries.
+	failing := func() (any, error) { return nil, errors.New("boom") }
+	if _, err := session.cachedOrBuild("bad", time.Second, failing); err == nil || errors.Is(err, errBuildPending) {
+		t.Fatalf("failing build error = %v, want boom", err)
+	}
+	if got, err := session.cachedOrBuild("bad", time.Second, func() (any, error) { return 1, nil }); err != nil || got != 1 {
+		t.Fatalf("retry = %v, %v; want 1", got, err)
+	}
+}
+
+func TestWriteBuildPending(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeBuildPending(w)
+	if w.Code != http.StatusAccepted || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("status = %d, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
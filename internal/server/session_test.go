@@ -37,8 +37,8 @@ func TestNewRepoSession(t *testing.T) {
 	if rs.broadcast == nil {
 		t.Error("broadcast channel is nil")
 	}
-	if rs.diffCache == nil {
-		t.Error("diffCache is nil")
+	if rs.cache == nil {
+		t.Error("cache is nil")
 	}
 	if rs.ctx == nil {
 		t.Error("ctx is nil")
@@ -103,8 +103,8 @@ func TestRepoSession_DefaultCacheSize(t *testing.T) {
 		// CacheSize: 0 — should default to defaultCacheSize
 	})
 
-	if rs.diffCache == nil {
-		t.Error("diffCache was not initialized with default size")
+	if rs.cache == nil {
+		t.Error("cache was not initialized with default size")
 	}
 }
 
@@ -131,11 +131,11 @@ func TestRepoSession_UpdateRepositoryClearsCaches(t *testing.T) {
 		Logger:      silentLogger(),
 	})
 
-	rs.diffCache.Put("diff", "value")
+	rs.cache.Put("diff", "value")
 
 	rs.updateRepository()
 
-	if _, ok := rs.diffCache.Get("diff"); ok {
+	if _, ok := rs.cache.Get("diff"); ok {
 		t.Fatal("expected stale diff cache entry to be cleared")
 	}
 }
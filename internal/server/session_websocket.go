@@ -137,6 +137,7 @@ func (rs *RepoSession) broadcastUpdate(message UpdateMessage) {
 	select {
 	case rs.broadcast <- message:
 	default:
+		rs.metrics.observeBroadcastDropped(rs.id)
 		rs.logger.Warn("Broadcast channel full, dropping message; clients may be slow")
 	}
 }
@@ -173,7 +174,7 @@ func (rs *RepoSession) sendInitialBootstrap(conn *websocket.Conn) error {
 	}
 
 	delta := repositoryview.DiffRepositories(repo, gitcore.NewEmptyRepository())
-	for _, msg := range buildBootstrapMessages(delta) {
+	for _, msg := range buildBootstrapMessages(delta, rs.Tuning()) {
 		if err := rs.sendMessage(conn, msg); err != nil {
 			return err
 		}
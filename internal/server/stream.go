@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ndjsonStream writes newline-delimited JSON records, flushing after each one.
+// Every write pushes the response write deadline forward, so long-running
+// scans stay within writeDeadline as long as they keep producing output.
+type ndjsonStream struct {
+	w   http.ResponseWriter
+	r   *http.Request
+	rc  *http.ResponseController
+	enc *json.Encoder
+}
+
+func newNDJSONStream(w http.ResponseWriter, r *http.Request) *ndjsonStream {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	return &ndjsonStream{
+		w:   w,
+		r:   r,
+		rc:  http.NewResponseController(w),
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Send writes one record. It fails once the client has gone away, which lets
+// producers use the error to abandon work nobody is waiting for.
+func (s *ndjsonStream) Send(v any) error {
+	if err := s.r.Context().Err(); err != nil {
+		return err
+	}
+	_ = s.rc.SetWriteDeadline(time.Now().Add(apiWriteDeadline))
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	_ = s.rc.Flush()
+	return nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/tlscert"
+)
+
+func TestServer_TLSServesHTTP2AndWebSocket(t *testing.T) {
+	dir := t.TempDir()
+	cert, err := tlscert.SelfSigned(dir, []string{"127.0.0.1"}, time.Now())
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	caPEM, err := os.ReadFile(tlscert.CAPath(dir))
+	if err != nil {
+		t.Fatalf("read CA: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	repo := gitcore.NewEmptyRepository()
+	s := newTestServer(t)
+	s.session = NewRepoSession(SessionConfig{
+		ID:          "test",
+		InitialRepo: repo,
+		ReloadFn:    func() (*gitcore.Repository, error) { return repo, nil },
+		Logger:      silentLogger(),
+	})
+	s.SetTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		if strings.Contains(err.Error(), "operation not permitted") || strings.Contains(err.Error(), "permission denied") {
+			t.Skipf("skipping TLS test in restricted environment: %v", err)
+		}
+		t.Fatalf("listen: %v", err)
+	}
+	srv := s.newHTTPServer(s.handler())
+	go func() {
+		if err := srv.ServeTLS(ln, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("ServeTLS() error = %v", err)
+		}
+	}()
+	t.Cleanup(func() { _ = srv.Close() })
+	baseURL := "https://" + ln.Addr().String()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get(baseURL + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+		t.Fatalf("status = %d, proto = %s; want 200 over HTTP/2", resp.StatusCode, resp.Proto)
+	}
+
+	dialer := websocket.Dialer{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	header := http.Header{}
+	header.Set("Origin", baseURL)
+	conn, _, err := dialer.Dial("wss://"+ln.Addr().String()+"/api/ws", header)
+	if err != nil {
+		t.Fatalf("dial wss: %v", err)
+	}
+	defer conn.Close()
+	if msg := readUpdateMessage(t, conn); msg.Type != messageTypeRepoSummary {
+		t.Fatalf("first message type = %q, want %q", msg.Type, messageTypeRepoSummary)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import "time"
+
+// Tuning holds the timings and limits that can be changed while the server
+// runs. Start from DefaultTuning; zero counts, sizes, and intervals fall back
+// to their defaults, while a zero BootstrapBatchPause or CacheTTL is kept.
+type Tuning struct {
+	// WatchDebounce is how long the watcher waits for .git to settle before
+	// reloading the repository.
+	WatchDebounce time.Duration
+	// StatusPollInterval is how often the working tree is polled for changes
+	// that do not touch .git.
+	StatusPollInterval time.Duration
+
+	// BootstrapWindowDays sends commits within this many days of HEAD first.
+	BootstrapWindowDays int
+	// BootstrapFirstBatchBytes and BootstrapBatchBytes are the estimated
+	// JSON payload targets for the first and later bootstrap batches.
+	BootstrapFirstBatchBytes    int
+	BootstrapBatchBytes         int
+	BootstrapMaxCommitsPerBatch int
+	BootstrapBatchPause         time.Duration
+	// ForceModeMaxCommits is the history size above which commits outside the
+	// bootstrap window are sent without their messages.
+	ForceModeMaxCommits int
+
+	CacheEntries int
+	CacheBytes   int64
+	// CacheTTL expires cached responses after this long. Zero means never.
+	CacheTTL time.Duration
+}
+
+// DefaultTuning returns the built-in timings and limits.
+func DefaultTuning() Tuning {
+	return Tuning{
+		WatchDebounce:               debounceTime,
+		StatusPollInterval:          statusPollInterval,
+		BootstrapWindowDays:         initialBootstrapWindowDays,
+		BootstrapFirstBatchBytes:    bootstrapFirstBatchTarget,
+		BootstrapBatchBytes:         bootstrapBatchTarget,
+		BootstrapMaxCommitsPerBatch: bootstrapMaxCommitsPerBatch,
+		BootstrapBatchPause:         bootstrapBatchPause,
+		ForceModeMaxCommits:         forceModeMaxCommits,
+		CacheEntries:                defaultCacheSize,
+		CacheBytes:                  defaultCacheBytes,
+	}
+}
+
+// withDefaults replaces zero fields with their defaults.
+func (t Tuning) withDefaults() Tuning {
+	d := DefaultTuning()
+	if t.WatchDebounce <= 0 {
+		t.WatchDebounce = d.WatchDebounce
+	}
+	if t.StatusPollInterval <= 0 {
+		t.StatusPollInterval = d.StatusPollInterval
+	}
+	if t.BootstrapWindowDays <= 0 {
+		t.BootstrapWindowDays = d.BootstrapWindowDays
+	}
+	if t.BootstrapFirstBatchBytes <= 0 {
+		t.BootstrapFirstBatchBytes = d.BootstrapFirstBatchBytes
+	}
+	if t.BootstrapBatchBytes <= 0 {
+		t.BootstrapBatchBytes = d.BootstrapBatchBytes
+	}
+	if t.BootstrapMaxCommitsPerBatch <= 0 {
+		t.BootstrapMaxCommitsPerBatch = d.BootstrapMaxCommitsPerBatch
+	}
+	if t.BootstrapBatchPause < 0 {
+		t.BootstrapBatchPause = 0
+	}
+	if t.ForceModeMaxCommits <= 0 {
+		t.ForceModeMaxCommits = d.ForceModeMaxCommits
+	}
+	if t.CacheEntries <= 0 {
+		t.CacheEntries = d.CacheEntries
+	}
+	if t.CacheBytes <= 0 {
+		t.CacheBytes = d.CacheBytes
+	}
+	if t.CacheTTL < 0 {
+		t.CacheTTL = 0
+	}
+	return t
+}
+
+// Tuning returns the session's current timings and limits.
+func (rs *RepoSession) Tuning() Tuning {
+	return *rs.tuning.Load()
+}
+
+// SetTuning changes the session's timings and limits. It is safe to call
+// while the session runs: shrinking the cache evicts entries immediately, and
+// the other values take effect the next time they are used.
+func (rs *RepoSession) SetTuning(t Tuning) {
+	t = t.withDefaults()
+	rs.tuning.Store(&t)
+	if wc, ok := rs.cache.(*WeightedCache); ok {
+		wc.SetLimits(t.CacheBytes, t.CacheEntries, t.CacheTTL)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/repositoryview"
+)
+
+func TestSetTuningResizesCache(t *testing.T) {
+	rs := newTestSession(nil)
+	for i := range 10 {
+		rs.cache.Put(fmt.Sprintf("commit-diff:v1:%d", i), strings.Repeat("x", 100))
+	}
+
+	tuning := rs.Tuning()
+	tuning.CacheEntries = 4
+	rs.SetTuning(tuning)
+
+	if got := rs.cache.Len(); got != 4 {
+		t.Fatalf("cache Len() after shrinking = %d, want 4", got)
+	}
+	if _, ok := rs.cache.Get("commit-diff:v1:9"); !ok {
+		t.Fatal("most recent entry was evicted")
+	}
+	if got := rs.Tuning().CacheEntries; got != 4 {
+		t.Fatalf("Tuning().CacheEntries = %d, want 4", got)
+	}
+}
+
+func TestSetTuningFillsDefaults(t *testing.T) {
+	rs := newTestSession(nil)
+	rs.SetTuning(Tuning{WatchDebounce: time.Second})
+	got := rs.Tuning()
+	want := DefaultTuning()
+	want.WatchDebounce = time.Second
+	want.BootstrapBatchPause = 0 // a zero pause is kept, not defaulted
+	if got != want {
+		t.Fatalf("Tuning() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanInitialCommitBatches_UsesTuning(t *testing.T) {
+	when := time.Now()
+	delta := &repositoryview.RepositoryDelta{}
+	for i := range 10 {
+		delta.AddedCommits = append(delta.AddedCommits, &gitcore.Commit{
+			ID:        gitcore.Hash(fmt.Sprintf("%040d", i)),
+			Committer: gitcore.Signature{When: when.Add(-time.Duration(i) * time.Hour)},
+			Message:   "message",
+		})
+	}
+
+	tuning := DefaultTuning()
+	if batches := planInitialCommitBatches(delta, tuning); len(batches) != 1 {
+		t.Fatalf("default batches = %d, want 1", len(batches))
+	}
+	tuning.BootstrapMaxCommitsPerBatch = 3
+	if batches := planInitialCommitBatches(delta, tuning); len(batches) != 4 {
+		t.Fatalf("batches with 3 commits each = %d, want 4", len(batches))
+	}
+
+	// Past ForceModeMaxCommits, commits outside the window lose their messages.
+	tuning = DefaultTuning()
+	tuning.BootstrapWindowDays = 1
+	tuning.ForceModeMaxCommits = 5
+	delta.AddedCommits[9].Committer.When = when.AddDate(0, 0, -10)
+	var last *gitcore.Commit
+	for _, batch := range planInitialCommitBatches(delta, tuning) {
+		last = batch[len(batch)-1]
+	}
+	if last == nil || last.ID != delta.AddedCommits[9].ID || last.Message != "" {
+		t.Fatalf("last bootstrap commit = %+v, want lightweight old commit", last)
+	}
+}
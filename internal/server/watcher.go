@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
+// Defaults for Tuning.WatchDebounce and Tuning.StatusPollInterval.
 const (
 	debounceTime = 100 * time.Millisecond
 
@@ -19,6 +21,31 @@ const (
 	statusPollInterval = 2 * time.Second
 )
 
+// Watcher states reported by /readyz.
+const (
+	watcherNotStarted = "not_started"
+	watcherStarting   = "starting"
+	watcherRunning    = "running"
+	watcherFailed     = "failed"
+	watcherStopped    = "stopped"
+)
+
+func (s *Server) setWatcherState(state string, err error) {
+	s.watcherMu.Lock()
+	s.watcherState = state
+	s.watcherErr = err
+	s.watcherMu.Unlock()
+}
+
+func (s *Server) watcherStatus() (string, error) {
+	s.watcherMu.Lock()
+	defer s.watcherMu.Unlock()
+	if s.watcherState == "" {
+		return watcherNotStarted, nil
+	}
+	return s.watcherState, s.watcherErr
+}
+
 func (s *Server) startWatcher() error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -56,8 +83,9 @@ func (s *Server) startWatcher() error {
 	go s.statusPollLoop()
 
 	s.wg.Add(1)
-	go s.watchLoop(watcher)
+	go s.watchLoop(watcher, gitDir)
 	ownedByLoop = true
+	s.setWatcherState(watcherRunning, nil)
 
 	s.logger.Info("Watching Git repository for changes", "gitDir", gitDir)
 	return nil
@@ -93,7 +121,8 @@ func walkAndWatch(watcher *fsnotify.Watcher, dir string, logger *slog.Logger) {
 func (s *Server) statusPollLoop() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(statusPollInterval)
+	interval := s.session.Tuning().StatusPollInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	var lastJSON []byte
@@ -103,6 +132,10 @@ func (s *Server) statusPollLoop() {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
+			if next := s.session.Tuning().StatusPollInterval; next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
 			repo := s.session.Repo()
 			status := getWorkingTreeStatus(repo)
 			if status == nil {
@@ -124,13 +157,19 @@ func (s *Server) statusPollLoop() {
 	}
 }
 
-func (s *Server) watchLoop(watcher *fsnotify.Watcher) {
+func (s *Server) watchLoop(watcher *fsnotify.Watcher, gitDir string) {
 	defer s.wg.Done()
 	defer func() {
 		if err := watcher.Close(); err != nil {
 			s.logger.Error("Failed to close watcher", "err", err)
 		}
 	}()
+	defer func() {
+		if s.ctx.Err() == nil {
+			s.setWatcherState(watcherStopped, errors.New("watcher event stream closed"))
+			s.logger.Error("File watcher stopped; repository changes will not be detected")
+		}
+	}()
 
 	var debounceTimer *time.Timer
 
@@ -143,6 +182,9 @@ func (s *Server) watchLoop(watcher *fsnotify.Watcher) {
 			if !ok {
 				return
 			}
+			if isGitVistaDataPath(gitDir, event.Name) {
+				continue
+			}
 			watchNewDirectory(watcher, event, s.logger)
 			if shouldIgnoreEvent(event) {
 				continue
@@ -153,7 +195,7 @@ func (s *Server) watchLoop(watcher *fsnotify.Watcher) {
 			if debounceTimer != nil {
 				debounceTimer.Stop()
 			}
-			debounceTimer = time.AfterFunc(debounceTime, func() {
+			debounceTimer = time.AfterFunc(s.session.Tuning().WatchDebounce, func() {
 				if s.ctx.Err() != nil {
 					return
 				}
@@ -182,6 +224,14 @@ func watchNewDirectory(watcher *fsnotify.Watcher, event fsnotify.Event, logger *
 	walkAndWatch(watcher, event.Name, logger)
 }
 
+// isGitVistaDataPath reports whether path is inside the directory GitVista
+// keeps its own files in, such as the persistent cache. Writes there are not
+// repository changes and must not trigger a reload.
+func isGitVistaDataPath(gitDir, path string) bool {
+	dataDir := filepath.Join(gitDir, "gitvista")
+	return path == dataDir || strings.HasPrefix(path, dataDir+string(filepath.Separator))
+}
+
 func shouldIgnoreEvent(event fsnotify.Event) bool {
 	base := filepath.Base(event.Name)
 	path := event.Name
@@ -46,6 +46,25 @@ func TestWatchNewDirectory_AddsCreatedDirectorySubtree(t *testing.T) {
 	}
 }
 
+func TestIsGitVistaDataPath(t *testing.T) {
+	gitDir := filepath.Join("repo", ".git")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: filepath.Join(gitDir, "gitvista"), want: true},
+		{path: filepath.Join(gitDir, "gitvista", "cache", "entries", "ab", "cd"), want: true},
+		{path: filepath.Join(gitDir, "refs", "heads", "gitvista"), want: false},
+		{path: filepath.Join(gitDir, "gitvista-notes"), want: false},
+		{path: filepath.Join(gitDir, "HEAD"), want: false},
+	}
+	for _, tt := range tests {
+		if got := isGitVistaDataPath(gitDir, tt.path); got != tt.want {
+			t.Errorf("isGitVistaDataPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func containsPath(paths []string, want string) bool {
 	for _, path := range paths {
 		if path == want {
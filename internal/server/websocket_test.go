@@ -13,6 +13,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/rybkr/gitvista/gitcore"
+	"github.com/rybkr/gitvista/internal/auth"
 )
 
 func newWebSocketTestServer(t *testing.T, handler http.Handler) *httptest.Server {
@@ -308,3 +309,50 @@ func readUpdateMessage(t *testing.T, conn *websocket.Conn) UpdateMessage {
 	}
 	return message
 }
+
+func TestHandleWebSocket_RequiresAuthentication(t *testing.T) {
+	repo := gitcore.NewEmptyRepository()
+	session := NewRepoSession(SessionConfig{
+		ID:          "test",
+		InitialRepo: repo,
+		ReloadFn:    func() (*gitcore.Repository, error) { return repo, nil },
+		Logger:      silentLogger(),
+	})
+	s := newTestServer(t)
+	s.session = session
+	authenticator, err := auth.New(auth.Options{Providers: []auth.Provider{auth.NewTokenProvider("s3cret")}})
+	if err != nil {
+		t.Fatalf("auth.New() error = %v", err)
+	}
+	s.SetAuthenticator(authenticator)
+
+	ts := newWebSocketTestServer(t, s.handler())
+	defer ts.Close()
+	wsURL := websocketURL(t, ts.URL+"/api/ws")
+
+	header := http.Header{}
+	header.Set("Origin", ts.URL)
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous dial: err = %v, resp = %v, want 401", err, resp)
+	}
+
+	health, err := http.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	_ = health.Body.Close()
+	if health.StatusCode != http.StatusOK {
+		t.Fatalf("/health status = %d, want 200 without credentials", health.StatusCode)
+	}
+
+	header.Set("Authorization", "Bearer s3cret")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("authenticated dial: %v", err)
+	}
+	defer conn.Close()
+	if msg := readUpdateMessage(t, conn); msg.Type != messageTypeRepoSummary {
+		t.Fatalf("first message type = %q, want %q", msg.Type, messageTypeRepoSummary)
+	}
+}
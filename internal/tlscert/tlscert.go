@@ -0,0 +1,231 @@
+// Package tlscert loads TLS certificates for the GitVista server and issues
+// self-signed ones from a certificate authority cached on disk.
+//
+// The local CA is generated once and reused, so a user who trusts it in their
+// browser or system store keeps trusting every certificate issued afterwards.
+package tlscert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	caCertFile   = "ca.pem"
+	caKeyFile    = "ca-key.pem"
+	leafCertFile = "cert.pem"
+	leafKeyFile  = "key.pem"
+
+	caValidity = 10 * 365 * 24 * time.Hour
+	// leafValidity stays under the 398-day ceiling browsers enforce.
+	leafValidity = 397 * 24 * time.Hour
+	// RenewBefore is how close to expiry a certificate is reissued, and when
+	// doctor starts warning about provided certificates.
+	RenewBefore = 30 * 24 * time.Hour
+)
+
+// DefaultCacheDir returns the directory holding the local CA and the
+// certificates it issues.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "gitvista", "tls"), nil
+}
+
+// CAPath returns the path of the local CA certificate in dir. This is the file
+// users import to trust self-signed certificates.
+func CAPath(dir string) string {
+	return filepath.Join(dir, caCertFile)
+}
+
+// CertPaths returns the certificate and key paths of the cached self-signed
+// certificate in dir.
+func CertPaths(dir string) (string, string) {
+	return filepath.Join(dir, leafCertFile), filepath.Join(dir, leafKeyFile)
+}
+
+// LoadKeyPair reads a PEM certificate chain and private key and parses the
+// leaf certificate.
+func LoadKeyPair(certFile, keyFile string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("parse TLS certificate: %w", err)
+		}
+		cert.Leaf = leaf
+	}
+	return cert, nil
+}
+
+// CheckValidity reports an error when cert is not valid at now.
+func CheckValidity(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate is not valid until %s", cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate expired %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// SelfSigned returns a certificate for hosts signed by the CA cached in dir.
+// The CA is created on first use. The leaf is reused until it nears expiry or
+// no longer covers every host, and is then reissued.
+func SelfSigned(dir string, hosts []string, now time.Time) (tls.Certificate, error) {
+	if len(hosts) == 0 {
+		return tls.Certificate{}, errors.New("self-signed certificate needs at least one host")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return tls.Certificate{}, fmt.Errorf("create TLS cache directory: %w", err)
+	}
+
+	ca, caKey, err := loadOrCreateCA(dir, now)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certPath, keyPath := CertPaths(dir)
+	if cert, err := LoadKeyPair(certPath, keyPath); err == nil && reusable(cert.Leaf, ca, hosts, now) {
+		return cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generate TLS key: %w", err)
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{Organization: []string{"GitVista"}, CommonName: hosts[0]},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(leafValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	if err := issue(template, ca, caKey, key, certPath, keyPath); err != nil {
+		return tls.Certificate{}, err
+	}
+	return LoadKeyPair(certPath, keyPath)
+}
+
+func loadOrCreateCA(dir string, now time.Time) (*x509.Certificate, crypto.Signer, error) {
+	certPath, keyPath := filepath.Join(dir, caCertFile), filepath.Join(dir, caKeyFile)
+	if pair, err := LoadKeyPair(certPath, keyPath); err == nil {
+		if signer, ok := pair.PrivateKey.(crypto.Signer); ok && pair.Leaf.IsCA && now.Before(pair.Leaf.NotAfter.Add(-leafValidity)) {
+			return pair.Leaf, signer, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("local CA in %s is unreadable; remove it to start over: %w", dir, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate CA key: %w", err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{"GitVista"}, CommonName: "GitVista Local CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	if err := issue(template, nil, key, key, certPath, keyPath); err != nil {
+		return nil, nil, err
+	}
+	pair, err := LoadKeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair.Leaf, key, nil
+}
+
+// issue signs template with parentKey (self-signing when parent is nil) and
+// writes the certificate and key as PEM files.
+func issue(template, parent *x509.Certificate, parentKey crypto.Signer, key *ecdsa.PrivateKey, certPath, keyPath string) error {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("generate serial number: %w", err)
+	}
+	template.SerialNumber = serial
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return fmt.Errorf("create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("encode private key: %w", err)
+	}
+
+	// The key goes first so a certificate is never left without its key.
+	if err := writePEM(keyPath, "PRIVATE KEY", keyDER, 0o600); err != nil {
+		return err
+	}
+	return writePEM(certPath, "CERTIFICATE", der, 0o644)
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), perm); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func reusable(leaf, ca *x509.Certificate, hosts []string, now time.Time) bool {
+	if leaf == nil || leaf.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	if CheckValidity(leaf, now) != nil || now.Add(RenewBefore).After(leaf.NotAfter) {
+		return false
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !slices.ContainsFunc(leaf.IPAddresses, ip.Equal) {
+				return false
+			}
+		} else if !slices.ContainsFunc(leaf.DNSNames, func(name string) bool { return strings.EqualFold(name, host) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package tlscert
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedIssuesCertificateFromCachedCA(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	cert, err := SelfSigned(dir, []string{"localhost", "127.0.0.1", "::1"}, now)
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+
+	caPEM, err := os.ReadFile(CAPath(dir))
+	if err != nil {
+		t.Fatalf("read CA: %v", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		t.Fatal("CA file holds no certificate")
+	}
+	for _, host := range []string{"localhost", "127.0.0.1", "::1"} {
+		if _, err := cert.Leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err != nil {
+			t.Errorf("Verify(%s) error = %v", host, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		t.Fatalf("stat CA key: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("CA key mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestSelfSignedReusesAndReissues(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	first, err := SelfSigned(dir, []string{"localhost"}, now)
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	again, err := SelfSigned(dir, []string{"localhost"}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	if again.Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) != 0 {
+		t.Fatal("certificate was reissued although it was still valid")
+	}
+
+	widened, err := SelfSigned(dir, []string{"localhost", "devbox.lan"}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	if widened.Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) == 0 {
+		t.Fatal("certificate was not reissued for a new host")
+	}
+	if widened.Leaf.CheckSignatureFrom(mustLoadCA(t, dir)) != nil {
+		t.Fatal("reissued certificate is not signed by the cached CA")
+	}
+
+	renewed, err := SelfSigned(dir, []string{"localhost", "devbox.lan"}, widened.Leaf.NotAfter.Add(-RenewBefore/2))
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	if renewed.Leaf.SerialNumber.Cmp(widened.Leaf.SerialNumber) == 0 {
+		t.Fatal("certificate near expiry was not renewed")
+	}
+}
+
+func mustLoadCA(t *testing.T, dir string) *x509.Certificate {
+	t.Helper()
+	pair, err := LoadKeyPair(CAPath(dir), filepath.Join(dir, caKeyFile))
+	if err != nil {
+		t.Fatalf("LoadKeyPair(CA) error = %v", err)
+	}
+	return pair.Leaf
+}
+
+func TestCheckValidity(t *testing.T) {
+	cert, err := SelfSigned(t.TempDir(), []string{"localhost"}, time.Now())
+	if err != nil {
+		t.Fatalf("SelfSigned() error = %v", err)
+	}
+	if err := CheckValidity(cert.Leaf, time.Now()); err != nil {
+		t.Fatalf("CheckValidity(now) error = %v", err)
+	}
+	if err := CheckValidity(cert.Leaf, cert.Leaf.NotAfter.Add(time.Minute)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("CheckValidity(after) error = %v, want expired", err)
+	}
+	if err := CheckValidity(cert.Leaf, cert.Leaf.NotBefore.Add(-time.Minute)); err == nil {
+		t.Fatal("CheckValidity(before) error = nil, want error")
+	}
+}
+
+func TestLoadKeyPairErrors(t *testing.T) {
+	if _, err := LoadKeyPair("missing.pem", "missing-key.pem"); err == nil {
+		t.Fatal("LoadKeyPair(missing) error = nil, want error")
+	}
+	if _, err := SelfSigned(t.TempDir(), nil, time.Now()); err == nil {
+		t.Fatal("SelfSigned(no hosts) error = nil, want error")
+	}
+}
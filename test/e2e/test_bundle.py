@@ -0,0 +1,65 @@
+import subprocess
+from pathlib import Path
+
+ALL_REPOS = ["express", "gitvista", "cpython", "octocat", "git"]
+QUICK_REPOS = ["express", "gitvista", "octocat"]
+
+# Bundle recent history only, so the largest repositories stay quick.
+RECENT_COMMITS = "50"
+
+
+def pytest_generate_tests(metafunc):
+    if "repo_name" not in metafunc.fixturenames:
+        return
+
+    repo_names = QUICK_REPOS if metafunc.config.getoption("--quick") else ALL_REPOS
+    metafunc.parametrize("repo_name", repo_names)
+
+
+def recent_range(run_git, repo_dir: Path) -> str:
+    base = run_git(repo_dir, "rev-list", "--max-count=1", f"--skip={RECENT_COMMITS}", "HEAD").strip()
+    return f"{base}..HEAD" if base else "HEAD"
+
+
+def test_bundle_create_verifies(
+    repo_name: str,
+    root_dir: Path,
+    run_git,
+    run_cli,
+    tmp_path: Path,
+) -> None:
+    repo_dir = root_dir / "testdata" / "repos" / repo_name
+    git_dir = repo_dir / ".git"
+
+    assert git_dir.exists(), f"prepared repository missing at {repo_dir}; run scripts/prepare_test_repos.py first"
+
+    rev_range = recent_range(run_git, repo_dir)
+    bundle = tmp_path / "recent.bundle"
+    run_cli(root_dir, "--repo", str(repo_dir), "bundle", "create", str(bundle), rev_range)
+
+    # git checks the pack and that the repository has every prerequisite.
+    run_git(repo_dir, "bundle", "verify", str(bundle))
+    heads = run_git(repo_dir, "bundle", "list-heads", str(bundle))
+    assert heads.split() == [run_git(repo_dir, "rev-parse", "HEAD").strip(), "HEAD"]
+
+
+def test_bundle_opens_as_repository(
+    repo_name: str,
+    root_dir: Path,
+    run_git,
+    run_cli,
+    tmp_path: Path,
+) -> None:
+    repo_dir = root_dir / "testdata" / "repos" / repo_name
+    git_dir = repo_dir / ".git"
+
+    assert git_dir.exists(), f"prepared repository missing at {repo_dir}; run scripts/prepare_test_repos.py first"
+
+    rev_range = recent_range(run_git, repo_dir)
+    bundle = tmp_path / "git.bundle"
+    # git writes thin packs, which gitcore indexes without the prerequisites.
+    run_git(repo_dir, "bundle", "create", str(bundle), rev_range)
+
+    expected = run_git(repo_dir, "rev-list", rev_range)
+    actual = run_cli(root_dir, "--repo", str(bundle), "rev-list", "HEAD")
+    assert actual == expected
@@ -0,0 +1,133 @@
+from __future__ import annotations
+
+import os
+import socket
+import subprocess
+import time
+import urllib.error
+import urllib.request
+from pathlib import Path
+
+import pytest
+
+GIT_ENV = {
+    "GIT_AUTHOR_NAME": "Test",
+    "GIT_AUTHOR_EMAIL": "test@example.com",
+    "GIT_COMMITTER_NAME": "Test",
+    "GIT_COMMITTER_EMAIL": "test@example.com",
+    "GIT_CONFIG_GLOBAL": os.devnull,
+    "GIT_CONFIG_SYSTEM": os.devnull,
+    "GIT_TERMINAL_PROMPT": "0",
+}
+
+
+def git(cwd: Path, *args: str) -> str:
+    completed = subprocess.run(
+        ["git", *args],
+        cwd=cwd,
+        check=True,
+        capture_output=True,
+        text=True,
+        env={**os.environ, **GIT_ENV},
+    )
+    return completed.stdout.strip()
+
+
+def free_port() -> int:
+    with socket.socket() as sock:
+        sock.bind(("127.0.0.1", 0))
+        return sock.getsockname()[1]
+
+
+@pytest.fixture(scope="module")
+def vista_path(tmp_path_factory: pytest.TempPathFactory, root_dir: Path) -> Path:
+    build_dir = tmp_path_factory.mktemp("gitvista-e2e-vista")
+    vista = build_dir / "gitvista"
+    subprocess.run(
+        ["go", "build", "-o", str(vista), "./cmd/vista"],
+        cwd=root_dir,
+        check=True,
+    )
+    return vista
+
+
+@pytest.fixture
+def source_repo(tmp_path: Path) -> Path:
+    repo = tmp_path / "source"
+    repo.mkdir()
+    git(repo, "init", "-q", "-b", "main")
+    (repo / "README.md").write_text("hello\n")
+    git(repo, "add", "-A")
+    git(repo, "commit", "-q", "-m", "first")
+    git(repo, "tag", "-a", "v1", "-m", "release v1")
+    git(repo, "checkout", "-q", "-b", "feature")
+    (repo / "src").mkdir()
+    (repo / "src" / "main.go").write_text("package main\n")
+    git(repo, "add", "-A")
+    git(repo, "commit", "-q", "-m", "feature")
+    git(repo, "checkout", "-q", "main")
+    (repo / "README.md").write_text("hello\nworld\n")
+    git(repo, "commit", "-q", "-am", "second")
+    return repo
+
+
+@pytest.fixture
+def served_url(vista_path: Path, source_repo: Path, tmp_path: Path):
+    port = free_port()
+    env = {**os.environ, "XDG_CONFIG_HOME": str(tmp_path / "config")}
+    proc = subprocess.Popen(
+        [
+            str(vista_path),
+            "serve",
+            "-repo", str(source_repo),
+            "-port", str(port),
+            "-git-http",
+            "-cache-dir", "off",
+        ],
+        env=env,
+        stdout=subprocess.DEVNULL,
+        stderr=subprocess.DEVNULL,
+    )
+    base = f"http://127.0.0.1:{port}"
+    try:
+        deadline = time.monotonic() + 30
+        while True:
+            try:
+                with urllib.request.urlopen(f"{base}/readyz", timeout=1) as resp:
+                    if resp.status == 200:
+                        break
+            except (urllib.error.URLError, ConnectionError):
+                pass
+            if proc.poll() is not None or time.monotonic() > deadline:
+                pytest.fail("gitvista serve did not become ready")
+            time.sleep(0.1)
+        yield f"{base}/git/source.git"
+    finally:
+        proc.terminate()
+        proc.wait(timeout=10)
+
+
+def test_git_clone(served_url: str, source_repo: Path, tmp_path: Path) -> None:
+    dest = tmp_path / "clone"
+    git(tmp_path, "-c", "protocol.version=2", "clone", "-q", served_url, str(dest))
+
+    for local, remote in [
+        ("main", "origin/main"),
+        ("feature", "origin/feature"),
+        ("v1", "v1"),
+        ("HEAD", "HEAD"),
+    ]:
+        assert git(dest, "rev-parse", remote) == git(source_repo, "rev-parse", local)
+    assert git(dest, "symbolic-ref", "HEAD") == "refs/heads/main"
+    git(dest, "fsck", "--strict", "--no-dangling")
+    assert (dest / "README.md").read_text() == "hello\nworld\n"
+
+
+def test_git_push_is_refused(served_url: str, tmp_path: Path) -> None:
+    dest = tmp_path / "clone"
+    git(tmp_path, "clone", "-q", served_url, str(dest))
+    (dest / "new.txt").write_text("new\n")
+    git(dest, "add", "-A")
+    git(dest, "commit", "-q", "-m", "new")
+    with pytest.raises(subprocess.CalledProcessError):
+        git(dest, "push", "-q", "origin", "main")
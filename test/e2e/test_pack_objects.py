@@ -0,0 +1,78 @@
+import subprocess
+from pathlib import Path
+
+ALL_REPOS = ["express", "gitvista", "cpython", "octocat", "git"]
+QUICK_REPOS = ["express", "gitvista", "octocat"]
+
+# Enough history for deltas between versions of the same files without making
+# the largest repositories slow to pack.
+MAX_COMMITS = "200"
+
+
+def pytest_generate_tests(metafunc):
+    if "repo_name" not in metafunc.fixturenames:
+        return
+
+    repo_names = QUICK_REPOS if metafunc.config.getoption("--quick") else ALL_REPOS
+    metafunc.parametrize("repo_name", repo_names)
+
+
+def pack_objects(cli_path: Path, repo_dir: Path, objects: str, *args: str) -> bytes:
+    completed = subprocess.run(
+        [str(cli_path), "--repo", str(repo_dir), "pack-objects", *args],
+        input=objects.encode(),
+        check=True,
+        capture_output=True,
+    )
+    return completed.stdout
+
+
+def test_pack_objects_verifies(
+    repo_name: str,
+    root_dir: Path,
+    cli_path: Path,
+    run_git,
+    tmp_path: Path,
+) -> None:
+    repo_dir = root_dir / "testdata" / "repos" / repo_name
+    git_dir = repo_dir / ".git"
+
+    assert git_dir.exists(), f"prepared repository missing at {repo_dir}; run scripts/prepare_test_repos.py first"
+
+    objects = run_git(repo_dir, "rev-list", "--objects", f"--max-count={MAX_COMMITS}", "HEAD")
+    checksum = pack_objects(cli_path, repo_dir, objects, str(tmp_path / "pack")).decode().strip()
+    pack = tmp_path / f"pack-{checksum}.pack"
+    idx = tmp_path / f"pack-{checksum}.idx"
+
+    verify = run_git(repo_dir, "verify-pack", "-v", str(idx))
+    want_ids = {line.split()[0] for line in objects.splitlines() if line}
+    got_ids = {line.split()[0] for line in verify.splitlines() if line[:1].isalnum() and len(line.split()[0]) == 40}
+    assert got_ids == want_ids
+
+    # git derives a byte-identical index from the pack alone.
+    git_idx = tmp_path / "git.idx"
+    run_git(repo_dir, "index-pack", "-o", str(git_idx), str(pack))
+    assert idx.read_bytes() == git_idx.read_bytes()
+
+
+def test_pack_objects_stdout_without_deltas(
+    repo_name: str,
+    root_dir: Path,
+    cli_path: Path,
+    run_git,
+    tmp_path: Path,
+) -> None:
+    repo_dir = root_dir / "testdata" / "repos" / repo_name
+    git_dir = repo_dir / ".git"
+
+    assert git_dir.exists(), f"prepared repository missing at {repo_dir}; run scripts/prepare_test_repos.py first"
+
+    objects = run_git(repo_dir, "rev-list", "--objects", "--max-count=20", "HEAD")
+    pack = tmp_path / "whole.pack"
+    pack.write_bytes(pack_objects(cli_path, repo_dir, objects, "--window=0", "--stdout"))
+
+    idx = tmp_path / "whole.idx"
+    run_git(repo_dir, "index-pack", "-o", str(idx), str(pack))
+    verify = run_git(repo_dir, "verify-pack", "-v", str(idx))
+    assert "chain length" not in verify
+    assert f"non delta: {len(set(line.split()[0] for line in objects.splitlines() if line))} objects" in verify
@@ -1,6 +1,7 @@
 /**
  * Analytics view — commit velocity trend line chart, author contributions,
- * activity heatmap, merge statistics, change size distribution, and rework rate.
+ * activity heatmap, working patterns, merge statistics, change size distribution,
+ * and rework rate.
  *
  * Factory: createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetchGraphCommits, fetchAnalytics })
  * Returns: { el, update() }
@@ -23,6 +24,11 @@ const TOP_N_AUTHORS = 10;
 const DAY_NAMES = ["Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"];
 const HOUR_LABELS = ["12a", "", "", "3a", "", "", "6a", "", "", "9a", "", "", "12p", "", "", "3p", "", "", "6p", "", "", "9p", "", ""];
 
+const TIME_ZONES = [
+    { label: "UTC", value: "utc" },
+    { label: "Author local", value: "local" },
+];
+
 const CHURN_WINDOW_DAYS = 21;
 const SIZE_BUCKETS = [
     { label: "XS", max: 5 },
@@ -36,7 +42,8 @@ const ANALYTICS_HELP = {
     riskHotspots: "Files with the highest delivery risk based on repeated change, rework, large diffs, and concentrated ownership. Use this list to target review and cleanup effort.",
     velocity: "Weekly commit volume with a rolling average overlay. Use it to spot bursts, slowdowns, and whether the recent pace is an outlier or part of a trend.",
     contributors: "Top authors by commit count for the selected range. This is useful for spotting ownership concentration, not for measuring code quality or impact.",
-    heatmap: "Commit activity by weekday and hour in UTC. Read it as a coordination pattern, not a productivity score.",
+    heatmap: "Commit activity by weekday and hour in UTC, on each author's own clock, or in your time zone. Read it as a coordination pattern, not a productivity score.",
+    workPatterns: "When commits land relative to their authors' working day, and how long after authoring they were committed. After-hours and weekend shares use each author's recorded offset; a long commit delay usually means heavy rebasing.",
     merges: "How often work lands through merge commits instead of linear history. Helpful for understanding branch integration behavior and repository hygiene.",
     changeSize: "How many files change per commit. Smaller, steadier changes are usually easier to review and less likely to hide risk.",
     rework: `Share of files changed again within ${CHURN_WINDOW_DAYS} days. Rising rework can indicate churn, unstable requirements, or code that is hard to land cleanly.`,
@@ -419,6 +426,7 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
     const DEFAULT_PERIOD = "All";
     let selectedPeriod = "All";
     let customRange = { start: "", end: "" };
+    let selectedTimeZone = "utc";
     const ANALYTICS_HYDRATE_CHUNK = 200;
     let hydrationInFlight = false;
     const attemptedHydration = new Set();
@@ -522,12 +530,13 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
         }
     }
 
-    function analyticsCacheKey({ period, start, end } = {}) {
+    function analyticsCacheKey({ period, start, end, tz } = {}) {
+        const zone = typeof tz === "string" && tz ? tz : "utc";
         if (typeof start === "string" && start && typeof end === "string" && end) {
-            return `range:${start}:${end}`;
+            return `range:${start}:${end}:${zone}`;
         }
         const p = typeof period === "string" && period ? period : "all";
-        return `period:${p}`;
+        return `period:${p}:${zone}`;
     }
 
     async function fetchAnalyticsCached(opts = {}) {
@@ -849,9 +858,51 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
     const heatmapCanvas = document.createElement("canvas");
     heatmapCanvas.className = "analytics-chart-canvas";
     heatmapChartContainer.appendChild(heatmapCanvas);
+    const timeZoneSelect = document.createElement("select");
+    timeZoneSelect.className = "analytics-period-input analytics-timezone-select";
+    timeZoneSelect.setAttribute("aria-label", "Heatmap time zone");
+    // The local fallback only buckets by UTC.
+    timeZoneSelect.hidden = !fetchAnalytics;
+    const browserZone = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    const zoneOptions = browserZone && browserZone !== "UTC"
+        ? [...TIME_ZONES, { label: browserZone, value: browserZone }]
+        : TIME_ZONES;
+    for (const zone of zoneOptions) {
+        const option = document.createElement("option");
+        option.value = zone.value;
+        option.textContent = zone.label;
+        timeZoneSelect.appendChild(option);
+    }
+    timeZoneSelect.addEventListener("change", () => {
+        selectedTimeZone = timeZoneSelect.value;
+        update();
+    });
+    heatmapSection.body.appendChild(timeZoneSelect);
     heatmapSection.body.appendChild(heatmapChartContainer);
     el.appendChild(heatmapSection.el);
 
+    // ── Working patterns section ──
+    const patternsSection = makeSection("Working Patterns", {
+        helpText: ANALYTICS_HELP.workPatterns,
+        collapsible: true,
+        defaultExpanded: false,
+    });
+    const patternsSummary = document.createElement("div");
+    patternsSummary.className = "analytics-summary";
+    const afterHoursStat = makeStat("After hours");
+    const weekendStat = makeStat("Weekend");
+    const medianSkewStat = makeStat("Median commit delay");
+    const p90SkewStat = makeStat("P90 commit delay");
+    const rewrittenStat = makeStat("Rewritten");
+    for (const stat of [afterHoursStat, weekendStat, medianSkewStat, p90SkewStat, rewrittenStat]) {
+        patternsSummary.appendChild(stat.el);
+    }
+    const authorHoursList = document.createElement("div");
+    authorHoursList.className = "analytics-author-hours";
+    patternsSection.body.appendChild(patternsSummary);
+    patternsSection.body.appendChild(authorHoursList);
+    el.appendChild(patternsSection.el);
+
     // ── Merge stats section ──
     const mergeSection = makeSection("Merge Statistics", {
         helpText: ANALYTICS_HELP.merges,
@@ -1524,6 +1575,50 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
         ctx.stroke();
     }
 
+    function formatHours(hours) {
+        const n = Number(hours || 0);
+        if (n < 1) return `${Math.round(n * 60)}m`;
+        if (n < 48) return `${n.toFixed(1)}h`;
+        return `${(n / 24).toFixed(1)}d`;
+    }
+
+    /** Renders the working patterns stats and one hour strip per author. */
+    function renderWorkPatterns(patterns) {
+        const afterHours = patterns?.afterHours || {};
+        const skew = patterns?.dateSkew || {};
+        afterHoursStat.value.textContent = `${Number(afterHours.afterHoursShare || 0).toFixed(1)}%`;
+        weekendStat.value.textContent = `${Number(afterHours.weekendShare || 0).toFixed(1)}%`;
+        medianSkewStat.value.textContent = formatHours(skew.medianHours);
+        p90SkewStat.value.textContent = formatHours(skew.p90Hours);
+        rewrittenStat.value.textContent = `${Number(skew.rewrittenShare || 0).toFixed(1)}%`;
+
+        authorHoursList.innerHTML = "";
+        const authors = Array.isArray(patterns?.authors) ? patterns.authors : [];
+        for (const author of authors) {
+            const hours = Array.isArray(author.hours) ? author.hours : [];
+            const max = Math.max(0, ...hours);
+            const row = document.createElement("div");
+            row.className = "analytics-author-hours-row";
+            const name = document.createElement("span");
+            name.className = "analytics-author-hours-name";
+            name.textContent = author.name || author.email || "unknown";
+            name.title = `${author.email || ""} · ${Number(author.count || 0)} commits`;
+            row.appendChild(name);
+            const strip = document.createElement("span");
+            strip.className = "analytics-author-hours-strip";
+            for (let h = 0; h < 24; h++) {
+                const count = Number(hours[h] || 0);
+                const cell = document.createElement("span");
+                cell.className = "analytics-author-hours-cell";
+                cell.style.opacity = count === 0 || max === 0 ? "0.12" : String(0.25 + 0.75 * (count / max));
+                cell.title = `${String(h).padStart(2, "0")}:00 · ${count} commit${count !== 1 ? "s" : ""}`;
+                strip.appendChild(cell);
+            }
+            row.appendChild(strip);
+            authorHoursList.appendChild(row);
+        }
+    }
+
     function formatDelta(value, suffix = "%") {
         const n = Number(value || 0);
         const sign = n > 0 ? "+" : "";
@@ -1669,6 +1764,7 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
             chartContainer.style.display = "";
             authorSection.el.style.display = "";
             heatmapSection.el.style.display = "";
+            patternsSection.el.style.display = "none";
             mergeSection.el.style.display = "";
             changeSizeSection.el.style.display = "";
             reworkSection.el.style.display = "";
@@ -1689,6 +1785,7 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
             chartContainer.style.display = "none";
             authorSection.el.style.display = "none";
             heatmapSection.el.style.display = "none";
+            patternsSection.el.style.display = "none";
             mergeSection.el.style.display = "none";
             changeSizeSection.el.style.display = "none";
             reworkSection.el.style.display = "none";
@@ -1700,8 +1797,8 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
         if (fetchAnalytics) {
             try {
                 const payload = usingCustomRange
-                    ? await fetchAnalyticsCached({ start: customRange.start, end: customRange.end })
-                    : await fetchAnalyticsCached({ period: periodKey });
+                    ? await fetchAnalyticsCached({ start: customRange.start, end: customRange.end, tz: selectedTimeZone })
+                    : await fetchAnalyticsCached({ period: periodKey, tz: selectedTimeZone });
                 showSections();
                 if (usingCustomRange) {
                     const s = payload?.start?.slice?.(0, 10) || customRange.start;
@@ -1730,6 +1827,9 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
                 heatmap.grid = Array.isArray(heatmap?.grid) ? heatmap.grid : Array.from({ length: 7 }, () => Array(24).fill(0));
                 drawHeatmap(heatmap);
 
+                patternsSection.el.style.display = "";
+                renderWorkPatterns(payload?.workPatterns);
+
                 const merges = payload?.merges || {};
                 mergeCountStat.value.textContent = Number(merges.mergeCount || 0).toLocaleString();
                 mergePercentStat.value.textContent = `${Number(merges.mergePercent || 0).toFixed(1)}%`;
@@ -1782,6 +1882,7 @@ export function createAnalyticsView({ getCommits, getTags, fetchDiffStats, fetch
             chartContainer.style.display = "none";
             authorSection.el.style.display = "none";
             heatmapSection.el.style.display = "none";
+            patternsSection.el.style.display = "none";
             mergeSection.el.style.display = "none";
             changeSizeSection.el.style.display = "none";
             reworkSection.el.style.display = "none";
@@ -5,6 +5,8 @@
  *   - Detects "Repository not available" responses and updates errorState
  *   - Tracks consecutive failures for repository-unavailable escalation
  *   - Resets failure tracking on success
+ *   - Reloads the page once when the server reports the session has expired,
+ *     so the browser goes back through the server's login flow
  *
  * Does NOT auto-retry — retry decisions belong to the calling component.
  * Returns the raw Response so callers handle JSON parsing themselves.
@@ -19,6 +21,7 @@ const ESCALATION_WINDOW_MS = 10_000;
 
 /** @type {{ time: number }[]} */
 let recentFailures = [];
+let reloadingForAuth = false;
 
 /**
  * Fetch wrapper for API endpoints.
@@ -41,6 +44,15 @@ export async function apiFetch(url, options) {
         return response;
     }
 
+    if (response.status === 401) {
+        if (!reloadingForAuth && typeof location !== "undefined") {
+            reloadingForAuth = true;
+            logger.warn("API request unauthorized — reloading to sign in again", { url });
+            location.reload();
+        }
+        return response;
+    }
+
     if (response.status === 500) {
         // Clone before reading body so callers can still consume the original
         const clone = response.clone();
@@ -0,0 +1,288 @@
+/**
+ * Branch Compare View
+ *
+ * Shows how a head revision relates to a base revision before it is pushed:
+ * ahead/behind counts, the commits unique to each side, and the three-dot
+ * diff (merge base vs head) with per-file line stats. Backed by /api/compare.
+ */
+
+import { apiUrl } from "./apiBase.js";
+import { apiFetch } from "./apiFetch.js";
+import { createDiffContentViewer } from "./diffContentViewer.js";
+import { createInlineError } from "./inlineError.js";
+
+const STATUS_LABELS = {
+    added: "A",
+    modified: "M",
+    deleted: "D",
+    renamed: "R",
+};
+
+function compareUrl(base, head, path = null) {
+    const params = new URLSearchParams({ base, head });
+    if (path) params.set("path", path);
+    return apiUrl("/compare?" + params.toString());
+}
+
+function shortHash(hash) {
+    return typeof hash === "string" ? hash.slice(0, 7) : "";
+}
+
+function subjectLine(message) {
+    return (message || "").split("\n")[0];
+}
+
+/**
+ * @param {{ onCommitSelect?: (hash: string) => void }} options
+ */
+export function createCompareView(options = {}) {
+    const el = document.createElement("div");
+    el.className = "compare-view";
+
+    const form = document.createElement("form");
+    form.className = "compare-form";
+    const baseInput = document.createElement("input");
+    baseInput.className = "compare-input";
+    baseInput.placeholder = "base";
+    baseInput.setAttribute("aria-label", "Base revision");
+    const separator = document.createElement("span");
+    separator.className = "compare-separator";
+    separator.textContent = "...";
+    const headInput = document.createElement("input");
+    headInput.className = "compare-input";
+    headInput.placeholder = "head";
+    headInput.setAttribute("aria-label", "Head revision");
+    const submit = document.createElement("button");
+    submit.type = "submit";
+    submit.className = "compare-submit";
+    submit.textContent = "Compare";
+    form.append(baseInput, separator, headInput, submit);
+    el.appendChild(form);
+
+    const body = document.createElement("div");
+    body.className = "compare-body";
+    el.appendChild(body);
+
+    const diffViewer = createDiffContentViewer();
+    const diffOverlay = document.createElement("div");
+    diffOverlay.className = "compare-diff-overlay";
+    diffOverlay.style.display = "none";
+    diffOverlay.appendChild(diffViewer.el);
+    el.appendChild(diffOverlay);
+
+    diffViewer.onBack(() => {
+        diffViewer.close();
+        diffOverlay.style.display = "none";
+    });
+
+    let requestId = 0;
+    let current = null;
+
+    form.addEventListener("submit", (event) => {
+        event.preventDefault();
+        const base = baseInput.value.trim();
+        const head = headInput.value.trim();
+        if (base && head) load(base, head);
+    });
+
+    function renderSummary(data) {
+        const summary = document.createElement("div");
+        summary.className = "compare-summary";
+
+        const stat = (value, label, modifier) => {
+            const item = document.createElement("div");
+            item.className = "compare-stat" + (modifier ? ` compare-stat--${modifier}` : "");
+            const v = document.createElement("span");
+            v.className = "compare-stat-value";
+            v.textContent = value;
+            const l = document.createElement("span");
+            l.className = "compare-stat-label";
+            l.textContent = label;
+            item.append(v, l);
+            return item;
+        };
+
+        summary.append(
+            stat(String(data.ahead), "ahead"),
+            stat(String(data.behind), "behind"),
+            stat(String(data.stats.filesChanged), data.stats.filesChanged === 1 ? "file" : "files"),
+            stat(`+${data.stats.insertions}`, "insertions", "add"),
+            stat(`−${data.stats.deletions}`, "deletions", "del"),
+        );
+
+        const base = document.createElement("div");
+        base.className = "compare-merge-base";
+        base.textContent = `Merge base ${shortHash(data.mergeBase)}`;
+        if (Array.isArray(data.mergeBases) && data.mergeBases.length > 1) {
+            base.textContent += ` (${data.mergeBases.length} candidates)`;
+        }
+        base.title = data.mergeBase;
+        summary.appendChild(base);
+        return summary;
+    }
+
+    function renderCommitList(title, commits, total) {
+        const section = document.createElement("section");
+        section.className = "compare-section";
+        const heading = document.createElement("h3");
+        heading.className = "compare-section-title";
+        heading.textContent = `${title} (${total})`;
+        section.appendChild(heading);
+
+        if (total === 0) {
+            const empty = document.createElement("div");
+            empty.className = "compare-empty";
+            empty.textContent = "No commits";
+            section.appendChild(empty);
+            return section;
+        }
+
+        const list = document.createElement("ul");
+        list.className = "compare-commit-list";
+        for (const commit of commits) {
+            const item = document.createElement("li");
+            const button = document.createElement("button");
+            button.type = "button";
+            button.className = "compare-commit";
+            button.title = commit.message || "";
+            const hash = document.createElement("span");
+            hash.className = "compare-commit-hash";
+            hash.textContent = shortHash(commit.hash);
+            const subject = document.createElement("span");
+            subject.className = "compare-commit-subject";
+            subject.textContent = subjectLine(commit.message);
+            const author = document.createElement("span");
+            author.className = "compare-commit-author";
+            author.textContent = commit.author?.name || "";
+            button.append(hash, subject, author);
+            button.addEventListener("click", () => options.onCommitSelect?.(commit.hash));
+            item.appendChild(button);
+            list.appendChild(item);
+        }
+        section.appendChild(list);
+        if (total > commits.length) {
+            section.appendChild(truncationNote(`Showing the newest ${commits.length} of ${total} commits`));
+        }
+        return section;
+    }
+
+    function truncationNote(text) {
+        const note = document.createElement("div");
+        note.className = "compare-empty";
+        note.textContent = text;
+        return note;
+    }
+
+    function renderFileList(data) {
+        const section = document.createElement("section");
+        section.className = "compare-section";
+        const heading = document.createElement("h3");
+        heading.className = "compare-section-title";
+        heading.textContent = `Changes since merge base (${data.stats.filesChanged})`;
+        section.appendChild(heading);
+
+        if (data.files.length === 0) {
+            const empty = document.createElement("div");
+            empty.className = "compare-empty";
+            empty.textContent = "No file changes";
+            section.appendChild(empty);
+            return section;
+        }
+
+        const list = document.createElement("ul");
+        list.className = "compare-file-list";
+        for (const file of data.files) {
+            const item = document.createElement("li");
+            const button = document.createElement("button");
+            button.type = "button";
+            button.className = "compare-file";
+            const status = document.createElement("span");
+            status.className = `compare-file-status compare-file-status--${file.status}`;
+            status.textContent = STATUS_LABELS[file.status] || "?";
+            const path = document.createElement("span");
+            path.className = "compare-file-path";
+            path.textContent = file.oldPath ? `${file.oldPath} → ${file.path}` : file.path;
+            const counts = document.createElement("span");
+            counts.className = "compare-file-counts";
+            if (file.isBinary) {
+                counts.textContent = "binary";
+            } else {
+                const add = document.createElement("span");
+                add.className = "compare-count-add";
+                add.textContent = `+${file.insertions}`;
+                const del = document.createElement("span");
+                del.className = "compare-count-del";
+                del.textContent = `−${file.deletions}`;
+                counts.append(add, del);
+            }
+            button.append(status, path, counts);
+            button.addEventListener("click", () => {
+                diffOverlay.style.display = "flex";
+                diffViewer.showFromUrl(compareUrl(data.baseRef, data.headRef, file.path));
+            });
+            item.appendChild(button);
+            list.appendChild(item);
+        }
+        section.appendChild(list);
+        if (data.truncated) {
+            section.appendChild(truncationNote(
+                `Showing the first ${data.files.length} of ${data.stats.filesChanged} files; line counts cover only these`,
+            ));
+        }
+        return section;
+    }
+
+    function render(data) {
+        body.innerHTML = "";
+        body.appendChild(renderSummary(data));
+        const commits = document.createElement("div");
+        commits.className = "compare-commits";
+        commits.append(
+            renderCommitList(`Only on ${data.headRef}`, data.aheadCommits || [], data.ahead),
+            renderCommitList(`Only on ${data.baseRef}`, data.behindCommits || [], data.behind),
+        );
+        body.appendChild(commits);
+        body.appendChild(renderFileList(data));
+    }
+
+    async function load(base, head) {
+        requestId += 1;
+        const id = requestId;
+        current = { base, head };
+        baseInput.value = base;
+        headInput.value = head;
+        diffViewer.close();
+        diffOverlay.style.display = "none";
+
+        body.innerHTML = "";
+        const loading = document.createElement("div");
+        loading.className = "compare-empty";
+        loading.textContent = `Comparing ${base}...${head}…`;
+        body.appendChild(loading);
+
+        try {
+            const resp = await apiFetch(compareUrl(base, head));
+            if (id !== requestId) return;
+            if (!resp.ok) {
+                const text = (await resp.text()).trim();
+                throw new Error(text || `HTTP ${resp.status}`);
+            }
+            const data = await resp.json();
+            if (id !== requestId) return;
+            render(data);
+        } catch (err) {
+            if (id !== requestId) return;
+            body.innerHTML = "";
+            body.appendChild(createInlineError({
+                message: `Failed to compare ${base}...${head}: ${err.message}`,
+                onRetry: () => load(base, head),
+            }));
+        }
+    }
+
+    return {
+        el,
+        load,
+        refresh: () => (current ? load(current.base, current.head) : Promise.resolve()),
+    };
+}
@@ -41,6 +41,7 @@ export function createDiffView(backend, diffContentViewer) {
         filterText: "",         // Filter input text (optional for v1, included for completeness)
         generation: 0,          // Stale response protection counter
         commitMessage: null,    // First line of commit message for header
+        owners: new Map(),      // path -> CODEOWNERS owners at HEAD, fetched after the entries
     };
 
     async function fetchCommitDiff(commitHash) {
@@ -60,6 +61,36 @@ export function createDiffView(backend, diffContentViewer) {
         return response.json();
     }
 
+    async function fetchCodeOwners(paths) {
+        const query = paths.map((p) => `path=${encodeURIComponent(p)}`).join("&");
+        const response = await apiFetch(apiUrl(`/codeowners?${query}`));
+        if (!response.ok) {
+            throw new Error(`Failed to fetch code owners: ${response.status} ${response.statusText}`);
+        }
+        return response.json();
+    }
+
+    /**
+     * Owners come from CODEOWNERS at HEAD rather than the commit diff, which
+     * is cached as immutable, so they load separately and are best effort.
+     */
+    async function loadOwners(gen) {
+        const paths = state.entries.map((entry) => entry.path);
+        for (let i = 0; i < paths.length; i += 100) {
+            try {
+                const data = await fetchCodeOwners(paths.slice(i, i + 100));
+                if (state.generation !== gen) return;
+                for (const [path, match] of Object.entries(data.owners || {})) {
+                    if (match.owners?.length) state.owners.set(path, match.owners);
+                }
+            } catch (err) {
+                console.error("Failed to fetch code owners:", err);
+                return;
+            }
+        }
+        if (state.owners.size > 0 && !state.showFileContent) render();
+    }
+
     function render() {
         el.innerHTML = "";
 
@@ -176,6 +207,15 @@ export function createDiffView(backend, diffContentViewer) {
             path.title = entry.path;
             item.appendChild(path);
 
+            const owners = state.owners.get(entry.path);
+            if (owners) {
+                const ownersBadge = document.createElement("span");
+                ownersBadge.className = "diff-file-owners";
+                ownersBadge.textContent = owners.join(" ");
+                ownersBadge.title = `CODEOWNERS: ${owners.join(", ")}`;
+                item.appendChild(ownersBadge);
+            }
+
             if (entry.binary) {
                 const binaryBadge = document.createElement("span");
                 binaryBadge.className = "diff-file-binary-badge";
@@ -280,6 +320,7 @@ export function createDiffView(backend, diffContentViewer) {
         state.selectedFile = null;
         state.showFileContent = false;
         state.filterText = "";
+        state.owners = new Map();
 
         el.style.display = "flex";
         render();
@@ -295,6 +336,7 @@ export function createDiffView(backend, diffContentViewer) {
             state.stats = diffData.stats || { added: 0, modified: 0, deleted: 0, filesChanged: 0 };
             state.loading = false;
             render();
+            loadOwners(gen);
         } catch (err) {
             console.error("Failed to fetch commit diff:", err);
             if (state.generation !== gen) return;
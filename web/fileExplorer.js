@@ -99,6 +99,14 @@ export function createFileExplorer() {
         externalDiff: null,         // { path, title, url } or null
     };
     let currentExternalDiffUrl = null;
+    // Ownership is computed at HEAD, so it is cached per directory path and
+    // dropped whenever a commit is opened.
+    const ownershipCache = new Map(); // path -> response, or null on failure
+    // Declared CODEOWNERS owners at HEAD, keyed like ownershipCache but with
+    // directories written as "dir/". Paths are looked up in batches.
+    const declaredOwnersCache = new Map(); // path -> owners array ([] when unowned)
+    const declaredOwnersPending = new Set();
+    const DECLARED_OWNERS_BATCH = 100;
 
     async function fetchTree(treeHash) {
         const response = await apiFetch(apiUrl(`/tree/${treeHash}`));
@@ -108,6 +116,63 @@ export function createFileExplorer() {
         return response.json();
     }
 
+    // The server builds the ownership model in the background after a HEAD
+    // change and answers 202 with Retry-After until it is ready.
+    async function fetchOwnership(path) {
+        for (;;) {
+            const response = await apiFetch(apiUrl(`/ownership?path=${encodeURIComponent(path)}`));
+            if (response.status === 202) {
+                const seconds = Number(response.headers.get("Retry-After")) || 2;
+                await new Promise((resolve) => setTimeout(resolve, seconds * 1000));
+                continue;
+            }
+            if (!response.ok) {
+                throw new Error(`Failed to fetch ownership for ${path || "/"}: ${response.status}`);
+            }
+            return response.json();
+        }
+    }
+
+    async function fetchDeclaredOwners(paths) {
+        const query = paths.map((p) => `path=${encodeURIComponent(p)}`).join("&");
+        const response = await apiFetch(apiUrl(`/codeowners?${query}`));
+        if (!response.ok) {
+            throw new Error(`Failed to fetch code owners: ${response.status}`);
+        }
+        return response.json();
+    }
+
+    /** Looks up declared owners for any paths not yet cached, then re-renders. */
+    function requestDeclaredOwners(paths) {
+        const missing = paths.filter((p) => !declaredOwnersCache.has(p) && !declaredOwnersPending.has(p));
+        if (missing.length === 0) return;
+        const gen = state.generation;
+        for (let i = 0; i < missing.length; i += DECLARED_OWNERS_BATCH) {
+            const batch = missing.slice(i, i + DECLARED_OWNERS_BATCH);
+            batch.forEach((p) => declaredOwnersPending.add(p));
+            fetchDeclaredOwners(batch)
+                .then((data) => {
+                    for (const p of batch) {
+                        declaredOwnersCache.set(p, data.owners?.[p]?.owners || []);
+                    }
+                })
+                .catch(() => batch.forEach((p) => declaredOwnersCache.set(p, [])))
+                .finally(() => {
+                    batch.forEach((p) => declaredOwnersPending.delete(p));
+                    if (state.generation === gen) render();
+                });
+        }
+    }
+
+    function declaredOwnersKey(path, isDir) {
+        return isDir ? `${path}/` : path;
+    }
+
+    function parentDirKey(path) {
+        const idx = path.lastIndexOf("/");
+        return idx < 0 ? "" : path.slice(0, idx + 1);
+    }
+
     async function fetchCommit(hash) {
         const response = await apiFetch(apiUrl(`/graph/commits?hashes=${encodeURIComponent(hash)}`));
         if (!response.ok) {
@@ -315,6 +380,42 @@ export function createFileExplorer() {
         return bar;
     }
 
+    /** Ownership summary for the current breadcrumb directory at HEAD. */
+    function renderOwnership() {
+        const path = state.breadcrumbPath || "";
+        if (!ownershipCache.has(path)) {
+            const gen = state.generation;
+            ownershipCache.set(path, undefined);
+            fetchOwnership(path)
+                .then((data) => ownershipCache.set(path, data))
+                .catch(() => ownershipCache.set(path, null))
+                .finally(() => {
+                    if (state.generation === gen && (state.breadcrumbPath || "") === path) render();
+                });
+            return null;
+        }
+        const data = ownershipCache.get(path);
+        if (!data?.directory) return null;
+
+        const dir = data.directory;
+        const bar = document.createElement("div");
+        bar.className = "file-explorer-ownership";
+        bar.title = `Knowledge at HEAD from lines changed, halving every ${data.halfLifeDays} days`;
+        const owners = (dir.owners || []).slice(0, 3)
+            .map((o) => `${o.name} ${Number(o.share || 0).toFixed(0)}%${o.active ? "" : " (inactive)"}`)
+            .join(", ");
+        const parts = [`Bus factor ${dir.busFactor}`];
+        if (owners) parts.push(owners);
+        const declared = path ? declaredOwnersCache.get(declaredOwnersKey(path, true)) : null;
+        if (declared?.length) parts.push(`CODEOWNERS ${declared.join(" ")}`);
+        if (data.orphanedCount > 0) {
+            parts.push(`${data.orphanedCount} orphaned file${data.orphanedCount === 1 ? "" : "s"}`);
+        }
+        bar.textContent = parts.join(" \u00B7 ");
+        if (data.orphanedCount > 0) bar.classList.add("has-orphans");
+        return bar;
+    }
+
     /** Scroll to and focus a specific path in the tree. */
     function scrollToPath(path) {
         const row = el.querySelector(`[data-path="${CSS.escape(path)}"]`);
@@ -517,6 +618,10 @@ export function createFileExplorer() {
         if (breadcrumbs) {
             el.appendChild(breadcrumbs);
         }
+        const ownership = renderOwnership();
+        if (ownership) {
+            el.appendChild(ownership);
+        }
 
         if (state.loading) {
             el.appendChild(renderSkeletonRows());
@@ -531,6 +636,14 @@ export function createFileExplorer() {
         treeContainer.setAttribute("tabindex", "0");
 
         const visibleEntries = buildVisibleEntries();
+        const ownerKeys = new Set();
+        if (state.breadcrumbPath) ownerKeys.add(declaredOwnersKey(state.breadcrumbPath, true));
+        for (const entry of visibleEntries) {
+            ownerKeys.add(declaredOwnersKey(entry.path, entry.isDir));
+            const parent = parentDirKey(entry.path);
+            if (parent) ownerKeys.add(parent);
+        }
+        requestDeclaredOwners([...ownerKeys]);
 
         if (state.focusedIndex >= visibleEntries.length) {
             state.focusedIndex = Math.max(0, visibleEntries.length - 1);
@@ -593,6 +706,19 @@ export function createFileExplorer() {
             name.textContent = entry.name;
             row.appendChild(name);
 
+            // Owners are only labelled where they differ from the parent
+            // directory's, so a catch-all rule does not repeat on every row.
+            const declared = declaredOwnersCache.get(declaredOwnersKey(entry.path, entry.isDir));
+            const parentKey = parentDirKey(entry.path);
+            const inherited = parentKey ? declaredOwnersCache.get(parentKey) : null;
+            if (declared?.length && declared.join(" ") !== inherited?.join(" ")) {
+                const owners = document.createElement("span");
+                owners.className = "explorer-owners";
+                owners.textContent = declared.join(" ");
+                owners.title = `CODEOWNERS: ${declared.join(", ")}`;
+                row.appendChild(owners);
+            }
+
             const statusInfo = getStatusForPath(entry.path, entry.isDir);
             if (statusInfo) {
                 if (statusInfo.isDirIndicator) {
@@ -873,6 +999,8 @@ export function createFileExplorer() {
         state.rootTreeHash = resolvedCommit.tree;
         state.expandedDirs.clear();
         state.treeCache.clear();
+        ownershipCache.clear();
+        declaredOwnersCache.clear();
         state.selectedFile = null;
         state.focusedIndex = 0;
         state.filterText = "";
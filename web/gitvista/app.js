@@ -4,6 +4,7 @@ import { startBackend } from "../backend.js";
 import { createWorkbench } from "../workbench.js";
 import { createIndexView } from "../indexView.js";
 import { createStagingView } from "../stagingView.js";
+import { createCompareView } from "../compareView.js";
 import { showToast } from "../toast.js";
 import { createKeyboardShortcuts } from "../keyboardShortcuts.js";
 import { createKeyboardHelp } from "../keyboardHelp.js";
@@ -139,6 +140,25 @@ export function bootstrapGraph(root, options = {}) {
         telemetryStore.recordDiffStatsRequest(limit, true);
         return resp.json();
     };
+    const fetchPickaxe = async (term, { onRecord, signal } = {}) => {
+        const resp = await apiFetch(apiUrl("/pickaxe?s=" + encodeURIComponent(term)), { signal });
+        if (!resp.ok || !resp.body) {
+            throw new Error("Failed to run pickaxe search");
+        }
+        const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+        let buffered = "";
+        for (;;) {
+            const { value, done } = await reader.read();
+            if (done) break;
+            buffered += value;
+            let newline;
+            while ((newline = buffered.indexOf("\n")) >= 0) {
+                const line = buffered.slice(0, newline);
+                buffered = buffered.slice(newline + 1);
+                if (line) onRecord?.(JSON.parse(line));
+            }
+        }
+    };
     let workbench = null;
     const stagingView = createStagingView({
         onSelectFile: ({ path }) => {
@@ -240,7 +260,7 @@ export function bootstrapGraph(root, options = {}) {
     const fileExplorerPanel = createLazyHost("Loading file explorer…");
     const analyticsPanel = createLazyHost("Loading analytics…");
 
-    const fetchAnalytics = async ({ period, start, end } = {}) => {
+    const fetchAnalytics = async ({ period, start, end, tz } = {}) => {
         const params = new URLSearchParams();
         if (typeof start === "string" && start && typeof end === "string" && end) {
             params.set("start", start);
@@ -249,6 +269,9 @@ export function bootstrapGraph(root, options = {}) {
             const p = typeof period === "string" && period ? period : "all";
             params.set("period", p);
         }
+        if (typeof tz === "string" && tz && tz !== "utc") {
+            params.set("tz", tz);
+        }
         const resp = await apiFetch(apiUrl(`/analytics?${params.toString()}`));
         if (!resp.ok) throw new Error("Failed to fetch analytics");
         return resp.json();
@@ -289,6 +312,10 @@ export function bootstrapGraph(root, options = {}) {
             })
     );
 
+    const compareView = createCompareView({
+        onCommitSelect: (hash) => graph?.selectAndCenter?.(hash),
+    });
+
     const ensureFileExplorerLoaded = () => fileExplorerLoader.ensure();
     const ensureAnalyticsLoaded = () => analyticsLoader.ensure();
 
@@ -320,6 +347,7 @@ export function bootstrapGraph(root, options = {}) {
             },
         },
         { name: "three-zones", tooltip: "Lifecycle", content: stagingView.el },
+        { name: "compare", tooltip: "Compare", content: compareView.el },
         {
             name: "analytics",
             tooltip: "Analytics",
@@ -394,6 +422,7 @@ export function bootstrapGraph(root, options = {}) {
         getCommitCount: () => graph.getCommitCount(),
         getTags: () => graph.getTags?.() ?? new Map(),
         fetchDiffStats,
+        fetchPickaxe,
         onSearch: ({ searchState }) => {
             graph.setSearchState(searchState ?? null);
             search.clearPosition();
@@ -558,8 +587,8 @@ export function bootstrapGraph(root, options = {}) {
     }
 
     function getLaunchTarget() {
-        if (typeof parseLaunchTarget !== "function") return { path: null, commitHash: null };
-        return parseLaunchTarget() || { path: null, commitHash: null };
+        if (typeof parseLaunchTarget !== "function") return { path: null, compare: null, commitHash: null };
+        return parseLaunchTarget() || { path: null, compare: null, commitHash: null };
     }
 
     function openInitialPath(path, commitHash) {
@@ -614,6 +643,10 @@ export function bootstrapGraph(root, options = {}) {
                 openInitialPath(launchTarget.path, launchTarget.commitHash || permalinkHash);
             }, 120);
         }
+        if (launchTarget.compare) {
+            workbench?.focusView?.("compare");
+            compareView.load(launchTarget.compare.base, launchTarget.compare.head);
+        }
         openHeadInExplorerIfEmpty();
     }
 
@@ -4,9 +4,20 @@ export function parseHashFragment(hash) {
     return { commitHash: COMMIT_HASH_RE.test(fragment) ? fragment : null };
 }
 
+export function parseCompareRange(value) {
+    if (typeof value !== "string") return null;
+    const sep = value.indexOf("...");
+    if (sep <= 0) return null;
+    const base = value.slice(0, sep);
+    const head = value.slice(sep + 3);
+    if (!head) return null;
+    return { base, head };
+}
+
 export function parseLaunchTarget(search, hash) {
     const params = new URLSearchParams(typeof search === "string" ? search : "");
     const path = params.get("path") || null;
+    const compare = parseCompareRange(params.get("compare"));
     const commitHash = parseHashFragment(hash).commitHash;
-    return { path, commitHash };
+    return { path, compare, commitHash };
 }
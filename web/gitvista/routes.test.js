@@ -1,6 +1,6 @@
 import { describe, it } from "node:test";
 import assert from "node:assert/strict";
-import { parseHashFragment, parseLaunchTarget } from "./routes.js";
+import { parseCompareRange, parseHashFragment, parseLaunchTarget } from "./routes.js";
 
 describe("parseHashFragment", () => {
     it("returns null when the fragment is empty", () => {
@@ -28,6 +28,7 @@ describe("parseLaunchTarget", () => {
         const hash = "0123456789abcdef0123456789abcdef01234567";
         assert.deepEqual(parseLaunchTarget("?path=src/main.js", `#${hash}`), {
             path: "src/main.js",
+            compare: null,
             commitHash: hash,
         });
     });
@@ -35,7 +36,25 @@ describe("parseLaunchTarget", () => {
     it("returns nulls when launch params are absent", () => {
         assert.deepEqual(parseLaunchTarget("", ""), {
             path: null,
+            compare: null,
             commitHash: null,
         });
     });
+
+    it("parses three-dot compare ranges", () => {
+        assert.deepEqual(parseLaunchTarget("?compare=main...feature/x", ""), {
+            path: null,
+            compare: { base: "main", head: "feature/x" },
+            commitHash: null,
+        });
+    });
+});
+
+describe("parseCompareRange", () => {
+    it("rejects ranges without both sides", () => {
+        assert.equal(parseCompareRange("main..feature"), null);
+        assert.equal(parseCompareRange("...feature"), null);
+        assert.equal(parseCompareRange("main..."), null);
+        assert.equal(parseCompareRange(null), null);
+    });
 });
@@ -20,6 +20,8 @@
  * @property {GraphSignature} [author] Author metadata.
  * @property {GraphSignature} [committer] Committer metadata.
  * @property {string[]} [parents] Array of parent commit hashes.
+ * @property {{key: string, value: string}[]} [trailers] Trailers parsed from the end of the message.
+ * @property {GraphSignature[]} [coAuthors] Identities from Co-authored-by trailers.
  * @property {string} [branchLabel] Derived branch label for this commit.
  * @property {string} [branchLabelSource] Provenance for the derived branch label.
  */
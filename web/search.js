@@ -3,7 +3,11 @@
  *
  * Renders a debounced search input with:
  *   - Structured query parsing via searchQuery.js (qualifiers: author:, hash:,
- *     after:, before:, merge:, branch:, message:, tag:; negation via - prefix)
+ *     after:, before:, merge:, branch:, message:, tag:, file:, path:, pickaxe:,
+ *     trailer:;
+ *     negation via - prefix)
+ *   - pickaxe: scans stream from the server; the graph refines as matches
+ *     arrive and the result badge shows scan progress
  *   - Inline "N / M" result count badge updated after each search
  *   - Inline parse-error hints for malformed qualifiers
  *   - Search dropdown: qualifier suggestions + recent searches (localStorage)
@@ -28,6 +32,10 @@ const MAX_RECENT_SEARCHES = 5;
 // localStorage key for recent searches.
 const RECENT_SEARCHES_KEY = "gitvista-recent-searches";
 
+// Minimum interval between re-running the search while a pickaxe scan streams
+// in matches, so long scans don't re-filter the graph on every record.
+const PICKAXE_REFRESH_MS = 250;
+
 // ── Qualifier definitions ──────────────────────────────────────────────────────
 
 /**
@@ -44,6 +52,8 @@ const QUALIFIERS = [
     { text: "tag:",    description: "Commits pointed at by a matching tag" },
     { text: "file:",   description: "Commits that touched a file (basename match)" },
     { text: "path:",   description: "Commits that touched files under a directory" },
+    { text: "pickaxe:", description: "Commits that added or removed a string (git log -S)" },
+    { text: "trailer:", description: "Commits with a trailer, e.g. trailer:co-authored-by=jane" },
     { text: "merge:only",    description: "Show only merge commits" },
     { text: "merge:exclude", description: "Exclude merge commits" },
     { text: "branch:", description: "Commits reachable from branch" },
@@ -89,6 +99,7 @@ function saveRecentSearch(query) {
  *   getCommitCount: () => { matching: number, total: number },
  *   getTags: () => Map<string, string>,
  *   fetchDiffStats: (opts?: { limit?: number }) => Promise<Object>,
+ *   fetchPickaxe?: (term: string, opts: { onRecord: (record: Object) => void, signal: AbortSignal }) => Promise<void>,
  *   onSearch: (result: {
  *     searchState: { query: import("./searchQuery.js").SearchQuery, matcher: ((commit: any) => boolean) | null } | null
  *   }) => void,
@@ -100,7 +111,7 @@ function saveRecentSearch(query) {
  *   destroy(): void,
  * }}
  */
-export function createSearch(container, { getBranches, getCommits, getCommitCount, getTags, fetchDiffStats, onSearch }) {
+export function createSearch(container, { getBranches, getCommits, getCommitCount, getTags, fetchDiffStats, fetchPickaxe, onSearch }) {
     // ── DOM construction ───────────────────────────────────────────────────────
 
     // Outer positioning wrapper — provides the relative context for the dropdown.
@@ -184,6 +195,9 @@ export function createSearch(container, { getBranches, getCommits, getCommitCoun
     let diffStatsCache = null;       // Map<string, string[]> | null
     let diffStatsPartial = false;    // true when backend reports incomplete coverage
     let diffStatsFetchPromise = null; // Promise | null (dedup concurrent fetches)
+    const pickaxeIndex = new Map();   // Map<string, Set<string>> term → matching commit hashes
+    const pickaxeScans = new Map();   // Map<string, { controller, scanned, total }> in-flight scans
+    let pickaxeRefreshTimer = null;
 
     // ── Dropdown rendering ─────────────────────────────────────────────────────
 
@@ -400,12 +414,87 @@ export function createSearch(container, { getBranches, getCommits, getCommitCoun
         const { matching, total, pendingHydration = 0 } = getCommitCount();
         const loadingSuffix = pendingHydration > 0 ? ` (${pendingHydration} loading)` : "";
         const partialSuffix = diffStatsPartial ? " (partial diffstats)" : "";
-        resultCount.textContent = `${matching} / ${total}${loadingSuffix}${partialSuffix}`;
+        resultCount.textContent = `${matching} / ${total}${loadingSuffix}${partialSuffix}${pickaxeProgressSuffix()}`;
         resultCount.style.display = "inline-flex";
         // Warning styling when zero results.
         resultCount.classList.toggle("is-empty", matching === 0);
     }
 
+    /**
+     * Describes in-flight pickaxe scans for the result badge, e.g. " (scanning 40%)".
+     *
+     * @returns {string}
+     */
+    function pickaxeProgressSuffix() {
+        if (pickaxeScans.size === 0) return "";
+        let scanned = 0;
+        let total = 0;
+        for (const scan of pickaxeScans.values()) {
+            scanned += scan.scanned;
+            total += scan.total;
+        }
+        if (total === 0) return " (scanning history)";
+        return ` (scanning ${Math.floor((scanned / total) * 100)}%)`;
+    }
+
+    // ── Pickaxe scans ───────────────────────────────────────────────────────────
+
+    function schedulePickaxeRefresh() {
+        if (pickaxeRefreshTimer !== null) return;
+        pickaxeRefreshTimer = setTimeout(() => {
+            pickaxeRefreshTimer = null;
+            executeSearch(input.value);
+        }, PICKAXE_REFRESH_MS);
+    }
+
+    /**
+     * Starts streaming scans for pickaxe terms that have no results yet and
+     * aborts scans for terms no longer in the query. Aborted terms are dropped
+     * from the index so a partial result set is never mistaken for a complete
+     * one; finished scans stay cached for the lifetime of the component.
+     *
+     * @param {string[]} terms
+     */
+    function syncPickaxeScans(terms) {
+        const wanted = new Set(terms);
+        for (const [term, scan] of pickaxeScans) {
+            if (wanted.has(term)) continue;
+            scan.controller.abort();
+            pickaxeScans.delete(term);
+            pickaxeIndex.delete(term);
+        }
+        if (!fetchPickaxe) return;
+
+        for (const term of wanted) {
+            if (pickaxeIndex.has(term)) continue;
+            const hashes = new Set();
+            const scan = { controller: new AbortController(), scanned: 0, total: 0 };
+            pickaxeIndex.set(term, hashes);
+            pickaxeScans.set(term, scan);
+
+            fetchPickaxe(term, {
+                signal: scan.controller.signal,
+                onRecord: (record) => {
+                    if (record.type === "match" && record.hash) {
+                        hashes.add(record.hash);
+                    } else if (record.type === "progress" || record.type === "done") {
+                        scan.scanned = record.scanned ?? scan.scanned;
+                        scan.total = record.total ?? scan.total;
+                    }
+                    schedulePickaxeRefresh();
+                },
+            })
+                .catch(() => {
+                    // Aborted or failed: whatever matched so far stays visible.
+                })
+                .finally(() => {
+                    if (pickaxeScans.get(term) !== scan) return;
+                    pickaxeScans.delete(term);
+                    executeSearch(input.value);
+                });
+        }
+    }
+
     // ── Search execution ────────────────────────────────────────────────────────
 
     /**
@@ -426,6 +515,8 @@ export function createSearch(container, { getBranches, getCommits, getCommitCoun
             parseHint.textContent = "";
         }
 
+        syncPickaxeScans([...query.pickaxes, ...query.negatedPickaxes]);
+
         if (query.isEmpty) {
             onSearch({ searchState: null });
             updateResultCount(false);
@@ -464,7 +555,7 @@ export function createSearch(container, { getBranches, getCommits, getCommitCoun
 
         // Build the matcher with live graph data (called at search time so it
         // always captures the current branches/commits/tags maps).
-        const matcher = createSearchMatcher(query, getBranches(), getCommits(), getTags(), diffStatsCache);
+        const matcher = createSearchMatcher(query, getBranches(), getCommits(), getTags(), diffStatsCache, pickaxeIndex);
 
         const searchState = { query, matcher };
         onSearch({ searchState });
@@ -505,6 +596,7 @@ export function createSearch(container, { getBranches, getCommits, getCommitCoun
         parseHint.style.display = "none";
         parseHint.textContent = "";
         clearTimeout(debounceTimer);
+        syncPickaxeScans([]);
         onSearch({ searchState: null });
         closeDropdown();
         input.focus();
@@ -644,6 +736,8 @@ export function createSearch(container, { getBranches, getCommits, getCommitCoun
         /** Removes DOM nodes and event listeners. */
         destroy() {
             clearTimeout(debounceTimer);
+            clearTimeout(pickaxeRefreshTimer);
+            syncPickaxeScans([]);
             input.removeEventListener("input", onInputChange);
             input.removeEventListener("focus", onFocus);
             input.removeEventListener("keydown", onKeyDown);
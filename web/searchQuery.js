@@ -17,6 +17,11 @@
  *   tag:<value>          — commits pointed at by a tag matching the value (substring)
  *   file:<name>          — commits that touched a file with matching basename
  *   path:<prefix>        — commits that touched files under a directory prefix
+ *   pickaxe:<string>     — commits that added or removed <string> (git log -S);
+ *                          case-sensitive, matched server-side
+ *   trailer:<key>=<value> — commits with a message trailer <key> (e.g.
+ *                          co-authored-by) whose value contains <value>;
+ *                          trailer:<key> alone matches any value
  *
  * Negation: any qualifier or bare term can be prefixed with `-` to invert it.
  *   -author:bot          — exclude commits by authors matching "bot"
@@ -28,6 +33,9 @@
  * Bare (unqualified) tokens are treated as message/author/hash substrings.
  * Multiple values for the same qualifier are OR'd; different qualifiers AND.
  * Unrecognized qualifier prefixes are treated as bare text (forgiving parser).
+ *
+ * internal/search implements the same language in Go for /api/search, which
+ * answers queries against the full history; keep the two in step.
  */
 
 // ── Types ──────────────────────────────────────────────────────────────────────
@@ -61,10 +69,20 @@
  * @property {string[]} negatedFiles Negated file: qualifier values.
  * @property {string[]} paths Positive path: qualifier values (directory prefix match).
  * @property {string[]} negatedPaths Negated path: qualifier values.
+ * @property {string[]} pickaxes Positive pickaxe: qualifier values (case preserved).
+ * @property {string[]} negatedPickaxes Negated pickaxe: qualifier values.
+ * @property {TrailerFilter[]} trailers Positive trailer: qualifier filters.
+ * @property {TrailerFilter[]} negatedTrailers Negated trailer: qualifier filters.
  * @property {ParseError[]} errors Parse-time warnings for malformed qualifiers.
  * @property {boolean} isEmpty True when no meaningful criteria are present.
  */
 
+/**
+ * @typedef {Object} TrailerFilter
+ * @property {string} key Lowercased trailer key, e.g. "co-authored-by".
+ * @property {string} value Lowercased value substring; empty matches any value.
+ */
+
 // ── Date parsing ───────────────────────────────────────────────────────────────
 
 /**
@@ -171,7 +189,7 @@ function tokenize(raw) {
 // ── Known qualifiers ──────────────────────────────────────────────────────────
 
 /** Set of recognized qualifier prefixes (lowercase, without colon). */
-const KNOWN_QUALIFIERS = new Set(["author", "hash", "after", "before", "merge", "branch", "message", "tag", "file", "path"]);
+const KNOWN_QUALIFIERS = new Set(["author", "hash", "after", "before", "merge", "branch", "message", "tag", "file", "path", "pickaxe", "trailer"]);
 
 // ── parseSearchQuery ──────────────────────────────────────────────────────────
 
@@ -207,6 +225,10 @@ export function parseSearchQuery(raw) {
         negatedFiles: [],
         paths: [],
         negatedPaths: [],
+        pickaxes: [],
+        negatedPickaxes: [],
+        trailers: [],
+        negatedTrailers: [],
         errors: [],
         isEmpty: false,
     };
@@ -336,6 +358,30 @@ export function parseSearchQuery(raw) {
                             else query.paths.push(value.toLowerCase());
                         }
                         break;
+                    case "pickaxe":
+                        // Unlike other qualifiers the value keeps its case:
+                        // git's -S is case-sensitive.
+                        if (value) {
+                            if (negated) query.negatedPickaxes.push(value);
+                            else query.pickaxes.push(value);
+                        }
+                        break;
+                    case "trailer": {
+                        if (!value) break;
+                        const eqIdx = value.indexOf("=");
+                        const key = (eqIdx >= 0 ? value.slice(0, eqIdx) : value).trim().toLowerCase();
+                        const wanted = eqIdx >= 0 ? value.slice(eqIdx + 1).trim().toLowerCase() : "";
+                        if (!key) {
+                            query.errors.push({
+                                token: token,
+                                message: `Missing trailer key — use trailer:key or trailer:key=value`,
+                            });
+                            break;
+                        }
+                        if (negated) query.negatedTrailers.push({ key, value: wanted });
+                        else query.trailers.push({ key, value: wanted });
+                        break;
+                    }
                 }
                 continue;
             }
@@ -369,7 +415,11 @@ export function parseSearchQuery(raw) {
         query.files.length > 0 ||
         query.negatedFiles.length > 0 ||
         query.paths.length > 0 ||
-        query.negatedPaths.length > 0;
+        query.negatedPaths.length > 0 ||
+        query.pickaxes.length > 0 ||
+        query.negatedPickaxes.length > 0 ||
+        query.trailers.length > 0 ||
+        query.negatedTrailers.length > 0;
 
     query.isEmpty = !hasContent;
     return query;
@@ -412,6 +462,20 @@ function buildReachableSet(tipHash, commits) {
     return reachable;
 }
 
+/**
+ * Reports whether a commit has a trailer matching the filter. Keys compare
+ * case-insensitively, as git's do.
+ *
+ * @param {import("./graph/types.js").GraphCommit} commit
+ * @param {TrailerFilter} filter
+ * @returns {boolean}
+ */
+function hasTrailer(commit, filter) {
+    return (commit.trailers ?? []).some(
+        (t) => (t.key ?? "").toLowerCase() === filter.key && (t.value ?? "").toLowerCase().includes(filter.value),
+    );
+}
+
 // ── createSearchMatcher ────────────────────────────────────────────────────────
 
 /**
@@ -430,9 +494,10 @@ function buildReachableSet(tipHash, commits) {
  * @param {Map<string, import("./graph/types.js").GraphCommit>} [commits] All known commits. Required for branch: qualifier.
  * @param {Map<string, string>} [tags] Tag map (name → commit hash). Required for tag: qualifier.
  * @param {Map<string, string[]>} [fileIndex] Commit hash → file paths touched. Required for file:/path: qualifiers.
+ * @param {Map<string, Set<string>>} [pickaxeIndex] Pickaxe string → hashes of commits that changed it. Required for pickaxe: qualifiers.
  * @returns {((commit: import("./graph/types.js").GraphCommit) => boolean) | null}
  */
-export function createSearchMatcher(query, branches, commits, tags, fileIndex) {
+export function createSearchMatcher(query, branches, commits, tags, fileIndex, pickaxeIndex) {
     if (query.isEmpty) return null;
 
     // Pre-compute the branch reachability set once (not per-commit).
@@ -584,6 +649,16 @@ export function createSearchMatcher(query, branches, commits, tags, fileIndex) {
             if (matchesAny) return false;
         }
 
+        // ── trailer: (OR among values) ────────────────────────────────────────
+        if (query.trailers.length > 0) {
+            if (!query.trailers.some((f) => hasTrailer(commit, f))) return false;
+        }
+
+        // -trailer: any match → exclude
+        if (query.negatedTrailers.length > 0) {
+            if (query.negatedTrailers.some((f) => hasTrailer(commit, f))) return false;
+        }
+
         // ── merge: only | exclude (with negation inversion) ───────────────────
         if (query.merge !== null) {
             const parentCount = commit.parents?.length ?? 0;
@@ -655,6 +730,18 @@ export function createSearchMatcher(query, branches, commits, tags, fileIndex) {
             }
         }
 
+        // ── pickaxe: (OR among values) — commit changed the string ───────────
+        if (query.pickaxes.length > 0) {
+            const matchesAny = query.pickaxes.some((p) => pickaxeIndex?.get(p)?.has(commit.hash));
+            if (!matchesAny) return false;
+        }
+
+        // -pickaxe: any match → exclude
+        if (query.negatedPickaxes.length > 0) {
+            const matchesAny = query.negatedPickaxes.some((p) => pickaxeIndex?.get(p)?.has(commit.hash));
+            if (matchesAny) return false;
+        }
+
         // ── branch: reachability (with negation inversion) ────────────────────
         if (reachableSet !== null) {
             if (query.negateBranch) {
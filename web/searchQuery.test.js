@@ -321,6 +321,45 @@ describe("parseSearchQuery", () => {
         });
     });
 
+    describe("pickaxe: qualifier", () => {
+        it("parses pickaxe: preserving case", () => {
+            const q = parseSearchQuery("pickaxe:loadConfig");
+            assert.deepEqual(q.pickaxes, ["loadConfig"]);
+            assert.equal(q.isEmpty, false);
+        });
+
+        it("accepts quoted values with spaces", () => {
+            const q = parseSearchQuery('pickaxe:"max_connections = 10"');
+            assert.deepEqual(q.pickaxes, ["max_connections = 10"]);
+        });
+
+        it("parses -pickaxe: as negated", () => {
+            const q = parseSearchQuery("-pickaxe:TODO");
+            assert.deepEqual(q.negatedPickaxes, ["TODO"]);
+            assert.deepEqual(q.pickaxes, []);
+        });
+    });
+
+    describe("trailer: qualifier", () => {
+        it("parses key=value lowercased", () => {
+            const q = parseSearchQuery('trailer:"Co-authored-by = Jane Doe"');
+            assert.deepEqual(q.trailers, [{ key: "co-authored-by", value: "jane doe" }]);
+            assert.equal(q.isEmpty, false);
+        });
+
+        it("parses a key alone and negation", () => {
+            const q = parseSearchQuery("-trailer:Signed-off-by");
+            assert.deepEqual(q.negatedTrailers, [{ key: "signed-off-by", value: "" }]);
+            assert.deepEqual(q.trailers, []);
+        });
+
+        it("reports a missing key", () => {
+            const q = parseSearchQuery("trailer:=jane");
+            assert.equal(q.errors.length, 1);
+            assert.equal(q.isEmpty, true);
+        });
+    });
+
     describe("combined qualifiers", () => {
         it("parses author + after together", () => {
             const q = parseSearchQuery("author:alice after:7d");
@@ -709,6 +748,58 @@ describe("createSearchMatcher", () => {
         });
     });
 
+    describe("pickaxe: qualifier matching", () => {
+        const pickaxeIndex = new Map([
+            ["loadConfig", new Set(["aabbccdd00112233445566778899aabbccddeeff"])],
+        ]);
+
+        it("matches commits in the pickaxe results", () => {
+            const q = parseSearchQuery("pickaxe:loadConfig");
+            const matcher = createSearchMatcher(q, new Map(), new Map(), new Map(), null, pickaxeIndex);
+            assert.equal(matcher(makeCommit()), true);
+            assert.equal(matcher(makeCommit({ hash: "1234" })), false);
+        });
+
+        it("rejects everything while results are not loaded", () => {
+            const q = parseSearchQuery("pickaxe:other");
+            const matcher = createSearchMatcher(q, new Map(), new Map(), new Map(), null, pickaxeIndex);
+            assert.equal(matcher(makeCommit()), false);
+        });
+
+        it("excludes with -pickaxe:", () => {
+            const q = parseSearchQuery("-pickaxe:loadConfig");
+            const matcher = createSearchMatcher(q, new Map(), new Map(), new Map(), null, pickaxeIndex);
+            assert.equal(matcher(makeCommit()), false);
+            assert.equal(matcher(makeCommit({ hash: "1234" })), true);
+        });
+    });
+
+    describe("trailer: qualifier matching", () => {
+        const paired = makeCommit({
+            trailers: [
+                { key: "Co-authored-by", value: "Bob <bob@example.com>" },
+                { key: "Reviewed-by", value: "Carol <carol@example.com>" },
+            ],
+        });
+
+        it("matches key and value substring", () => {
+            const matcher = createSearchMatcher(parseSearchQuery("trailer:co-authored-by=bob"));
+            assert.equal(matcher(paired), true);
+            assert.equal(matcher(makeCommit()), false);
+        });
+
+        it("matches any value for a bare key", () => {
+            const matcher = createSearchMatcher(parseSearchQuery("trailer:reviewed-by"));
+            assert.equal(matcher(paired), true);
+        });
+
+        it("excludes with -trailer:", () => {
+            const matcher = createSearchMatcher(parseSearchQuery("-trailer:reviewed-by=carol"));
+            assert.equal(matcher(paired), false);
+            assert.equal(matcher(makeCommit()), true);
+        });
+    });
+
     describe("path: qualifier matching", () => {
         const fileIndex = new Map([
             ["aabbccdd00112233445566778899aabbccddeeff", ["internal/server/handlers.go", "web/app.js"]],
@@ -6,6 +6,60 @@
 
 import { Tooltip, createTooltipElement } from "./baseTooltip.js";
 import { formatRelativeTime } from "../utils/format.js";
+import { apiFetch } from "../apiFetch.js";
+import { apiUrl } from "../apiBase.js";
+
+const DESCRIBE_CACHE_TTL_MS = 60_000;
+
+/**
+ * Describe lookups keyed by commit hash. Entries expire so that newly created
+ * tags show up without a page reload; failed lookups are evicted immediately.
+ * @type {Map<string, {fetchedAt: number, pending: Promise<{describe: string, contains: string} | null>}>}
+ */
+const describeCache = new Map();
+
+/**
+ * Fetches the `git describe` style name for a commit, sharing in-flight requests.
+ *
+ * @param {string} hash Full commit hash.
+ * @returns {Promise<{describe: string, contains: string} | null>}
+ */
+function fetchDescribe(hash) {
+    const cached = describeCache.get(hash);
+    if (cached && Date.now() - cached.fetchedAt < DESCRIBE_CACHE_TTL_MS) {
+        return cached.pending;
+    }
+
+    const pending = apiFetch(apiUrl(`/commit/describe/${hash}`))
+        .then((response) => (response.ok ? response.json() : null))
+        .catch(() => null)
+        .then((data) => {
+            if (!data) describeCache.delete(hash);
+            return data;
+        });
+    describeCache.set(hash, { fetchedAt: Date.now(), pending });
+    return pending;
+}
+
+/** Trailers listed under the author line, with the label each is shown with. */
+const CREDIT_TRAILERS = [
+    ["signed-off-by", "Signed-off-by"],
+    ["reviewed-by", "Reviewed-by"],
+];
+
+/**
+ * Returns the names in a commit's trailers with the given key, dropping the
+ * "<email>" part of "Name <email>" values.
+ *
+ * @param {import("../graph/types.js").GraphCommit} commit Commit to read.
+ * @param {string} key Lowercase trailer key.
+ * @returns {string[]}
+ */
+function trailerNames(commit, key) {
+    return (commit.trailers ?? [])
+        .filter((t) => (t.key ?? "").toLowerCase() === key)
+        .map((t) => t.value.replace(/\s*<[^>]*>$/, "") || t.value);
+}
 
 /**
  * Tooltip that displays commit details such as hash, author, and message.
@@ -71,7 +125,11 @@ export class CommitTooltip extends Tooltip {
         this.hashRowEl.append(this.hashEl, this.copyBtn);
 
         this.metaEl = createTooltipElement("div", "commit-tooltip-meta");
-        this.headerEl.append(this.hashRowEl, this.metaEl);
+        this.creditsEl = createTooltipElement("div", "commit-tooltip-credits");
+        this.creditsEl.hidden = true;
+        this.describeEl = createTooltipElement("div", "commit-tooltip-describe");
+        this.describeEl.hidden = true;
+        this.headerEl.append(this.hashRowEl, this.metaEl, this.creditsEl, this.describeEl);
 
         this.stashBadgeEl = createTooltipElement("div", "commit-tooltip-stash-badge");
         this.stashBadgeEl.style.cssText = `
@@ -183,6 +241,25 @@ export class CommitTooltip extends Tooltip {
         }
         this.metaEl.textContent = metaParts.join(" \u2022 ");
 
+        // Co-authors, sign-offs, and reviewers from the message trailers.
+        const credits = [];
+        const coAuthors = (commit.coAuthors ?? []).map((a) => a.name || a.email);
+        if (coAuthors.length > 0) {
+            credits.push(`Co-authored-by ${coAuthors.join(", ")}`);
+        }
+        for (const [key, label] of CREDIT_TRAILERS) {
+            const names = trailerNames(commit, key);
+            if (names.length > 0) credits.push(`${label} ${names.join(", ")}`);
+        }
+        this.creditsEl.textContent = credits.join("\n");
+        this.creditsEl.hidden = credits.length === 0;
+
+        this.describeEl.hidden = true;
+        this.describeEl.textContent = "";
+        if (!node.isStash) {
+            this._loadDescribe(commit.hash);
+        }
+
         this.messageEl.textContent = commit.message || "(no message)";
 
         // Keep button state consistent: enabled when navigate is wired.
@@ -191,6 +268,25 @@ export class CommitTooltip extends Tooltip {
         this.nextBtn.disabled = !hasNav;
     }
 
+    /**
+     * Fills the describe row once the server responds, unless the tooltip has
+     * moved on to a different commit in the meantime.
+     *
+     * @param {string} hash Commit hash to describe.
+     */
+    _loadDescribe(hash) {
+        fetchDescribe(hash).then((data) => {
+            if (!data || this.targetData?.commit?.hash !== hash) return;
+            const parts = [];
+            if (data.describe) parts.push(data.describe);
+            if (data.contains) parts.push(`in ${data.contains}`);
+            if (parts.length === 0) return;
+            this.describeEl.textContent = parts.join(" \u2022 ");
+            this.describeEl.title = "git describe --tags / name-rev --tags";
+            this.describeEl.hidden = false;
+        });
+    }
+
     /**
      * @param {import("../graph/types.js").GraphNodeCommit} node Commit node used for anchoring.
      * @returns {{x: number, y: number}} Logical coordinates for tooltip placement.
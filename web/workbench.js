@@ -24,6 +24,9 @@ const ICONS = {
         <rect x="6.5" y="4" width="3" height="11" rx=".75"/>
         <rect x="12" y="1" width="3" height="14" rx=".75"/>
     </svg>`,
+    compare: `<svg width="20" height="20" viewBox="0 0 16 16" fill="currentColor">
+        <path d="M5 1.5a2 2 0 00-.75 3.855v5.29a2 2 0 101.5 0v-5.29A2 2 0 005 1.5zm6 9.145V6.25A2.25 2.25 0 008.75 4H7.5V2.25L4.75 4.75 7.5 7.25V5.5h1.25a.75.75 0 01.75.75v4.395a2 2 0 101.5 0z"/>
+    </svg>`,
 };
 
 const panelRegistry = new Map();